@@ -13,6 +13,7 @@ import (
 
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
 	"github.com/robherley/snips.sh/internal/web"
@@ -270,9 +271,9 @@ func newAPIClient(s *APIIntegrationSuite) *apiClient {
 	}, cfg.Limits.APIKeysPerUser))
 
 	mux := http.NewServeMux()
-	web.NewAPI(cfg, database).Register(mux)
+	web.NewAPI(cfg, database, events.NewHub()).Register(mux)
 	// Raw signed-file access is part of the API signing flow.
-	mux.HandleFunc("GET /f/{fileID}", web.NewUI(cfg, database, testutil.Assets(s.T())).File)
+	mux.HandleFunc("GET /f/{fileID}", web.NewUI(cfg, database, testutil.Assets(s.T()), events.NewHub()).File)
 	server := httptest.NewServer(web.WithMiddleware(mux))
 	s.T().Cleanup(server.Close)
 
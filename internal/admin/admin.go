@@ -0,0 +1,324 @@
+// Package admin implements `snips admin`, commands for operators that act on
+// the database directly, the same for either backend, rather than through
+// the SSH or HTTP services.
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/db"
+)
+
+// DefaultUsersLimit is how many users `users ls` lists when no limit is given.
+const DefaultUsersLimit = 50
+
+// Usage describes the admin commands.
+const Usage = `Usage: snips admin <command>
+
+Commands:
+  users ls [-limit n]        list users, newest first
+  users show <id>            show a user's public keys and files
+  users delete <id>          delete a user and everything they own
+  files rm <id>              delete a file and its revisions
+  keys revoke <fingerprint>  delete a public key, even a user's last one
+  stats                      show the instance's totals
+  migrate status             list migrations and whether they're applied
+  migrate down               roll back the most recently applied migration
+`
+
+var (
+	// ErrUsage is wrapped by the errors of commands that were invoked wrong.
+	ErrUsage = errors.New("invalid usage")
+
+	ErrUserNotFound      = errors.New("user not found")
+	ErrFileNotFound      = errors.New("file not found")
+	ErrPublicKeyNotFound = errors.New("public key not found")
+)
+
+// CLI runs admin commands against DB, writing their output to Out.
+type CLI struct {
+	DB  *db.DB
+	Out io.Writer
+}
+
+// Run runs the command in args, e.g. ["users", "ls"].
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: a command is required", ErrUsage)
+	}
+
+	command, rest := args[0], args[1:]
+	if command == "stats" {
+		return c.Stats(ctx)
+	}
+
+	if len(rest) == 0 {
+		return fmt.Errorf("%w: %q requires a subcommand", ErrUsage, command)
+	}
+
+	switch command + " " + rest[0] {
+	case "users ls":
+		return c.ListUsers(ctx, rest[1:])
+	case "users show":
+		return c.ShowUser(ctx, rest[1:])
+	case "users delete":
+		return c.DeleteUser(ctx, rest[1:])
+	case "files rm":
+		return c.RemoveFile(ctx, rest[1:])
+	case "keys revoke":
+		return c.RevokeKey(ctx, rest[1:])
+	case "migrate status":
+		return c.MigrationStatus(ctx)
+	case "migrate down":
+		return c.MigrateDown(ctx)
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, command+" "+rest[0])
+	}
+}
+
+// ListUsers lists users, newest first, with how many keys and files each has.
+func (c *CLI) ListUsers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("users ls", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	limit := flags.Uint64("limit", DefaultUsersLimit, "maximum number of users to list")
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	users, err := c.DB.Users.List(ctx, db.WithLimit(*limit))
+	if err != nil {
+		return err
+	}
+
+	return c.table(func(tabs io.Writer) error {
+		fmt.Fprintln(tabs, "ID\tCREATED\tKEYS\tFILES")
+		for _, user := range users {
+			keys, err := c.DB.PublicKeys.FindByUser(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			files, err := c.DB.Files.CountByUser(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(tabs, "%s\t%s\t%d\t%d\n", user.ID, user.CreatedAt.UTC().Format(time.RFC3339), len(keys), files)
+		}
+		return nil
+	})
+}
+
+// ShowUser shows a user along with their public keys and files.
+func (c *CLI) ShowUser(ctx context.Context, args []string) error {
+	userID, err := argument(args, "users show <id>")
+	if err != nil {
+		return err
+	}
+
+	user, err := c.DB.Users.Find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("%w: %q", ErrUserNotFound, userID)
+	}
+
+	keys, err := c.DB.PublicKeys.FindByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	files, err := c.DB.Files.FindByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	apiKeys, err := c.DB.APIKeys.FindByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	webhooks, err := c.DB.Webhooks.FindByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = c.table(func(tabs io.Writer) error {
+		fmt.Fprintf(tabs, "id:\t%s\n", user.ID)
+		fmt.Fprintf(tabs, "created:\t%s\n", user.CreatedAt.UTC().Format(time.RFC3339))
+		fmt.Fprintf(tabs, "api keys:\t%d\n", len(apiKeys))
+		fmt.Fprintf(tabs, "webhooks:\t%d\n", len(webhooks))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Out)
+	err = c.table(func(tabs io.Writer) error {
+		fmt.Fprintln(tabs, "KEY\tFINGERPRINT\tTYPE\tCREATED")
+		for _, key := range keys {
+			fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", key.ID, key.Fingerprint, key.Type, key.CreatedAt.UTC().Format(time.RFC3339))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Out)
+	return c.table(func(tabs io.Writer) error {
+		fmt.Fprintln(tabs, "FILE\tNAME\tTYPE\tSIZE\tVISIBILITY\tCREATED")
+		for _, file := range files {
+			name := file.Name
+			if name == "" {
+				name = "-"
+			}
+			visibility := "public"
+			if file.Private {
+				visibility = "private"
+			}
+			fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\t%s\t%s\n", file.ID, name, strings.ToLower(file.Type), humanize.Bytes(file.Size),
+				visibility, file.CreatedAt.UTC().Format(time.RFC3339))
+		}
+		return nil
+	})
+}
+
+// DeleteUser deletes a user along with everything they own.
+func (c *CLI) DeleteUser(ctx context.Context, args []string) error {
+	userID, err := argument(args, "users delete <id>")
+	if err != nil {
+		return err
+	}
+
+	deleted, err := c.DB.Users.Delete(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %q", ErrUserNotFound, userID)
+	}
+
+	fmt.Fprintf(c.Out, "deleted user %s\n", userID)
+	return nil
+}
+
+// RemoveFile deletes a file and its revisions. The CLI runs outside the server
+// process, so no delete event is published: live viewers aren't told and
+// webhooks aren't sent.
+func (c *CLI) RemoveFile(ctx context.Context, args []string) error {
+	fileID, err := argument(args, "files rm <id>")
+	if err != nil {
+		return err
+	}
+
+	file, err := c.DB.Files.Find(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		return fmt.Errorf("%w: %q", ErrFileNotFound, fileID)
+	}
+
+	if err := c.DB.Files.Delete(ctx, file.ID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "deleted file %s owned by user %s\n", file.ID, file.UserID)
+	return nil
+}
+
+// RevokeKey deletes a public key by its fingerprint, so it can no longer
+// access the user's files. If it was the user's last key, connecting with it
+// again creates a new user.
+func (c *CLI) RevokeKey(ctx context.Context, args []string) error {
+	fingerprint, err := argument(args, "keys revoke <fingerprint>")
+	if err != nil {
+		return err
+	}
+
+	key, err := c.DB.PublicKeys.DeleteByFingerprint(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("%w: %q", ErrPublicKeyNotFound, fingerprint)
+	}
+
+	fmt.Fprintf(c.Out, "revoked key %s of user %s\n", key.Fingerprint, key.UserID)
+	return nil
+}
+
+// Stats shows the instance's totals.
+func (c *CLI) Stats(ctx context.Context) error {
+	counts, err := c.DB.Stats.Count(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.table(func(tabs io.Writer) error {
+		fmt.Fprintf(tabs, "users:\t%d\n", counts.Users)
+		fmt.Fprintf(tabs, "public keys:\t%d\n", counts.PublicKeys)
+		fmt.Fprintf(tabs, "files:\t%d (%s)\n", counts.Files, humanize.Bytes(uint64(counts.FileBytes)))
+		fmt.Fprintf(tabs, "revisions:\t%d\n", counts.Revisions)
+		fmt.Fprintf(tabs, "api keys:\t%d\n", counts.APIKeys)
+		fmt.Fprintf(tabs, "webhooks:\t%d\n", counts.Webhooks)
+		fmt.Fprintf(tabs, "webhook deliveries:\t%d\n", counts.WebhookDeliveries)
+		return nil
+	})
+}
+
+// MigrationStatus lists every migration and whether it's been applied.
+func (c *CLI) MigrationStatus(ctx context.Context) error {
+	migrations, err := c.DB.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.table(func(tabs io.Writer) error {
+		fmt.Fprintln(tabs, "VERSION\tMIGRATION\tAPPLIED")
+		for _, migration := range migrations {
+			applied := "pending"
+			if migration.AppliedAt != nil {
+				applied = migration.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(tabs, "%d\t%s\t%s\n", migration.Version, migration.Source, applied)
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the most recently applied migration. The server
+// applies pending migrations when it starts, so this is only useful before
+// running an older version.
+func (c *CLI) MigrateDown(ctx context.Context) error {
+	migration, err := c.DB.MigrateDown(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "rolled back migration %d (%s)\n", migration.Version, migration.Source)
+	return nil
+}
+
+// table writes the rows written by fn aligned in columns.
+func (c *CLI) table(fn func(tabs io.Writer) error) error {
+	tabs := tabwriter.NewWriter(c.Out, 1, 0, 2, ' ', 0)
+	if err := fn(tabs); err != nil {
+		return err
+	}
+	return tabs.Flush()
+}
+
+// argument returns the single argument a command takes.
+func argument(args []string, usage string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", fmt.Errorf("%w: expected %s", ErrUsage, usage)
+	}
+	return args[0], nil
+}
@@ -0,0 +1,175 @@
+package admin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/admin"
+	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/id"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCLI(t *testing.T) (*admin.CLI, *testutil.Database, *bytes.Buffer) {
+	t.Helper()
+
+	database := testutil.NewDatabase(t, dsn.SQLite, true)
+	out := &bytes.Buffer{}
+	return &admin.CLI{DB: database.DB, Out: out}, database, out
+}
+
+func createUser(t *testing.T, database *testutil.Database) (*snips.User, *snips.PublicKey) {
+	t.Helper()
+
+	key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"}
+	user, err := database.Users.CreateWithPublicKey(t.Context(), key)
+	require.NoError(t, err)
+	return user, key
+}
+
+func createFile(t *testing.T, database *testutil.Database, userID, name string) *snips.File {
+	t.Helper()
+
+	file := &snips.File{Type: "plaintext", UserID: userID, Name: name}
+	require.NoError(t, database.Files.Create(t.Context(), file, []byte("hello"), 0))
+	return file
+}
+
+func TestCLI_Usage(t *testing.T) {
+	cli, _, _ := newCLI(t)
+
+	cases := [][]string{
+		nil,
+		{"users"},
+		{"users", "nope"},
+		{"users", "show"},
+		{"users", "ls", "-limit", "many"},
+		{"files", "rm", "a", "b"},
+	}
+
+	for _, args := range cases {
+		err := cli.Run(t.Context(), args)
+		assert.ErrorIs(t, err, admin.ErrUsage, "%v", args)
+	}
+}
+
+func TestCLI_Users(t *testing.T) {
+	cli, database, out := newCLI(t)
+
+	user, key := createUser(t, database)
+	other, _ := createUser(t, database)
+	file := createFile(t, database, user.ID, "notes.md")
+
+	t.Run("ls", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, cli.Run(t.Context(), []string{"users", "ls"}))
+
+		assert.Contains(t, out.String(), "ID")
+		assert.Contains(t, out.String(), user.ID)
+		assert.Contains(t, out.String(), other.ID)
+
+		out.Reset()
+		require.NoError(t, cli.Run(t.Context(), []string{"users", "ls", "-limit", "1"}))
+		assert.Contains(t, out.String(), other.ID)
+		assert.NotContains(t, out.String(), user.ID)
+	})
+
+	t.Run("show", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, cli.Run(t.Context(), []string{"users", "show", user.ID}))
+
+		assert.Contains(t, out.String(), user.ID)
+		assert.Contains(t, out.String(), key.Fingerprint)
+		assert.Contains(t, out.String(), file.ID)
+		assert.Contains(t, out.String(), "notes.md")
+
+		err := cli.Run(t.Context(), []string{"users", "show", "missing"})
+		assert.ErrorIs(t, err, admin.ErrUserNotFound)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		out.Reset()
+		require.NoError(t, cli.Run(t.Context(), []string{"users", "delete", user.ID}))
+		assert.Contains(t, out.String(), "deleted user "+user.ID)
+
+		found, err := database.Users.Find(t.Context(), user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+
+		found2, err := database.Files.Find(t.Context(), file.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found2)
+
+		err = cli.Run(t.Context(), []string{"users", "delete", user.ID})
+		assert.ErrorIs(t, err, admin.ErrUserNotFound)
+	})
+}
+
+func TestCLI_RemoveFile(t *testing.T) {
+	cli, database, out := newCLI(t)
+
+	user, _ := createUser(t, database)
+	file := createFile(t, database, user.ID, "")
+
+	require.NoError(t, cli.Run(t.Context(), []string{"files", "rm", file.ID}))
+	assert.Contains(t, out.String(), "deleted file "+file.ID+" owned by user "+user.ID)
+
+	found, err := database.Files.Find(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	err = cli.Run(t.Context(), []string{"files", "rm", file.ID})
+	assert.ErrorIs(t, err, admin.ErrFileNotFound)
+}
+
+func TestCLI_RevokeKey(t *testing.T) {
+	cli, database, out := newCLI(t)
+
+	user, key := createUser(t, database)
+
+	require.NoError(t, cli.Run(t.Context(), []string{"keys", "revoke", key.Fingerprint}))
+	assert.Contains(t, out.String(), "revoked key "+key.Fingerprint+" of user "+user.ID)
+
+	found, err := database.PublicKeys.FindByFingerprint(t.Context(), key.Fingerprint)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	err = cli.Run(t.Context(), []string{"keys", "revoke", key.Fingerprint})
+	assert.ErrorIs(t, err, admin.ErrPublicKeyNotFound)
+}
+
+func TestCLI_Stats(t *testing.T) {
+	cli, database, out := newCLI(t)
+
+	user, _ := createUser(t, database)
+	createFile(t, database, user.ID, "")
+
+	require.NoError(t, cli.Run(t.Context(), []string{"stats"}))
+	assert.Regexp(t, `users:\s+1\n`, out.String())
+	assert.Regexp(t, `files:\s+1 \(5 B\)\n`, out.String())
+	assert.Regexp(t, `webhooks:\s+0\n`, out.String())
+}
+
+func TestCLI_Migrate(t *testing.T) {
+	cli, database, out := newCLI(t)
+
+	require.NoError(t, cli.Run(t.Context(), []string{"migrate", "status"}))
+	assert.Contains(t, out.String(), "00001_init.sql")
+	assert.NotContains(t, out.String(), "pending")
+
+	migrations, err := database.MigrationStatus(t.Context())
+	require.NoError(t, err)
+	latest := migrations[len(migrations)-1]
+
+	out.Reset()
+	require.NoError(t, cli.Run(t.Context(), []string{"migrate", "down"}))
+	assert.Contains(t, out.String(), "rolled back migration")
+	assert.Contains(t, out.String(), latest.Source)
+
+	out.Reset()
+	require.NoError(t, cli.Run(t.Context(), []string{"migrate", "status"}))
+	assert.Regexp(t, latest.Source+`\s+pending`, out.String())
+}
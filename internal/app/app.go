@@ -12,13 +12,17 @@ import (
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/ssh"
 	"github.com/robherley/snips.sh/internal/web"
+	"github.com/robherley/snips.sh/internal/webhooks"
 )
 
 type App struct {
 	SSH        *ssh.Service
 	HTTP       *web.Service
+	Reaper     *Reaper
+	Webhooks   *webhooks.Dispatcher
 	DB         *db.DB
 	OnShutdown func(context.Context)
 }
@@ -47,6 +51,8 @@ func (app *App) listen() {
 	}{
 		app.SSH,
 		app.HTTP,
+		app.Reaper,
+		app.Webhooks,
 	}
 
 	for _, svc := range services {
@@ -64,6 +70,8 @@ func (app *App) shutdown(ctx context.Context) {
 	}{
 		app.SSH,
 		app.HTTP,
+		app.Reaper,
+		app.Webhooks,
 	}
 
 	wg := sync.WaitGroup{}
@@ -94,20 +102,23 @@ func New(cfg *config.Config, assets web.Assets) (*App, error) {
 		return nil, err
 	}
 	database := connection
+	hub := events.NewHub()
 
-	ssh, err := ssh.New(cfg, database)
+	ssh, err := ssh.New(cfg, database, hub)
 	if err != nil {
 		return nil, err
 	}
 
-	httpSvc, err := web.New(cfg, database, assets)
+	httpSvc, err := web.New(cfg, database, assets, hub)
 	if err != nil {
 		return nil, err
 	}
 
 	return &App{
-		SSH:  ssh,
-		HTTP: httpSvc,
-		DB:   database,
+		SSH:      ssh,
+		HTTP:     httpSvc,
+		Reaper:   NewReaper(database, hub, cfg.Reaper.Interval),
+		Webhooks: webhooks.NewDispatcher(cfg, database, hub),
+		DB:       database,
 	}, nil
 }
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+)
+
+// Reaper periodically purges expired files. It implements the same
+// ListenAndServe/Shutdown pair as the network services so the app can manage
+// it alongside them.
+type Reaper struct {
+	db       *db.DB
+	events   *events.Hub
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewReaper creates a reaper that purges expired files every interval,
+// publishing each purge to hub as a delete. A non-positive interval disables
+// it.
+func NewReaper(database *db.DB, hub *events.Hub, interval time.Duration) *Reaper {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reaper{
+		db:       database,
+		events:   hub,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// ListenAndServe reaps immediately and then every interval, until Shutdown.
+func (r *Reaper) ListenAndServe() error {
+	defer close(r.done)
+
+	if r.interval <= 0 {
+		slog.Info("file reaper disabled")
+		return nil
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.Reap(r.ctx)
+
+		select {
+		case <-r.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown stops the reaper, waiting for an in-flight purge to finish.
+func (r *Reaper) Shutdown(ctx context.Context) error {
+	r.once.Do(r.cancel)
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reap deletes all files that have expired, returning the number deleted.
+func (r *Reaper) Reap(ctx context.Context) int64 {
+	deleted, err := r.db.Files.DeleteExpired(ctx, time.Now().UTC())
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Error("unable to purge expired files", "err", err)
+		}
+		return 0
+	}
+
+	for _, file := range deleted {
+		r.events.Publish(events.NewEvent(events.KindDelete, file))
+	}
+
+	count := int64(len(deleted))
+	if count > 0 {
+		metrics.IncrCounter([]string{"file", "expired"}, float32(count))
+		slog.Info("purged expired files", "count", count)
+	}
+
+	return count
+}
@@ -0,0 +1,70 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/app"
+	dbmock "github.com/robherley/snips.sh/internal/db/mock"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaper_Reap(t *testing.T) {
+	mockDB := dbmock.NewDB(t)
+	expired := []*snips.File{{ID: "file1"}, {ID: "file2"}, {ID: "file3"}}
+	mockDB.Files.EXPECT().DeleteExpired(mock.Anything, mock.AnythingOfType("time.Time")).Return(expired, nil).Once()
+	mockDB.Files.EXPECT().DeleteExpired(mock.Anything, mock.Anything).Return(nil, errors.New("boom")).Once()
+
+	hub := events.NewHub()
+	stream, unsubscribe := hub.Subscribe("file2")
+	defer unsubscribe()
+
+	reaper := app.NewReaper(mockDB.DB, hub, time.Minute)
+	assert.Equal(t, int64(3), reaper.Reap(t.Context()))
+	assert.Zero(t, reaper.Reap(t.Context()))
+
+	// purged files are deleted as far as viewers and webhooks are concerned
+	require.Len(t, stream, 1)
+	assert.Equal(t, events.KindDelete, (<-stream).Kind)
+}
+
+func TestReaper_ListenAndShutdown(t *testing.T) {
+	mockDB := dbmock.NewDB(t)
+	reaped := make(chan struct{}, 1)
+	mockDB.Files.EXPECT().DeleteExpired(mock.Anything, mock.Anything).RunAndReturn(
+		func(context.Context, time.Time) ([]*snips.File, error) {
+			select {
+			case reaped <- struct{}{}:
+			default:
+			}
+			return nil, nil
+		})
+
+	reaper := app.NewReaper(mockDB.DB, nil, time.Hour)
+	stopped := make(chan error, 1)
+	go func() { stopped <- reaper.ListenAndServe() }()
+
+	// reaps immediately on start, rather than waiting an interval
+	select {
+	case <-reaped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reaper did not run")
+	}
+
+	require.NoError(t, reaper.Shutdown(t.Context()))
+	require.NoError(t, <-stopped)
+}
+
+func TestReaper_Disabled(t *testing.T) {
+	mockDB := dbmock.NewDB(t)
+
+	reaper := app.NewReaper(mockDB.DB, nil, 0)
+	require.NoError(t, reaper.ListenAndServe())
+	require.NoError(t, reaper.Shutdown(t.Context()))
+}
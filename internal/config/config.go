@@ -6,9 +6,12 @@ import (
 	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"net/netip"
 	"net/url"
 	"os"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"sync"
 	"text/tabwriter"
 	"time"
@@ -39,20 +42,44 @@ type Config struct {
 	FileCompression bool `default:"True" desc:"enable compression of file contents"`
 
 	Limits struct {
-		FileSize         uint64        `default:"1048576" desc:"maximum file size in bytes"`
-		FilesPerUser     uint64        `default:"100" desc:"maximum number of files per user"`
-		SessionDuration  time.Duration `default:"15m" desc:"maximum ssh session duration"`
-		RevisionsPerFile uint64        `default:"64" desc:"maximum number of revisions per file"`
-		APIKeysPerUser   uint64        `default:"16" desc:"maximum number of api keys per user"`
+		FileSize             uint64        `default:"1048576" desc:"maximum file size in bytes"`
+		FilesPerUser         uint64        `default:"100" desc:"maximum number of files per user"`
+		SessionDuration      time.Duration `default:"15m" desc:"maximum ssh session duration"`
+		RevisionsPerFile     uint64        `default:"64" desc:"maximum number of revisions per file"`
+		APIKeysPerUser       uint64        `default:"16" desc:"maximum number of api keys per user"`
+		WebhooksPerUser      uint64        `default:"8" desc:"maximum number of webhooks per user"`
+		DeliveriesPerWebhook uint64        `default:"50" desc:"maximum number of deliveries kept in each webhook's log"`
 	}
 
 	DB struct {
 		URL string `default:"data/snips.db" desc:"database URL or DSN"`
 	}
 
+	Reaper struct {
+		Interval time.Duration `default:"1m" desc:"how often expired files are purged, 0 disables purging"`
+	}
+
+	Webhooks struct {
+		Interval     time.Duration `default:"5s" desc:"how often due webhook deliveries are sent, 0 disables webhooks"`
+		Timeout      time.Duration `default:"10s" desc:"how long a webhook has to respond to a delivery"`
+		MaxAttempts  int           `default:"8" desc:"how many times a webhook delivery is attempted before it fails"`
+		AllowPrivate bool          `default:"False" desc:"allow webhooks to reach private, loopback, link-local and other non-public addresses"`
+	}
+
+	RateLimit struct {
+		APIKey           int `default:"120" desc:"api requests allowed per minute for each api key, 0 disables the limit"`
+		APIKeyBurst      int `default:"60" desc:"api requests an api key can make at once before being limited"`
+		Fingerprint      int `default:"30" desc:"ssh sessions allowed per minute for each public key fingerprint, 0 disables the limit"`
+		FingerprintBurst int `default:"15" desc:"ssh sessions a public key fingerprint can open at once before being limited"`
+		IP               int `default:"120" desc:"anonymous web requests allowed per minute for each remote ip, 0 disables the limit"`
+		IPBurst          int `default:"60" desc:"anonymous web requests a remote ip can make at once before being limited"`
+	}
+
 	HTTP struct {
 		Internal url.URL `default:"http://localhost:8080" desc:"internal address to listen for http requests"`
 		External url.URL `default:"http://localhost:8080" desc:"external http address displayed in commands"`
+
+		TrustedProxies []netip.Prefix `desc:"CIDRs of reverse proxies trusted to report the client ip in X-Forwarded-For or X-Real-IP"`
 	}
 
 	HTML struct {
@@ -66,6 +93,13 @@ type Config struct {
 		HostKeyPath        string  `default:"data/keys/snips" desc:"path to host keys (without extension)"`
 		AuthorizedKeys     string  `default:"" desc:"authorized keys content; takes precedence over authorized keys path"`
 		AuthorizedKeysPath string  `default:"" desc:"path to authorized keys, if specified will restrict SSH access"`
+
+		TrustedUserCAKeys     string `default:"" desc:"trusted user CA public keys content; takes precedence over trusted user CA keys path"`
+		TrustedUserCAKeysPath string `default:"" desc:"path to trusted user CA public keys, certificates they sign authenticate by principal"`
+	}
+
+	Admin struct {
+		Fingerprints []string `desc:"public key fingerprints (or principal:<name> for certificates) granted admin commands"`
 	}
 
 	Metrics struct {
@@ -103,6 +137,17 @@ func (cfg *Config) SSHCommandForNamedFile(name string) string {
 	return cfg.sshCommandFor("n:" + name)
 }
 
+// SSHCommandWithArgs returns the ssh command to run a snips command, e.g.
+// "ssh snips.sh -- keys ls".
+func (cfg *Config) SSHCommandWithArgs(args ...string) string {
+	sshCommand := "ssh " + cfg.SSH.External.Hostname()
+	if sshPort := cfg.SSH.External.Port(); sshPort != "" && sshPort != "22" {
+		sshCommand += fmt.Sprintf(" -p %s", sshPort)
+	}
+
+	return sshCommand + " -- " + strings.Join(args, " ")
+}
+
 func (cfg *Config) sshCommandFor(user string) string {
 	sshCommand := fmt.Sprintf("ssh %s@%s", user, cfg.SSH.External.Hostname())
 	if sshPort := cfg.SSH.External.Port(); sshPort != "" && sshPort != "22" {
@@ -112,43 +157,62 @@ func (cfg *Config) sshCommandFor(user string) string {
 	return sshCommand
 }
 
+// IsAdmin reports whether the public key fingerprint, as stored for a user,
+// belongs to an admin.
+func (cfg *Config) IsAdmin(fingerprint string) bool {
+	return fingerprint != "" && slices.Contains(cfg.Admin.Fingerprints, fingerprint)
+}
+
 // SSHAuthorizedKeys returns the configured authorized keys.
 func (cfg *Config) SSHAuthorizedKeys() ([]ssh.PublicKey, error) {
-	authorizedKeys := make([]ssh.PublicKey, 0)
-	authorizedKeysContent := []byte(cfg.SSH.AuthorizedKeys)
-	configured := cfg.SSH.AuthorizedKeys != "" || cfg.SSH.AuthorizedKeysPath != ""
+	return parsePublicKeys("authorized keys", cfg.SSH.AuthorizedKeys, cfg.SSH.AuthorizedKeysPath)
+}
+
+// SSHTrustedUserCAKeys returns the configured certificate authorities trusted
+// to sign user certificates.
+func (cfg *Config) SSHTrustedUserCAKeys() ([]ssh.PublicKey, error) {
+	return parsePublicKeys("trusted user CA keys", cfg.SSH.TrustedUserCAKeys, cfg.SSH.TrustedUserCAKeysPath)
+}
 
-	if len(authorizedKeysContent) == 0 {
-		if cfg.SSH.AuthorizedKeysPath == "" {
-			return authorizedKeys, nil
+// parsePublicKeys parses public keys in authorized_keys format from content,
+// or the file at path when content is empty. Invalid lines are skipped, but
+// it's an error for a configured source to have no valid keys at all.
+func parsePublicKeys(name, content, path string) ([]ssh.PublicKey, error) {
+	keys := make([]ssh.PublicKey, 0)
+	keysContent := []byte(content)
+	configured := content != "" || path != ""
+
+	if len(keysContent) == 0 {
+		if path == "" {
+			return keys, nil
 		}
 
 		var err error
-		authorizedKeysContent, err = os.ReadFile(cfg.SSH.AuthorizedKeysPath)
+		keysContent, err = os.ReadFile(path)
 		if err != nil {
-			return nil, fmt.Errorf("unable to read authorized keys file: %w", err)
+			return nil, fmt.Errorf("unable to read %s file: %w", name, err)
 		}
 	}
 
-	for i, keyBytes := range bytes.Split(authorizedKeysContent, []byte("\n")) {
+	for i, keyBytes := range bytes.Split(keysContent, []byte("\n")) {
 		if len(bytes.TrimSpace(keyBytes)) == 0 {
 			continue
 		}
 
 		out, _, _, _, err := ssh.ParseAuthorizedKey(keyBytes)
 		if err != nil {
-			slog.Warn("unable to parse authorized key", "line", i, "err", err)
+			slog.Warn("unable to parse "+name+" entry", "line", i, "err", err)
 			continue
 		}
 
-		authorizedKeys = append(authorizedKeys, out)
+		keys = append(keys, out)
 	}
 
-	if configured && len(authorizedKeys) == 0 {
-		return nil, fmt.Errorf("authorized keys were configured but no valid keys were found")
+	if configured && len(keys) == 0 {
+		return nil, fmt.Errorf("%s were configured but no valid keys were found", name)
 	}
 
-	return authorizedKeys, nil
+	return keys, nil
 }
 
 func Load() (*Config, error) {
@@ -2,7 +2,9 @@ package config_test
 
 import (
 	"log/slog"
+	"net/netip"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/robherley/snips.sh/internal/config"
@@ -207,6 +209,55 @@ ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKrOJrpYRgEiuGuoNhyPbeEldjIRkwRG/fjjySPUks/y
 	})
 }
 
+func TestConfig_SSHTrustedUserCAKeys(t *testing.T) {
+	t.Run("no keys", func(t *testing.T) {
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		caKeys, err := cfg.SSHTrustedUserCAKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(caKeys) != 0 {
+			t.Fatalf("expected 0 keys, got %d", len(caKeys))
+		}
+	})
+
+	t.Run("keys from environment", func(t *testing.T) {
+		t.Setenv("SNIPS_SSH_TRUSTEDUSERCAKEYS", `ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEnqsMuqOhEVw3HyWMp2fqqn6l1IZtJHD1UWkOXszUcl ca@example.com`)
+
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		caKeys, err := cfg.SSHTrustedUserCAKeys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(caKeys) != 1 {
+			t.Fatalf("expected 1 key, got %d", len(caKeys))
+		}
+	})
+
+	t.Run("errors when configured path yields no valid keys", func(t *testing.T) {
+		caKeysFile := testutil.TempFile(t, "trusted_user_ca_keys", `
+		this is not a ca key 🦝
+		`)
+
+		t.Setenv("SNIPS_SSH_TRUSTEDUSERCAKEYSPATH", caKeysFile)
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := cfg.SSHTrustedUserCAKeys(); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
 func TestConfig_SSHHostKey(t *testing.T) {
 	t.Setenv("SNIPS_SSH_HOSTKEY", "private-key-content")
 
@@ -218,3 +269,42 @@ func TestConfig_SSHHostKey(t *testing.T) {
 		t.Fatalf("SSH.HostKey = %q, want private-key-content", cfg.SSH.HostKey)
 	}
 }
+
+func TestConfig_IsAdmin(t *testing.T) {
+	t.Setenv("SNIPS_ADMIN_FINGERPRINTS", "SHA256:admin,principal:ops")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for fingerprint, want := range map[string]bool{
+		"SHA256:admin":   true,
+		"principal:ops":  true,
+		"SHA256:someone": false,
+		"":               false,
+	} {
+		if got := cfg.IsAdmin(fingerprint); got != want {
+			t.Errorf("IsAdmin(%q) = %v, want %v", fingerprint, got, want)
+		}
+	}
+}
+
+func TestConfig_HTTPTrustedProxies(t *testing.T) {
+	t.Setenv("SNIPS_HTTP_TRUSTEDPROXIES", "10.0.0.0/8,::1/128")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	if !slices.Equal(cfg.HTTP.TrustedProxies, want) {
+		t.Fatalf("HTTP.TrustedProxies = %v, want %v", cfg.HTTP.TrustedProxies, want)
+	}
+
+	t.Setenv("SNIPS_HTTP_TRUSTEDPROXIES", "10.0.0.1")
+	if _, err := config.Load(); err == nil {
+		t.Fatal("expected an error for an address without a prefix length")
+	}
+}
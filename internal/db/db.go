@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/robherley/snips.sh/internal/snips"
 )
@@ -11,46 +12,116 @@ import (
 type DB struct {
 	Migrator
 	io.Closer
-	Files      Files
-	PublicKeys PublicKeys
-	Users      Users
-	Revisions  Revisions
-	APIKeys    APIKeys
+	Files             Files
+	PublicKeys        PublicKeys
+	Users             Users
+	Revisions         Revisions
+	APIKeys           APIKeys
+	Webhooks          Webhooks
+	WebhookDeliveries WebhookDeliveries
+	Stats             Stats
 }
 
 type Migrator interface {
 	// Migrate migrates the database.
 	Migrate(ctx context.Context) error
+	// MigrationStatus returns every migration, oldest first, and whether each has been applied.
+	MigrationStatus(ctx context.Context) ([]*Migration, error)
+	// MigrateDown rolls back the most recently applied migration, returning it. If none are applied,
+	// ErrNoMigrations is returned.
+	MigrateDown(ctx context.Context) (*Migration, error)
+}
+
+// Migration is a schema migration, see Migrator.
+type Migration struct {
+	Version int64
+	// Source is the migration's file name, e.g. 00001_init.sql.
+	Source string
+	// AppliedAt is when the migration was applied, nil if it's pending.
+	AppliedAt *time.Time
+}
+
+// Counts are an instance's totals across every user, see Stats.
+type Counts struct {
+	Users             int64
+	PublicKeys        int64
+	Files             int64
+	Revisions         int64
+	APIKeys           int64
+	Webhooks          int64
+	WebhookDeliveries int64
+	// FileBytes is the total size of every file's content, uncompressed.
+	FileBytes int64
 }
 
 type Files interface {
 	// Find returns a file by its ID. It does not include file content.
 	Find(ctx context.Context, id string) (*snips.File, error)
+	// FindMany returns the files with the given IDs, skipping any that don't exist, in no particular order. It does
+	// not include file content.
+	FindMany(ctx context.Context, ids []string) ([]*snips.File, error)
 	// FindWithContent returns a file and its decompressed content by ID in a single query.
 	FindWithContent(ctx context.Context, id string) (*snips.File, []byte, error)
+	// FindWithContentAndDelete atomically returns a file with its decompressed content and deletes it (and its revisions).
+	// Of concurrent callers, only one gets the file; the rest get nil, as if it never existed.
+	FindWithContentAndDelete(ctx context.Context, id string) (*snips.File, []byte, error)
 	// Create creates a new file, setting file.Size from content. If a user has more than maxFiles, an error is returned.
 	Create(ctx context.Context, file *snips.File, content []byte, maxFiles uint64) error
 	// FindContent returns a file's decompressed content by ID.
 	FindContent(ctx context.Context, id string) ([]byte, error)
 	// Update updates a file's metadata, never its content.
 	Update(ctx context.Context, file *snips.File) error
-	// UpdateContent updates a file and replaces its content, setting file.Size.
-	UpdateContent(ctx context.Context, file *snips.File, content []byte) error
+	// UpdateMany updates the metadata of several files in a single transaction, never their content. Either every
+	// file is updated or, on error, none are.
+	UpdateMany(ctx context.Context, files []*snips.File) error
+	// UpdateContent updates a file and replaces its content, setting file.Size. Preconditions are checked in the same
+	// statement as the update; if they fail, ErrPreconditionFailed is returned and nothing changes.
+	UpdateContent(ctx context.Context, file *snips.File, content []byte, conds ...PreconditionOption) error
 	// Delete deletes a file by its ID.
 	Delete(ctx context.Context, id string) error
-	// DeleteByUser deletes all of a user's files and their revisions, returning the number of files deleted.
-	DeleteByUser(ctx context.Context, userID string) (int64, error)
-	// FindByUser returns a user's files, newest first. It does not include file content.
+	// DeleteMany deletes the files with the given IDs (and their revisions) in a single transaction, returning the
+	// number of files deleted.
+	DeleteMany(ctx context.Context, ids []string) (int64, error)
+	// DeleteByUser deletes all of a user's files and their revisions, returning the deleted files.
+	DeleteByUser(ctx context.Context, userID string) ([]*snips.File, error)
+	// FindByUser returns a user's unexpired files, newest first. It does not include file content.
 	FindByUser(ctx context.Context, userID string, opts ...PageOption) ([]*snips.File, error)
-	// FindByName returns a user's file with the given name (case-insensitive). It does not include file content.
+	// FindRecent returns unexpired files across every user, newest first. It does not include file content.
+	FindRecent(ctx context.Context, opts ...PageOption) ([]*snips.File, error)
+	// FindByTag returns a user's unexpired files tagged with tag (see snips.NormalizeTag), newest first. It does not
+	// include file content.
+	FindByTag(ctx context.Context, userID, tag string, opts ...PageOption) ([]*snips.File, error)
+	// Search returns a user's unexpired files whose name or content contains every term of query (see SearchTerms),
+	// newest first. It does not include file content.
+	Search(ctx context.Context, userID, query string, opts ...PageOption) ([]*snips.File, error)
+	// FindByName returns a user's unexpired file with the given name (case-insensitive). It does not include file content.
 	FindByName(ctx context.Context, userID, name string) (*snips.File, error)
-	// CountByUser returns the number of files a user has.
+	// CountByUser returns the number of unexpired files a user has.
 	CountByUser(ctx context.Context, userID string) (int64, error)
+	// DeleteExpired deletes all files (and their revisions) that expired at or before now, returning the deleted files.
+	DeleteExpired(ctx context.Context, now time.Time) ([]*snips.File, error)
 }
 
 type PublicKeys interface {
 	// FindByFingerprint returns a public key by its fingerprint.
 	FindByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error)
+	// FindByUser returns all public keys for a user, oldest first.
+	FindByUser(ctx context.Context, userID string) ([]*snips.PublicKey, error)
+	// Create adds a public key to an existing user (publicKey.UserID). If the fingerprint is already registered,
+	// ErrPublicKeyTaken is returned.
+	Create(ctx context.Context, publicKey *snips.PublicKey) error
+	// Delete deletes a user's public key by ID, reporting whether a key was deleted. A user's last key is never
+	// deleted, so they can't be locked out of their files.
+	Delete(ctx context.Context, id, userID string) (bool, error)
+	// CreateLink stores a code for linking another key to link.UserID, clearing out expired links.
+	CreateLink(ctx context.Context, link *snips.KeyLink) error
+	// DeleteByFingerprint deletes a public key by its fingerprint, returning the deleted key, or nil if there was
+	// none. Unlike Delete, it will delete a user's last key.
+	DeleteByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error)
+	// ClaimLink consumes an unexpired link code by its hash and moves the public key with fingerprint to the link's
+	// user, returning the moved key. If the code is unknown or expired, ErrLinkInvalid is returned. If the key is the
+	// only one for an account with unexpired files, which moving it would orphan, ErrKeyHasFiles is returned.
+	ClaimLink(ctx context.Context, codeHash, fingerprint string) (*snips.PublicKey, error)
 }
 
 type Users interface {
@@ -60,6 +131,14 @@ type Users interface {
 	Find(ctx context.Context, id string) (*snips.User, error)
 	// Update updates a user's mutable fields (currently theme color and updated_at).
 	Update(ctx context.Context, user *snips.User) error
+	// Ban marks a user as banned (see snips.User.IsBanned) and deletes their API keys, reporting whether the user
+	// exists. Banning a user again keeps the original ban time.
+	Ban(ctx context.Context, id string) (bool, error)
+	// List returns every user, newest first.
+	List(ctx context.Context, opts ...PageOption) ([]*snips.User, error)
+	// Delete deletes a user along with everything they own: their public keys, files (and revisions), API keys and
+	// webhooks (and deliveries), reporting whether the user was deleted.
+	Delete(ctx context.Context, id string) (bool, error)
 }
 
 type Revisions interface {
@@ -87,3 +166,32 @@ type APIKeys interface {
 	// Touch updates an API key's last_used_at timestamp.
 	Touch(ctx context.Context, id string) error
 }
+
+type Webhooks interface {
+	// Create creates a new webhook. If a user has maxWebhooks or more webhooks, ErrWebhookLimit is returned.
+	Create(ctx context.Context, webhook *snips.Webhook, maxWebhooks uint64) error
+	// Find returns a webhook by ID.
+	Find(ctx context.Context, id string) (*snips.Webhook, error)
+	// FindByUser returns all of a user's webhooks, newest first.
+	FindByUser(ctx context.Context, userID string) ([]*snips.Webhook, error)
+	// Delete deletes a user's webhook by ID, along with its deliveries, reporting whether a webhook was deleted.
+	Delete(ctx context.Context, id, userID string) (bool, error)
+}
+
+type WebhookDeliveries interface {
+	// Create queues a delivery, setting its ID and timestamps. If maxDeliveries > 0, prunes the webhook's oldest
+	// deliveries exceeding the limit.
+	Create(ctx context.Context, delivery *snips.WebhookDelivery, maxDeliveries uint64) error
+	// FindDue returns up to limit pending deliveries whose next attempt is due at or before now, most overdue first.
+	// It includes payloads.
+	FindDue(ctx context.Context, now time.Time, limit uint64) ([]*snips.WebhookDelivery, error)
+	// FindByWebhook returns up to limit of a webhook's deliveries, newest first. It does not include payloads.
+	FindByWebhook(ctx context.Context, webhookID string, limit uint64) ([]*snips.WebhookDelivery, error)
+	// Update records the outcome of a delivery attempt: its status, attempts, next attempt, response code and error.
+	Update(ctx context.Context, delivery *snips.WebhookDelivery) error
+}
+
+type Stats interface {
+	// Count returns the instance's totals, for operators.
+	Count(ctx context.Context) (*Counts, error)
+}
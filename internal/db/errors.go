@@ -3,7 +3,13 @@ package db
 import "errors"
 
 var (
-	ErrFileLimit   = errors.New("file limit reached")
-	ErrNameTaken   = errors.New("file already exists with that name")
-	ErrAPIKeyLimit = errors.New("api key limit reached")
+	ErrFileLimit          = errors.New("file limit reached")
+	ErrNameTaken          = errors.New("file already exists with that name")
+	ErrAPIKeyLimit        = errors.New("api key limit reached")
+	ErrPreconditionFailed = errors.New("file was modified")
+	ErrPublicKeyTaken     = errors.New("public key already registered")
+	ErrLinkInvalid        = errors.New("link code is invalid or expired")
+	ErrKeyHasFiles        = errors.New("key is the only one for an account with files")
+	ErrWebhookLimit       = errors.New("webhook limit reached")
+	ErrNoMigrations       = errors.New("no migrations to roll back")
 )
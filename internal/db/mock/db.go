@@ -11,13 +11,16 @@ func (nopCloser) Close() error { return nil }
 
 // Database bundles a DB with its table-level mocks.
 type Database struct {
-	DB         *db.DB
-	Migrator   *MockMigrator
-	Files      *MockFiles
-	PublicKeys *MockPublicKeys
-	Users      *MockUsers
-	Revisions  *MockRevisions
-	APIKeys    *MockAPIKeys
+	DB                *db.DB
+	Migrator          *MockMigrator
+	Files             *MockFiles
+	PublicKeys        *MockPublicKeys
+	Users             *MockUsers
+	Revisions         *MockRevisions
+	APIKeys           *MockAPIKeys
+	Webhooks          *MockWebhooks
+	WebhookDeliveries *MockWebhookDeliveries
+	Stats             *MockStats
 }
 
 // NewDB creates a database composed of independently mockable table stores.
@@ -26,21 +29,27 @@ func NewDB(t interface {
 	Cleanup(func())
 }) *Database {
 	mocks := &Database{
-		Migrator:   NewMockMigrator(t),
-		Files:      NewMockFiles(t),
-		PublicKeys: NewMockPublicKeys(t),
-		Users:      NewMockUsers(t),
-		Revisions:  NewMockRevisions(t),
-		APIKeys:    NewMockAPIKeys(t),
+		Migrator:          NewMockMigrator(t),
+		Files:             NewMockFiles(t),
+		PublicKeys:        NewMockPublicKeys(t),
+		Users:             NewMockUsers(t),
+		Revisions:         NewMockRevisions(t),
+		APIKeys:           NewMockAPIKeys(t),
+		Webhooks:          NewMockWebhooks(t),
+		WebhookDeliveries: NewMockWebhookDeliveries(t),
+		Stats:             NewMockStats(t),
 	}
 	mocks.DB = &db.DB{
-		Migrator:   mocks.Migrator,
-		Closer:     nopCloser{},
-		Files:      mocks.Files,
-		PublicKeys: mocks.PublicKeys,
-		Users:      mocks.Users,
-		Revisions:  mocks.Revisions,
-		APIKeys:    mocks.APIKeys,
+		Migrator:          mocks.Migrator,
+		Closer:            nopCloser{},
+		Files:             mocks.Files,
+		PublicKeys:        mocks.PublicKeys,
+		Users:             mocks.Users,
+		Revisions:         mocks.Revisions,
+		APIKeys:           mocks.APIKeys,
+		Webhooks:          mocks.Webhooks,
+		WebhookDeliveries: mocks.WebhookDeliveries,
+		Stats:             mocks.Stats,
 	}
 
 	return mocks
@@ -6,6 +6,7 @@ package dbmock
 
 import (
 	"context"
+	"time"
 
 	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/snips"
@@ -232,22 +233,24 @@ func (_c *MockFiles_Delete_Call) RunAndReturn(run func(ctx context.Context, id s
 }
 
 // DeleteByUser provides a mock function for the type MockFiles
-func (_mock *MockFiles) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+func (_mock *MockFiles) DeleteByUser(ctx context.Context, userID string) ([]*snips.File, error) {
 	ret := _mock.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteByUser")
 	}
 
-	var r0 int64
+	var r0 []*snips.File
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]*snips.File, error)); ok {
 		return returnFunc(ctx, userID)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []*snips.File); ok {
 		r0 = returnFunc(ctx, userID)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
 		r1 = returnFunc(ctx, userID)
@@ -287,12 +290,146 @@ func (_c *MockFiles_DeleteByUser_Call) Run(run func(ctx context.Context, userID
 	return _c
 }
 
-func (_c *MockFiles_DeleteByUser_Call) Return(n int64, err error) *MockFiles_DeleteByUser_Call {
+func (_c *MockFiles_DeleteByUser_Call) Return(files []*snips.File, err error) *MockFiles_DeleteByUser_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_DeleteByUser_Call) RunAndReturn(run func(ctx context.Context, userID string) ([]*snips.File, error)) *MockFiles_DeleteByUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteExpired provides a mock function for the type MockFiles
+func (_mock *MockFiles) DeleteExpired(ctx context.Context, now time.Time) ([]*snips.File, error) {
+	ret := _mock.Called(ctx, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteExpired")
+	}
+
+	var r0 []*snips.File
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) ([]*snips.File, error)); ok {
+		return returnFunc(ctx, now)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) []*snips.File); ok {
+		r0 = returnFunc(ctx, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = returnFunc(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_DeleteExpired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteExpired'
+type MockFiles_DeleteExpired_Call struct {
+	*mock.Call
+}
+
+// DeleteExpired is a helper method to define mock.On call
+//   - ctx context.Context
+//   - now time.Time
+func (_e *MockFiles_Expecter) DeleteExpired(ctx any, now any) *MockFiles_DeleteExpired_Call {
+	return &MockFiles_DeleteExpired_Call{Call: _e.mock.On("DeleteExpired", ctx, now)}
+}
+
+func (_c *MockFiles_DeleteExpired_Call) Run(run func(ctx context.Context, now time.Time)) *MockFiles_DeleteExpired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 time.Time
+		if args[1] != nil {
+			arg1 = args[1].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_DeleteExpired_Call) Return(files []*snips.File, err error) *MockFiles_DeleteExpired_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_DeleteExpired_Call) RunAndReturn(run func(ctx context.Context, now time.Time) ([]*snips.File, error)) *MockFiles_DeleteExpired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteMany provides a mock function for the type MockFiles
+func (_mock *MockFiles) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	ret := _mock.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMany")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) (int64, error)); ok {
+		return returnFunc(ctx, ids)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) int64); ok {
+		r0 = returnFunc(ctx, ids)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_DeleteMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteMany'
+type MockFiles_DeleteMany_Call struct {
+	*mock.Call
+}
+
+// DeleteMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ids []string
+func (_e *MockFiles_Expecter) DeleteMany(ctx any, ids any) *MockFiles_DeleteMany_Call {
+	return &MockFiles_DeleteMany_Call{Call: _e.mock.On("DeleteMany", ctx, ids)}
+}
+
+func (_c *MockFiles_DeleteMany_Call) Run(run func(ctx context.Context, ids []string)) *MockFiles_DeleteMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_DeleteMany_Call) Return(n int64, err error) *MockFiles_DeleteMany_Call {
 	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockFiles_DeleteByUser_Call) RunAndReturn(run func(ctx context.Context, userID string) (int64, error)) *MockFiles_DeleteByUser_Call {
+func (_c *MockFiles_DeleteMany_Call) RunAndReturn(run func(ctx context.Context, ids []string) (int64, error)) *MockFiles_DeleteMany_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -439,6 +576,95 @@ func (_c *MockFiles_FindByName_Call) RunAndReturn(run func(ctx context.Context,
 	return _c
 }
 
+// FindByTag provides a mock function for the type MockFiles
+func (_mock *MockFiles) FindByTag(ctx context.Context, userID string, tag string, opts ...db.PageOption) ([]*snips.File, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, userID, tag, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, userID, tag)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByTag")
+	}
+
+	var r0 []*snips.File
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, ...db.PageOption) ([]*snips.File, error)); ok {
+		return returnFunc(ctx, userID, tag, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, ...db.PageOption) []*snips.File); ok {
+		r0 = returnFunc(ctx, userID, tag, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, ...db.PageOption) error); ok {
+		r1 = returnFunc(ctx, userID, tag, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_FindByTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByTag'
+type MockFiles_FindByTag_Call struct {
+	*mock.Call
+}
+
+// FindByTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - tag string
+//   - opts ...db.PageOption
+func (_e *MockFiles_Expecter) FindByTag(ctx any, userID any, tag any, opts ...any) *MockFiles_FindByTag_Call {
+	return &MockFiles_FindByTag_Call{Call: _e.mock.On("FindByTag",
+		append([]any{ctx, userID, tag}, opts...)...)}
+}
+
+func (_c *MockFiles_FindByTag_Call) Run(run func(ctx context.Context, userID string, tag string, opts ...db.PageOption)) *MockFiles_FindByTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []db.PageOption
+		var variadicArgs []db.PageOption
+		if len(args) > 3 {
+			variadicArgs = args[3].([]db.PageOption)
+		}
+		arg3 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_FindByTag_Call) Return(files []*snips.File, err error) *MockFiles_FindByTag_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_FindByTag_Call) RunAndReturn(run func(ctx context.Context, userID string, tag string, opts ...db.PageOption) ([]*snips.File, error)) *MockFiles_FindByTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindByUser provides a mock function for the type MockFiles
 func (_mock *MockFiles) FindByUser(ctx context.Context, userID string, opts ...db.PageOption) ([]*snips.File, error) {
 	var tmpRet mock.Arguments
@@ -590,6 +816,151 @@ func (_c *MockFiles_FindContent_Call) RunAndReturn(run func(ctx context.Context,
 	return _c
 }
 
+// FindMany provides a mock function for the type MockFiles
+func (_mock *MockFiles) FindMany(ctx context.Context, ids []string) ([]*snips.File, error) {
+	ret := _mock.Called(ctx, ids)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindMany")
+	}
+
+	var r0 []*snips.File
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) ([]*snips.File, error)); ok {
+		return returnFunc(ctx, ids)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []string) []*snips.File); ok {
+		r0 = returnFunc(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = returnFunc(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_FindMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindMany'
+type MockFiles_FindMany_Call struct {
+	*mock.Call
+}
+
+// FindMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ids []string
+func (_e *MockFiles_Expecter) FindMany(ctx any, ids any) *MockFiles_FindMany_Call {
+	return &MockFiles_FindMany_Call{Call: _e.mock.On("FindMany", ctx, ids)}
+}
+
+func (_c *MockFiles_FindMany_Call) Run(run func(ctx context.Context, ids []string)) *MockFiles_FindMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		if args[1] != nil {
+			arg1 = args[1].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_FindMany_Call) Return(files []*snips.File, err error) *MockFiles_FindMany_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_FindMany_Call) RunAndReturn(run func(ctx context.Context, ids []string) ([]*snips.File, error)) *MockFiles_FindMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindRecent provides a mock function for the type MockFiles
+func (_mock *MockFiles) FindRecent(ctx context.Context, opts ...db.PageOption) ([]*snips.File, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, opts)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindRecent")
+	}
+
+	var r0 []*snips.File
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...db.PageOption) ([]*snips.File, error)); ok {
+		return returnFunc(ctx, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...db.PageOption) []*snips.File); ok {
+		r0 = returnFunc(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, ...db.PageOption) error); ok {
+		r1 = returnFunc(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_FindRecent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindRecent'
+type MockFiles_FindRecent_Call struct {
+	*mock.Call
+}
+
+// FindRecent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - opts ...db.PageOption
+func (_e *MockFiles_Expecter) FindRecent(ctx any, opts ...any) *MockFiles_FindRecent_Call {
+	return &MockFiles_FindRecent_Call{Call: _e.mock.On("FindRecent",
+		append([]any{ctx}, opts...)...)}
+}
+
+func (_c *MockFiles_FindRecent_Call) Run(run func(ctx context.Context, opts ...db.PageOption)) *MockFiles_FindRecent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []db.PageOption
+		var variadicArgs []db.PageOption
+		if len(args) > 1 {
+			variadicArgs = args[1].([]db.PageOption)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_FindRecent_Call) Return(files []*snips.File, err error) *MockFiles_FindRecent_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_FindRecent_Call) RunAndReturn(run func(ctx context.Context, opts ...db.PageOption) ([]*snips.File, error)) *MockFiles_FindRecent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindWithContent provides a mock function for the type MockFiles
 func (_mock *MockFiles) FindWithContent(ctx context.Context, id string) (*snips.File, []byte, error) {
 	ret := _mock.Called(ctx, id)
@@ -666,6 +1037,171 @@ func (_c *MockFiles_FindWithContent_Call) RunAndReturn(run func(ctx context.Cont
 	return _c
 }
 
+// FindWithContentAndDelete provides a mock function for the type MockFiles
+func (_mock *MockFiles) FindWithContentAndDelete(ctx context.Context, id string) (*snips.File, []byte, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindWithContentAndDelete")
+	}
+
+	var r0 *snips.File
+	var r1 []byte
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*snips.File, []byte, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *snips.File); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) []byte); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = returnFunc(ctx, id)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockFiles_FindWithContentAndDelete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindWithContentAndDelete'
+type MockFiles_FindWithContentAndDelete_Call struct {
+	*mock.Call
+}
+
+// FindWithContentAndDelete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockFiles_Expecter) FindWithContentAndDelete(ctx any, id any) *MockFiles_FindWithContentAndDelete_Call {
+	return &MockFiles_FindWithContentAndDelete_Call{Call: _e.mock.On("FindWithContentAndDelete", ctx, id)}
+}
+
+func (_c *MockFiles_FindWithContentAndDelete_Call) Run(run func(ctx context.Context, id string)) *MockFiles_FindWithContentAndDelete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_FindWithContentAndDelete_Call) Return(file *snips.File, bytes []byte, err error) *MockFiles_FindWithContentAndDelete_Call {
+	_c.Call.Return(file, bytes, err)
+	return _c
+}
+
+func (_c *MockFiles_FindWithContentAndDelete_Call) RunAndReturn(run func(ctx context.Context, id string) (*snips.File, []byte, error)) *MockFiles_FindWithContentAndDelete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Search provides a mock function for the type MockFiles
+func (_mock *MockFiles) Search(ctx context.Context, userID string, query string, opts ...db.PageOption) ([]*snips.File, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, userID, query, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, userID, query)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []*snips.File
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, ...db.PageOption) ([]*snips.File, error)); ok {
+		return returnFunc(ctx, userID, query, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, ...db.PageOption) []*snips.File); ok {
+		r0 = returnFunc(ctx, userID, query, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.File)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, ...db.PageOption) error); ok {
+		r1 = returnFunc(ctx, userID, query, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockFiles_Search_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Search'
+type MockFiles_Search_Call struct {
+	*mock.Call
+}
+
+// Search is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - query string
+//   - opts ...db.PageOption
+func (_e *MockFiles_Expecter) Search(ctx any, userID any, query any, opts ...any) *MockFiles_Search_Call {
+	return &MockFiles_Search_Call{Call: _e.mock.On("Search",
+		append([]any{ctx, userID, query}, opts...)...)}
+}
+
+func (_c *MockFiles_Search_Call) Run(run func(ctx context.Context, userID string, query string, opts ...db.PageOption)) *MockFiles_Search_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []db.PageOption
+		var variadicArgs []db.PageOption
+		if len(args) > 3 {
+			variadicArgs = args[3].([]db.PageOption)
+		}
+		arg3 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_Search_Call) Return(files []*snips.File, err error) *MockFiles_Search_Call {
+	_c.Call.Return(files, err)
+	return _c
+}
+
+func (_c *MockFiles_Search_Call) RunAndReturn(run func(ctx context.Context, userID string, query string, opts ...db.PageOption) ([]*snips.File, error)) *MockFiles_Search_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function for the type MockFiles
 func (_mock *MockFiles) Update(ctx context.Context, file *snips.File) error {
 	ret := _mock.Called(ctx, file)
@@ -724,16 +1260,22 @@ func (_c *MockFiles_Update_Call) RunAndReturn(run func(ctx context.Context, file
 }
 
 // UpdateContent provides a mock function for the type MockFiles
-func (_mock *MockFiles) UpdateContent(ctx context.Context, file *snips.File, content []byte) error {
-	ret := _mock.Called(ctx, file, content)
+func (_mock *MockFiles) UpdateContent(ctx context.Context, file *snips.File, content []byte, conds ...db.PreconditionOption) error {
+	var tmpRet mock.Arguments
+	if len(conds) > 0 {
+		tmpRet = _mock.Called(ctx, file, content, conds)
+	} else {
+		tmpRet = _mock.Called(ctx, file, content)
+	}
+	ret := tmpRet
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateContent")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *snips.File, []byte) error); ok {
-		r0 = returnFunc(ctx, file, content)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *snips.File, []byte, ...db.PreconditionOption) error); ok {
+		r0 = returnFunc(ctx, file, content, conds...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -749,11 +1291,13 @@ type MockFiles_UpdateContent_Call struct {
 //   - ctx context.Context
 //   - file *snips.File
 //   - content []byte
-func (_e *MockFiles_Expecter) UpdateContent(ctx any, file any, content any) *MockFiles_UpdateContent_Call {
-	return &MockFiles_UpdateContent_Call{Call: _e.mock.On("UpdateContent", ctx, file, content)}
+//   - conds ...db.PreconditionOption
+func (_e *MockFiles_Expecter) UpdateContent(ctx any, file any, content any, conds ...any) *MockFiles_UpdateContent_Call {
+	return &MockFiles_UpdateContent_Call{Call: _e.mock.On("UpdateContent",
+		append([]any{ctx, file, content}, conds...)...)}
 }
 
-func (_c *MockFiles_UpdateContent_Call) Run(run func(ctx context.Context, file *snips.File, content []byte)) *MockFiles_UpdateContent_Call {
+func (_c *MockFiles_UpdateContent_Call) Run(run func(ctx context.Context, file *snips.File, content []byte, conds ...db.PreconditionOption)) *MockFiles_UpdateContent_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -767,10 +1311,17 @@ func (_c *MockFiles_UpdateContent_Call) Run(run func(ctx context.Context, file *
 		if args[2] != nil {
 			arg2 = args[2].([]byte)
 		}
+		var arg3 []db.PreconditionOption
+		var variadicArgs []db.PreconditionOption
+		if len(args) > 3 {
+			variadicArgs = args[3].([]db.PreconditionOption)
+		}
+		arg3 = variadicArgs
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3...,
 		)
 	})
 	return _c
@@ -781,7 +1332,64 @@ func (_c *MockFiles_UpdateContent_Call) Return(err error) *MockFiles_UpdateConte
 	return _c
 }
 
-func (_c *MockFiles_UpdateContent_Call) RunAndReturn(run func(ctx context.Context, file *snips.File, content []byte) error) *MockFiles_UpdateContent_Call {
+func (_c *MockFiles_UpdateContent_Call) RunAndReturn(run func(ctx context.Context, file *snips.File, content []byte, conds ...db.PreconditionOption) error) *MockFiles_UpdateContent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateMany provides a mock function for the type MockFiles
+func (_mock *MockFiles) UpdateMany(ctx context.Context, files []*snips.File) error {
+	ret := _mock.Called(ctx, files)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []*snips.File) error); ok {
+		r0 = returnFunc(ctx, files)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockFiles_UpdateMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMany'
+type MockFiles_UpdateMany_Call struct {
+	*mock.Call
+}
+
+// UpdateMany is a helper method to define mock.On call
+//   - ctx context.Context
+//   - files []*snips.File
+func (_e *MockFiles_Expecter) UpdateMany(ctx any, files any) *MockFiles_UpdateMany_Call {
+	return &MockFiles_UpdateMany_Call{Call: _e.mock.On("UpdateMany", ctx, files)}
+}
+
+func (_c *MockFiles_UpdateMany_Call) Run(run func(ctx context.Context, files []*snips.File)) *MockFiles_UpdateMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []*snips.File
+		if args[1] != nil {
+			arg1 = args[1].([]*snips.File)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFiles_UpdateMany_Call) Return(err error) *MockFiles_UpdateMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockFiles_UpdateMany_Call) RunAndReturn(run func(ctx context.Context, files []*snips.File) error) *MockFiles_UpdateMany_Call {
 	_c.Call.Return(run)
 	return _c
 }
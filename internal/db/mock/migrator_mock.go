@@ -7,6 +7,7 @@ package dbmock
 import (
 	"context"
 
+	"github.com/robherley/snips.sh/internal/db"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -87,3 +88,127 @@ func (_c *MockMigrator_Migrate_Call) RunAndReturn(run func(ctx context.Context)
 	_c.Call.Return(run)
 	return _c
 }
+
+// MigrateDown provides a mock function for the type MockMigrator
+func (_mock *MockMigrator) MigrateDown(ctx context.Context) (*db.Migration, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MigrateDown")
+	}
+
+	var r0 *db.Migration
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (*db.Migration, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) *db.Migration); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*db.Migration)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockMigrator_MigrateDown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MigrateDown'
+type MockMigrator_MigrateDown_Call struct {
+	*mock.Call
+}
+
+// MigrateDown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) MigrateDown(ctx any) *MockMigrator_MigrateDown_Call {
+	return &MockMigrator_MigrateDown_Call{Call: _e.mock.On("MigrateDown", ctx)}
+}
+
+func (_c *MockMigrator_MigrateDown_Call) Run(run func(ctx context.Context)) *MockMigrator_MigrateDown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMigrator_MigrateDown_Call) Return(migration *db.Migration, err error) *MockMigrator_MigrateDown_Call {
+	_c.Call.Return(migration, err)
+	return _c
+}
+
+func (_c *MockMigrator_MigrateDown_Call) RunAndReturn(run func(ctx context.Context) (*db.Migration, error)) *MockMigrator_MigrateDown_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MigrationStatus provides a mock function for the type MockMigrator
+func (_mock *MockMigrator) MigrationStatus(ctx context.Context) ([]*db.Migration, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MigrationStatus")
+	}
+
+	var r0 []*db.Migration
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]*db.Migration, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []*db.Migration); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*db.Migration)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockMigrator_MigrationStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MigrationStatus'
+type MockMigrator_MigrationStatus_Call struct {
+	*mock.Call
+}
+
+// MigrationStatus is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockMigrator_Expecter) MigrationStatus(ctx any) *MockMigrator_MigrationStatus_Call {
+	return &MockMigrator_MigrationStatus_Call{Call: _e.mock.On("MigrationStatus", ctx)}
+}
+
+func (_c *MockMigrator_MigrationStatus_Call) Run(run func(ctx context.Context)) *MockMigrator_MigrationStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockMigrator_MigrationStatus_Call) Return(migrations []*db.Migration, err error) *MockMigrator_MigrationStatus_Call {
+	_c.Call.Return(migrations, err)
+	return _c
+}
+
+func (_c *MockMigrator_MigrationStatus_Call) RunAndReturn(run func(ctx context.Context) ([]*db.Migration, error)) *MockMigrator_MigrationStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -38,6 +38,334 @@ func (_m *MockPublicKeys) EXPECT() *MockPublicKeys_Expecter {
 	return &MockPublicKeys_Expecter{mock: &_m.Mock}
 }
 
+// ClaimLink provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) ClaimLink(ctx context.Context, codeHash string, fingerprint string) (*snips.PublicKey, error) {
+	ret := _mock.Called(ctx, codeHash, fingerprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimLink")
+	}
+
+	var r0 *snips.PublicKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*snips.PublicKey, error)); ok {
+		return returnFunc(ctx, codeHash, fingerprint)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *snips.PublicKey); ok {
+		r0 = returnFunc(ctx, codeHash, fingerprint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*snips.PublicKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, codeHash, fingerprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPublicKeys_ClaimLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimLink'
+type MockPublicKeys_ClaimLink_Call struct {
+	*mock.Call
+}
+
+// ClaimLink is a helper method to define mock.On call
+//   - ctx context.Context
+//   - codeHash string
+//   - fingerprint string
+func (_e *MockPublicKeys_Expecter) ClaimLink(ctx any, codeHash any, fingerprint any) *MockPublicKeys_ClaimLink_Call {
+	return &MockPublicKeys_ClaimLink_Call{Call: _e.mock.On("ClaimLink", ctx, codeHash, fingerprint)}
+}
+
+func (_c *MockPublicKeys_ClaimLink_Call) Run(run func(ctx context.Context, codeHash string, fingerprint string)) *MockPublicKeys_ClaimLink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_ClaimLink_Call) Return(publicKey *snips.PublicKey, err error) *MockPublicKeys_ClaimLink_Call {
+	_c.Call.Return(publicKey, err)
+	return _c
+}
+
+func (_c *MockPublicKeys_ClaimLink_Call) RunAndReturn(run func(ctx context.Context, codeHash string, fingerprint string) (*snips.PublicKey, error)) *MockPublicKeys_ClaimLink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Create provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) Create(ctx context.Context, publicKey *snips.PublicKey) error {
+	ret := _mock.Called(ctx, publicKey)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *snips.PublicKey) error); ok {
+		r0 = returnFunc(ctx, publicKey)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPublicKeys_Create_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Create'
+type MockPublicKeys_Create_Call struct {
+	*mock.Call
+}
+
+// Create is a helper method to define mock.On call
+//   - ctx context.Context
+//   - publicKey *snips.PublicKey
+func (_e *MockPublicKeys_Expecter) Create(ctx any, publicKey any) *MockPublicKeys_Create_Call {
+	return &MockPublicKeys_Create_Call{Call: _e.mock.On("Create", ctx, publicKey)}
+}
+
+func (_c *MockPublicKeys_Create_Call) Run(run func(ctx context.Context, publicKey *snips.PublicKey)) *MockPublicKeys_Create_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *snips.PublicKey
+		if args[1] != nil {
+			arg1 = args[1].(*snips.PublicKey)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_Create_Call) Return(err error) *MockPublicKeys_Create_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPublicKeys_Create_Call) RunAndReturn(run func(ctx context.Context, publicKey *snips.PublicKey) error) *MockPublicKeys_Create_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateLink provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) CreateLink(ctx context.Context, link *snips.KeyLink) error {
+	ret := _mock.Called(ctx, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLink")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *snips.KeyLink) error); ok {
+		r0 = returnFunc(ctx, link)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockPublicKeys_CreateLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateLink'
+type MockPublicKeys_CreateLink_Call struct {
+	*mock.Call
+}
+
+// CreateLink is a helper method to define mock.On call
+//   - ctx context.Context
+//   - link *snips.KeyLink
+func (_e *MockPublicKeys_Expecter) CreateLink(ctx any, link any) *MockPublicKeys_CreateLink_Call {
+	return &MockPublicKeys_CreateLink_Call{Call: _e.mock.On("CreateLink", ctx, link)}
+}
+
+func (_c *MockPublicKeys_CreateLink_Call) Run(run func(ctx context.Context, link *snips.KeyLink)) *MockPublicKeys_CreateLink_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *snips.KeyLink
+		if args[1] != nil {
+			arg1 = args[1].(*snips.KeyLink)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_CreateLink_Call) Return(err error) *MockPublicKeys_CreateLink_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockPublicKeys_CreateLink_Call) RunAndReturn(run func(ctx context.Context, link *snips.KeyLink) error) *MockPublicKeys_CreateLink_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) Delete(ctx context.Context, id string, userID string) (bool, error) {
+	ret := _mock.Called(ctx, id, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (bool, error)); ok {
+		return returnFunc(ctx, id, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = returnFunc(ctx, id, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPublicKeys_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockPublicKeys_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - userID string
+func (_e *MockPublicKeys_Expecter) Delete(ctx any, id any, userID any) *MockPublicKeys_Delete_Call {
+	return &MockPublicKeys_Delete_Call{Call: _e.mock.On("Delete", ctx, id, userID)}
+}
+
+func (_c *MockPublicKeys_Delete_Call) Run(run func(ctx context.Context, id string, userID string)) *MockPublicKeys_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_Delete_Call) Return(b bool, err error) *MockPublicKeys_Delete_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockPublicKeys_Delete_Call) RunAndReturn(run func(ctx context.Context, id string, userID string) (bool, error)) *MockPublicKeys_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteByFingerprint provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) DeleteByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
+	ret := _mock.Called(ctx, fingerprint)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteByFingerprint")
+	}
+
+	var r0 *snips.PublicKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*snips.PublicKey, error)); ok {
+		return returnFunc(ctx, fingerprint)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *snips.PublicKey); ok {
+		r0 = returnFunc(ctx, fingerprint)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*snips.PublicKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, fingerprint)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPublicKeys_DeleteByFingerprint_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteByFingerprint'
+type MockPublicKeys_DeleteByFingerprint_Call struct {
+	*mock.Call
+}
+
+// DeleteByFingerprint is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fingerprint string
+func (_e *MockPublicKeys_Expecter) DeleteByFingerprint(ctx any, fingerprint any) *MockPublicKeys_DeleteByFingerprint_Call {
+	return &MockPublicKeys_DeleteByFingerprint_Call{Call: _e.mock.On("DeleteByFingerprint", ctx, fingerprint)}
+}
+
+func (_c *MockPublicKeys_DeleteByFingerprint_Call) Run(run func(ctx context.Context, fingerprint string)) *MockPublicKeys_DeleteByFingerprint_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_DeleteByFingerprint_Call) Return(publicKey *snips.PublicKey, err error) *MockPublicKeys_DeleteByFingerprint_Call {
+	_c.Call.Return(publicKey, err)
+	return _c
+}
+
+func (_c *MockPublicKeys_DeleteByFingerprint_Call) RunAndReturn(run func(ctx context.Context, fingerprint string) (*snips.PublicKey, error)) *MockPublicKeys_DeleteByFingerprint_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // FindByFingerprint provides a mock function for the type MockPublicKeys
 func (_mock *MockPublicKeys) FindByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
 	ret := _mock.Called(ctx, fingerprint)
@@ -105,3 +433,71 @@ func (_c *MockPublicKeys_FindByFingerprint_Call) RunAndReturn(run func(ctx conte
 	_c.Call.Return(run)
 	return _c
 }
+
+// FindByUser provides a mock function for the type MockPublicKeys
+func (_mock *MockPublicKeys) FindByUser(ctx context.Context, userID string) ([]*snips.PublicKey, error) {
+	ret := _mock.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindByUser")
+	}
+
+	var r0 []*snips.PublicKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]*snips.PublicKey, error)); ok {
+		return returnFunc(ctx, userID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []*snips.PublicKey); ok {
+		r0 = returnFunc(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.PublicKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockPublicKeys_FindByUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindByUser'
+type MockPublicKeys_FindByUser_Call struct {
+	*mock.Call
+}
+
+// FindByUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MockPublicKeys_Expecter) FindByUser(ctx any, userID any) *MockPublicKeys_FindByUser_Call {
+	return &MockPublicKeys_FindByUser_Call{Call: _e.mock.On("FindByUser", ctx, userID)}
+}
+
+func (_c *MockPublicKeys_FindByUser_Call) Run(run func(ctx context.Context, userID string)) *MockPublicKeys_FindByUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockPublicKeys_FindByUser_Call) Return(publicKeys []*snips.PublicKey, err error) *MockPublicKeys_FindByUser_Call {
+	_c.Call.Return(publicKeys, err)
+	return _c
+}
+
+func (_c *MockPublicKeys_FindByUser_Call) RunAndReturn(run func(ctx context.Context, userID string) ([]*snips.PublicKey, error)) *MockPublicKeys_FindByUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -7,6 +7,7 @@ package dbmock
 import (
 	"context"
 
+	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/snips"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -38,6 +39,72 @@ func (_m *MockUsers) EXPECT() *MockUsers_Expecter {
 	return &MockUsers_Expecter{mock: &_m.Mock}
 }
 
+// Ban provides a mock function for the type MockUsers
+func (_mock *MockUsers) Ban(ctx context.Context, id string) (bool, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Ban")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUsers_Ban_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Ban'
+type MockUsers_Ban_Call struct {
+	*mock.Call
+}
+
+// Ban is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockUsers_Expecter) Ban(ctx any, id any) *MockUsers_Ban_Call {
+	return &MockUsers_Ban_Call{Call: _e.mock.On("Ban", ctx, id)}
+}
+
+func (_c *MockUsers_Ban_Call) Run(run func(ctx context.Context, id string)) *MockUsers_Ban_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUsers_Ban_Call) Return(b bool, err error) *MockUsers_Ban_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUsers_Ban_Call) RunAndReturn(run func(ctx context.Context, id string) (bool, error)) *MockUsers_Ban_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateWithPublicKey provides a mock function for the type MockUsers
 func (_mock *MockUsers) CreateWithPublicKey(ctx context.Context, publickey *snips.PublicKey) (*snips.User, error) {
 	ret := _mock.Called(ctx, publickey)
@@ -106,6 +173,72 @@ func (_c *MockUsers_CreateWithPublicKey_Call) RunAndReturn(run func(ctx context.
 	return _c
 }
 
+// Delete provides a mock function for the type MockUsers
+func (_mock *MockUsers) Delete(ctx context.Context, id string) (bool, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 bool
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUsers_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockUsers_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockUsers_Expecter) Delete(ctx any, id any) *MockUsers_Delete_Call {
+	return &MockUsers_Delete_Call{Call: _e.mock.On("Delete", ctx, id)}
+}
+
+func (_c *MockUsers_Delete_Call) Run(run func(ctx context.Context, id string)) *MockUsers_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUsers_Delete_Call) Return(b bool, err error) *MockUsers_Delete_Call {
+	_c.Call.Return(b, err)
+	return _c
+}
+
+func (_c *MockUsers_Delete_Call) RunAndReturn(run func(ctx context.Context, id string) (bool, error)) *MockUsers_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Find provides a mock function for the type MockUsers
 func (_mock *MockUsers) Find(ctx context.Context, id string) (*snips.User, error) {
 	ret := _mock.Called(ctx, id)
@@ -174,6 +307,83 @@ func (_c *MockUsers_Find_Call) RunAndReturn(run func(ctx context.Context, id str
 	return _c
 }
 
+// List provides a mock function for the type MockUsers
+func (_mock *MockUsers) List(ctx context.Context, opts ...db.PageOption) ([]*snips.User, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, opts)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []*snips.User
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...db.PageOption) ([]*snips.User, error)); ok {
+		return returnFunc(ctx, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...db.PageOption) []*snips.User); ok {
+		r0 = returnFunc(ctx, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*snips.User)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, ...db.PageOption) error); ok {
+		r1 = returnFunc(ctx, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockUsers_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockUsers_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - opts ...db.PageOption
+func (_e *MockUsers_Expecter) List(ctx any, opts ...any) *MockUsers_List_Call {
+	return &MockUsers_List_Call{Call: _e.mock.On("List",
+		append([]any{ctx}, opts...)...)}
+}
+
+func (_c *MockUsers_List_Call) Run(run func(ctx context.Context, opts ...db.PageOption)) *MockUsers_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []db.PageOption
+		var variadicArgs []db.PageOption
+		if len(args) > 1 {
+			variadicArgs = args[1].([]db.PageOption)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockUsers_List_Call) Return(users []*snips.User, err error) *MockUsers_List_Call {
+	_c.Call.Return(users, err)
+	return _c
+}
+
+func (_c *MockUsers_List_Call) RunAndReturn(run func(ctx context.Context, opts ...db.PageOption) ([]*snips.User, error)) *MockUsers_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Update provides a mock function for the type MockUsers
 func (_mock *MockUsers) Update(ctx context.Context, user *snips.User) error {
 	ret := _mock.Called(ctx, user)
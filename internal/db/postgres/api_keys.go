@@ -38,9 +38,10 @@ func (s *apiKeys) Create(ctx context.Context, key *snips.APIKey, maxKeys uint64)
 	}
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO api_keys
-			(display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NULL, $7)`,
+			(display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids)
+		VALUES ($1, $2, $3, $4, $5, $6, NULL, $7, $8, $9)`,
 		keyID, now, now, nullableName(key.Name), key.TokenHash, key.UserID, key.ExpiresAt,
+		db.EncodeTags(key.Scopes), db.EncodeTags(key.FileIDs),
 	)
 	if err != nil {
 		return err
@@ -56,8 +57,9 @@ func scanAPIKey(scan func(...any) error) (*snips.APIKey, error) {
 	key := &snips.APIKey{}
 	var name sql.NullString
 	var lastUsedAt, expiresAt sql.NullTime
+	var scopes, fileIDs []byte
 	if err := scan(&key.ID, &key.CreatedAt, &key.UpdatedAt, &name, &key.TokenHash,
-		&key.UserID, &lastUsedAt, &expiresAt); err != nil {
+		&key.UserID, &lastUsedAt, &expiresAt, &scopes, &fileIDs); err != nil {
 		return nil, err
 	}
 	key.CreatedAt = key.CreatedAt.UTC()
@@ -71,12 +73,19 @@ func scanAPIKey(scan func(...any) error) (*snips.APIKey, error) {
 		expires := expiresAt.Time.UTC()
 		key.ExpiresAt = &expires
 	}
+	var err error
+	if key.Scopes, err = db.DecodeTags(scopes); err != nil {
+		return nil, err
+	}
+	if key.FileIDs, err = db.DecodeTags(fileIDs); err != nil {
+		return nil, err
+	}
 	return key, nil
 }
 
 func (s *apiKeys) FindByTokenHash(ctx context.Context, tokenHash string) (*snips.APIKey, error) {
 	key, err := scanAPIKey(s.QueryRowContext(ctx, `
-		SELECT display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at
+		SELECT display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids
 		FROM api_keys WHERE token_hash = $1`, tokenHash).Scan)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
@@ -86,7 +95,7 @@ func (s *apiKeys) FindByTokenHash(ctx context.Context, tokenHash string) (*snips
 
 func (s *apiKeys) FindByUser(ctx context.Context, userID string) ([]*snips.APIKey, error) {
 	rows, err := s.QueryContext(ctx, `
-		SELECT display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at
+		SELECT display_id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids
 		FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, display_id DESC`, userID)
 	if err != nil {
 		return nil, err
@@ -47,6 +47,21 @@ func TestAPIKeys(t *testing.T) {
 		require.Nil(t, missingKey)
 	})
 
+	t.Run("Scopes", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		key := testutil.Fixtures.APIKey(t)
+		key.UserID = user.ID
+		key.Scopes = []string{snips.APIKeyScopeFilesRead, snips.APIKeyScopeSign}
+		key.FileIDs = []string{"abc123"}
+		require.NoError(t, database.APIKeys.Create(t.Context(), &key, 0))
+
+		foundKey, err := database.APIKeys.FindByTokenHash(t.Context(), key.TokenHash)
+		require.NoError(t, err)
+		require.Equal(t, key.Scopes, foundKey.Scopes)
+		require.Equal(t, key.FileIDs, foundKey.FileIDs)
+	})
+
 	t.Run("FindByUser", func(t *testing.T) {
 		database := newTestDB(t)
 		firstUser := database.createTestUser(t)
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/robherley/snips.sh/internal/db"
@@ -18,51 +21,108 @@ type files struct {
 
 type scanner interface{ Scan(...any) error }
 
-func scanFile(row scanner) (*snips.File, error) {
+// fileColumns are the columns scanned by scanFile, in order.
+const fileColumns = `display_id, created_at, updated_at, size, private, type, user_id, name, expires_at, burn_after_read, description, tags`
+
+// notExpired filters out files that have expired but not been purged yet,
+// given the current time as the nth argument.
+func notExpired(n int) string {
+	return `(f.expires_at IS NULL OR f.expires_at > $` + strconv.Itoa(n) + `)`
+}
+
+// scanFile scans a row of fileColumns, followed by any extra destinations.
+func scanFile(row scanner, extra ...any) (*snips.File, error) {
 	file := &snips.File{}
 	var name sql.NullString
-	if err := row.Scan(&file.ID, &file.CreatedAt, &file.UpdatedAt, &file.Size,
-		&file.Private, &file.Type, &file.UserID, &name); err != nil {
+	var expiresAt sql.NullTime
+	var tags []byte
+	dest := append([]any{&file.ID, &file.CreatedAt, &file.UpdatedAt, &file.Size,
+		&file.Private, &file.Type, &file.UserID, &name, &expiresAt, &file.BurnAfterRead,
+		&file.Description, &tags}, extra...)
+	if err := row.Scan(dest...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	normalizeFile(file, name, expiresAt)
+	var err error
+	if file.Tags, err = db.DecodeTags(tags); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func scanFileWithContent(row scanner) (*snips.File, []byte, error) {
+	var content []byte
+	file, err := scanFile(row, &content)
+	if err != nil || file == nil {
+		return nil, nil, err
+	}
+	decoded, err := snips.DecodeContent(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, decoded, nil
+}
+
+func normalizeFile(file *snips.File, name sql.NullString, expiresAt sql.NullTime) {
 	file.Name = name.String
 	file.CreatedAt = file.CreatedAt.UTC()
 	file.UpdatedAt = file.UpdatedAt.UTC()
-	return file, nil
+	file.ExpiresAt = nil
+	if expiresAt.Valid {
+		t := expiresAt.Time.UTC()
+		file.ExpiresAt = &t
+	}
+}
+
+// expiresAtParam normalizes an optional expiry to the microsecond precision
+// postgres stores, so callers see the value that round-trips.
+func expiresAtParam(file *snips.File) *time.Time {
+	if file.ExpiresAt == nil {
+		return nil
+	}
+	t := file.ExpiresAt.UTC().Truncate(time.Microsecond)
+	file.ExpiresAt = &t
+	return file.ExpiresAt
 }
 
 func (s *files) Find(ctx context.Context, fileID string) (*snips.File, error) {
 	return scanFile(s.QueryRowContext(ctx, `
-		SELECT display_id, created_at, updated_at, size, private, type, user_id, name
-		FROM files WHERE display_id = $1`, fileID))
+		SELECT `+fileColumns+` FROM files WHERE display_id = $1`, fileID))
 }
 
-func (s *files) FindWithContent(ctx context.Context, fileID string) (*snips.File, []byte, error) {
-	file := &snips.File{}
-	var name sql.NullString
-	var content []byte
-	err := s.QueryRowContext(ctx, `
-		SELECT display_id, created_at, updated_at, size, content, private, type, user_id, name
-		FROM files WHERE display_id = $1`, fileID,
-	).Scan(&file.ID, &file.CreatedAt, &file.UpdatedAt, &file.Size, &content,
-		&file.Private, &file.Type, &file.UserID, &name)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil, nil
+func (s *files) FindMany(ctx context.Context, fileIDs []string) ([]*snips.File, error) {
+	if len(fileIDs) == 0 {
+		return []*snips.File{}, nil
 	}
+	return s.query(ctx, `
+		SELECT `+fileColumns+` FROM files WHERE display_id = ANY($1)`, fileIDs)
+}
+
+func (s *files) FindWithContent(ctx context.Context, fileID string) (*snips.File, []byte, error) {
+	return scanFileWithContent(s.QueryRowContext(ctx, `
+		SELECT `+fileColumns+`, content FROM files WHERE display_id = $1`, fileID))
+}
+
+func (s *files) FindWithContentAndDelete(ctx context.Context, fileID string) (*snips.File, []byte, error) {
+	tx, err := s.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, nil, err
 	}
-	file.Name = name.String
-	file.CreatedAt = file.CreatedAt.UTC()
-	file.UpdatedAt = file.UpdatedAt.UTC()
-	decoded, err := snips.DecodeContent(content)
-	if err != nil {
+	defer func() { _ = tx.Rollback() }()
+	// the delete is the read: a concurrent caller blocks on the row lock and
+	// then finds nothing to delete
+	file, content, err := scanFileWithContent(tx.QueryRowContext(ctx, `
+		DELETE FROM files WHERE display_id = $1 RETURNING `+fileColumns+`, content`, fileID))
+	if err != nil || file == nil {
 		return nil, nil, err
 	}
-	return file, decoded, nil
+	if _, err := tx.ExecContext(ctx, `DELETE FROM revisions WHERE file_id = $1`, fileID); err != nil {
+		return nil, nil, err
+	}
+	return file, content, tx.Commit()
 }
 
 func nameConstraintErr(err error) error {
@@ -84,9 +144,16 @@ func (s *files) Create(ctx context.Context, file *snips.File, content []byte, ma
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// a key claimed away from the user concurrently would orphan the file,
+	// see checkOrphans
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE display_id = $1 FOR SHARE`, file.UserID); err != nil {
+		return err
+	}
+
 	if maxFiles > 0 {
 		var count uint64
-		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM files WHERE user_id = $1`, file.UserID).Scan(&count); err != nil {
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM files AS f WHERE f.user_id = $1 AND `+notExpired(2), file.UserID, nowUTC()).Scan(&count); err != nil {
 			return err
 		}
 		if count >= maxFiles {
@@ -94,14 +161,20 @@ func (s *files) Create(ctx context.Context, file *snips.File, content []byte, ma
 		}
 	}
 
+	if err := releaseExpiredName(ctx, tx, file); err != nil {
+		return err
+	}
+
 	now := nowUTC()
 	fileID := id.New()
 	_, err = tx.ExecContext(ctx, `
 		INSERT INTO files
-			(display_id, created_at, updated_at, size, content, private, type, user_id, name)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			(display_id, created_at, updated_at, size, content, private, type, user_id, name, expires_at, burn_after_read,
+			search, description, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, to_tsvector('simple', $12), $13, $14)`,
 		fileID, now, now, len(content), storedContent, file.Private, file.Type,
-		file.UserID, nullableName(file.Name),
+		file.UserID, nullableName(file.Name), expiresAtParam(file), file.BurnAfterRead,
+		db.SearchText(file, content), file.Description, db.EncodeTags(file.Tags),
 	)
 	if err != nil {
 		return nameConstraintErr(err)
@@ -114,11 +187,51 @@ func (s *files) Create(ctx context.Context, file *snips.File, content []byte, ma
 }
 
 func (s *files) Update(ctx context.Context, file *snips.File) error {
+	return updateFile(ctx, s.DB, file)
+}
+
+func (s *files) UpdateMany(ctx context.Context, files []*snips.File) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, file := range files {
+		if err := updateFile(ctx, tx, file); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// releaseExpiredName unnames the user's expired file holding file's name, if
+// any, so the name can be taken before the reaper purges that file.
+func releaseExpiredName(ctx context.Context, exec execer, file *snips.File) error {
+	if file.Name == "" {
+		return nil
+	}
+	_, err := exec.ExecContext(ctx, `
+		UPDATE files SET name = NULL
+		WHERE user_id = $1 AND lower(name) = lower($2) AND display_id != $3
+			AND expires_at IS NOT NULL AND expires_at <= $4`, file.UserID, file.Name, file.ID, nowUTC())
+	return err
+}
+
+func updateFile(ctx context.Context, exec execer, file *snips.File) error {
+	if err := releaseExpiredName(ctx, exec, file); err != nil {
+		return err
+	}
 	updatedAt := nowUTC()
-	_, err := s.ExecContext(ctx, `
-		UPDATE files SET updated_at = $1, size = $2, private = $3, type = $4, name = $5
-		WHERE display_id = $6`, updatedAt, file.Size, file.Private, file.Type,
-		nullableName(file.Name), file.ID)
+	_, err := exec.ExecContext(ctx, `
+		UPDATE files SET updated_at = $1, size = $2, private = $3, type = $4, name = $5, expires_at = $6,
+			description = $7, tags = $8
+		WHERE display_id = $9`, updatedAt, file.Size, file.Private, file.Type,
+		nullableName(file.Name), expiresAtParam(file), file.Description, db.EncodeTags(file.Tags), file.ID)
 	if err != nil {
 		return nameConstraintErr(err)
 	}
@@ -138,20 +251,41 @@ func (s *files) FindContent(ctx context.Context, fileID string) ([]byte, error)
 	return snips.DecodeContent(content)
 }
 
-func (s *files) UpdateContent(ctx context.Context, file *snips.File, content []byte) error {
+func (s *files) UpdateContent(ctx context.Context, file *snips.File, content []byte, conds ...db.PreconditionOption) error {
 	storedContent, err := snips.EncodeContent(content, s.compress)
 	if err != nil {
 		return err
 	}
 	updatedAt := nowUTC()
-	_, err = s.ExecContext(ctx, `
+	query := `
 		UPDATE files
-		SET updated_at = $1, size = $2, content = $3, private = $4, type = $5, name = $6
-		WHERE display_id = $7`, updatedAt, len(content), storedContent, file.Private, file.Type,
-		nullableName(file.Name), file.ID)
+		SET updated_at = $1, size = $2, content = $3, private = $4, type = $5, name = $6, expires_at = $7,
+			search = to_tsvector('simple', $8), description = $9, tags = $10
+		WHERE display_id = $11`
+	args := []any{updatedAt, len(content), storedContent, file.Private, file.Type,
+		nullableName(file.Name), expiresAtParam(file), db.SearchText(file, content),
+		file.Description, db.EncodeTags(file.Tags), file.ID}
+
+	cond := db.ResolvePrecondition(conds...)
+	if cond.UpdatedAt != nil {
+		args = append(args, cond.UpdatedAt.UTC())
+		query += ` AND updated_at = $` + strconv.Itoa(len(args))
+	}
+	if cond.Revision != nil {
+		args = append(args, *cond.Revision)
+		query += ` AND (SELECT COALESCE(MAX(sequence), 0) FROM revisions WHERE file_id = files.display_id) = $` +
+			strconv.Itoa(len(args))
+	}
+
+	result, err := s.ExecContext(ctx, query, args...)
 	if err != nil {
 		return nameConstraintErr(err)
 	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 && (cond.UpdatedAt != nil || cond.Revision != nil) {
+		return db.ErrPreconditionFailed
+	}
 	file.UpdatedAt, file.Size = updatedAt, uint64(len(content))
 	return nil
 }
@@ -159,13 +293,46 @@ func (s *files) UpdateContent(ctx context.Context, file *snips.File, content []b
 func (s *files) FindByUser(ctx context.Context, userID string, opts ...db.PageOption) ([]*snips.File, error) {
 	page := db.ResolvePage(opts...)
 	query := `
-		SELECT f.display_id, f.created_at, f.updated_at, f.size, f.private, f.type, f.user_id, f.name
-		FROM files AS f WHERE f.user_id = $1`
-	args := []any{userID}
+		SELECT ` + fileColumns + `
+		FROM files AS f WHERE f.user_id = $1 AND ` + notExpired(2)
+	args := []any{userID, nowUTC()}
+	if page.Cursor.ID != "" {
+		query += ` AND f.id < (
+			SELECT cursor.id FROM files AS cursor
+			WHERE cursor.display_id = $3 AND cursor.user_id = $1
+		)`
+		args = append(args, page.Cursor.ID)
+	}
+	query += ` ORDER BY f.id DESC`
+	args = applyLimit(&query, args, page)
+	return s.query(ctx, query, args...)
+}
+
+func (s *files) FindRecent(ctx context.Context, opts ...db.PageOption) ([]*snips.File, error) {
+	page := db.ResolvePage(opts...)
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files AS f WHERE ` + notExpired(1)
+	args := []any{nowUTC()}
+	if page.Cursor.ID != "" {
+		query += ` AND f.id < (SELECT cursor.id FROM files AS cursor WHERE cursor.display_id = $2)`
+		args = append(args, page.Cursor.ID)
+	}
+	query += ` ORDER BY f.id DESC`
+	args = applyLimit(&query, args, page)
+	return s.query(ctx, query, args...)
+}
+
+func (s *files) FindByTag(ctx context.Context, userID, tag string, opts ...db.PageOption) ([]*snips.File, error) {
+	page := db.ResolvePage(opts...)
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files AS f WHERE f.user_id = $1 AND f.tags ? $2 AND ` + notExpired(3)
+	args := []any{userID, tag, nowUTC()}
 	if page.Cursor.ID != "" {
 		query += ` AND f.id < (
 			SELECT cursor.id FROM files AS cursor
-			WHERE cursor.display_id = $2 AND cursor.user_id = $1
+			WHERE cursor.display_id = $4 AND cursor.user_id = $1
 		)`
 		args = append(args, page.Cursor.ID)
 	}
@@ -174,11 +341,49 @@ func (s *files) FindByUser(ctx context.Context, userID string, opts ...db.PageOp
 	return s.query(ctx, query, args...)
 }
 
+func (s *files) Search(ctx context.Context, userID, query string, opts ...db.PageOption) ([]*snips.File, error) {
+	terms := db.SearchTerms(query)
+	if len(terms) == 0 {
+		return []*snips.File{}, nil
+	}
+	// terms are only letters and numbers, so they're safe as tsquery lexemes
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	// names change without content, so they're matched here instead of being
+	// part of the indexed search column
+	page := db.ResolvePage(opts...)
+	searchQuery := `
+		SELECT ` + fileColumns + `
+		FROM files AS f WHERE f.user_id = $1
+		AND (coalesce(f.search, ''::tsvector) ||
+			to_tsvector('simple', regexp_replace(coalesce(f.name, ''), '[^[:alnum:]]+', ' ', 'g'))
+		) @@ to_tsquery('simple', $2)
+		AND ` + notExpired(3)
+	args := []any{userID, strings.Join(terms, " & "), nowUTC()}
+	if page.Cursor.ID != "" {
+		searchQuery += ` AND f.id < (
+			SELECT cursor.id FROM files AS cursor
+			WHERE cursor.display_id = $4 AND cursor.user_id = $1
+		)`
+		args = append(args, page.Cursor.ID)
+	}
+	searchQuery += ` ORDER BY f.id DESC`
+	args = applyLimit(&searchQuery, args, page)
+	return s.query(ctx, searchQuery, args...)
+}
+
 func (s *files) query(ctx context.Context, query string, args ...any) ([]*snips.File, error) {
 	rows, err := s.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	return scanFiles(rows)
+}
+
+// scanFiles scans and closes rows of fileColumns.
+func scanFiles(rows *sql.Rows) ([]*snips.File, error) {
 	defer rows.Close()
 	result := []*snips.File{}
 	for rows.Next() {
@@ -193,13 +398,14 @@ func (s *files) query(ctx context.Context, query string, args ...any) ([]*snips.
 
 func (s *files) FindByName(ctx context.Context, userID, name string) (*snips.File, error) {
 	return scanFile(s.QueryRowContext(ctx, `
-		SELECT display_id, created_at, updated_at, size, private, type, user_id, name
-		FROM files WHERE user_id = $1 AND lower(name) = lower($2)`, userID, name))
+		SELECT `+fileColumns+`
+		FROM files AS f WHERE f.user_id = $1 AND lower(f.name) = lower($2) AND `+notExpired(3), userID, name, nowUTC()))
 }
 
 func (s *files) CountByUser(ctx context.Context, userID string) (int64, error) {
 	var count int64
-	err := s.QueryRowContext(ctx, `SELECT COUNT(*) FROM files WHERE user_id = $1`, userID).Scan(&count)
+	err := s.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM files AS f WHERE f.user_id = $1 AND `+notExpired(2), userID, nowUTC()).Scan(&count)
 	return count, err
 }
 
@@ -218,17 +424,19 @@ func (s *files) Delete(ctx context.Context, fileID string) error {
 	return tx.Commit()
 }
 
-func (s *files) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+func (s *files) DeleteMany(ctx context.Context, fileIDs []string) (int64, error) {
+	if len(fileIDs) == 0 {
+		return 0, nil
+	}
 	tx, err := s.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 	defer func() { _ = tx.Rollback() }()
-	if _, err := tx.ExecContext(ctx, `
-		DELETE FROM revisions WHERE file_id IN (SELECT display_id FROM files WHERE user_id = $1)`, userID); err != nil {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM revisions WHERE file_id = ANY($1)`, fileIDs); err != nil {
 		return 0, err
 	}
-	result, err := tx.ExecContext(ctx, `DELETE FROM files WHERE user_id = $1`, userID)
+	result, err := tx.ExecContext(ctx, `DELETE FROM files WHERE display_id = ANY($1)`, fileIDs)
 	if err != nil {
 		return 0, err
 	}
@@ -238,3 +446,49 @@ func (s *files) DeleteByUser(ctx context.Context, userID string) (int64, error)
 	}
 	return count, tx.Commit()
 }
+
+func (s *files) DeleteByUser(ctx context.Context, userID string) ([]*snips.File, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM revisions WHERE file_id IN (SELECT display_id FROM files WHERE user_id = $1)`, userID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `DELETE FROM files WHERE user_id = $1 RETURNING `+fileColumns, userID)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := scanFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (s *files) DeleteExpired(ctx context.Context, now time.Time) ([]*snips.File, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM revisions WHERE file_id IN (
+			SELECT display_id FROM files WHERE expires_at IS NOT NULL AND expires_at <= $1
+		)`, now.UTC()); err != nil {
+		return nil, err
+	}
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM files WHERE expires_at IS NOT NULL AND expires_at <= $1
+		RETURNING `+fileColumns, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := scanFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
@@ -1,11 +1,17 @@
 package postgres_test
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -58,6 +64,20 @@ func TestFiles(t *testing.T) {
 		require.Nil(t, missingFile)
 	})
 
+	t.Run("FindMany", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		first := database.createTestFile(t, user.ID, "First", "content")
+		second := database.createTestFile(t, user.ID, "Second", "content")
+
+		found, err := database.Files.FindMany(t.Context(), []string{first.ID, "missing", second.ID})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*snips.File{first, second}, found)
+		found, err = database.Files.FindMany(t.Context(), nil)
+		require.NoError(t, err)
+		require.Empty(t, found)
+	})
+
 	t.Run("FindWithContent", func(t *testing.T) {
 		database := newTestDB(t)
 		user := database.createTestUser(t)
@@ -106,6 +126,30 @@ func TestFiles(t *testing.T) {
 		require.ErrorIs(t, err, db.ErrNameTaken)
 	})
 
+	t.Run("UpdateMany", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		first := database.createTestFile(t, user.ID, "First", "content")
+		second := database.createTestFile(t, user.ID, "Second", "content")
+		first.Private, second.Private = true, true
+		first.Name = ""
+
+		require.NoError(t, database.Files.UpdateMany(t.Context(), []*snips.File{first, second}))
+		found, err := database.Files.FindMany(t.Context(), []string{first.ID, second.ID})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []*snips.File{first, second}, found)
+
+		// a failure rolls back the whole batch
+		first.Private = false
+		second.Name = "fIRST"
+		database.createTestFile(t, user.ID, "First", "content")
+		err = database.Files.UpdateMany(t.Context(), []*snips.File{first, second})
+		require.ErrorIs(t, err, db.ErrNameTaken)
+		unchanged, err := database.Files.Find(t.Context(), first.ID)
+		require.NoError(t, err)
+		require.True(t, unchanged.Private)
+	})
+
 	t.Run("UpdateContent", func(t *testing.T) {
 		database := newTestDB(t)
 		user := database.createTestUser(t)
@@ -130,6 +174,49 @@ func TestFiles(t *testing.T) {
 		require.Equal(t, []byte("new content"), content)
 	})
 
+	t.Run("UpdateContentPreconditions", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		file := database.createTestFile(t, user.ID, "Guarded", "original content")
+		found, err := database.Files.Find(t.Context(), file.ID)
+		require.NoError(t, err)
+		loadedAt := found.UpdatedAt
+
+		require.NoError(t, database.Files.UpdateContent(t.Context(), found, []byte("first"), db.IfUpdatedAt(loadedAt), db.IfRevision(0)))
+
+		stale := *file
+		err = database.Files.UpdateContent(t.Context(), &stale, []byte("second"), db.IfUpdatedAt(loadedAt))
+		require.ErrorIs(t, err, db.ErrPreconditionFailed)
+		require.Equal(t, file.UpdatedAt, stale.UpdatedAt)
+
+		revision := testutil.Fixtures.Revision(t)
+		revision.FileID = file.ID
+		require.NoError(t, database.Revisions.Create(t.Context(), &revision, []byte("diff"), 0))
+
+		err = database.Files.UpdateContent(t.Context(), found, []byte("third"), db.IfRevision(0))
+		require.ErrorIs(t, err, db.ErrPreconditionFailed)
+		require.NoError(t, database.Files.UpdateContent(t.Context(), found, []byte("third"), db.IfRevision(revision.Sequence), db.IfUpdatedAt(found.UpdatedAt)))
+
+		content, err := database.Files.FindContent(t.Context(), file.ID)
+		require.NoError(t, err)
+		require.Equal(t, []byte("third"), content)
+	})
+
+	t.Run("FindRecent", func(t *testing.T) {
+		database := newTestDB(t)
+		first := database.createTestFile(t, database.createTestUser(t).ID, "", "first")
+		second := database.createTestFile(t, database.createTestUser(t).ID, "", "second")
+		third := database.createTestFile(t, database.createTestUser(t).ID, "", "third")
+
+		files, err := database.Files.FindRecent(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, []*snips.File{third, second, first}, files)
+
+		files, err = database.Files.FindRecent(t.Context(), db.WithLimit(1), db.WithCursor(db.Cursor{ID: third.ID}))
+		require.NoError(t, err)
+		require.Equal(t, []*snips.File{second}, files)
+	})
+
 	t.Run("FindByUser", func(t *testing.T) {
 		database := newTestDB(t)
 		user := database.createTestUser(t)
@@ -208,6 +295,27 @@ func TestFiles(t *testing.T) {
 		require.NoError(t, database.Files.Delete(t.Context(), "missing"))
 	})
 
+	t.Run("DeleteMany", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		first := database.createTestFile(t, user.ID, "First", "content")
+		second := database.createTestFile(t, user.ID, "Second", "content")
+		kept := database.createTestFile(t, user.ID, "Kept", "content")
+		revision := testutil.Fixtures.Revision(t)
+		revision.FileID = first.ID
+		require.NoError(t, database.Revisions.Create(t.Context(), &revision, []byte("diff"), 0))
+
+		count, err := database.Files.DeleteMany(t.Context(), []string{first.ID, second.ID, "missing"})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, count)
+		found, err := database.Files.FindMany(t.Context(), []string{first.ID, second.ID, kept.ID})
+		require.NoError(t, err)
+		require.Equal(t, []*snips.File{kept}, found)
+		revisions, err := database.Revisions.CountByFileID(t.Context(), first.ID)
+		require.NoError(t, err)
+		require.Zero(t, revisions)
+	})
+
 	t.Run("DeleteByUser", func(t *testing.T) {
 		database := newTestDB(t)
 		user := database.createTestUser(t)
@@ -225,7 +333,7 @@ func TestFiles(t *testing.T) {
 
 		deletedFiles, err := database.Files.DeleteByUser(t.Context(), user.ID)
 		require.NoError(t, err)
-		require.Equal(t, int64(2), deletedFiles)
+		require.ElementsMatch(t, []*snips.File{firstFile, secondFile}, deletedFiles)
 		count, err := database.Files.CountByUser(t.Context(), user.ID)
 		require.NoError(t, err)
 		require.Zero(t, count)
@@ -240,6 +348,240 @@ func TestFiles(t *testing.T) {
 
 		deletedFiles, err = database.Files.DeleteByUser(t.Context(), "missing")
 		require.NoError(t, err)
-		require.Zero(t, deletedFiles)
+		require.Empty(t, deletedFiles)
+	})
+
+	t.Run("FindWithContentAndDelete", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		file := testutil.Fixtures.File(t)
+		file.UserID = user.ID
+		file.Type = "plaintext"
+		file.BurnAfterRead = true
+		require.NoError(t, database.Files.Create(t.Context(), &file, []byte("secret"), 0))
+		revision := testutil.Fixtures.Revision(t)
+		revision.FileID = file.ID
+		require.NoError(t, database.Revisions.Create(t.Context(), &revision, []byte("diff"), 0))
+
+		// of concurrent readers, exactly one gets the content
+		var wg sync.WaitGroup
+		var burned atomic.Int32
+		for range 8 {
+			wg.Go(func() {
+				foundFile, content, err := database.Files.FindWithContentAndDelete(t.Context(), file.ID)
+				assert.NoError(t, err)
+				if foundFile != nil {
+					assert.Equal(t, &file, foundFile)
+					assert.Equal(t, []byte("secret"), content)
+					burned.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), burned.Load())
+
+		missingFile, err := database.Files.Find(t.Context(), file.ID)
+		require.NoError(t, err)
+		require.Nil(t, missingFile)
+		count, err := database.Revisions.CountByFileID(t.Context(), file.ID)
+		require.NoError(t, err)
+		require.Zero(t, count)
+	})
+
+	t.Run("DeleteExpired", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		now := time.Now().UTC()
+		create := func(name string, expiresAt *time.Time) *snips.File {
+			file := testutil.Fixtures.File(t)
+			file.UserID = user.ID
+			file.Type = "plaintext"
+			file.Name = name
+			file.ExpiresAt = expiresAt
+			require.NoError(t, database.Files.Create(t.Context(), &file, []byte("content"), 0))
+			return &file
+		}
+		past, future := now.Add(-time.Minute), now.Add(time.Hour)
+		expiredFile := create("Expired", &past)
+		liveFile := create("Live", &future)
+		foreverFile := create("Forever", nil)
+		revision := testutil.Fixtures.Revision(t)
+		revision.FileID = expiredFile.ID
+		require.NoError(t, database.Revisions.Create(t.Context(), &revision, []byte("diff"), 0))
+
+		foundLiveFile, err := database.Files.Find(t.Context(), liveFile.ID)
+		require.NoError(t, err)
+		require.Equal(t, liveFile, foundLiveFile)
+
+		deletedFiles, err := database.Files.DeleteExpired(t.Context(), now)
+		require.NoError(t, err)
+		require.Len(t, deletedFiles, 1)
+		require.Equal(t, expiredFile.ID, deletedFiles[0].ID)
+		missingFile, err := database.Files.Find(t.Context(), expiredFile.ID)
+		require.NoError(t, err)
+		require.Nil(t, missingFile)
+		count, err := database.Revisions.CountByFileID(t.Context(), expiredFile.ID)
+		require.NoError(t, err)
+		require.Zero(t, count)
+		for _, file := range []*snips.File{liveFile, foreverFile} {
+			foundFile, err := database.Files.Find(t.Context(), file.ID)
+			require.NoError(t, err)
+			require.NotNil(t, foundFile)
+		}
+	})
+
+	t.Run("ExpiredNotListed", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		now := time.Now().UTC()
+		create := func(name string, expiresAt *time.Time) *snips.File {
+			file := testutil.Fixtures.File(t)
+			file.UserID = user.ID
+			file.Type = "plaintext"
+			file.Name = name
+			file.ExpiresAt = expiresAt
+			file.Tags = []string{"notes"}
+			require.NoError(t, database.Files.Create(t.Context(), &file, []byte("hello world"), 0))
+			return &file
+		}
+		// not yet purged by the reaper
+		past, future := now.Add(-time.Minute), now.Add(time.Hour)
+		expiredFile := create("Expired", &past)
+		liveFile := create("Live", &future)
+		foreverFile := create("Forever", nil)
+		want := []string{foreverFile.ID, liveFile.ID}
+
+		ids := func(files []*snips.File, err error) []string {
+			require.NoError(t, err)
+			ids := []string{}
+			for _, file := range files {
+				ids = append(ids, file.ID)
+			}
+			return ids
+		}
+		require.Equal(t, want, ids(database.Files.FindByUser(t.Context(), user.ID)))
+		require.Equal(t, want, ids(database.Files.FindByTag(t.Context(), user.ID, "notes")))
+		require.Equal(t, want, ids(database.Files.Search(t.Context(), user.ID, "hello")))
+		require.Equal(t, want, ids(database.Files.FindRecent(t.Context())))
+		// the cursor is numbered after the expiry argument
+		require.Equal(t, want[1:], ids(database.Files.FindByUser(t.Context(), user.ID, db.WithCursor(db.Cursor{ID: foreverFile.ID}))))
+
+		foundFile, err := database.Files.FindByName(t.Context(), user.ID, "expired")
+		require.NoError(t, err)
+		require.Nil(t, foundFile)
+		count, err := database.Files.CountByUser(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+		// an expired file doesn't hold on to its name
+		reusedFile := create("expired", nil)
+		foundFile, err = database.Files.FindByName(t.Context(), user.ID, "Expired")
+		require.NoError(t, err)
+		require.Equal(t, reusedFile.ID, foundFile.ID)
+		foundFile, err = database.Files.Find(t.Context(), expiredFile.ID)
+		require.NoError(t, err)
+		require.Empty(t, foundFile.Name)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		other := database.createTestUser(t)
+		create := func(userID, name, fileType string, content []byte) *snips.File {
+			file := testutil.Fixtures.File(t)
+			file.UserID = userID
+			file.Type = fileType
+			file.Name = name
+			require.NoError(t, database.Files.Create(t.Context(), &file, content, 0))
+			return &file
+		}
+		search := func(query string, opts ...db.PageOption) []string {
+			files, err := database.Files.Search(t.Context(), user.ID, query, opts...)
+			require.NoError(t, err)
+			ids := []string{}
+			for _, file := range files {
+				ids = append(ids, file.ID)
+			}
+			return ids
+		}
+
+		nginx := create(user.ID, "", "nginx", []byte("# nginx\nserver {\n  server_name example.com;\n}\n"))
+		notes := create(user.ID, "deploy-notes", "markdown", []byte("# Deploying\n\nRun the migrations first.\n"))
+		binary := create(user.ID, "", snips.FileTypeBinary, []byte{0x00, 'n', 'g', 'i', 'n', 'x'})
+		create(other.ID, "", "nginx", []byte("server_name other.example.com;"))
+
+		assert.Equal(t, []string{nginx.ID}, search("nginx"))
+		assert.Equal(t, []string{nginx.ID}, search("SERVER_NAME exam"))
+		assert.Equal(t, []string{notes.ID}, search("deploy"))
+		assert.Empty(t, search("server migrations"))
+		assert.Empty(t, search(`" | ! :*`))
+		assert.Equal(t, []string{notes.ID, nginx.ID}, search("n"))
+		assert.Equal(t, []string{nginx.ID}, search("n", db.WithCursor(db.Cursor{ID: notes.ID})))
+
+		binary.Name = "nginx-logo"
+		require.NoError(t, database.Files.Update(t.Context(), binary))
+		assert.Equal(t, []string{binary.ID, nginx.ID}, search("nginx"))
+
+		require.NoError(t, database.Files.UpdateContent(t.Context(), nginx, []byte("listen 443 ssl;")))
+		assert.Equal(t, []string{nginx.ID}, search("443 ssl"))
+
+		// too many unique words for a tsvector are indexed only in part
+		words := &strings.Builder{}
+		for i := range 200_000 {
+			fmt.Fprintf(words, "w%d ", i)
+		}
+		require.NoError(t, database.Files.UpdateContent(t.Context(), notes, []byte(words.String())))
+		assert.Equal(t, []string{notes.ID}, search("w0"))
+		assert.Empty(t, search("w199999"))
+		assert.Equal(t, []string{binary.ID}, search("nginx"))
+	})
+
+	t.Run("DescriptionAndTags", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		other := database.createTestUser(t)
+		create := func(userID string, tags ...string) *snips.File {
+			file := testutil.Fixtures.File(t)
+			file.UserID = userID
+			file.Description = "scratch"
+			file.Tags = tags
+			require.NoError(t, database.Files.Create(t.Context(), &file, []byte("hello"), 0))
+			return &file
+		}
+		findByTag := func(tag string, opts ...db.PageOption) []string {
+			files, err := database.Files.FindByTag(t.Context(), user.ID, tag, opts...)
+			require.NoError(t, err)
+			ids := []string{}
+			for _, file := range files {
+				ids = append(ids, file.ID)
+			}
+			return ids
+		}
+
+		first := create(user.ID, "go", "infra")
+		second := create(user.ID, "go")
+		untagged := create(user.ID)
+		create(other.ID, "go")
+
+		found, err := database.Files.Find(t.Context(), first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "scratch", found.Description)
+		assert.Equal(t, []string{"go", "infra"}, found.Tags)
+
+		found, err = database.Files.Find(t.Context(), untagged.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.Tags)
+
+		assert.Equal(t, []string{second.ID, first.ID}, findByTag("go"))
+		assert.Equal(t, []string{first.ID}, findByTag("go", db.WithCursor(db.Cursor{ID: second.ID})))
+		assert.Empty(t, findByTag("inf"))
+
+		first.Description = ""
+		first.Tags = []string{"deploy"}
+		require.NoError(t, database.Files.Update(t.Context(), first))
+		found, err = database.Files.Find(t.Context(), first.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found.Description)
+		assert.Equal(t, []string{"deploy"}, found.Tags)
+		assert.Equal(t, []string{second.ID}, findByTag("go"))
 	})
 }
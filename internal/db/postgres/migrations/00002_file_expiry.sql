@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE files ADD COLUMN expires_at timestamptz;
+
+CREATE INDEX idx_files_expires_at ON files (expires_at) WHERE expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_files_expires_at;
+
+ALTER TABLE files DROP COLUMN expires_at;
+-- +goose StatementEnd
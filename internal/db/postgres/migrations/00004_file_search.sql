@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- built by the application from the decompressed content (see db.SearchText),
+-- NULL until the file is indexed
+ALTER TABLE files ADD COLUMN search tsvector;
+
+CREATE INDEX idx_files_search ON files USING gin (search);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_files_search;
+
+ALTER TABLE files DROP COLUMN search;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE files ADD COLUMN description text NOT NULL DEFAULT '';
+
+-- a JSON array of normalized tags (see snips.NormalizeTags), queried with ?
+ALTER TABLE files ADD COLUMN tags jsonb NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_files_tags ON files USING gin (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_files_tags;
+
+ALTER TABLE files DROP COLUMN tags;
+
+ALTER TABLE files DROP COLUMN description;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_public_keys_user_id ON public_keys (user_id);
+
+-- pending codes for linking another key to a user, see snips.KeyLink
+CREATE TABLE public_key_links (
+    code_hash text PRIMARY KEY,
+    user_id text NOT NULL,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL
+);
+
+CREATE INDEX idx_public_key_links_expires_at ON public_key_links (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE public_key_links;
+
+DROP INDEX idx_public_keys_user_id;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhooks (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    display_id text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    user_id text NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    -- a JSON array of normalized events, see snips.NormalizeWebhookEvents
+    events jsonb NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_webhooks_user_id_created_at ON webhooks (user_id, created_at DESC, display_id DESC);
+
+-- events queued for, sent to, or given up on by webhooks, see snips.WebhookDelivery
+CREATE TABLE webhook_deliveries (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    display_id text NOT NULL UNIQUE,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL,
+    webhook_id text NOT NULL,
+    event text NOT NULL,
+    file_id text NOT NULL,
+    payload bytea NOT NULL,
+    status text NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz,
+    response_code integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_webhook_deliveries_webhook_id_id ON webhook_deliveries (webhook_id, id DESC);
+CREATE INDEX idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
+-- +goose StatementEnd
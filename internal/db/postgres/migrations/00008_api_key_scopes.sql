@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a JSON array of normalized scopes (see snips.NormalizeAPIKeyScopes), empty grants every scope
+ALTER TABLE api_keys ADD COLUMN scopes jsonb NOT NULL DEFAULT '[]';
+
+-- a JSON array of the file ids a key is restricted to, empty allows every file
+ALTER TABLE api_keys ADD COLUMN file_ids jsonb NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys DROP COLUMN file_ids;
+
+ALTER TABLE api_keys DROP COLUMN scopes;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- set when an admin bans the user, who can then no longer connect
+ALTER TABLE users ADD COLUMN banned_at timestamptz;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN banned_at;
+-- +goose StatementEnd
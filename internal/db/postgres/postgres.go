@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"io/fs"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -32,27 +34,110 @@ func New(value string, compress bool) (*db.DB, error) {
 // NewWithDB builds a PostgreSQL backend around an existing connection pool.
 func NewWithDB(database *sql.DB, compress bool) *db.DB {
 	return &db.DB{
-		Migrator:   &migrator{DB: database},
-		Closer:     database,
-		Files:      &files{DB: database, compress: compress},
-		PublicKeys: &publicKeys{DB: database},
-		Users:      &users{DB: database},
-		Revisions:  &revisions{DB: database, compress: compress},
-		APIKeys:    &apiKeys{DB: database},
+		Migrator:          &migrator{DB: database},
+		Closer:            database,
+		Files:             &files{DB: database, compress: compress},
+		PublicKeys:        &publicKeys{DB: database},
+		Users:             &users{DB: database},
+		Revisions:         &revisions{DB: database, compress: compress},
+		APIKeys:           &apiKeys{DB: database},
+		Webhooks:          &webhooks{DB: database},
+		WebhookDeliveries: &webhookDeliveries{DB: database},
+		Stats:             &stats{DB: database},
 	}
 }
 
-func (s *migrator) Migrate(ctx context.Context) error {
+func (s *migrator) provider() (*goose.Provider, error) {
 	migrations, err := fs.Sub(migrations, "migrations")
 	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, s.DB, migrations)
+}
+
+func (s *migrator) Migrate(ctx context.Context) error {
+	provider, err := s.provider()
+	if err != nil {
+		return err
+	}
+	if _, err := provider.Up(ctx); err != nil {
 		return err
 	}
-	provider, err := goose.NewProvider(goose.DialectPostgres, s.DB, migrations)
+	return s.indexFiles(ctx)
+}
+
+func (s *migrator) MigrationStatus(ctx context.Context) ([]*db.Migration, error) {
+	provider, err := s.provider()
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]*db.Migration, 0, len(statuses))
+	for _, status := range statuses {
+		migration := &db.Migration{Version: status.Source.Version, Source: filepath.Base(status.Source.Path)}
+		if status.State == goose.StateApplied {
+			appliedAt := status.AppliedAt.UTC()
+			migration.AppliedAt = &appliedAt
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+func (s *migrator) MigrateDown(ctx context.Context) (*db.Migration, error) {
+	provider, err := s.provider()
 	if err != nil {
+		return nil, err
+	}
+	result, err := provider.Down(ctx)
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		return nil, db.ErrNoMigrations
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &db.Migration{Version: result.Source.Version, Source: filepath.Base(result.Source.Path)}, nil
+}
+
+// indexFiles builds the search column of files created before it existed.
+// Content may be compressed, so this can't be a migration.
+func (s *migrator) indexFiles(ctx context.Context) error {
+	rows, err := s.QueryContext(ctx, `SELECT display_id FROM files WHERE search IS NULL`)
+	if err != nil {
+		return err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
-	_, err = provider.Up(ctx)
-	return err
+
+	store := &files{DB: s.DB}
+	for _, id := range ids {
+		file, content, err := store.FindWithContent(ctx, id)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			continue
+		}
+		if _, err := s.ExecContext(ctx, `UPDATE files SET search = to_tsvector('simple', $1) WHERE display_id = $2`,
+			db.SearchText(file, content), id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func applyLimit(query *string, args []any, page db.Page) []any {
@@ -4,25 +4,238 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
 type publicKeys struct{ *sql.DB }
 
-func (s *publicKeys) FindByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
+func scanPublicKey(scan func(...any) error) (*snips.PublicKey, error) {
 	key := &snips.PublicKey{}
-	err := s.QueryRowContext(ctx, `
+	if err := scan(&key.ID, &key.CreatedAt, &key.UpdatedAt, &key.Fingerprint, &key.Type, &key.UserID); err != nil {
+		return nil, err
+	}
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.UpdatedAt = key.UpdatedAt.UTC()
+	return key, nil
+}
+
+func (s *publicKeys) FindByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
+	key, err := scanPublicKey(s.QueryRowContext(ctx, `
 		SELECT display_id, created_at, updated_at, fingerprint, type, user_id
 		FROM public_keys WHERE fingerprint = $1`, fingerprint,
-	).Scan(&key.ID, &key.CreatedAt, &key.UpdatedAt, &key.Fingerprint, &key.Type, &key.UserID)
+	).Scan)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	key.CreatedAt = key.CreatedAt.UTC()
-	key.UpdatedAt = key.UpdatedAt.UTC()
 	return key, nil
 }
+
+func (s *publicKeys) FindByUser(ctx context.Context, userID string) ([]*snips.PublicKey, error) {
+	rows, err := s.QueryContext(ctx, `
+		SELECT display_id, created_at, updated_at, fingerprint, type, user_id
+		FROM public_keys WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*snips.PublicKey{}
+	for rows.Next() {
+		key, err := scanPublicKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func fingerprintConstraintErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "public_keys_fingerprint_key" {
+		return db.ErrPublicKeyTaken
+	}
+	return err
+}
+
+func (s *publicKeys) Create(ctx context.Context, publicKey *snips.PublicKey) error {
+	now := nowUTC()
+	keyID := id.New()
+	_, err := s.ExecContext(ctx, `
+		INSERT INTO public_keys (display_id, created_at, updated_at, fingerprint, type, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		keyID, now, now, publicKey.Fingerprint, publicKey.Type, publicKey.UserID,
+	)
+	if err != nil {
+		return fingerprintConstraintErr(err)
+	}
+	publicKey.ID, publicKey.CreatedAt, publicKey.UpdatedAt = keyID, now, now
+	return nil
+}
+
+func (s *publicKeys) Delete(ctx context.Context, keyID, userID string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// a concurrent delete or claim of another of the user's keys waits for
+	// these locks, so the user can't be left without any
+	count, err := lockKeys(ctx, tx, userID)
+	if err != nil || count <= 1 {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM public_keys WHERE display_id = $1 AND user_id = $2`, keyID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, tx.Commit()
+}
+
+// lockKeys locks a user's public keys until the transaction ends, returning
+// how many there are.
+func lockKeys(ctx context.Context, tx *sql.Tx, userID string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT display_id FROM public_keys WHERE user_id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
+
+func (s *publicKeys) DeleteByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
+	key, err := scanPublicKey(s.QueryRowContext(ctx, `
+		DELETE FROM public_keys WHERE fingerprint = $1
+		RETURNING display_id, created_at, updated_at, fingerprint, type, user_id`, fingerprint,
+	).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *publicKeys) CreateLink(ctx context.Context, link *snips.KeyLink) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := nowUTC()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM public_key_links WHERE expires_at <= $1`, now); err != nil {
+		return err
+	}
+
+	expiresAt := link.ExpiresAt.UTC().Truncate(time.Microsecond)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO public_key_links (code_hash, user_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)`,
+		link.CodeHash, link.UserID, now, expiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	link.CreatedAt, link.ExpiresAt = now, expiresAt
+	return nil
+}
+
+// checkOrphans returns ErrKeyHasFiles if the user has only one key left and
+// unexpired files, which moving the key away would orphan. It holds locks on
+// the user's keys and row until the transaction ends, so neither another key
+// nor a file can go or come in the meantime.
+func checkOrphans(ctx context.Context, tx *sql.Tx, userID string) error {
+	keys, err := lockKeys(ctx, tx, userID)
+	if err != nil || keys > 1 {
+		return err
+	}
+	// files.Create shares this lock, so a file can't be uploaded unseen
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE display_id = $1 FOR UPDATE`, userID); err != nil {
+		return err
+	}
+	var files int64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM files AS f WHERE f.user_id = $1 AND `+notExpired(2), userID, nowUTC(),
+	).Scan(&files); err != nil {
+		return err
+	}
+	if files > 0 {
+		return db.ErrKeyHasFiles
+	}
+	return nil
+}
+
+func (s *publicKeys) ClaimLink(ctx context.Context, codeHash, fingerprint string) (*snips.PublicKey, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// deleting the link claims it, so a code can't be used twice
+	var userID string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM public_key_links
+		WHERE code_hash = $1 AND expires_at > $2
+		RETURNING user_id`, codeHash, nowUTC(),
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrLinkInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var previousUserID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id FROM public_keys WHERE fingerprint = $1 FOR UPDATE`, fingerprint,
+	).Scan(&previousUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrLinkInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if previousUserID != userID {
+		if err := checkOrphans(ctx, tx, previousUserID); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := scanPublicKey(tx.QueryRowContext(ctx, `
+		UPDATE public_keys SET user_id = $1, updated_at = $2
+		WHERE fingerprint = $3
+		RETURNING display_id, created_at, updated_at, fingerprint, type, user_id`,
+		userID, nowUTC(), fingerprint,
+	).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, db.ErrLinkInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, tx.Commit()
+}
@@ -1,9 +1,15 @@
 package postgres_test
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
+	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,3 +29,136 @@ func TestPublicKeys(t *testing.T) {
 		require.Nil(t, missingPublicKey)
 	})
 }
+
+func TestPublicKeysLinking(t *testing.T) {
+	t.Run("CreateAndFindByUser", func(t *testing.T) {
+		database := newTestDB(t)
+		userID := id.New()
+
+		first := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+		second := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-rsa", UserID: userID}
+		require.NoError(t, database.PublicKeys.Create(t.Context(), first))
+		require.NoError(t, database.PublicKeys.Create(t.Context(), second))
+
+		keys, err := database.PublicKeys.FindByUser(t.Context(), userID)
+		require.NoError(t, err)
+		require.Equal(t, []*snips.PublicKey{first, second}, keys)
+
+		err = database.PublicKeys.Create(t.Context(), &snips.PublicKey{Fingerprint: first.Fingerprint, Type: "ssh-ed25519", UserID: id.New()})
+		require.ErrorIs(t, err, db.ErrPublicKeyTaken)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		database := newTestDB(t)
+		userID := id.New()
+
+		first := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+		second := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+		require.NoError(t, database.PublicKeys.Create(t.Context(), first))
+		require.NoError(t, database.PublicKeys.Create(t.Context(), second))
+
+		deleted, err := database.PublicKeys.Delete(t.Context(), first.ID, id.New())
+		require.NoError(t, err)
+		require.False(t, deleted)
+
+		deleted, err = database.PublicKeys.Delete(t.Context(), first.ID, userID)
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		deleted, err = database.PublicKeys.Delete(t.Context(), second.ID, userID)
+		require.NoError(t, err)
+		require.False(t, deleted)
+	})
+
+	t.Run("DeleteByFingerprint", func(t *testing.T) {
+		database := newTestDB(t)
+		publicKey := testutil.Fixtures.PublicKey(t)
+		user, err := database.Users.CreateWithPublicKey(t.Context(), &publicKey)
+		require.NoError(t, err)
+
+		// unlike Delete, the last key is removed
+		deleted, err := database.PublicKeys.DeleteByFingerprint(t.Context(), publicKey.Fingerprint)
+		require.NoError(t, err)
+		require.NotNil(t, deleted)
+		require.Equal(t, publicKey.ID, deleted.ID)
+		require.Equal(t, user.ID, deleted.UserID)
+
+		deleted, err = database.PublicKeys.DeleteByFingerprint(t.Context(), publicKey.Fingerprint)
+		require.NoError(t, err)
+		require.Nil(t, deleted)
+	})
+
+	t.Run("ClaimLink", func(t *testing.T) {
+		database := newTestDB(t)
+		userID := id.New()
+
+		key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: id.New()}
+		require.NoError(t, database.PublicKeys.Create(t.Context(), key))
+
+		_, hash, err := snips.NewKeyLinkCode()
+		require.NoError(t, err)
+		link := &snips.KeyLink{CodeHash: hash, UserID: userID, ExpiresAt: time.Now().Add(snips.KeyLinkTTL)}
+		require.NoError(t, database.PublicKeys.CreateLink(t.Context(), link))
+
+		claimed, err := database.PublicKeys.ClaimLink(t.Context(), hash, key.Fingerprint)
+		require.NoError(t, err)
+		require.Equal(t, userID, claimed.UserID)
+
+		_, err = database.PublicKeys.ClaimLink(t.Context(), hash, key.Fingerprint)
+		require.ErrorIs(t, err, db.ErrLinkInvalid)
+
+		_, expiredHash, err := snips.NewKeyLinkCode()
+		require.NoError(t, err)
+		expired := &snips.KeyLink{CodeHash: expiredHash, UserID: userID, ExpiresAt: time.Now().Add(-time.Minute)}
+		require.NoError(t, database.PublicKeys.CreateLink(t.Context(), expired))
+
+		_, err = database.PublicKeys.ClaimLink(t.Context(), expiredHash, key.Fingerprint)
+		require.ErrorIs(t, err, db.ErrLinkInvalid)
+	})
+
+	t.Run("ClaimLinkOrphanedFiles", func(t *testing.T) {
+		database := newTestDB(t)
+		publicKey := testutil.Fixtures.PublicKey(t)
+		user, err := database.Users.CreateWithPublicKey(t.Context(), &publicKey)
+		require.NoError(t, err)
+		database.createTestFile(t, user.ID, "", "content")
+
+		_, hash, err := snips.NewKeyLinkCode()
+		require.NoError(t, err)
+		link := &snips.KeyLink{CodeHash: hash, UserID: id.New(), ExpiresAt: time.Now().Add(snips.KeyLinkTTL)}
+		require.NoError(t, database.PublicKeys.CreateLink(t.Context(), link))
+
+		_, err = database.PublicKeys.ClaimLink(t.Context(), hash, publicKey.Fingerprint)
+		require.ErrorIs(t, err, db.ErrKeyHasFiles)
+
+		otherKey := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: user.ID}
+		require.NoError(t, database.PublicKeys.Create(t.Context(), otherKey))
+		claimed, err := database.PublicKeys.ClaimLink(t.Context(), hash, publicKey.Fingerprint)
+		require.NoError(t, err)
+		require.Equal(t, link.UserID, claimed.UserID)
+	})
+
+	t.Run("DeleteConcurrently", func(t *testing.T) {
+		database := newTestDB(t)
+		userID := id.New()
+		keys := make([]*snips.PublicKey, 4)
+		for i := range keys {
+			keys[i] = &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+			require.NoError(t, database.PublicKeys.Create(t.Context(), keys[i]))
+		}
+
+		// racing deletes of every key still leave one behind
+		var wg sync.WaitGroup
+		for _, key := range keys {
+			wg.Go(func() {
+				_, err := database.PublicKeys.Delete(t.Context(), key.ID, userID)
+				assert.NoError(t, err)
+			})
+		}
+		wg.Wait()
+
+		remaining, err := database.PublicKeys.FindByUser(t.Context(), userID)
+		require.NoError(t, err)
+		require.Len(t, remaining, 1)
+	})
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/robherley/snips.sh/internal/db"
+)
+
+type stats struct{ *sql.DB }
+
+func (s *stats) Count(ctx context.Context) (*db.Counts, error) {
+	counts := &db.Counts{}
+	if err := s.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM public_keys),
+			(SELECT COUNT(*) FROM files),
+			(SELECT COUNT(*) FROM revisions),
+			(SELECT COUNT(*) FROM api_keys),
+			(SELECT COUNT(*) FROM webhooks),
+			(SELECT COUNT(*) FROM webhook_deliveries),
+			(SELECT COALESCE(SUM(size), 0) FROM files)`,
+	).Scan(
+		&counts.Users, &counts.PublicKeys, &counts.Files, &counts.Revisions,
+		&counts.APIKeys, &counts.Webhooks, &counts.WebhookDeliveries, &counts.FileBytes,
+	); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
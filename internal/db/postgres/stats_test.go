@@ -0,0 +1,36 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	database := newTestDB(t)
+
+	counts, err := database.Stats.Count(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, &db.Counts{}, counts)
+
+	user := database.createTestUser(t)
+	database.createTestFile(t, user.ID, "", "hello")
+	database.createTestFile(t, user.ID, "", "world!")
+
+	counts, err = database.Stats.Count(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, &db.Counts{Users: 1, PublicKeys: 1, Files: 2, FileBytes: 11}, counts)
+}
+
+func TestMigrationStatus(t *testing.T) {
+	database := newTestDB(t)
+
+	migrations, err := database.MigrationStatus(t.Context())
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	require.Equal(t, "00001_init.sql", migrations[0].Source)
+	for _, migration := range migrations {
+		require.NotNil(t, migration.AppliedAt, migration.Source)
+	}
+}
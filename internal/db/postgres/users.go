@@ -5,12 +5,32 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
+// userColumns are the columns scanned by scanUser, in order.
+const userColumns = `display_id, created_at, updated_at, theme_color, banned_at`
+
 type users struct{ *sql.DB }
 
+// scanUser scans a row of userColumns.
+func scanUser(scan func(dest ...any) error) (*snips.User, error) {
+	user := &snips.User{}
+	var bannedAt sql.NullTime
+	if err := scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.ThemeColor, &bannedAt); err != nil {
+		return nil, err
+	}
+	user.CreatedAt = user.CreatedAt.UTC()
+	user.UpdatedAt = user.UpdatedAt.UTC()
+	if bannedAt.Valid {
+		t := bannedAt.Time.UTC()
+		user.BannedAt = &t
+	}
+	return user, nil
+}
+
 func (s *users) CreateWithPublicKey(ctx context.Context, publicKey *snips.PublicKey) (*snips.User, error) {
 	tx, err := s.BeginTx(ctx, nil)
 	if err != nil {
@@ -47,18 +67,15 @@ func (s *users) CreateWithPublicKey(ctx context.Context, publicKey *snips.Public
 }
 
 func (s *users) Find(ctx context.Context, userID string) (*snips.User, error) {
-	user := &snips.User{}
-	err := s.QueryRowContext(ctx, `
-		SELECT display_id, created_at, updated_at, theme_color FROM users WHERE display_id = $1`, userID,
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.ThemeColor)
+	user, err := scanUser(s.QueryRowContext(ctx,
+		`SELECT `+userColumns+` FROM users WHERE display_id = $1`, userID,
+	).Scan)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	user.CreatedAt = user.CreatedAt.UTC()
-	user.UpdatedAt = user.UpdatedAt.UTC()
 	return user, nil
 }
 
@@ -73,3 +90,91 @@ func (s *users) Update(ctx context.Context, user *snips.User) error {
 	user.UpdatedAt = updatedAt
 	return nil
 }
+
+func (s *users) Ban(ctx context.Context, userID string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// banning twice keeps the original ban time
+	now := nowUTC()
+	result, err := tx.ExecContext(ctx,
+		`UPDATE users SET banned_at = COALESCE(banned_at, $1), updated_at = $1 WHERE display_id = $2`,
+		now, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = $1`, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *users) List(ctx context.Context, opts ...db.PageOption) ([]*snips.User, error) {
+	page := db.ResolvePage(opts...)
+	query := `SELECT ` + userColumns + ` FROM users AS u`
+	args := []any{}
+	if page.Cursor.ID != "" {
+		query += ` WHERE u.id < (SELECT cursor.id FROM users AS cursor WHERE cursor.display_id = $1)`
+		args = append(args, page.Cursor.ID)
+	}
+	query += ` ORDER BY u.id DESC`
+	args = applyLimit(&query, args, page)
+
+	rows, err := s.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*snips.User{}
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (s *users) Delete(ctx context.Context, userID string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// nothing cascades, so everything the user owns goes first
+	for _, query := range []string{
+		`DELETE FROM revisions WHERE file_id IN (SELECT display_id FROM files WHERE user_id = $1)`,
+		`DELETE FROM files WHERE user_id = $1`,
+		`DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT display_id FROM webhooks WHERE user_id = $1)`,
+		`DELETE FROM webhooks WHERE user_id = $1`,
+		`DELETE FROM api_keys WHERE user_id = $1`,
+		`DELETE FROM public_key_links WHERE user_id = $1`,
+		`DELETE FROM public_keys WHERE user_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE display_id = $1`, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
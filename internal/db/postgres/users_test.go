@@ -3,6 +3,9 @@ package postgres_test
 import (
 	"testing"
 
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
+	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -57,4 +60,87 @@ func TestUsers(t *testing.T) {
 		require.Equal(t, "#abcdef", foundUser.ThemeColor)
 		require.Equal(t, user.UpdatedAt, foundUser.UpdatedAt)
 	})
+	t.Run("List", func(t *testing.T) {
+		database := newTestDB(t)
+		first := database.createTestUser(t)
+		second := database.createTestUser(t)
+		third := database.createTestUser(t)
+
+		users, err := database.Users.List(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, []*snips.User{third, second, first}, users)
+
+		users, err = database.Users.List(t.Context(), db.WithLimit(1), db.WithCursor(db.Cursor{ID: third.ID}))
+		require.NoError(t, err)
+		require.Equal(t, []*snips.User{second}, users)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		other := database.createTestUser(t)
+
+		for _, userID := range []string{user.ID, other.ID} {
+			file := database.createTestFile(t, userID, "", "hello")
+			require.NoError(t, database.Revisions.Create(t.Context(), &snips.Revision{FileID: file.ID, Sequence: 1, Type: "plaintext"}, []byte("diff"), 0))
+			require.NoError(t, database.APIKeys.Create(t.Context(), &snips.APIKey{TokenHash: id.New(), UserID: userID}, 0))
+
+			webhook := &snips.Webhook{UserID: userID, URL: "https://example.com/hook"}
+			require.NoError(t, database.Webhooks.Create(t.Context(), webhook, 0))
+			delivery := &snips.WebhookDelivery{WebhookID: webhook.ID, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending}
+			require.NoError(t, database.WebhookDeliveries.Create(t.Context(), delivery, 0))
+		}
+
+		deleted, err := database.Users.Delete(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		// everything the user owned went with them
+		for _, table := range []string{"users", "public_keys", "files", "revisions", "api_keys", "webhooks", "webhook_deliveries"} {
+			var count int
+			require.NoError(t, database.SQL.QueryRowContext(t.Context(),
+				`SELECT count(*) FROM `+database.Schema+`.`+table,
+			).Scan(&count))
+			require.Equal(t, 1, count, table)
+		}
+
+		deleted, err = database.Users.Delete(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.False(t, deleted)
+	})
+	t.Run("Ban", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		require.NoError(t, database.APIKeys.Create(t.Context(), &snips.APIKey{TokenHash: id.New(), UserID: user.ID}, 0))
+		database.createTestFile(t, user.ID, "", "hello")
+
+		banned, err := database.Users.Ban(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.True(t, banned)
+
+		found, err := database.Users.Find(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.True(t, found.IsBanned())
+		bannedAt := *found.BannedAt
+
+		// api keys are revoked, files are kept
+		keys, err := database.APIKeys.FindByUser(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.Empty(t, keys)
+		count, err := database.Files.CountByUser(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, count)
+
+		// banning again keeps the original time
+		banned, err = database.Users.Ban(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.True(t, banned)
+		found, err = database.Users.Find(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, bannedAt, *found.BannedAt)
+
+		banned, err = database.Users.Ban(t.Context(), "missing")
+		require.NoError(t, err)
+		require.False(t, banned)
+	})
 }
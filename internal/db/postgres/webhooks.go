@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+type webhooks struct{ *sql.DB }
+
+// webhookColumns are the columns scanned by scanWebhook, in order.
+const webhookColumns = `display_id, created_at, updated_at, user_id, url, secret, events`
+
+func (s *webhooks) Create(ctx context.Context, webhook *snips.Webhook, maxWebhooks uint64) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if maxWebhooks > 0 {
+		var count uint64
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhooks WHERE user_id = $1`, webhook.UserID).Scan(&count); err != nil {
+			return err
+		}
+		if count >= maxWebhooks {
+			return db.ErrWebhookLimit
+		}
+	}
+
+	now := nowUTC()
+	webhookID := id.New()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO webhooks (`+webhookColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		webhookID, now, now, webhook.UserID, webhook.URL, webhook.Secret, db.EncodeTags(webhook.Events),
+	); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	webhook.ID, webhook.CreatedAt, webhook.UpdatedAt = webhookID, now, now
+	return nil
+}
+
+func scanWebhook(row scanner) (*snips.Webhook, error) {
+	webhook := &snips.Webhook{}
+	var events []byte
+	if err := row.Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt, &webhook.UserID,
+		&webhook.URL, &webhook.Secret, &events); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	webhook.CreatedAt = webhook.CreatedAt.UTC()
+	webhook.UpdatedAt = webhook.UpdatedAt.UTC()
+	var err error
+	if webhook.Events, err = db.DecodeTags(events); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *webhooks) Find(ctx context.Context, webhookID string) (*snips.Webhook, error) {
+	return scanWebhook(s.QueryRowContext(ctx, `
+		SELECT `+webhookColumns+` FROM webhooks WHERE display_id = $1`, webhookID))
+}
+
+func (s *webhooks) FindByUser(ctx context.Context, userID string) ([]*snips.Webhook, error) {
+	rows, err := s.QueryContext(ctx, `
+		SELECT `+webhookColumns+` FROM webhooks
+		WHERE user_id = $1 ORDER BY created_at DESC, display_id DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	webhooks := []*snips.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *webhooks) Delete(ctx context.Context, webhookID, userID string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE display_id = $1 AND user_id = $2`, webhookID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = $1`, webhookID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+type webhookDeliveries struct{ *sql.DB }
+
+// deliveryColumns are the columns scanned by scanDelivery, in order.
+const deliveryColumns = `display_id, created_at, updated_at, webhook_id, event, file_id, status, attempts,
+	next_attempt_at, response_code, error`
+
+func (s *webhookDeliveries) Create(ctx context.Context, delivery *snips.WebhookDelivery, maxDeliveries uint64) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := nowUTC()
+	deliveryID := id.New()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (`+deliveryColumns+`, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		deliveryID, now, now, delivery.WebhookID, delivery.Event, delivery.FileID, delivery.Status,
+		delivery.Attempts, nextAttemptAtParam(delivery), delivery.ResponseCode, delivery.Error, delivery.Payload,
+	); err != nil {
+		return err
+	}
+
+	if maxDeliveries > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM webhook_deliveries WHERE webhook_id = $1 AND id NOT IN (
+				SELECT id FROM webhook_deliveries WHERE webhook_id = $1
+				ORDER BY id DESC LIMIT $2
+			)`, delivery.WebhookID, maxDeliveries); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	delivery.ID, delivery.CreatedAt, delivery.UpdatedAt = deliveryID, now, now
+	return nil
+}
+
+// scanDelivery scans a row of deliveryColumns, followed by any extra
+// destinations.
+func scanDelivery(row scanner, extra ...any) (*snips.WebhookDelivery, error) {
+	delivery := &snips.WebhookDelivery{}
+	var nextAttemptAt sql.NullTime
+	dest := append([]any{&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt, &delivery.WebhookID,
+		&delivery.Event, &delivery.FileID, &delivery.Status, &delivery.Attempts, &nextAttemptAt,
+		&delivery.ResponseCode, &delivery.Error}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	delivery.CreatedAt = delivery.CreatedAt.UTC()
+	delivery.UpdatedAt = delivery.UpdatedAt.UTC()
+	if nextAttemptAt.Valid {
+		next := nextAttemptAt.Time.UTC()
+		delivery.NextAttemptAt = &next
+	}
+	return delivery, nil
+}
+
+func nextAttemptAtParam(delivery *snips.WebhookDelivery) *time.Time {
+	if delivery.NextAttemptAt == nil {
+		return nil
+	}
+	next := delivery.NextAttemptAt.UTC().Truncate(time.Microsecond)
+	delivery.NextAttemptAt = &next
+	return &next
+}
+
+func (s *webhookDeliveries) FindDue(ctx context.Context, now time.Time, limit uint64) ([]*snips.WebhookDelivery, error) {
+	rows, err := s.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`, payload FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC, id ASC LIMIT $3`,
+		snips.WebhookDeliveryPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := []*snips.WebhookDelivery{}
+	for rows.Next() {
+		var payload []byte
+		delivery, err := scanDelivery(rows, &payload)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *webhookDeliveries) FindByWebhook(ctx context.Context, webhookID string, limit uint64) ([]*snips.WebhookDelivery, error) {
+	rows, err := s.QueryContext(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1 ORDER BY id DESC LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deliveries := []*snips.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *webhookDeliveries) Update(ctx context.Context, delivery *snips.WebhookDelivery) error {
+	now := nowUTC()
+	if _, err := s.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET updated_at = $1, status = $2, attempts = $3, next_attempt_at = $4, response_code = $5, error = $6
+		WHERE display_id = $7`,
+		now, delivery.Status, delivery.Attempts, nextAttemptAtParam(delivery), delivery.ResponseCode,
+		delivery.Error, delivery.ID,
+	); err != nil {
+		return err
+	}
+	delivery.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,119 @@
+package postgres_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	t.Run("Create", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		webhook := &snips.Webhook{
+			UserID: user.ID,
+			URL:    "https://example.com/hook",
+			Events: []string{snips.WebhookEventDelete, snips.WebhookEventUpdate},
+			Secret: "whsec_secret",
+		}
+
+		require.NoError(t, database.Webhooks.Create(t.Context(), webhook, 1))
+		require.NotEmpty(t, webhook.ID)
+		require.False(t, webhook.CreatedAt.IsZero())
+
+		foundWebhook, err := database.Webhooks.Find(t.Context(), webhook.ID)
+		require.NoError(t, err)
+		require.Equal(t, webhook, foundWebhook)
+		missingWebhook, err := database.Webhooks.Find(t.Context(), "missing")
+		require.NoError(t, err)
+		require.Nil(t, missingWebhook)
+
+		err = database.Webhooks.Create(t.Context(), &snips.Webhook{UserID: user.ID, URL: "https://example.com/2"}, 1)
+		require.ErrorIs(t, err, db.ErrWebhookLimit)
+	})
+
+	t.Run("FindByUser", func(t *testing.T) {
+		database := newTestDB(t)
+		user := database.createTestUser(t)
+		other := database.createTestUser(t)
+		first := &snips.Webhook{UserID: user.ID, URL: "https://example.com/1"}
+		second := &snips.Webhook{UserID: user.ID, URL: "https://example.com/2"}
+		require.NoError(t, database.Webhooks.Create(t.Context(), first, 0))
+		require.NoError(t, database.Webhooks.Create(t.Context(), second, 0))
+		require.NoError(t, database.Webhooks.Create(t.Context(), &snips.Webhook{UserID: other.ID, URL: "https://example.com/3"}, 0))
+
+		webhooks, err := database.Webhooks.FindByUser(t.Context(), user.ID)
+		require.NoError(t, err)
+		require.Equal(t, []*snips.Webhook{second, first}, webhooks)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		database := newTestDB(t)
+		owner := database.createTestUser(t)
+		other := database.createTestUser(t)
+		webhook := &snips.Webhook{UserID: owner.ID, URL: "https://example.com/hook"}
+		require.NoError(t, database.Webhooks.Create(t.Context(), webhook, 0))
+		delivery := &snips.WebhookDelivery{WebhookID: webhook.ID, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending}
+		require.NoError(t, database.WebhookDeliveries.Create(t.Context(), delivery, 0))
+
+		deleted, err := database.Webhooks.Delete(t.Context(), webhook.ID, other.ID)
+		require.NoError(t, err)
+		require.False(t, deleted)
+		deleted, err = database.Webhooks.Delete(t.Context(), webhook.ID, owner.ID)
+		require.NoError(t, err)
+		require.True(t, deleted)
+
+		deliveries, err := database.WebhookDeliveries.FindByWebhook(t.Context(), webhook.ID, 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	})
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	t.Run("FindDue", func(t *testing.T) {
+		database := newTestDB(t)
+		now := time.Now().UTC()
+		later := now.Add(time.Hour)
+		due := &snips.WebhookDelivery{WebhookID: "hook", Event: snips.WebhookEventUpdate, FileID: "file",
+			Payload: []byte(`{"event":"update"}`), Status: snips.WebhookDeliveryPending, NextAttemptAt: &now}
+		notDue := &snips.WebhookDelivery{WebhookID: "hook", Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending, NextAttemptAt: &later}
+		require.NoError(t, database.WebhookDeliveries.Create(t.Context(), due, 0))
+		require.NoError(t, database.WebhookDeliveries.Create(t.Context(), notDue, 0))
+
+		found, err := database.WebhookDeliveries.FindDue(t.Context(), now.Add(time.Second), 10)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		require.Equal(t, due.ID, found[0].ID)
+		require.Equal(t, due.Payload, found[0].Payload)
+
+		due.Status = snips.WebhookDeliverySucceeded
+		due.Attempts = 1
+		due.ResponseCode = 204
+		due.NextAttemptAt = nil
+		require.NoError(t, database.WebhookDeliveries.Update(t.Context(), due))
+
+		found, err = database.WebhookDeliveries.FindDue(t.Context(), later, 10)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		require.Equal(t, notDue.ID, found[0].ID)
+	})
+
+	t.Run("Prunes", func(t *testing.T) {
+		database := newTestDB(t)
+		ids := make([]string, 0, 3)
+		for range 3 {
+			delivery := &snips.WebhookDelivery{WebhookID: "hook", Payload: []byte(`{}`), Status: snips.WebhookDeliveryFailed}
+			require.NoError(t, database.WebhookDeliveries.Create(t.Context(), delivery, 2))
+			ids = append(ids, delivery.ID)
+		}
+
+		deliveries, err := database.WebhookDeliveries.FindByWebhook(t.Context(), "hook", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, ids[2], deliveries[0].ID)
+		require.Equal(t, ids[1], deliveries[1].ID)
+	})
+}
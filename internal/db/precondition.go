@@ -0,0 +1,37 @@
+package db
+
+import "time"
+
+// Precondition guards a content update: the update only applies if the file
+// still matches every set field when it is written, otherwise
+// ErrPreconditionFailed is returned and nothing changes.
+type Precondition struct {
+	// UpdatedAt must equal the file's updated_at.
+	UpdatedAt *time.Time
+	// Revision must equal the file's latest revision sequence (0 for none).
+	Revision *int64
+}
+
+// PreconditionOption adds a check to a Precondition.
+type PreconditionOption func(*Precondition)
+
+// IfUpdatedAt requires the file to be unchanged since it was read with the
+// given updated_at.
+func IfUpdatedAt(updatedAt time.Time) PreconditionOption {
+	return func(p *Precondition) { p.UpdatedAt = &updatedAt }
+}
+
+// IfRevision requires the file's latest revision to be the given sequence.
+func IfRevision(sequence int64) PreconditionOption {
+	return func(p *Precondition) { p.Revision = &sequence }
+}
+
+// ResolvePrecondition applies precondition options for use by a database
+// backend.
+func ResolvePrecondition(opts ...PreconditionOption) Precondition {
+	p := Precondition{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// SearchMaxTermLength is the longest word that is indexed for search. Longer
+// runs (hashes, base64 blobs) are skipped.
+const SearchMaxTermLength = 64
+
+// SearchMaxTextLength caps the bytes of text indexed for a file, past which
+// later words aren't indexed. Postgres can't store a tsvector over 1MB, and
+// each word takes a few bytes more there than in the text.
+const SearchMaxTextLength = 256 * 1024
+
+// SearchText returns the text indexed for a file's content: its unique words,
+// lowercased and space separated, up to SearchMaxTextLength. Binary content is
+// not indexed, and bundles index their member names and text members.
+func SearchText(file *snips.File, content []byte) string {
+	seen := map[string]struct{}{}
+	words := []string{}
+	size := 0
+	add := func(text string) {
+		for _, word := range SearchTerms(text) {
+			if _, ok := seen[word]; ok || utf8.RuneCountInString(word) > SearchMaxTermLength {
+				continue
+			}
+			// whole words are dropped, so the text stays valid UTF-8
+			if size+len(word) > SearchMaxTextLength {
+				return
+			}
+			seen[word] = struct{}{}
+			words = append(words, word)
+			size += len(word) + 1
+		}
+	}
+
+	switch {
+	case file.IsBundle():
+		members, err := snips.ParseBundle(content)
+		if err != nil {
+			return ""
+		}
+		for _, member := range members {
+			add(member.Name)
+			if utf8.Valid(member.Content) {
+				add(string(member.Content))
+			}
+		}
+	case file.IsBinary():
+	default:
+		add(string(content))
+	}
+
+	return strings.Join(words, " ")
+}
+
+// SearchTerms splits a search query (or indexed text) into lowercased words on
+// anything that isn't a letter or number, so "nginx.conf" and "server_name" are
+// two words each. Backends match every term, each also as a prefix.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
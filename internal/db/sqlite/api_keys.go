@@ -20,8 +20,8 @@ func (s *apiKeys) Create(ctx context.Context, key *snips.APIKey, maxKeys uint64)
 
 	query := `
 		INSERT INTO api_keys (
-			id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at
-		) SELECT ?, ?, ?, ?, ?, ?, NULL, ?
+			id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids
+		) SELECT ?, ?, ?, ?, ?, ?, NULL, ?, ?, ?
 	`
 	args := []any{
 		keyID,
@@ -31,6 +31,8 @@ func (s *apiKeys) Create(ctx context.Context, key *snips.APIKey, maxKeys uint64)
 		key.TokenHash,
 		key.UserID,
 		key.ExpiresAt,
+		db.EncodeTags(key.Scopes),
+		db.EncodeTags(key.FileIDs),
 	}
 	if maxKeys > 0 {
 		query += `WHERE (SELECT COUNT(*) FROM api_keys WHERE user_id = ?) < ?`
@@ -57,7 +59,7 @@ func (s *apiKeys) Create(ctx context.Context, key *snips.APIKey, maxKeys uint64)
 
 func (s *apiKeys) FindByTokenHash(ctx context.Context, tokenHash string) (*snips.APIKey, error) {
 	const query = `
-		SELECT id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at
+		SELECT id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids
 		FROM api_keys
 		WHERE token_hash = ?
 	`
@@ -76,7 +78,7 @@ func (s *apiKeys) FindByTokenHash(ctx context.Context, tokenHash string) (*snips
 
 func (s *apiKeys) FindByUser(ctx context.Context, userID string) ([]*snips.APIKey, error) {
 	const query = `
-		SELECT id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at
+		SELECT id, created_at, updated_at, name, token_hash, user_id, last_used_at, expires_at, scopes, file_ids
 		FROM api_keys
 		WHERE user_id = ?
 		ORDER BY created_at DESC, id DESC
@@ -106,6 +108,8 @@ func scanAPIKey(scan func(dest ...any) error) (*snips.APIKey, error) {
 	name := sql.NullString{}
 	lastUsedAt := sql.NullTime{}
 	expiresAt := sql.NullTime{}
+	scopes := []byte{}
+	fileIDs := []byte{}
 
 	if err := scan(
 		&key.ID,
@@ -116,6 +120,8 @@ func scanAPIKey(scan func(dest ...any) error) (*snips.APIKey, error) {
 		&key.UserID,
 		&lastUsedAt,
 		&expiresAt,
+		&scopes,
+		&fileIDs,
 	); err != nil {
 		return nil, err
 	}
@@ -128,6 +134,14 @@ func scanAPIKey(scan func(dest ...any) error) (*snips.APIKey, error) {
 		key.ExpiresAt = &expiresAt.Time
 	}
 
+	var err error
+	if key.Scopes, err = db.DecodeTags(scopes); err != nil {
+		return nil, err
+	}
+	if key.FileIDs, err = db.DecodeTags(fileIDs); err != nil {
+		return nil, err
+	}
+
 	return key, nil
 }
 
@@ -18,59 +18,82 @@ type files struct {
 	compress bool
 }
 
+// fileColumns are the columns scanned by scanFile, in order.
+const fileColumns = `id, created_at, updated_at, size, private, type, user_id, name, expires_at, burn_after_read, description, tags`
+
+// notExpired filters out files that have expired but not been purged yet,
+// given the current time.
+const notExpired = `(expires_at IS NULL OR expires_at > ?)`
+
 func (s *files) Find(ctx context.Context, id string) (*snips.File, error) {
 	const query = `
-		SELECT id, created_at, updated_at, size, private, type, user_id, name
+		SELECT ` + fileColumns + `
 		FROM files
 		WHERE id = ?
 	`
 
-	return scanFile(s.QueryRowContext(ctx, query, id))
+	return findFile(s.QueryRowContext(ctx, query, id))
+}
+
+func (s *files) FindMany(ctx context.Context, ids []string) ([]*snips.File, error) {
+	if len(ids) == 0 {
+		return []*snips.File{}, nil
+	}
+
+	in, args := inList(ids)
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE id IN (` + in + `)
+	`
+
+	return s.query(ctx, query, args...)
 }
 
 func (s *files) FindWithContent(ctx context.Context, id string) (*snips.File, []byte, error) {
 	const query = `
-		SELECT id, created_at, updated_at, size, content, private, type, user_id, name
+		SELECT ` + fileColumns + `, content
 		FROM files
 		WHERE id = ?
 	`
 
-	file := &snips.File{}
-	name := sql.NullString{}
-	var content []byte
+	return findFileWithContent(s.QueryRowContext(ctx, query, id))
+}
 
-	if err := s.QueryRowContext(ctx, query, id).Scan(
-		&file.ID,
-		&file.CreatedAt,
-		&file.UpdatedAt,
-		&file.Size,
-		&content,
-		&file.Private,
-		&file.Type,
-		&file.UserID,
-		&name,
-	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil, nil
-		}
+func (s *files) FindWithContentAndDelete(ctx context.Context, id string) (*snips.File, []byte, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
 
+	if _, err := tx.ExecContext(ctx, `DELETE FROM revisions WHERE file_id = ?`, id); err != nil {
 		return nil, nil, err
 	}
 
-	file.Name = name.String
-	decoded, err := snips.DecodeContent(content)
-	if err != nil {
+	// the delete is the read: of concurrent callers, only one gets the row
+	const query = `
+		DELETE FROM files
+		WHERE id = ?
+		RETURNING ` + fileColumns + `, content
+	`
+
+	file, content, err := findFileWithContent(tx.QueryRowContext(ctx, query, id))
+	if err != nil || file == nil {
 		return nil, nil, err
 	}
 
-	return file, decoded, nil
+	return file, content, tx.Commit()
 }
 
-func scanFile(row *sql.Row) (*snips.File, error) {
+// scanFile scans a row of fileColumns, followed by any extra destinations.
+func scanFile(scan func(dest ...any) error, extra ...any) (*snips.File, error) {
 	file := &snips.File{}
 	name := sql.NullString{}
+	expiresAt := sql.NullTime{}
+	tags := []byte{}
 
-	if err := row.Scan(
+	dest := append([]any{
 		&file.ID,
 		&file.CreatedAt,
 		&file.UpdatedAt,
@@ -79,16 +102,48 @@ func scanFile(row *sql.Row) (*snips.File, error) {
 		&file.Type,
 		&file.UserID,
 		&name,
-	); err != nil {
+		&expiresAt,
+		&file.BurnAfterRead,
+		&file.Description,
+		&tags,
+	}, extra...)
+	err := scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Name = name.String
+	file.ExpiresAt = nullableTime(expiresAt)
+	file.Tags, err = db.DecodeTags(tags)
+	return file, err
+}
+
+func findFile(row *sql.Row) (*snips.File, error) {
+	file, err := scanFile(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return file, err
+}
+
+func findFileWithContent(row *sql.Row) (*snips.File, []byte, error) {
+	var content []byte
+	file, err := scanFile(row.Scan, &content)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, nil, nil
 		}
 
-		return nil, err
+		return nil, nil, err
 	}
 
-	file.Name = name.String
-	return file, nil
+	decoded, err := snips.DecodeContent(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, decoded, nil
 }
 
 func nameConstraintErr(err error) error {
@@ -103,10 +158,10 @@ func nameConstraintErr(err error) error {
 }
 
 func (s *files) Create(ctx context.Context, file *snips.File, content []byte, maxFileCount uint64) error {
-	const countQuery = `SELECT COUNT(*) FROM files WHERE user_id = ?`
+	const countQuery = `SELECT COUNT(*) FROM files WHERE user_id = ? AND ` + notExpired
 
 	var count uint64
-	if err := s.QueryRowContext(ctx, countQuery, file.UserID).Scan(&count); err != nil {
+	if err := s.QueryRowContext(ctx, countQuery, file.UserID, time.Now().UTC()).Scan(&count); err != nil {
 		return err
 	}
 	if maxFileCount > 0 && count >= maxFileCount {
@@ -121,14 +176,29 @@ func (s *files) Create(ctx context.Context, file *snips.File, content []byte, ma
 	file.CreatedAt = time.Now().UTC()
 	file.UpdatedAt = time.Now().UTC()
 	file.Size = uint64(len(content))
+	if file.ExpiresAt != nil {
+		expiresAt := file.ExpiresAt.UTC()
+		file.ExpiresAt = &expiresAt
+	}
+
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := releaseExpiredName(ctx, tx, file); err != nil {
+		return err
+	}
 
 	const insertQuery = `
 		INSERT INTO files (
-			id, created_at, updated_at, size, content, private, type, user_id, name
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, created_at, updated_at, size, content, private, type, user_id, name, expires_at, burn_after_read,
+			description, tags
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	if _, err := s.ExecContext(ctx, insertQuery,
+	if _, err := tx.ExecContext(ctx, insertQuery,
 		file.ID,
 		file.CreatedAt,
 		file.UpdatedAt,
@@ -138,28 +208,97 @@ func (s *files) Create(ctx context.Context, file *snips.File, content []byte, ma
 		file.Type,
 		file.UserID,
 		nullableName(file.Name),
+		file.ExpiresAt,
+		file.BurnAfterRead,
+		file.Description,
+		db.EncodeTags(file.Tags),
 	); err != nil {
 		return nameConstraintErr(err)
 	}
 
-	return nil
+	if err := indexFile(ctx, tx, file, content); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// indexFile replaces a file's row in the full-text search index.
+func indexFile(ctx context.Context, tx *sql.Tx, file *snips.File, content []byte) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM files_fts WHERE file_id = ?`, file.ID); err != nil {
+		return err
+	}
+
+	const query = `INSERT INTO files_fts (file_id, name, content) VALUES (?, ?, ?)`
+
+	_, err := tx.ExecContext(ctx, query, file.ID, nullableName(file.Name), db.SearchText(file, content))
+	return err
 }
 
 func (s *files) Update(ctx context.Context, file *snips.File) error {
+	return updateFile(ctx, s.DB, file)
+}
+
+func (s *files) UpdateMany(ctx context.Context, files []*snips.File) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, file := range files {
+		if err := updateFile(ctx, tx, file); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// releaseExpiredName unnames the user's expired file holding file's name, if
+// any, so the name can be taken before the reaper purges that file.
+func releaseExpiredName(ctx context.Context, exec execer, file *snips.File) error {
+	if file.Name == "" {
+		return nil
+	}
+
+	const query = `
+		UPDATE files
+		SET name = NULL
+		WHERE user_id = ? AND name = ? COLLATE NOCASE AND id != ? AND expires_at IS NOT NULL AND expires_at <= ?
+	`
+
+	_, err := exec.ExecContext(ctx, query, file.UserID, file.Name, file.ID, time.Now().UTC())
+	return err
+}
+
+func updateFile(ctx context.Context, exec execer, file *snips.File) error {
 	file.UpdatedAt = time.Now().UTC()
 
+	if err := releaseExpiredName(ctx, exec, file); err != nil {
+		return err
+	}
+
 	const query = `
 		UPDATE files
-		SET updated_at = ?, size = ?, private = ?, type = ?, name = ?
+		SET updated_at = ?, size = ?, private = ?, type = ?, name = ?, expires_at = ?, description = ?, tags = ?
 		WHERE id = ?
 	`
 
-	if _, err := s.ExecContext(ctx, query,
+	if _, err := exec.ExecContext(ctx, query,
 		file.UpdatedAt,
 		file.Size,
 		file.Private,
 		file.Type,
 		nullableName(file.Name),
+		file.ExpiresAt,
+		file.Description,
+		db.EncodeTags(file.Tags),
 		file.ID,
 	); err != nil {
 		return nameConstraintErr(err)
@@ -182,70 +321,147 @@ func (s *files) FindContent(ctx context.Context, id string) ([]byte, error) {
 	return snips.DecodeContent(content)
 }
 
-func (s *files) UpdateContent(ctx context.Context, file *snips.File, content []byte) error {
+func (s *files) UpdateContent(ctx context.Context, file *snips.File, content []byte, conds ...db.PreconditionOption) error {
 	storedContent, err := snips.EncodeContent(content, s.compress)
 	if err != nil {
 		return err
 	}
 
-	file.UpdatedAt = time.Now().UTC()
-	file.Size = uint64(len(content))
-	const query = `
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	updatedAt := time.Now().UTC()
+	query := `
 		UPDATE files
-		SET updated_at = ?, size = ?, content = ?, private = ?, type = ?, name = ?
+		SET updated_at = ?, size = ?, content = ?, private = ?, type = ?, name = ?, expires_at = ?,
+			description = ?, tags = ?
 		WHERE id = ?
 	`
-	if _, err := s.ExecContext(ctx, query,
-		file.UpdatedAt,
-		file.Size,
+	args := []any{
+		updatedAt,
+		len(content),
 		storedContent,
 		file.Private,
 		file.Type,
 		nullableName(file.Name),
+		file.ExpiresAt,
+		file.Description,
+		db.EncodeTags(file.Tags),
 		file.ID,
-	); err != nil {
+	}
+
+	cond := db.ResolvePrecondition(conds...)
+	if cond.UpdatedAt != nil {
+		query += ` AND updated_at = ?`
+		args = append(args, cond.UpdatedAt.UTC())
+	}
+	if cond.Revision != nil {
+		query += ` AND (SELECT COALESCE(MAX(sequence), 0) FROM revisions WHERE file_id = files.id) = ?`
+		args = append(args, *cond.Revision)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
 		return nameConstraintErr(err)
 	}
 
-	return nil
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 && (cond.UpdatedAt != nil || cond.Revision != nil) {
+		return db.ErrPreconditionFailed
+	}
+
+	file.UpdatedAt = updatedAt
+	file.Size = uint64(len(content))
+	if err := indexFile(ctx, tx, file, content); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *files) FindByUser(ctx context.Context, userID string, opts ...db.PageOption) ([]*snips.File, error) {
 	query := `
-		SELECT id, created_at, updated_at, size, private, type, user_id, name
+		SELECT ` + fileColumns + `
 		FROM files
-		WHERE user_id = ?
+		WHERE user_id = ? AND ` + notExpired + `
+		ORDER BY created_at DESC, id DESC`
+	args := applyPage(&query, []any{userID, time.Now().UTC()}, opts)
+
+	return s.query(ctx, query, args...)
+}
+
+func (s *files) FindRecent(ctx context.Context, opts ...db.PageOption) ([]*snips.File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE ` + notExpired + `
+		ORDER BY created_at DESC, id DESC`
+	args := applyPage(&query, []any{time.Now().UTC()}, opts)
+
+	return s.query(ctx, query, args...)
+}
+
+func (s *files) FindByTag(ctx context.Context, userID, tag string, opts ...db.PageOption) ([]*snips.File, error) {
+	query := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE user_id = ? AND ` + notExpired + ` AND EXISTS (
+			SELECT 1 FROM json_each(files.tags) WHERE json_each.value = ?
+		)
 		ORDER BY created_at DESC, id DESC`
-	args := applyPage(&query, []any{userID}, opts)
+	args := applyPage(&query, []any{userID, time.Now().UTC(), tag}, opts)
 
 	return s.query(ctx, query, args...)
 }
 
+func (s *files) Search(ctx context.Context, userID, query string, opts ...db.PageOption) ([]*snips.File, error) {
+	terms := db.SearchTerms(query)
+	if len(terms) == 0 {
+		return []*snips.File{}, nil
+	}
+
+	// terms are only letters and numbers, so quoting them is enough to keep
+	// them from being read as FTS5 syntax
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = `"` + term + `"*`
+	}
+
+	searchQuery := `
+		SELECT ` + fileColumns + `
+		FROM files
+		WHERE user_id = ? AND ` + notExpired + ` AND id IN (
+			SELECT file_id FROM files_fts WHERE files_fts MATCH ?
+		)
+		ORDER BY created_at DESC, id DESC`
+	args := applyPage(&searchQuery, []any{userID, time.Now().UTC(), strings.Join(match, " ")}, opts)
+
+	return s.query(ctx, searchQuery, args...)
+}
+
 func (s *files) query(ctx context.Context, query string, args ...any) ([]*snips.File, error) {
 	rows, err := s.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	return scanFiles(rows)
+}
+
+// scanFiles scans and closes rows of fileColumns.
+func scanFiles(rows *sql.Rows) ([]*snips.File, error) {
 	defer rows.Close()
 
 	files := []*snips.File{}
 	for rows.Next() {
-		file := &snips.File{}
-		name := sql.NullString{}
-		if err := rows.Scan(
-			&file.ID,
-			&file.CreatedAt,
-			&file.UpdatedAt,
-			&file.Size,
-			&file.Private,
-			&file.Type,
-			&file.UserID,
-			&name,
-		); err != nil {
+		file, err := scanFile(rows.Scan)
+		if err != nil {
 			return nil, err
 		}
 
-		file.Name = name.String
 		files = append(files, file)
 	}
 
@@ -254,19 +470,19 @@ func (s *files) query(ctx context.Context, query string, args ...any) ([]*snips.
 
 func (s *files) FindByName(ctx context.Context, userID, name string) (*snips.File, error) {
 	const query = `
-		SELECT id, created_at, updated_at, size, private, type, user_id, name
+		SELECT ` + fileColumns + `
 		FROM files
-		WHERE user_id = ? AND name = ? COLLATE NOCASE
+		WHERE user_id = ? AND name = ? COLLATE NOCASE AND ` + notExpired + `
 	`
 
-	return scanFile(s.QueryRowContext(ctx, query, userID, name))
+	return findFile(s.QueryRowContext(ctx, query, userID, name, time.Now().UTC()))
 }
 
 func (s *files) CountByUser(ctx context.Context, userID string) (int64, error) {
-	const query = `SELECT COUNT(*) FROM files WHERE user_id = ?`
+	const query = `SELECT COUNT(*) FROM files WHERE user_id = ? AND ` + notExpired
 
 	var count int64
-	if err := s.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+	if err := s.QueryRowContext(ctx, query, userID, time.Now().UTC()).Scan(&count); err != nil {
 		return 0, err
 	}
 
@@ -290,22 +506,23 @@ func (s *files) Delete(ctx context.Context, id string) error {
 	return tx.Commit()
 }
 
-func (s *files) DeleteByUser(ctx context.Context, userID string) (int64, error) {
+func (s *files) DeleteMany(ctx context.Context, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
 	tx, err := s.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	const deleteRevisionsQuery = `
-		DELETE FROM revisions
-		WHERE file_id IN (SELECT id FROM files WHERE user_id = ?)
-	`
-	if _, err := tx.ExecContext(ctx, deleteRevisionsQuery, userID); err != nil {
+	in, args := inList(ids)
+	if _, err := tx.ExecContext(ctx, `DELETE FROM revisions WHERE file_id IN (`+in+`)`, args...); err != nil {
 		return 0, err
 	}
 
-	result, err := tx.ExecContext(ctx, `DELETE FROM files WHERE user_id = ?`, userID)
+	result, err := tx.ExecContext(ctx, `DELETE FROM files WHERE id IN (`+in+`)`, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -316,3 +533,69 @@ func (s *files) DeleteByUser(ctx context.Context, userID string) (int64, error)
 
 	return count, tx.Commit()
 }
+
+func (s *files) DeleteByUser(ctx context.Context, userID string) ([]*snips.File, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const deleteRevisionsQuery = `
+		DELETE FROM revisions
+		WHERE file_id IN (SELECT id FROM files WHERE user_id = ?)
+	`
+	if _, err := tx.ExecContext(ctx, deleteRevisionsQuery, userID); err != nil {
+		return nil, err
+	}
+
+	const deleteFilesQuery = `
+		DELETE FROM files
+		WHERE user_id = ?
+		RETURNING ` + fileColumns + `
+	`
+	rows, err := tx.QueryContext(ctx, deleteFilesQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := scanFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, tx.Commit()
+}
+
+func (s *files) DeleteExpired(ctx context.Context, now time.Time) ([]*snips.File, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const deleteRevisionsQuery = `
+		DELETE FROM revisions
+		WHERE file_id IN (
+			SELECT id FROM files WHERE expires_at IS NOT NULL AND expires_at <= ?
+		)
+	`
+	if _, err := tx.ExecContext(ctx, deleteRevisionsQuery, now.UTC()); err != nil {
+		return nil, err
+	}
+
+	const deleteFilesQuery = `
+		DELETE FROM files
+		WHERE expires_at IS NOT NULL AND expires_at <= ?
+		RETURNING ` + fileColumns + `
+	`
+	rows, err := tx.QueryContext(ctx, deleteFilesQuery, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := scanFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, tx.Commit()
+}
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE `files` ADD COLUMN `expires_at` datetime;
+
+-- only expiring files are indexed, which is all the reaper needs to scan
+CREATE INDEX IF NOT EXISTS `idx_files_expires_at` ON `files` (`expires_at`) WHERE `expires_at` IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS `idx_files_expires_at`;
+
+ALTER TABLE `files` DROP COLUMN `expires_at`;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE `files` ADD COLUMN `burn_after_read` boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE `files` DROP COLUMN `burn_after_read`;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- content holds the words of the decompressed file content, written by the
+-- application (see db.SearchText), since stored content may be compressed
+CREATE VIRTUAL TABLE IF NOT EXISTS `files_fts` USING fts5(
+    `file_id` UNINDEXED,
+    `name`,
+    `content`
+);
+
+CREATE TRIGGER IF NOT EXISTS `files_fts_delete` AFTER DELETE ON `files` BEGIN
+    DELETE FROM `files_fts` WHERE `file_id` = old.`id`;
+END;
+
+CREATE TRIGGER IF NOT EXISTS `files_fts_rename` AFTER UPDATE OF `name` ON `files` BEGIN
+    UPDATE `files_fts` SET `name` = new.`name` WHERE `file_id` = new.`id`;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS `files_fts_rename`;
+
+DROP TRIGGER IF EXISTS `files_fts_delete`;
+
+DROP TABLE IF EXISTS `files_fts`;
+-- +goose StatementEnd
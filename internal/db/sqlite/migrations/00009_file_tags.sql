@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE `files` ADD COLUMN `description` TEXT NOT NULL DEFAULT '';
+
+-- a JSON array of normalized tags (see snips.NormalizeTags), queried with json_each
+ALTER TABLE `files` ADD COLUMN `tags` TEXT NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE `files` DROP COLUMN `tags`;
+
+ALTER TABLE `files` DROP COLUMN `description`;
+-- +goose StatementEnd
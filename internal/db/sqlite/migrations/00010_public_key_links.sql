@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS `idx_public_keys_user_id` ON `public_keys` (`user_id`);
+
+-- pending codes for linking another key to a user, see snips.KeyLink
+CREATE TABLE IF NOT EXISTS `public_key_links` (
+    `code_hash` text NOT NULL,
+    `user_id` text NOT NULL,
+    `created_at` datetime NOT NULL,
+    `expires_at` datetime NOT NULL,
+    PRIMARY KEY (`code_hash`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_public_key_links_expires_at` ON `public_key_links` (`expires_at`);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `public_key_links`;
+
+DROP INDEX IF EXISTS `idx_public_keys_user_id`;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS `webhooks` (
+    `id` text PRIMARY KEY,
+    `created_at` datetime NOT NULL,
+    `updated_at` datetime NOT NULL,
+    `user_id` text NOT NULL,
+    `url` text NOT NULL,
+    `secret` text NOT NULL,
+    -- a JSON array of normalized events, see snips.NormalizeWebhookEvents
+    `events` text NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS `idx_webhooks_user_id` ON `webhooks` (`user_id`);
+
+-- events queued for, sent to, or given up on by webhooks, see snips.WebhookDelivery
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+    `id` text PRIMARY KEY,
+    `created_at` datetime NOT NULL,
+    `updated_at` datetime NOT NULL,
+    `webhook_id` text NOT NULL,
+    `event` text NOT NULL,
+    `file_id` text NOT NULL,
+    `payload` blob NOT NULL,
+    `status` text NOT NULL,
+    `attempts` integer NOT NULL DEFAULT 0,
+    `next_attempt_at` datetime,
+    `response_code` integer NOT NULL DEFAULT 0,
+    `error` text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook_id_created_at` ON `webhook_deliveries` (`webhook_id`, `created_at`);
+
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_next_attempt_at` ON `webhook_deliveries` (`next_attempt_at`) WHERE `status` = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS `webhook_deliveries`;
+
+DROP TABLE IF EXISTS `webhooks`;
+-- +goose StatementEnd
@@ -4,7 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
+	"github.com/mattn/go-sqlite3"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
@@ -17,8 +22,209 @@ func (s *publicKeys) FindByFingerprint(ctx context.Context, fingerprint string)
 		WHERE fingerprint = ?
 	`
 
+	key, err := scanPublicKey(s.QueryRowContext(ctx, query, fingerprint).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *publicKeys) FindByUser(ctx context.Context, userID string) ([]*snips.PublicKey, error) {
+	const query = `
+		SELECT id, created_at, updated_at, fingerprint, type, user_id
+		FROM public_keys
+		WHERE user_id = ?
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := s.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*snips.PublicKey{}
+	for rows.Next() {
+		key, err := scanPublicKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *publicKeys) Create(ctx context.Context, publicKey *snips.PublicKey) error {
+	keyID := id.New()
+	createdAt := time.Now().UTC()
+	updatedAt := time.Now().UTC()
+
+	const query = `
+		INSERT INTO public_keys (id, created_at, updated_at, fingerprint, type, user_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if _, err := s.ExecContext(ctx, query,
+		keyID,
+		createdAt,
+		updatedAt,
+		publicKey.Fingerprint,
+		publicKey.Type,
+		publicKey.UserID,
+	); err != nil {
+		return fingerprintConstraintErr(err)
+	}
+
+	publicKey.ID = keyID
+	publicKey.CreatedAt = createdAt
+	publicKey.UpdatedAt = updatedAt
+	return nil
+}
+
+func (s *publicKeys) Delete(ctx context.Context, id, userID string) (bool, error) {
+	const query = `
+		DELETE FROM public_keys
+		WHERE id = ? AND user_id = ?
+		AND (SELECT COUNT(*) FROM public_keys WHERE user_id = ?) > 1
+	`
+
+	result, err := s.ExecContext(ctx, query, id, userID, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (s *publicKeys) DeleteByFingerprint(ctx context.Context, fingerprint string) (*snips.PublicKey, error) {
+	const query = `
+		DELETE FROM public_keys
+		WHERE fingerprint = ?
+		RETURNING id, created_at, updated_at, fingerprint, type, user_id
+	`
+
+	key, err := scanPublicKey(s.QueryRowContext(ctx, query, fingerprint).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (s *publicKeys) CreateLink(ctx context.Context, link *snips.KeyLink) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	createdAt := time.Now().UTC()
+
+	const cleanupQuery = `DELETE FROM public_key_links WHERE expires_at <= ?`
+	if _, err := tx.ExecContext(ctx, cleanupQuery, createdAt); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO public_key_links (code_hash, user_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`
+	if _, err := tx.ExecContext(ctx, query, link.CodeHash, link.UserID, createdAt, link.ExpiresAt.UTC()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	link.CreatedAt = createdAt
+	return nil
+}
+
+func (s *publicKeys) ClaimLink(ctx context.Context, codeHash, fingerprint string) (*snips.PublicKey, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// deleting the link claims it, so a code can't be used twice
+	const claimQuery = `
+		DELETE FROM public_key_links
+		WHERE code_hash = ? AND expires_at > ?
+		RETURNING user_id
+	`
+	var userID string
+	if err := tx.QueryRowContext(ctx, claimQuery, codeHash, time.Now().UTC()).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrLinkInvalid
+		}
+		return nil, err
+	}
+
+	// the claim above took the write lock, so nothing changes underneath these
+	const ownerQuery = `SELECT user_id FROM public_keys WHERE fingerprint = ?`
+	var previousUserID string
+	if err := tx.QueryRowContext(ctx, ownerQuery, fingerprint).Scan(&previousUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrLinkInvalid
+		}
+		return nil, err
+	}
+
+	if previousUserID != userID {
+		const orphansQuery = `
+			SELECT
+				(SELECT COUNT(*) FROM public_keys WHERE user_id = ?),
+				(SELECT COUNT(*) FROM files WHERE user_id = ? AND ` + notExpired + `)
+		`
+		var keys, files int64
+		if err := tx.QueryRowContext(ctx, orphansQuery, previousUserID, previousUserID, time.Now().UTC()).Scan(&keys, &files); err != nil {
+			return nil, err
+		}
+		if keys <= 1 && files > 0 {
+			return nil, db.ErrKeyHasFiles
+		}
+	}
+
+	const moveQuery = `
+		UPDATE public_keys
+		SET user_id = ?, updated_at = ?
+		WHERE fingerprint = ?
+		RETURNING id, created_at, updated_at, fingerprint, type, user_id
+	`
+	key, err := scanPublicKey(tx.QueryRowContext(ctx, moveQuery, userID, time.Now().UTC(), fingerprint).Scan)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrLinkInvalid
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func scanPublicKey(scan func(dest ...any) error) (*snips.PublicKey, error) {
 	key := &snips.PublicKey{}
-	if err := s.QueryRowContext(ctx, query, fingerprint).Scan(
+	if err := scan(
 		&key.ID,
 		&key.CreatedAt,
 		&key.UpdatedAt,
@@ -26,12 +232,19 @@ func (s *publicKeys) FindByFingerprint(ctx context.Context, fingerprint string)
 		&key.Type,
 		&key.UserID,
 	); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
-		}
-
 		return nil, err
 	}
 
 	return key, nil
 }
+
+func fingerprintConstraintErr(err error) error {
+	sqliteErr := sqlite3.Error{}
+	if errors.As(err, &sqliteErr) &&
+		sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.Contains(sqliteErr.Error(), "public_keys.fingerprint") {
+		return db.ErrPublicKeyTaken
+	}
+
+	return err
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pressly/goose/v3"
 	"github.com/robherley/snips.sh/internal/db"
@@ -29,29 +33,136 @@ func New(dsn string, compress bool) (*db.DB, error) {
 // NewWithDB builds a SQLite backend around an existing connection pool.
 func NewWithDB(database *sql.DB, compress bool) *db.DB {
 	return &db.DB{
-		Migrator:   &migrator{DB: database},
-		Closer:     database,
-		Files:      &files{DB: database, compress: compress},
-		PublicKeys: &publicKeys{DB: database},
-		Users:      &users{DB: database},
-		Revisions:  &revisions{DB: database, compress: compress},
-		APIKeys:    &apiKeys{DB: database},
+		Migrator:          &migrator{DB: database},
+		Closer:            database,
+		Files:             &files{DB: database, compress: compress},
+		PublicKeys:        &publicKeys{DB: database},
+		Users:             &users{DB: database},
+		Revisions:         &revisions{DB: database, compress: compress},
+		APIKeys:           &apiKeys{DB: database},
+		Webhooks:          &webhooks{DB: database},
+		WebhookDeliveries: &webhookDeliveries{DB: database},
+		Stats:             &stats{DB: database},
 	}
 }
 
-func (s *migrator) Migrate(ctx context.Context) error {
+func (s *migrator) provider() (*goose.Provider, error) {
 	migrations, err := fs.Sub(sqliteMigrations, "migrations")
 	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(goose.DialectSQLite3, s.DB, migrations)
+}
+
+func (s *migrator) Migrate(ctx context.Context) error {
+	provider, err := s.provider()
+	if err != nil {
+		return err
+	}
+
+	if _, err := provider.Up(ctx); err != nil {
 		return err
 	}
 
-	provider, err := goose.NewProvider(goose.DialectSQLite3, s.DB, migrations)
+	return s.indexFiles(ctx)
+}
+
+func (s *migrator) MigrationStatus(ctx context.Context) ([]*db.Migration, error) {
+	provider, err := s.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*db.Migration, 0, len(statuses))
+	for _, status := range statuses {
+		migration := &db.Migration{
+			Version: status.Source.Version,
+			Source:  filepath.Base(status.Source.Path),
+		}
+		if status.State == goose.StateApplied {
+			appliedAt := status.AppliedAt.UTC()
+			migration.AppliedAt = &appliedAt
+		}
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+func (s *migrator) MigrateDown(ctx context.Context) (*db.Migration, error) {
+	provider, err := s.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := provider.Down(ctx)
+	if err != nil {
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return nil, db.ErrNoMigrations
+		}
+		return nil, err
+	}
+
+	return &db.Migration{
+		Version: result.Source.Version,
+		Source:  filepath.Base(result.Source.Path),
+	}, nil
+}
+
+// indexFiles adds files missing from the search index, i.e. those created
+// before it existed. Content may be compressed, so this can't be a migration.
+func (s *migrator) indexFiles(ctx context.Context) error {
+	const query = `SELECT id FROM files WHERE id NOT IN (SELECT file_id FROM files_fts)`
+
+	rows, err := s.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
 
-	_, err = provider.Up(ctx)
-	return err
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	store := &files{DB: s.DB}
+	for _, id := range ids {
+		file, content, err := store.FindWithContent(ctx, id)
+		if err != nil {
+			return err
+		}
+		if file == nil {
+			continue
+		}
+
+		tx, err := s.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := indexFile(ctx, tx, file, content); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // applyPage appends SQLite limit/offset pagination to a listing query. SQLite
@@ -77,6 +188,24 @@ func applyPage(query *string, args []any, opts []db.PageOption) []any {
 	return args
 }
 
+// inList returns the placeholders and arguments for an IN (...) clause over
+// values.
+func inList(values []string) (string, []any) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
 func nullableName(name string) sql.NullString {
 	return sql.NullString{String: name, Valid: name != ""}
 }
+
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -429,9 +429,12 @@ func (s *SqliteSuite) TestDeleteFilesByUser() {
 	}
 	otherFileID := insertFile(otherUserID)
 
-	count, err := database.Files.DeleteByUser(context.TODO(), userID)
+	deleted, err := database.Files.DeleteByUser(context.TODO(), userID)
 	s.Require().NoError(err)
-	s.Require().Equal(int64(numFiles), count)
+	s.Require().Len(deleted, numFiles)
+	for _, file := range deleted {
+		s.Require().Equal(userID, file.UserID)
+	}
 
 	var remainingFiles int
 	err = s.testDB.QueryRow("SELECT COUNT(*) FROM files").Scan(&remainingFiles)
@@ -603,6 +606,151 @@ func (s *SqliteSuite) TestFindPublicKeyByFingerprint_DoesNotExist() {
 	s.Require().Nil(pk)
 }
 
+func (s *SqliteSuite) TestCreatePublicKey() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	key := &snips.PublicKey{
+		Fingerprint: "SHA256:" + id.New(),
+		Type:        "ssh-ed25519",
+		UserID:      userID,
+	}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), key))
+	s.Require().NotEmpty(key.ID)
+	s.Require().NotEmpty(key.CreatedAt)
+
+	found, err := database.PublicKeys.FindByFingerprint(context.TODO(), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Equal(key, found)
+
+	err = database.PublicKeys.Create(context.TODO(), &snips.PublicKey{
+		Fingerprint: key.Fingerprint,
+		Type:        "ssh-ed25519",
+		UserID:      id.New(),
+	})
+	s.Require().ErrorIs(err, db.ErrPublicKeyTaken)
+}
+
+func (s *SqliteSuite) TestFindPublicKeysByUser() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	first := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+	second := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-rsa", UserID: userID}
+	other := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: id.New()}
+	for _, key := range []*snips.PublicKey{first, second, other} {
+		s.Require().NoError(database.PublicKeys.Create(context.TODO(), key))
+	}
+
+	keys, err := database.PublicKeys.FindByUser(context.TODO(), userID)
+	s.Require().NoError(err)
+	s.Require().Equal([]*snips.PublicKey{first, second}, keys)
+
+	keys, err = database.PublicKeys.FindByUser(context.TODO(), id.New())
+	s.Require().NoError(err)
+	s.Require().Empty(keys)
+}
+
+func (s *SqliteSuite) TestDeletePublicKey() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	first := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+	second := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: userID}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), first))
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), second))
+
+	// another user cannot delete it
+	deleted, err := database.PublicKeys.Delete(context.TODO(), first.ID, id.New())
+	s.Require().NoError(err)
+	s.Require().False(deleted)
+
+	deleted, err = database.PublicKeys.Delete(context.TODO(), first.ID, userID)
+	s.Require().NoError(err)
+	s.Require().True(deleted)
+
+	// the last key is never removed
+	deleted, err = database.PublicKeys.Delete(context.TODO(), second.ID, userID)
+	s.Require().NoError(err)
+	s.Require().False(deleted)
+
+	keys, err := database.PublicKeys.FindByUser(context.TODO(), userID)
+	s.Require().NoError(err)
+	s.Require().Equal([]*snips.PublicKey{second}, keys)
+}
+
+func (s *SqliteSuite) TestClaimPublicKeyLink() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: id.New()}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), key))
+
+	code, hash, err := snips.NewKeyLinkCode()
+	s.Require().NoError(err)
+	link := &snips.KeyLink{CodeHash: hash, UserID: userID, ExpiresAt: time.Now().Add(snips.KeyLinkTTL)}
+	s.Require().NoError(database.PublicKeys.CreateLink(context.TODO(), link))
+	s.Require().NotEmpty(link.CreatedAt)
+
+	claimed, err := database.PublicKeys.ClaimLink(context.TODO(), snips.HashKeyLinkCode(code), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Equal(key.ID, claimed.ID)
+	s.Require().Equal(userID, claimed.UserID)
+
+	// codes are single use
+	_, err = database.PublicKeys.ClaimLink(context.TODO(), hash, key.Fingerprint)
+	s.Require().ErrorIs(err, db.ErrLinkInvalid)
+}
+
+func (s *SqliteSuite) TestClaimPublicKeyLink_OrphanedFiles() {
+	database := s.getTestDB(true)
+	previousUserID := id.New()
+
+	key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: previousUserID}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), key))
+	file := &snips.File{Type: "plaintext", UserID: previousUserID}
+	s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello world"), 0))
+
+	_, hash, err := snips.NewKeyLinkCode()
+	s.Require().NoError(err)
+	link := &snips.KeyLink{CodeHash: hash, UserID: id.New(), ExpiresAt: time.Now().Add(snips.KeyLinkTTL)}
+	s.Require().NoError(database.PublicKeys.CreateLink(context.TODO(), link))
+
+	// the file would be left without a key to reach it
+	_, err = database.PublicKeys.ClaimLink(context.TODO(), hash, key.Fingerprint)
+	s.Require().ErrorIs(err, db.ErrKeyHasFiles)
+
+	// until another key keeps it reachable, and the code wasn't used up
+	other := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: previousUserID}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), other))
+
+	claimed, err := database.PublicKeys.ClaimLink(context.TODO(), hash, key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Equal(link.UserID, claimed.UserID)
+}
+
+func (s *SqliteSuite) TestClaimPublicKeyLink_Invalid() {
+	database := s.getTestDB(true)
+
+	key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519", UserID: id.New()}
+	s.Require().NoError(database.PublicKeys.Create(context.TODO(), key))
+
+	_, hash, err := snips.NewKeyLinkCode()
+	s.Require().NoError(err)
+	expired := &snips.KeyLink{CodeHash: hash, UserID: id.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	s.Require().NoError(database.PublicKeys.CreateLink(context.TODO(), expired))
+
+	_, err = database.PublicKeys.ClaimLink(context.TODO(), hash, key.Fingerprint)
+	s.Require().ErrorIs(err, db.ErrLinkInvalid)
+
+	_, err = database.PublicKeys.ClaimLink(context.TODO(), snips.HashKeyLinkCode("AAAA-AAAA"), key.Fingerprint)
+	s.Require().ErrorIs(err, db.ErrLinkInvalid)
+
+	found, err := database.PublicKeys.FindByFingerprint(context.TODO(), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Equal(key.UserID, found.UserID)
+}
+
 func (s *SqliteSuite) TestCreateUserWithPublicKey() {
 	database := s.getTestDB(true)
 
@@ -712,6 +860,240 @@ func (s *SqliteSuite) createFile(database *db.DB, name string) *snips.File {
 	return file
 }
 
+func (s *SqliteSuite) TestCreateFile_WithExpiry() {
+	database := s.getTestDB(true)
+
+	expires := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	file := &snips.File{Size: 11, Type: "plaintext", UserID: id.New(), ExpiresAt: &expires}
+	s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello world"), 0))
+
+	found, err := database.Files.Find(context.TODO(), file.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(found.ExpiresAt)
+	s.Require().Equal(expires, found.ExpiresAt.UTC())
+	s.Require().False(found.IsExpired())
+
+	unset := s.createFile(database, "")
+	found, err = database.Files.Find(context.TODO(), unset.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(found.ExpiresAt)
+}
+
+func (s *SqliteSuite) TestDeleteExpiredFiles() {
+	database := s.getTestDB(true)
+
+	now := time.Now().UTC()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	create := func(expiresAt *time.Time) *snips.File {
+		file := &snips.File{Size: 11, Type: "plaintext", UserID: id.New(), ExpiresAt: expiresAt}
+		s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello world"), 0))
+		return file
+	}
+
+	expired := create(&past)
+	live := create(&future)
+	forever := create(nil)
+
+	rev := &snips.Revision{FileID: expired.ID, Size: 11, Type: "plaintext"}
+	s.Require().NoError(database.Revisions.Create(context.TODO(), rev, []byte("diff"), 0))
+
+	deleted, err := database.Files.DeleteExpired(context.TODO(), now)
+	s.Require().NoError(err)
+	s.Require().Len(deleted, 1)
+	s.Require().Equal(expired.ID, deleted[0].ID)
+
+	found, err := database.Files.Find(context.TODO(), expired.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(found)
+
+	revisions, err := database.Revisions.CountByFileID(context.TODO(), expired.ID)
+	s.Require().NoError(err)
+	s.Require().Zero(revisions)
+
+	for _, file := range []*snips.File{live, forever} {
+		found, err := database.Files.Find(context.TODO(), file.ID)
+		s.Require().NoError(err)
+		s.Require().NotNil(found)
+	}
+
+	deleted, err = database.Files.DeleteExpired(context.TODO(), now)
+	s.Require().NoError(err)
+	s.Require().Empty(deleted)
+}
+
+func (s *SqliteSuite) TestExpiredFilesNotListed() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	past := time.Now().UTC().Add(-time.Minute)
+	future := time.Now().UTC().Add(time.Hour)
+
+	create := func(name string, expiresAt *time.Time) *snips.File {
+		file := &snips.File{Size: 11, Type: "plaintext", UserID: userID, Name: name, ExpiresAt: expiresAt, Tags: []string{"notes"}}
+		s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello world"), 0))
+		return file
+	}
+
+	// not yet purged by the reaper
+	expired := create("expired", &past)
+	live := create("live", &future)
+	forever := create("forever", nil)
+	want := []string{forever.ID, live.ID}
+
+	ids := func(files []*snips.File, err error) []string {
+		s.Require().NoError(err)
+		ids := []string{}
+		for _, file := range files {
+			ids = append(ids, file.ID)
+		}
+		return ids
+	}
+
+	s.Equal(want, ids(database.Files.FindByUser(context.TODO(), userID)))
+	s.Equal(want, ids(database.Files.FindByTag(context.TODO(), userID, "notes")))
+	s.Equal(want, ids(database.Files.Search(context.TODO(), userID, "hello")))
+	s.Equal(want, ids(database.Files.FindRecent(context.TODO())))
+
+	found, err := database.Files.FindByName(context.TODO(), userID, "expired")
+	s.Require().NoError(err)
+	s.Nil(found)
+
+	count, err := database.Files.CountByUser(context.TODO(), userID)
+	s.Require().NoError(err)
+	s.Equal(int64(2), count)
+
+	// an expired file doesn't hold on to its name
+	reused := create("Expired", nil)
+	found, err = database.Files.FindByName(context.TODO(), userID, "expired")
+	s.Require().NoError(err)
+	s.Require().NotNil(found)
+	s.Equal(reused.ID, found.ID)
+
+	found, err = database.Files.Find(context.TODO(), expired.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(found)
+	s.Empty(found.Name)
+}
+
+func (s *SqliteSuite) TestFindFileWithContentAndDelete() {
+	database := s.newTestDB(true, true)
+
+	file := &snips.File{Size: 6, Type: "plaintext", UserID: id.New(), BurnAfterRead: true}
+	s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("secret"), 0))
+
+	rev := &snips.Revision{FileID: file.ID, Size: 6, Type: "plaintext"}
+	s.Require().NoError(database.Revisions.Create(context.TODO(), rev, []byte("diff"), 0))
+
+	burned, content, err := database.Files.FindWithContentAndDelete(context.TODO(), file.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(burned)
+	s.Require().Equal(file.ID, burned.ID)
+	s.Require().True(burned.BurnAfterRead)
+	s.Require().Equal([]byte("secret"), content)
+
+	found, err := database.Files.Find(context.TODO(), file.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(found)
+
+	revisions, err := database.Revisions.CountByFileID(context.TODO(), file.ID)
+	s.Require().NoError(err)
+	s.Require().Zero(revisions)
+
+	// only the first reader gets the content
+	burned, content, err = database.Files.FindWithContentAndDelete(context.TODO(), file.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(burned)
+	s.Require().Nil(content)
+}
+
+func (s *SqliteSuite) TestFindManyFiles() {
+	database := s.getTestDB(true)
+	first := s.createFile(database, "")
+	second := s.createFile(database, "")
+	s.createFile(database, "")
+
+	files, err := database.Files.FindMany(context.Background(), []string{first.ID, "missing", second.ID})
+	s.Require().NoError(err)
+
+	ids := []string{}
+	for _, file := range files {
+		ids = append(ids, file.ID)
+	}
+	s.ElementsMatch([]string{first.ID, second.ID}, ids)
+
+	files, err = database.Files.FindMany(context.Background(), nil)
+	s.Require().NoError(err)
+	s.Empty(files)
+}
+
+func (s *SqliteSuite) TestUpdateManyFiles() {
+	database := s.getTestDB(true)
+	first := s.createFile(database, "first")
+	second := s.createFile(database, "")
+
+	first.Name = ""
+	first.Private = true
+	second.Tags = []string{"go"}
+	s.Require().NoError(database.Files.UpdateMany(context.Background(), []*snips.File{first, second}))
+
+	found, err := database.Files.Find(context.Background(), first.ID)
+	s.Require().NoError(err)
+	s.Empty(found.Name)
+	s.True(found.Private)
+
+	found, err = database.Files.Find(context.Background(), second.ID)
+	s.Require().NoError(err)
+	s.Equal([]string{"go"}, found.Tags)
+}
+
+func (s *SqliteSuite) TestUpdateManyFiles_RollsBack() {
+	database := s.getTestDB(true)
+	taken := s.createFile(database, "taken")
+	first := s.createFile(database, "")
+	second := s.createFile(database, "")
+
+	// both belong to taken's owner, so the second update conflicts
+	for _, file := range []*snips.File{first, second} {
+		file.UserID = taken.UserID
+		_, err := s.testDB.Exec("UPDATE files SET user_id = ? WHERE id = ?", taken.UserID, file.ID)
+		s.Require().NoError(err)
+	}
+
+	first.Private = true
+	second.Name = "taken"
+	err := database.Files.UpdateMany(context.Background(), []*snips.File{first, second})
+	s.Require().ErrorIs(err, db.ErrNameTaken)
+
+	found, err := database.Files.Find(context.Background(), first.ID)
+	s.Require().NoError(err)
+	s.False(found.Private)
+}
+
+func (s *SqliteSuite) TestDeleteManyFiles() {
+	database := s.getTestDB(true)
+	first := s.createFile(database, "")
+	second := s.createFile(database, "")
+	kept := s.createFile(database, "")
+
+	revision := &snips.Revision{FileID: first.ID, Type: "plaintext"}
+	s.Require().NoError(database.Revisions.Create(context.Background(), revision, []byte("diff"), 0))
+
+	count, err := database.Files.DeleteMany(context.Background(), []string{first.ID, second.ID, "missing"})
+	s.Require().NoError(err)
+	s.Equal(int64(2), count)
+
+	files, err := database.Files.FindMany(context.Background(), []string{first.ID, second.ID, kept.ID})
+	s.Require().NoError(err)
+	s.Require().Len(files, 1)
+	s.Equal(kept.ID, files[0].ID)
+
+	revisions, err := database.Revisions.CountByFileID(context.Background(), first.ID)
+	s.Require().NoError(err)
+	s.Zero(revisions)
+}
+
 func (s *SqliteSuite) TestCreateFile_WithName() {
 	database := s.getTestDB(true)
 
@@ -1128,3 +1510,541 @@ func (s *SqliteSuite) TestCreateAPIKey_WithExpiry() {
 	s.Require().NoError(err)
 	s.Require().True(found.IsExpired())
 }
+
+func (s *SqliteSuite) TestCreateAPIKey_WithScopes() {
+	database := s.getTestDB(true)
+
+	_, hash, err := snips.NewAPIKeyToken()
+	s.Require().NoError(err)
+	key := &snips.APIKey{
+		Name:      "ci",
+		TokenHash: hash,
+		UserID:    id.New(),
+		Scopes:    []string{snips.APIKeyScopeFilesWrite},
+		FileIDs:   []string{"abc123"},
+	}
+	s.Require().NoError(database.APIKeys.Create(context.TODO(), key, 16))
+
+	found, err := database.APIKeys.FindByTokenHash(context.TODO(), hash)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{snips.APIKeyScopeFilesWrite}, found.Scopes)
+	s.Require().Equal([]string{"abc123"}, found.FileIDs)
+
+	// unscoped keys read back as granting everything
+	_, hash, err = snips.NewAPIKeyToken()
+	s.Require().NoError(err)
+	s.Require().NoError(database.APIKeys.Create(context.TODO(), &snips.APIKey{TokenHash: hash, UserID: key.UserID}, 16))
+
+	found, err = database.APIKeys.FindByTokenHash(context.TODO(), hash)
+	s.Require().NoError(err)
+	s.Require().Nil(found.Scopes)
+	s.Require().Nil(found.FileIDs)
+}
+
+func (s *SqliteSuite) TestSearchFiles() {
+	database := s.newTestDB(true, true)
+	ctx := context.TODO()
+	userID := id.New()
+
+	create := func(userID, name, fileType string, content []byte) *snips.File {
+		file := &snips.File{Type: fileType, UserID: userID, Name: name}
+		s.Require().NoError(database.Files.Create(ctx, file, content, 0))
+		return file
+	}
+	search := func(query string) []string {
+		files, err := database.Files.Search(ctx, userID, query)
+		s.Require().NoError(err)
+		ids := []string{}
+		for _, file := range files {
+			ids = append(ids, file.ID)
+		}
+		return ids
+	}
+
+	nginx := create(userID, "", "nginx", []byte("# nginx\nserver {\n  server_name example.com;\n}\n"))
+	notes := create(userID, "deploy-notes", "markdown", []byte("# Deploying\n\nRun the migrations first.\n"))
+	binary := create(userID, "", snips.FileTypeBinary, []byte{0x00, 'n', 'g', 'i', 'n', 'x'})
+	bundle, err := snips.EncodeBundle([]snips.BundleMember{{Name: "cmd/main.go", Content: []byte("package main")}})
+	s.Require().NoError(err)
+	bundled := create(userID, "", snips.FileTypeBundle, bundle)
+	create(id.New(), "", "nginx", []byte("server_name other.example.com;"))
+
+	s.Equal([]string{nginx.ID}, search("nginx"), "binary content and other users' files are not searched")
+	s.Equal([]string{nginx.ID}, search("SERVER_NAME example"), "terms are split and case-insensitive")
+	s.Equal([]string{nginx.ID}, search("exam"), "terms match as prefixes")
+	s.Equal([]string{notes.ID}, search("deploy"), "names are searched")
+	s.Equal([]string{bundled.ID}, search("cmd main"), "bundle member names are searched")
+	s.Empty(search("server migrations"), "every term must match")
+	s.Empty(search(`" OR * -`), "queries without words match nothing")
+
+	paged, err := database.Files.Search(ctx, userID, "m", db.WithLimit(1), db.WithCursor(db.Cursor{Offset: 1}))
+	s.Require().NoError(err)
+	s.Require().Len(paged, 1)
+	s.Equal(notes.ID, paged[0].ID)
+
+	// renames, content updates and deletes keep the index in sync
+	binary.Name = "nginx-logo"
+	s.Require().NoError(database.Files.Update(ctx, binary))
+	s.Equal([]string{binary.ID, nginx.ID}, search("nginx"))
+
+	nginx.Type = "plaintext"
+	s.Require().NoError(database.Files.UpdateContent(ctx, nginx, []byte("listen 443 ssl;")))
+	s.Equal([]string{nginx.ID}, search("443 ssl"))
+	s.Equal([]string{binary.ID}, search("nginx"))
+
+	s.Require().NoError(database.Files.Delete(ctx, binary.ID))
+	s.Empty(search("nginx"))
+
+	// only so much of a file's text is indexed
+	words := &strings.Builder{}
+	for i := range 200_000 {
+		fmt.Fprintf(words, "w%d ", i)
+	}
+	s.Require().NoError(database.Files.UpdateContent(ctx, notes, []byte(words.String())))
+	s.Equal([]string{notes.ID}, search("w0"))
+	s.Empty(search("w199999"), "words past the limit are not indexed")
+}
+
+func (s *SqliteSuite) TestMigrateIndexesExistingFiles() {
+	database := s.getTestDB(true)
+
+	fileID := id.New()
+	_, err := s.testDB.Exec(
+		"INSERT INTO files (id, created_at, updated_at, size, content, private, type, user_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		fileID, time.Now().UTC(), time.Now().UTC(), 11, []byte("hello world"), false, "plaintext", "user1",
+	)
+	s.Require().NoError(err)
+
+	files, err := database.Files.Search(context.TODO(), "user1", "hello")
+	s.Require().NoError(err)
+	s.Empty(files)
+
+	s.Require().NoError(database.Migrate(context.TODO()))
+
+	files, err = database.Files.Search(context.TODO(), "user1", "hello")
+	s.Require().NoError(err)
+	s.Require().Len(files, 1)
+	s.Equal(fileID, files[0].ID)
+}
+
+func (s *SqliteSuite) TestFileDescriptionAndTags() {
+	database := s.newTestDB(true, true)
+	ctx := context.TODO()
+	userID := id.New()
+
+	file := &snips.File{Type: "go", UserID: userID, Description: "scratch", Tags: []string{"go", "infra"}}
+	s.Require().NoError(database.Files.Create(ctx, file, []byte("package main"), 0))
+
+	found, err := database.Files.Find(ctx, file.ID)
+	s.Require().NoError(err)
+	s.Equal("scratch", found.Description)
+	s.Equal([]string{"go", "infra"}, found.Tags)
+
+	found.Description = ""
+	found.Tags = nil
+	s.Require().NoError(database.Files.Update(ctx, found))
+
+	found, err = database.Files.Find(ctx, file.ID)
+	s.Require().NoError(err)
+	s.Empty(found.Description)
+	s.Nil(found.Tags)
+
+	found.Tags = []string{"deploy"}
+	s.Require().NoError(database.Files.UpdateContent(ctx, found, []byte("package deploy")))
+
+	found, err = database.Files.Find(ctx, file.ID)
+	s.Require().NoError(err)
+	s.Equal([]string{"deploy"}, found.Tags)
+}
+
+func (s *SqliteSuite) TestFindFilesByTag() {
+	database := s.newTestDB(true, true)
+	ctx := context.TODO()
+	userID := id.New()
+
+	create := func(userID string, tags ...string) *snips.File {
+		file := &snips.File{Type: "plaintext", UserID: userID, Tags: tags}
+		s.Require().NoError(database.Files.Create(ctx, file, []byte("hello"), 0))
+		return file
+	}
+	findByTag := func(tag string, opts ...db.PageOption) []string {
+		files, err := database.Files.FindByTag(ctx, userID, tag, opts...)
+		s.Require().NoError(err)
+		ids := []string{}
+		for _, file := range files {
+			ids = append(ids, file.ID)
+		}
+		return ids
+	}
+
+	first := create(userID, "go", "infra")
+	second := create(userID, "go")
+	create(userID)
+	create(id.New(), "go")
+
+	s.Equal([]string{second.ID, first.ID}, findByTag("go"), "other users' files are not included")
+	s.Equal([]string{first.ID}, findByTag("infra"))
+	s.Empty(findByTag("inf"), "tags match exactly")
+	s.Equal([]string{first.ID}, findByTag("go", db.WithLimit(1), db.WithCursor(db.Cursor{Offset: 1})))
+}
+
+func (s *SqliteSuite) TestUpdateFileContent_Preconditions() {
+	database := s.getTestDB(true)
+	ctx := context.TODO()
+	file := s.createFile(database, "")
+
+	found, err := database.Files.Find(ctx, file.ID)
+	s.Require().NoError(err)
+	loadedAt := found.UpdatedAt
+
+	s.Require().NoError(database.Files.UpdateContent(ctx, found, []byte("first"), db.IfUpdatedAt(loadedAt), db.IfRevision(0)))
+
+	// a stale reader is turned away and nothing changes
+	stale := *file
+	err = database.Files.UpdateContent(ctx, &stale, []byte("second"), db.IfUpdatedAt(loadedAt))
+	s.ErrorIs(err, db.ErrPreconditionFailed)
+	s.Equal(file.UpdatedAt, stale.UpdatedAt)
+
+	content, err := database.Files.FindContent(ctx, file.ID)
+	s.Require().NoError(err)
+	s.Equal("first", string(content))
+
+	revision := &snips.Revision{FileID: file.ID, Size: 5, Type: "plaintext"}
+	s.Require().NoError(database.Revisions.Create(ctx, revision, []byte("diff"), 0))
+
+	err = database.Files.UpdateContent(ctx, found, []byte("third"), db.IfRevision(0))
+	s.ErrorIs(err, db.ErrPreconditionFailed)
+	s.Require().NoError(database.Files.UpdateContent(ctx, found, []byte("third"), db.IfRevision(1), db.IfUpdatedAt(found.UpdatedAt)))
+
+	content, err = database.Files.FindContent(ctx, file.ID)
+	s.Require().NoError(err)
+	s.Equal("third", string(content))
+}
+
+func (s *SqliteSuite) TestCreateWebhook() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	webhook := &snips.Webhook{
+		UserID: userID,
+		URL:    "https://example.com/hook",
+		Events: []string{snips.WebhookEventDelete, snips.WebhookEventUpdate},
+		Secret: "whsec_secret",
+	}
+	s.Require().NoError(database.Webhooks.Create(context.TODO(), webhook, 2))
+	s.Require().NotEmpty(webhook.ID)
+	s.Require().NotEmpty(webhook.CreatedAt)
+
+	found, err := database.Webhooks.Find(context.TODO(), webhook.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(found)
+	s.Require().Equal(userID, found.UserID)
+	s.Require().Equal("https://example.com/hook", found.URL)
+	s.Require().Equal(webhook.Events, found.Events)
+	s.Require().Equal("whsec_secret", found.Secret)
+
+	missing, err := database.Webhooks.Find(context.TODO(), id.New())
+	s.Require().NoError(err)
+	s.Require().Nil(missing)
+
+	s.Require().NoError(database.Webhooks.Create(context.TODO(), &snips.Webhook{UserID: userID, URL: "https://example.com/2"}, 2))
+	err = database.Webhooks.Create(context.TODO(), &snips.Webhook{UserID: userID, URL: "https://example.com/3"}, 2)
+	s.Require().ErrorIs(err, db.ErrWebhookLimit)
+
+	// another user's webhooks don't count towards the limit, or show up
+	s.Require().NoError(database.Webhooks.Create(context.TODO(), &snips.Webhook{UserID: id.New(), URL: "https://example.com/4"}, 2))
+
+	webhooks, err := database.Webhooks.FindByUser(context.TODO(), userID)
+	s.Require().NoError(err)
+	s.Require().Len(webhooks, 2)
+	for _, found := range webhooks {
+		s.Require().Equal(userID, found.UserID)
+	}
+}
+
+func (s *SqliteSuite) TestDeleteWebhook() {
+	database := s.getTestDB(true)
+	userID := id.New()
+
+	webhook := &snips.Webhook{UserID: userID, URL: "https://example.com/hook"}
+	s.Require().NoError(database.Webhooks.Create(context.TODO(), webhook, 0))
+	delivery := &snips.WebhookDelivery{WebhookID: webhook.ID, Event: snips.WebhookEventCreate, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending}
+	s.Require().NoError(database.WebhookDeliveries.Create(context.TODO(), delivery, 0))
+
+	// another user cannot delete it
+	deleted, err := database.Webhooks.Delete(context.TODO(), webhook.ID, id.New())
+	s.Require().NoError(err)
+	s.Require().False(deleted)
+
+	deleted, err = database.Webhooks.Delete(context.TODO(), webhook.ID, userID)
+	s.Require().NoError(err)
+	s.Require().True(deleted)
+
+	found, err := database.Webhooks.Find(context.TODO(), webhook.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(found)
+
+	// its deliveries go with it
+	deliveries, err := database.WebhookDeliveries.FindByWebhook(context.TODO(), webhook.ID, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(deliveries)
+}
+
+func (s *SqliteSuite) TestWebhookDeliveries() {
+	database := s.getTestDB(true)
+	now := time.Now().UTC()
+	later := now.Add(time.Hour)
+
+	due := &snips.WebhookDelivery{
+		WebhookID:     id.New(),
+		Event:         snips.WebhookEventUpdate,
+		FileID:        id.New(),
+		Payload:       []byte(`{"event":"update"}`),
+		Status:        snips.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	s.Require().NoError(database.WebhookDeliveries.Create(context.TODO(), due, 2))
+	s.Require().NotEmpty(due.ID)
+
+	notDue := &snips.WebhookDelivery{WebhookID: due.WebhookID, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending, NextAttemptAt: &later}
+	s.Require().NoError(database.WebhookDeliveries.Create(context.TODO(), notDue, 2))
+
+	found, err := database.WebhookDeliveries.FindDue(context.TODO(), now.Add(time.Second), 10)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal(due.ID, found[0].ID)
+	s.Require().Equal(due.Payload, found[0].Payload)
+	s.Require().Equal(due.FileID, found[0].FileID)
+
+	due.Status = snips.WebhookDeliverySucceeded
+	due.Attempts = 1
+	due.ResponseCode = 204
+	due.NextAttemptAt = nil
+	s.Require().NoError(database.WebhookDeliveries.Update(context.TODO(), due))
+
+	found, err = database.WebhookDeliveries.FindDue(context.TODO(), later, 10)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal(notDue.ID, found[0].ID)
+
+	// the oldest is pruned once the log is full
+	newest := &snips.WebhookDelivery{WebhookID: due.WebhookID, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending, NextAttemptAt: &later}
+	s.Require().NoError(database.WebhookDeliveries.Create(context.TODO(), newest, 2))
+
+	log, err := database.WebhookDeliveries.FindByWebhook(context.TODO(), due.WebhookID, 10)
+	s.Require().NoError(err)
+	s.Require().Len(log, 2)
+	ids := []string{log[0].ID, log[1].ID}
+	s.Require().ElementsMatch([]string{notDue.ID, newest.ID}, ids)
+	s.Require().Nil(log[0].Payload)
+}
+
+func (s *SqliteSuite) TestMigrationStatusAndDown() {
+	database := s.getTestDB(false)
+
+	migrations, err := database.MigrationStatus(context.TODO())
+	s.Require().NoError(err)
+	s.Require().NotEmpty(migrations)
+	for _, migration := range migrations {
+		s.Require().Nil(migration.AppliedAt)
+	}
+	s.Require().Equal(int64(1), migrations[0].Version)
+	s.Require().Equal("00001_init.sql", migrations[0].Source)
+
+	s.Require().NoError(database.Migrate(context.TODO()))
+
+	migrations, err = database.MigrationStatus(context.TODO())
+	s.Require().NoError(err)
+	latest := migrations[len(migrations)-1]
+	s.Require().NotNil(latest.AppliedAt)
+
+	rolledBack, err := database.MigrateDown(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Equal(latest.Version, rolledBack.Version)
+	s.Require().Equal(latest.Source, rolledBack.Source)
+
+	migrations, err = database.MigrationStatus(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Nil(migrations[len(migrations)-1].AppliedAt)
+	s.Require().NotNil(migrations[len(migrations)-2].AppliedAt)
+}
+
+func (s *SqliteSuite) TestMigrateDown_NoMigrations() {
+	database := s.getTestDB(false)
+
+	_, err := database.MigrateDown(context.TODO())
+	s.Require().ErrorIs(err, db.ErrNoMigrations)
+}
+
+func (s *SqliteSuite) TestListUsers() {
+	database := s.getTestDB(true)
+
+	var users []*snips.User
+	for range 3 {
+		user, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+		s.Require().NoError(err)
+		users = append(users, user)
+		time.Sleep(time.Millisecond)
+	}
+
+	listed, err := database.Users.List(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Len(listed, 3)
+	s.Require().Equal(users[2].ID, listed[0].ID)
+	s.Require().Equal(users[0].ID, listed[2].ID)
+
+	listed, err = database.Users.List(context.TODO(), db.WithLimit(2))
+	s.Require().NoError(err)
+	s.Require().Len(listed, 2)
+	s.Require().Equal(users[2].ID, listed[0].ID)
+}
+
+func (s *SqliteSuite) TestDeleteUser() {
+	database := s.getTestDB(true)
+
+	user, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+	s.Require().NoError(err)
+	other, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+	s.Require().NoError(err)
+
+	for _, userID := range []string{user.ID, other.ID} {
+		file := &snips.File{Type: "plaintext", UserID: userID}
+		s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello"), 0))
+		s.Require().NoError(database.Revisions.Create(context.TODO(), &snips.Revision{FileID: file.ID, Sequence: 1, Type: "plaintext"}, []byte("diff"), 0))
+		s.Require().NoError(database.APIKeys.Create(context.TODO(), &snips.APIKey{TokenHash: id.New(), UserID: userID}, 0))
+
+		webhook := &snips.Webhook{UserID: userID, URL: "https://example.com/hook"}
+		s.Require().NoError(database.Webhooks.Create(context.TODO(), webhook, 0))
+		delivery := &snips.WebhookDelivery{WebhookID: webhook.ID, Payload: []byte(`{}`), Status: snips.WebhookDeliveryPending}
+		s.Require().NoError(database.WebhookDeliveries.Create(context.TODO(), delivery, 0))
+	}
+
+	deleted, err := database.Users.Delete(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().True(deleted)
+
+	found, err := database.Users.Find(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(found)
+
+	// everything the user owned went with them
+	for table, want := range map[string]int{
+		"users": 1, "public_keys": 1, "files": 1, "revisions": 1, "api_keys": 1, "webhooks": 1, "webhook_deliveries": 1,
+	} {
+		var count int
+		s.Require().NoError(s.testDB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count))
+		s.Require().Equal(want, count, table)
+	}
+
+	deleted, err = database.Users.Delete(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().False(deleted)
+}
+
+func (s *SqliteSuite) TestBanUser() {
+	database := s.getTestDB(true)
+
+	user, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+	s.Require().NoError(err)
+	s.Require().NoError(database.APIKeys.Create(context.TODO(), &snips.APIKey{TokenHash: id.New(), UserID: user.ID}, 0))
+	file := &snips.File{Type: "plaintext", UserID: user.ID}
+	s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello"), 0))
+
+	found, err := database.Users.Find(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().False(found.IsBanned())
+
+	banned, err := database.Users.Ban(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().True(banned)
+
+	found, err = database.Users.Find(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().True(found.IsBanned())
+	bannedAt := *found.BannedAt
+
+	// api keys are revoked, files are kept
+	keys, err := database.APIKeys.FindByUser(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().Empty(keys)
+	count, err := database.Files.CountByUser(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().EqualValues(1, count)
+
+	// banning again keeps the original time
+	banned, err = database.Users.Ban(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().True(banned)
+	found, err = database.Users.Find(context.TODO(), user.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(bannedAt, *found.BannedAt)
+
+	banned, err = database.Users.Ban(context.TODO(), id.New())
+	s.Require().NoError(err)
+	s.Require().False(banned)
+}
+
+func (s *SqliteSuite) TestFindRecentFiles() {
+	database := s.getTestDB(true)
+
+	var files []*snips.File
+	for range 3 {
+		user, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+		s.Require().NoError(err)
+		file := &snips.File{Type: "plaintext", UserID: user.ID}
+		s.Require().NoError(database.Files.Create(context.TODO(), file, []byte("hello"), 0))
+		files = append(files, file)
+		time.Sleep(time.Millisecond)
+	}
+
+	recent, err := database.Files.FindRecent(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Len(recent, 3)
+	s.Require().Equal(files[2].ID, recent[0].ID)
+	s.Require().Equal(files[0].ID, recent[2].ID)
+
+	recent, err = database.Files.FindRecent(context.TODO(), db.WithLimit(2))
+	s.Require().NoError(err)
+	s.Require().Len(recent, 2)
+	s.Require().Equal(files[1].ID, recent[1].ID)
+}
+
+func (s *SqliteSuite) TestDeletePublicKeyByFingerprint() {
+	database := s.getTestDB(true)
+
+	key := &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"}
+	user, err := database.Users.CreateWithPublicKey(context.TODO(), key)
+	s.Require().NoError(err)
+
+	// unlike Delete, the last key is removed
+	deleted, err := database.PublicKeys.DeleteByFingerprint(context.TODO(), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().NotNil(deleted)
+	s.Require().Equal(key.ID, deleted.ID)
+	s.Require().Equal(user.ID, deleted.UserID)
+
+	found, err := database.PublicKeys.FindByFingerprint(context.TODO(), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Nil(found)
+
+	deleted, err = database.PublicKeys.DeleteByFingerprint(context.TODO(), key.Fingerprint)
+	s.Require().NoError(err)
+	s.Require().Nil(deleted)
+}
+
+func (s *SqliteSuite) TestCountStats() {
+	database := s.getTestDB(true)
+
+	counts, err := database.Stats.Count(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Equal(&db.Counts{}, counts)
+
+	user, err := database.Users.CreateWithPublicKey(context.TODO(), &snips.PublicKey{Fingerprint: "SHA256:" + id.New(), Type: "ssh-ed25519"})
+	s.Require().NoError(err)
+	s.Require().NoError(database.Files.Create(context.TODO(), &snips.File{Type: "plaintext", UserID: user.ID}, []byte("hello"), 0))
+	s.Require().NoError(database.Files.Create(context.TODO(), &snips.File{Type: "plaintext", UserID: user.ID}, []byte("world!"), 0))
+
+	counts, err = database.Stats.Count(context.TODO())
+	s.Require().NoError(err)
+	s.Require().Equal(&db.Counts{Users: 1, PublicKeys: 1, Files: 2, FileBytes: 11}, counts)
+}
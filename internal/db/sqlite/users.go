@@ -6,12 +6,34 @@ import (
 	"errors"
 	"time"
 
+	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
+// userColumns are the columns scanned by scanUser, in order.
+const userColumns = `id, created_at, updated_at, theme_color, banned_at`
+
 type users struct{ *sql.DB }
 
+// scanUser scans a row of userColumns.
+func scanUser(scan func(dest ...any) error) (*snips.User, error) {
+	user := &snips.User{}
+	bannedAt := sql.NullTime{}
+	if err := scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.ThemeColor,
+		&bannedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	user.BannedAt = nullableTime(bannedAt)
+	return user, nil
+}
+
 func (s *users) CreateWithPublicKey(ctx context.Context, publicKey *snips.PublicKey) (*snips.User, error) {
 	tx, err := s.BeginTx(ctx, nil)
 	if err != nil {
@@ -60,18 +82,13 @@ func (s *users) CreateWithPublicKey(ctx context.Context, publicKey *snips.Public
 
 func (s *users) Find(ctx context.Context, id string) (*snips.User, error) {
 	const query = `
-		SELECT id, created_at, updated_at, theme_color
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = ?
 	`
 
-	user := &snips.User{}
-	if err := s.QueryRowContext(ctx, query, id).Scan(
-		&user.ID,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-		&user.ThemeColor,
-	); err != nil {
+	user, err := scanUser(s.QueryRowContext(ctx, query, id).Scan)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
@@ -97,3 +114,96 @@ func (s *users) Update(ctx context.Context, user *snips.User) error {
 	user.UpdatedAt = updatedAt
 	return nil
 }
+
+func (s *users) Ban(ctx context.Context, id string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// banning twice keeps the original ban time
+	const query = `
+		UPDATE users
+		SET banned_at = COALESCE(banned_at, ?), updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, query, now, now, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM api_keys WHERE user_id = ?`, id); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (s *users) List(ctx context.Context, opts ...db.PageOption) ([]*snips.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY created_at DESC, id DESC
+	`
+	args := applyPage(&query, nil, opts)
+
+	rows, err := s.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*snips.User{}
+	for rows.Next() {
+		user, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (s *users) Delete(ctx context.Context, id string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// nothing cascades, so everything the user owns goes first
+	queries := []string{
+		`DELETE FROM revisions WHERE file_id IN (SELECT id FROM files WHERE user_id = ?)`,
+		`DELETE FROM files WHERE user_id = ?`,
+		`DELETE FROM webhook_deliveries WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id = ?)`,
+		`DELETE FROM webhooks WHERE user_id = ?`,
+		`DELETE FROM api_keys WHERE user_id = ?`,
+		`DELETE FROM public_key_links WHERE user_id = ?`,
+		`DELETE FROM public_keys WHERE user_id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
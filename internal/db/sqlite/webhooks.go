@@ -0,0 +1,315 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/id"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+type webhooks struct{ *sql.DB }
+
+// webhookColumns are the columns scanned by scanWebhook, in order.
+const webhookColumns = `id, created_at, updated_at, user_id, url, secret, events`
+
+func (s *webhooks) Create(ctx context.Context, webhook *snips.Webhook, maxWebhooks uint64) error {
+	webhookID := id.New()
+	createdAt := time.Now().UTC()
+
+	query := `
+		INSERT INTO webhooks (` + webhookColumns + `)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+	`
+	args := []any{
+		webhookID,
+		createdAt,
+		createdAt,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		db.EncodeTags(webhook.Events),
+	}
+	if maxWebhooks > 0 {
+		query += `WHERE (SELECT COUNT(*) FROM webhooks WHERE user_id = ?) < ?`
+		args = append(args, webhook.UserID, maxWebhooks)
+	}
+
+	result, err := s.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return db.ErrWebhookLimit
+	}
+
+	webhook.ID = webhookID
+	webhook.CreatedAt = createdAt
+	webhook.UpdatedAt = createdAt
+	return nil
+}
+
+func (s *webhooks) Find(ctx context.Context, id string) (*snips.Webhook, error) {
+	const query = `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE id = ?
+	`
+
+	webhook, err := scanWebhook(s.QueryRowContext(ctx, query, id).Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	return webhook, err
+}
+
+func (s *webhooks) FindByUser(ctx context.Context, userID string) ([]*snips.Webhook, error) {
+	const query = `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := s.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*snips.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func scanWebhook(scan func(dest ...any) error) (*snips.Webhook, error) {
+	webhook := &snips.Webhook{}
+	events := []byte{}
+
+	if err := scan(
+		&webhook.ID,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	webhook.Events, err = db.DecodeTags(events)
+	return webhook, err
+}
+
+func (s *webhooks) Delete(ctx context.Context, id, userID string) (bool, error) {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+type webhookDeliveries struct{ *sql.DB }
+
+// deliveryColumns are the columns scanned by scanDelivery, in order.
+const deliveryColumns = `id, created_at, updated_at, webhook_id, event, file_id, status, attempts, next_attempt_at,
+	response_code, error`
+
+func (s *webhookDeliveries) Create(ctx context.Context, delivery *snips.WebhookDelivery, maxDeliveries uint64) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	deliveryID := id.New()
+	createdAt := time.Now().UTC()
+
+	const insertQuery = `
+		INSERT INTO webhook_deliveries (` + deliveryColumns + `, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := tx.ExecContext(ctx, insertQuery,
+		deliveryID,
+		createdAt,
+		createdAt,
+		delivery.WebhookID,
+		delivery.Event,
+		delivery.FileID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.ResponseCode,
+		delivery.Error,
+		delivery.Payload,
+	); err != nil {
+		return err
+	}
+
+	if maxDeliveries > 0 {
+		const pruneQuery = `
+			DELETE FROM webhook_deliveries
+			WHERE webhook_id = ? AND id NOT IN (
+				SELECT id FROM webhook_deliveries
+				WHERE webhook_id = ?
+				ORDER BY created_at DESC, id DESC
+				LIMIT ?
+			)
+		`
+		if _, err := tx.ExecContext(ctx, pruneQuery, delivery.WebhookID, delivery.WebhookID, maxDeliveries); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	delivery.ID = deliveryID
+	delivery.CreatedAt = createdAt
+	delivery.UpdatedAt = createdAt
+	return nil
+}
+
+func (s *webhookDeliveries) FindDue(ctx context.Context, now time.Time, limit uint64) ([]*snips.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + deliveryColumns + `, payload
+		FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC
+		LIMIT ?
+	`
+
+	rows, err := s.QueryContext(ctx, query, snips.WebhookDeliveryPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*snips.WebhookDelivery{}
+	for rows.Next() {
+		var payload []byte
+		delivery, err := scanDelivery(rows.Scan, &payload)
+		if err != nil {
+			return nil, err
+		}
+
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (s *webhookDeliveries) FindByWebhook(ctx context.Context, webhookID string, limit uint64) ([]*snips.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := s.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*snips.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// scanDelivery scans a row of deliveryColumns, followed by any extra
+// destinations.
+func scanDelivery(scan func(dest ...any) error, extra ...any) (*snips.WebhookDelivery, error) {
+	delivery := &snips.WebhookDelivery{}
+	nextAttemptAt := sql.NullTime{}
+
+	dest := append([]any{
+		&delivery.ID,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&delivery.FileID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&nextAttemptAt,
+		&delivery.ResponseCode,
+		&delivery.Error,
+	}, extra...)
+	if err := scan(dest...); err != nil {
+		return nil, err
+	}
+
+	delivery.NextAttemptAt = nullableTime(nextAttemptAt)
+	return delivery, nil
+}
+
+func (s *webhookDeliveries) Update(ctx context.Context, delivery *snips.WebhookDelivery) error {
+	updatedAt := time.Now().UTC()
+
+	const query = `
+		UPDATE webhook_deliveries
+		SET updated_at = ?, status = ?, attempts = ?, next_attempt_at = ?, response_code = ?, error = ?
+		WHERE id = ?
+	`
+
+	if _, err := s.ExecContext(ctx, query,
+		updatedAt,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.ResponseCode,
+		delivery.Error,
+		delivery.ID,
+	); err != nil {
+		return err
+	}
+
+	delivery.UpdatedAt = updatedAt
+	return nil
+}
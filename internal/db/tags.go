@@ -0,0 +1,30 @@
+package db
+
+import (
+	"encoding/json"
+)
+
+// EncodeTags returns the JSON array both backends store a file's tags as.
+func EncodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// marshaling a []string can't fail
+	raw, _ := json.Marshal(tags)
+	return string(raw)
+}
+
+// DecodeTags parses a stored JSON array of tags. No tags decode to nil, so
+// files without tags omit them.
+func DecodeTags(raw []byte) ([]string, error) {
+	tags := []string{}
+	if err := json.Unmarshal(raw, &tags); err != nil {
+		return nil, err
+	}
+
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
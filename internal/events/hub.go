@@ -0,0 +1,161 @@
+// Package events fans file changes out to in-process subscribers, such as
+// browsers live-tailing a file, and observers, such as webhooks.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// SubscriberBuffer is how many events a subscriber can fall behind before
+// newer ones are dropped for it.
+const SubscriberBuffer = 16
+
+type Kind string
+
+const (
+	// KindCreate is published when a file is created.
+	KindCreate Kind = "create"
+	// KindUpdate is published when a file's content is replaced or restored,
+	// or its visibility, type, description or tags change.
+	KindUpdate Kind = "update"
+	// KindAppend is published when content is appended to a file.
+	KindAppend Kind = "append"
+	// KindDelete is published when a file is deleted.
+	KindDelete Kind = "delete"
+	// KindRename is published when a file is named, renamed or unnamed.
+	KindRename Kind = "rename"
+)
+
+type Event struct {
+	Kind      Kind      `json:"kind"`
+	FileID    string    `json:"file_id"`
+	Size      uint64    `json:"size"`
+	Type      string    `json:"type"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// File is the file as of the change, and PreviousName its name before a
+	// rename. Neither is streamed to subscribers, they're for observers.
+	File         *snips.File `json:"-"`
+	PreviousName string      `json:"-"`
+}
+
+// NewEvent describes a change of kind to file.
+func NewEvent(kind Kind, file *snips.File) Event {
+	snapshot := *file
+	snapshot.Tags = slices.Clone(file.Tags)
+
+	return Event{
+		Kind:      kind,
+		FileID:    file.ID,
+		Size:      file.Size,
+		Type:      file.Type,
+		UpdatedAt: file.UpdatedAt,
+		File:      &snapshot,
+	}
+}
+
+// NewRenameEvent describes file being renamed from previousName, which is
+// empty if it had no name.
+func NewRenameEvent(file *snips.File, previousName string) Event {
+	event := NewEvent(KindRename, file)
+	event.PreviousName = previousName
+	return event
+}
+
+// Hub delivers published events to the subscribers of the changed file, and
+// to every observer. A nil Hub drops everything published to it.
+type Hub struct {
+	mu        sync.Mutex
+	subs      map[string]map[chan Event]struct{}
+	observers []func(Event)
+	closed    bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel of events for a file, and a func to stop
+// receiving them. The channel is closed once unsubscribed or the hub closes.
+func (h *Hub) Subscribe(fileID string) (<-chan Event, func()) {
+	ch := make(chan Event, SubscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if h.subs[fileID] == nil {
+		h.subs[fileID] = make(map[chan Event]struct{})
+	}
+	h.subs[fileID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.subs[fileID][ch]; !ok {
+			return
+		}
+		delete(h.subs[fileID], ch)
+		if len(h.subs[fileID]) == 0 {
+			delete(h.subs, fileID)
+		}
+		close(ch)
+	}
+}
+
+// Observe calls fn with every event published, on the publishing goroutine,
+// so fn must not block. Unlike subscribers, observers never miss an event.
+func (h *Hub) Observe(fn func(Event)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.observers = append(h.observers, fn)
+}
+
+// Publish delivers an event to the file's subscribers without blocking;
+// subscribers that are too far behind miss it. Observers are then called.
+func (h *Hub) Publish(event Event) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	for ch := range h.subs[event.FileID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	observers := h.observers
+	h.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(event)
+	}
+}
+
+// Close ends every subscription, so long-lived streams can finish during
+// shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for fileID, subs := range h.subs {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(h.subs, fileID)
+	}
+}
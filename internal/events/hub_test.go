@@ -0,0 +1,84 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub(t *testing.T) {
+	hub := events.NewHub()
+
+	file1, unsubscribe1 := hub.Subscribe("file1")
+	file2, unsubscribe2 := hub.Subscribe("file2")
+	defer unsubscribe2()
+
+	event := events.NewEvent(events.KindAppend, &snips.File{ID: "file1", Size: 5, Type: "plaintext"})
+	hub.Publish(event)
+
+	require.Len(t, file1, 1)
+	assert.Equal(t, event, <-file1)
+	assert.Empty(t, file2)
+
+	unsubscribe1()
+	unsubscribe1()
+	_, open := <-file1
+	assert.False(t, open)
+
+	// publishing never blocks on a subscriber that isn't reading
+	for range events.SubscriberBuffer + 1 {
+		hub.Publish(events.Event{Kind: events.KindUpdate, FileID: "file2"})
+	}
+	assert.Len(t, file2, events.SubscriberBuffer)
+}
+
+func TestHub_Close(t *testing.T) {
+	hub := events.NewHub()
+
+	ch, unsubscribe := hub.Subscribe("file1")
+	hub.Close()
+	unsubscribe()
+
+	_, open := <-ch
+	assert.False(t, open)
+
+	ch, _ = hub.Subscribe("file1")
+	_, open = <-ch
+	assert.False(t, open)
+}
+
+func TestHub_Nil(t *testing.T) {
+	var hub *events.Hub
+	hub.Publish(events.Event{Kind: events.KindDelete, FileID: "file1"})
+}
+
+func TestHub_Observe(t *testing.T) {
+	hub := events.NewHub()
+
+	var observed []events.Event
+	hub.Observe(func(event events.Event) {
+		observed = append(observed, event)
+	})
+
+	// observers see every event, subscribed to or not, however many
+	for range events.SubscriberBuffer + 1 {
+		hub.Publish(events.Event{Kind: events.KindUpdate, FileID: "file1"})
+	}
+	assert.Len(t, observed, events.SubscriberBuffer+1)
+}
+
+func TestNewEvent_Snapshot(t *testing.T) {
+	file := &snips.File{ID: "file1", Name: "new", Tags: []string{"go"}}
+
+	event := events.NewRenameEvent(file, "old")
+	file.Name = "newer"
+	file.Tags[0] = "rust"
+
+	assert.Equal(t, events.KindRename, event.Kind)
+	assert.Equal(t, "old", event.PreviousName)
+	assert.Equal(t, "new", event.File.Name)
+	assert.Equal(t, []string{"go"}, event.File.Tags)
+}
@@ -3,27 +3,123 @@ package files
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/pmezard/go-difflib/difflib"
+	"github.com/armon/go-metrics"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/renderer"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
+// ErrBurned is returned by View when a burn-after-read file was already viewed
+// (and deleted) by someone else.
+var ErrBurned = errors.New("file already burned")
+
+// ErrTooLarge is returned when a normalized bundle exceeds the file size
+// limit. Normalizing can grow small members to full tar blocks.
+var ErrTooLarge = errors.New("file too large")
+
+// ErrAppendBundle is returned by AppendContent for bundles, which hold a tar
+// stream that can't be appended to.
+var ErrAppendBundle = errors.New("bundles can't be appended to")
+
+// ErrNotOwned is returned by FindOwned, wrapped with the offending ID, when an
+// ID isn't one of the user's files.
+var ErrNotOwned = errors.New("not one of your files")
+
+// appendAttempts bounds how often AppendContent retries when the file changes
+// between reading and writing its content.
+const appendAttempts = 3
+
+// View returns a file's content for a reader. Burn-after-read files are
+// deleted by the read itself, so of concurrent viewers only one gets the
+// content and the rest get ErrBurned. A burn is published to hub as a delete.
+func View(ctx context.Context, database *db.DB, hub *events.Hub, file *snips.File) ([]byte, error) {
+	if !file.BurnAfterRead {
+		return database.Files.FindContent(ctx, file.ID)
+	}
+
+	burned, content, err := database.Files.FindWithContentAndDelete(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	if burned == nil {
+		return nil, ErrBurned
+	}
+
+	hub.Publish(events.NewEvent(events.KindDelete, burned))
+	metrics.IncrCounter([]string{"file", "burn"}, 1)
+	logger.From(ctx).Info("file burned after read", "file_id", file.ID, "user_id", file.UserID)
+
+	return content, nil
+}
+
+// FindOwned resolves ids to the user's files, in order. Unknown, expired and
+// other users' files fail with ErrNotOwned alike, so existence isn't leaked.
+func FindOwned(ctx context.Context, database *db.DB, userID string, ids []string) ([]*snips.File, error) {
+	found, err := database.Files.FindMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*snips.File, len(found))
+	for _, file := range found {
+		byID[file.ID] = file
+	}
+
+	owned := make([]*snips.File, 0, len(ids))
+	for _, id := range ids {
+		file, ok := byID[id]
+		if !ok || file.UserID != userID || file.IsExpired() {
+			return nil, fmt.Errorf("%w: %q", ErrNotOwned, id)
+		}
+		owned = append(owned, file)
+	}
+
+	return owned, nil
+}
+
 // UpdateContent replaces a file's content and persists it, re-detecting the
 // file type (optionally hinted by extension) and recording a revision diff
-// for non-binary files. Revision bookkeeping failures are logged, not fatal.
-func UpdateContent(ctx context.Context, database *db.DB, cfg *config.Config, file *snips.File, content []byte, extension string) error {
+// for non-binary files. Bundles stay bundles: their content must be a tar
+// stream, and they keep no revisions. Preconditions are checked by the
+// database as the content is written, and a revision is only recorded once it
+// has been, so a failed precondition (db.ErrPreconditionFailed) leaves the file
+// and its history untouched. Revision bookkeeping failures are logged, not
+// fatal.
+func UpdateContent(ctx context.Context, database *db.DB, cfg *config.Config, file *snips.File, content []byte, extension string, conds ...db.PreconditionOption) error {
 	log := logger.From(ctx)
 
+	if file.IsBundle() {
+		bundle, err := snips.NormalizeBundle(content)
+		if err != nil {
+			return err
+		}
+		if uint64(len(bundle)) > cfg.Limits.FileSize {
+			return ErrTooLarge
+		}
+
+		previous := file.Size
+		file.Size = uint64(len(bundle))
+		if err := database.Files.UpdateContent(ctx, file, bundle, conds...); err != nil {
+			file.Size = previous
+			return err
+		}
+		return nil
+	}
+
+	previous := *file
 	file.Size = uint64(len(content))
 	file.Type = renderer.DetectFileType(content, extension, cfg.EnableGuesser)
 
-	// Compute diff for revision history (skip binary files)
-	if !file.IsBinary() {
+	// Compute diff for revision history (skip binary files, and burn-after-read
+	// files, whose history would otherwise outlive the first view)
+	var diff string
+	if !file.IsBinary() && !file.BurnAfterRead {
 		oldContent, err := database.Files.FindContent(ctx, file.ID)
 		if err != nil {
 			log.Warn("unable to get old content for diff", "err", err)
@@ -34,27 +130,72 @@ func UpdateContent(ctx context.Context, database *db.DB, cfg *config.Config, fil
 			}
 			fromLabel := fmt.Sprintf("%s (v%d)", file.ID, revCount)
 			toLabel := fmt.Sprintf("%s (v%d)", file.ID, revCount+1)
-			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
-				A:        difflib.SplitLines(string(oldContent)),
-				B:        difflib.SplitLines(string(content)),
-				FromFile: fromLabel,
-				ToFile:   toLabel,
-				Context:  3,
-			})
+			diff, err = unifiedDiff(oldContent, content, fromLabel, toLabel)
 			if err != nil {
 				log.Warn("unable to compute diff", "err", err)
-			} else if diff != "" {
-				revision := &snips.Revision{
-					FileID: file.ID,
-					Size:   file.Size,
-					Type:   file.Type,
-				}
-				if err := database.Revisions.Create(ctx, revision, []byte(diff), cfg.Limits.RevisionsPerFile); err != nil {
-					log.Warn("unable to create revision", "err", err)
-				}
 			}
 		}
 	}
 
-	return database.Files.UpdateContent(ctx, file, content)
+	if err := database.Files.UpdateContent(ctx, file, content, conds...); err != nil {
+		*file = previous
+		return err
+	}
+
+	if diff != "" {
+		revision := &snips.Revision{
+			FileID: file.ID,
+			Size:   file.Size,
+			Type:   file.Type,
+		}
+		if err := database.Revisions.Create(ctx, revision, []byte(diff), cfg.Limits.RevisionsPerFile); err != nil {
+			log.Warn("unable to create revision", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// AppendContent adds content to the end of a file's existing content, keeping
+// its type and recording a revision like UpdateContent. The write is
+// conditioned on the file being unchanged since its content was read, and is
+// retried against the latest content if it was, so concurrent appends don't
+// overwrite each other.
+func AppendContent(ctx context.Context, database *db.DB, cfg *config.Config, file *snips.File, content []byte) error {
+	if file.IsBundle() {
+		return ErrAppendBundle
+	}
+
+	var err error
+	for range appendAttempts {
+		var existing []byte
+		existing, err = database.Files.FindContent(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+
+		if uint64(len(existing))+uint64(len(content)) > cfg.Limits.FileSize {
+			return ErrTooLarge
+		}
+
+		combined := make([]byte, 0, len(existing)+len(content))
+		combined = append(combined, existing...)
+		combined = append(combined, content...)
+
+		err = UpdateContent(ctx, database, cfg, file, combined, file.Type, db.IfUpdatedAt(file.UpdatedAt))
+		if !errors.Is(err, db.ErrPreconditionFailed) {
+			return err
+		}
+
+		latest, findErr := database.Files.Find(ctx, file.ID)
+		if findErr != nil {
+			return findErr
+		}
+		if latest == nil {
+			return err
+		}
+		*file = *latest
+	}
+
+	return err
 }
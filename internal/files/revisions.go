@@ -0,0 +1,351 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// HeadRevision refers to a file's current content wherever a revision is read
+// by reference, e.g. "2...head".
+const HeadRevision = "head"
+
+var (
+	// ErrInvalidRevision is returned for a revision reference that is neither
+	// a sequence nor HeadRevision.
+	ErrInvalidRevision = fmt.Errorf("revisions must be a non-negative sequence or %q", HeadRevision)
+	// ErrRevisionNotFound is returned when a sequence is outside of a file's
+	// kept revision history.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrRevisionUnavailable is returned when the revision diffs no longer
+	// line up with the file's content, e.g. after an update that recorded no
+	// revision (a switch to binary content).
+	ErrRevisionUnavailable = errors.New("revision content can't be reconstructed")
+)
+
+// ContentAt reconstructs a file's content as it was right after the revision
+// with the given sequence, by reverse-applying the newer revision diffs to the
+// current content. Sequence one less than the oldest kept revision is the
+// content before it, so 0 is the original upload while no revisions have been
+// pruned. It also returns the file type at that revision, or "" when unknown.
+func ContentAt(ctx context.Context, database *db.DB, file *snips.File, sequence int64) ([]byte, string, error) {
+	revisions, err := database.Revisions.FindByFileID(ctx, file.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// revisions are newest first
+	if len(revisions) == 0 || sequence < revisions[len(revisions)-1].Sequence-1 || sequence > revisions[0].Sequence {
+		return nil, "", ErrRevisionNotFound
+	}
+
+	content, err := database.Files.FindContent(ctx, file.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fileType := ""
+	for _, revision := range revisions {
+		if revision.Sequence == sequence {
+			// revisions record the file after they were applied, so it can be
+			// checked against what was reconstructed
+			if uint64(len(content)) != revision.Size {
+				return nil, "", ErrRevisionUnavailable
+			}
+			fileType = revision.Type
+			break
+		}
+
+		diff, err := database.Revisions.FindDiff(ctx, revision.ID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		content, err = unapplyDiff(content, diff)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: revision %d: %w", ErrRevisionUnavailable, revision.Sequence, err)
+		}
+	}
+
+	return content, fileType, nil
+}
+
+// ContentAtRef is ContentAt for a revision reference, which is either a
+// sequence or HeadRevision.
+func ContentAtRef(ctx context.Context, database *db.DB, file *snips.File, ref string) ([]byte, error) {
+	if !strings.EqualFold(ref, HeadRevision) {
+		sequence, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil || sequence < 0 {
+			return nil, ErrInvalidRevision
+		}
+
+		content, _, err := ContentAt(ctx, database, file, sequence)
+		return content, err
+	}
+
+	// reading a burn-after-read file here would skip burning it, and neither it
+	// nor binary content has revisions to read alongside
+	if file.BurnAfterRead {
+		return nil, ErrRevisionNotFound
+	}
+	if file.IsBinary() || file.IsBundle() {
+		return nil, fmt.Errorf("%w: %s content has no revisions", ErrRevisionUnavailable, file.Type)
+	}
+
+	return database.Files.FindContent(ctx, file.ID)
+}
+
+// Version is a file's content as of one point in its history.
+type Version struct {
+	Sequence  int64 // revision sequence, or -1 when the content isn't a revision
+	CreatedAt time.Time
+	Content   []byte
+}
+
+// History reconstructs every version of a file that its kept revisions can
+// account for, oldest first and ending with the current content. The content
+// before the oldest revision leads, dated when the file was created. Anything
+// older than a diff that no longer applies is left out, and so is everything
+// but the current content when the revisions don't lead up to it.
+func History(ctx context.Context, database *db.DB, file *snips.File) ([]Version, error) {
+	revisions, err := database.Revisions.FindByFileID(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := database.Files.FindContent(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// revisions are newest first
+	if len(revisions) == 0 || uint64(len(content)) != revisions[0].Size {
+		return []Version{{Sequence: -1, CreatedAt: file.UpdatedAt, Content: content}}, nil
+	}
+
+	history := []Version{}
+	for i, revision := range revisions {
+		history = append(history, Version{Sequence: revision.Sequence, CreatedAt: revision.CreatedAt, Content: content})
+
+		diff, err := database.Revisions.FindDiff(ctx, revision.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = unapplyDiff(content, diff)
+		if err != nil {
+			break
+		}
+
+		if i == len(revisions)-1 {
+			history = append(history, Version{Sequence: revision.Sequence - 1, CreatedAt: file.CreatedAt, Content: content})
+		} else if uint64(len(content)) != revisions[i+1].Size {
+			break
+		}
+	}
+
+	slices.Reverse(history)
+	return history, nil
+}
+
+// ParseRevisionRange splits a "from...to" range of revision references. An
+// empty side is HeadRevision, so "2..." compares revision 2 to the current
+// content.
+func ParseRevisionRange(revisions string) (from, to string, err error) {
+	from, to, ok := strings.Cut(revisions, "...")
+	if !ok {
+		return "", "", fmt.Errorf("%w: expected a range like \"1...3\"", ErrInvalidRevision)
+	}
+
+	if from == "" {
+		from = HeadRevision
+	}
+	if to == "" {
+		to = HeadRevision
+	}
+
+	return strings.ToLower(from), strings.ToLower(to), nil
+}
+
+// Compare returns a unified diff of a file's content between two revision
+// references (see ContentAtRef). It is empty when the contents are equal.
+func Compare(ctx context.Context, database *db.DB, file *snips.File, from, to string) (string, error) {
+	a, err := ContentAtRef(ctx, database, file, from)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := ContentAtRef(ctx, database, file, to)
+	if err != nil {
+		return "", err
+	}
+
+	return unifiedDiff(a, b, revisionLabel(file, from), revisionLabel(file, to))
+}
+
+// Preview returns a unified diff of a file's current content against content
+// it is about to be updated to. It is empty when the contents are equal.
+func Preview(file *snips.File, current, content []byte) (string, error) {
+	return unifiedDiff(current, content, revisionLabel(file, HeadRevision), fmt.Sprintf("%s (edited)", file.ID))
+}
+
+func revisionLabel(file *snips.File, ref string) string {
+	if ref == HeadRevision {
+		return fmt.Sprintf("%s (%s)", file.ID, HeadRevision)
+	}
+	return fmt.Sprintf("%s (v%s)", file.ID, ref)
+}
+
+// unifiedDiff diffs two contents the way revisions are recorded.
+func unifiedDiff(a, b []byte, fromLabel, toLabel string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: fromLabel,
+		ToFile:   toLabel,
+		Context:  3,
+	})
+}
+
+// Restore replaces a file's content with its content at the given revision
+// sequence (see ContentAt). The restore goes through UpdateContent, so it is
+// recorded as a new revision and can itself be undone.
+func Restore(ctx context.Context, database *db.DB, cfg *config.Config, file *snips.File, sequence int64) error {
+	content, fileType, err := ContentAt(ctx, database, file, sequence)
+	if err != nil {
+		return err
+	}
+
+	if err := UpdateContent(ctx, database, cfg, file, content, fileType); err != nil {
+		return err
+	}
+
+	metrics.IncrCounter([]string{"file", "restore"}, 1)
+	logger.From(ctx).Info("file restored", "file_id", file.ID, "user_id", file.UserID, "sequence", sequence)
+
+	return nil
+}
+
+// unapplyDiff returns the content a unified diff (as written by UpdateContent)
+// was computed from, given the content it produced. Context and added lines
+// must match content exactly.
+func unapplyDiff(content, diff []byte) ([]byte, error) {
+	// UpdateContent diffs difflib.SplitLines, which ends every line (even the
+	// last) with a newline, so the same split lines up with the diff and the
+	// extra trailing newline is dropped again when joining
+	newLines := difflib.SplitLines(string(content))
+	diffLines := strings.SplitAfter(string(diff), "\n")
+
+	oldLines := make([]string, 0, len(newLines))
+	pos := 0
+	i := 0
+
+	// skip the ---/+++ file header
+	for i < len(diffLines) && !strings.HasPrefix(diffLines[i], "@@ ") {
+		i++
+	}
+
+	for i < len(diffLines) && diffLines[i] != "" {
+		oldCount, newStart, newCount, err := parseHunkHeader(diffLines[i])
+		if err != nil {
+			return nil, err
+		}
+		i++
+
+		// an empty range starts at the line before it
+		start := newStart - 1
+		if newCount == 0 {
+			start = newStart
+		}
+		if start < pos || start > len(newLines) {
+			return nil, errors.New("hunk out of range")
+		}
+		oldLines = append(oldLines, newLines[pos:start]...)
+		pos = start
+
+		for oldCount > 0 || newCount > 0 {
+			if i >= len(diffLines) || diffLines[i] == "" {
+				return nil, errors.New("truncated hunk")
+			}
+			line := diffLines[i]
+			i++
+
+			prefix, text := line[0], line[1:]
+			switch {
+			case prefix == ' ' && oldCount > 0 && newCount > 0:
+				if pos >= len(newLines) || newLines[pos] != text {
+					return nil, errors.New("context does not match")
+				}
+				oldLines = append(oldLines, text)
+				pos++
+				oldCount--
+				newCount--
+			case prefix == '+' && newCount > 0:
+				if pos >= len(newLines) || newLines[pos] != text {
+					return nil, errors.New("added line does not match")
+				}
+				pos++
+				newCount--
+			case prefix == '-' && oldCount > 0:
+				oldLines = append(oldLines, text)
+				oldCount--
+			default:
+				return nil, fmt.Errorf("unexpected diff line %q", strings.TrimSuffix(line, "\n"))
+			}
+		}
+	}
+
+	oldLines = append(oldLines, newLines[pos:]...)
+	return []byte(strings.TrimSuffix(strings.Join(oldLines, ""), "\n")), nil
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@", where a missing count is 1.
+func parseHunkHeader(line string) (oldCount, newStart, newCount int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "@@" || fields[3] != "@@" ||
+		!strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header %q", strings.TrimSpace(line))
+	}
+
+	_, oldCount, err = parseHunkRange(fields[1][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	newStart, newCount, err = parseHunkRange(fields[2][1:])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	return oldCount, newStart, newCount, nil
+}
+
+func parseHunkRange(r string) (start, count int, err error) {
+	rawStart, rawCount, hasCount := strings.Cut(r, ",")
+
+	start, err = strconv.Atoi(rawStart)
+	if err != nil || start < 0 {
+		return 0, 0, fmt.Errorf("invalid hunk range %q", r)
+	}
+
+	count = 1
+	if hasCount {
+		count, err = strconv.Atoi(rawCount)
+		if err != nil || count < 0 {
+			return 0, 0, fmt.Errorf("invalid hunk range %q", r)
+		}
+	}
+
+	return start, count, nil
+}
@@ -0,0 +1,243 @@
+package files_test
+
+import (
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRevisionedFile(t *testing.T, versions ...string) (*testutil.Database, *config.Config, *snips.File) {
+	t.Helper()
+
+	database := testutil.NewDatabase(t, dsn.SQLite, true)
+	cfg := &config.Config{}
+	cfg.Limits.FileSize = 1024 * 1024
+
+	file := &snips.File{Type: "plaintext", UserID: "user1"}
+	require.NoError(t, database.Files.Create(t.Context(), file, []byte(versions[0]), 0))
+	for _, version := range versions[1:] {
+		require.NoError(t, files.UpdateContent(t.Context(), database.DB, cfg, file, []byte(version), "txt"))
+	}
+
+	return database, cfg, file
+}
+
+func TestContentAt(t *testing.T) {
+	versions := []string{
+		"alpha\nbeta\ngamma\n",
+		"alpha\nBETA\ngamma\ndelta",
+		"--- not a header\n@@ -1 +1 @@\n\n\nalpha\nBETA\ngamma\ndelta\n",
+		"",
+		"only line",
+		"one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n",
+		"zero\none\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\n",
+	}
+	database, _, file := newRevisionedFile(t, versions...)
+
+	for sequence, want := range versions {
+		got, _, err := files.ContentAt(t.Context(), database.DB, file, int64(sequence))
+		require.NoError(t, err, "sequence %d", sequence)
+		assert.Equal(t, want, string(got), "sequence %d", sequence)
+	}
+
+	for _, sequence := range []int64{-1, int64(len(versions))} {
+		_, _, err := files.ContentAt(t.Context(), database.DB, file, sequence)
+		assert.ErrorIs(t, err, files.ErrRevisionNotFound, "sequence %d", sequence)
+	}
+}
+
+func TestContentAt_NoRevisions(t *testing.T) {
+	database, _, file := newRevisionedFile(t, "hello")
+
+	_, _, err := files.ContentAt(t.Context(), database.DB, file, 0)
+	assert.ErrorIs(t, err, files.ErrRevisionNotFound)
+}
+
+func TestContentAt_ContentChangedWithoutRevision(t *testing.T) {
+	database, _, file := newRevisionedFile(t, "one\n", "two\n")
+
+	// binary updates record no revision, so the diffs no longer line up
+	file.Type = snips.FileTypeBinary
+	require.NoError(t, database.Files.UpdateContent(t.Context(), file, []byte{0x00, 0x01}))
+
+	_, _, err := files.ContentAt(t.Context(), database.DB, file, 0)
+	assert.ErrorIs(t, err, files.ErrRevisionUnavailable)
+
+	_, _, err = files.ContentAt(t.Context(), database.DB, file, 1)
+	assert.ErrorIs(t, err, files.ErrRevisionUnavailable)
+}
+
+func TestHistory(t *testing.T) {
+	versions := []string{"one\n", "one\ntwo\n", "one\ntwo\nthree\n"}
+	database, _, file := newRevisionedFile(t, versions...)
+
+	history, err := files.History(t.Context(), database.DB, file)
+	require.NoError(t, err)
+	require.Len(t, history, len(versions))
+
+	for i, version := range history {
+		assert.Equal(t, int64(i), version.Sequence)
+		assert.Equal(t, versions[i], string(version.Content))
+	}
+}
+
+func TestHistory_NoRevisions(t *testing.T) {
+	database, _, file := newRevisionedFile(t, "hello")
+
+	history, err := files.History(t.Context(), database.DB, file)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, int64(-1), history[0].Sequence)
+	assert.Equal(t, "hello", string(history[0].Content))
+}
+
+func TestHistory_ContentChangedWithoutRevision(t *testing.T) {
+	database, _, file := newRevisionedFile(t, "one\n", "two\n")
+
+	file.Type = snips.FileTypeBinary
+	require.NoError(t, database.Files.UpdateContent(t.Context(), file, []byte{0x00, 0x01}))
+
+	// only the current content is left, since the diffs can't reach it
+	history, err := files.History(t.Context(), database.DB, file)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, []byte{0x00, 0x01}, history[0].Content)
+}
+
+func TestRestore(t *testing.T) {
+	database, cfg, file := newRevisionedFile(t, "package main\n", "package main\n\nfunc main() {}\n", "oops")
+
+	require.NoError(t, files.Restore(t.Context(), database.DB, cfg, file, 1))
+
+	content, err := database.Files.FindContent(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+
+	// the restore is a revision of its own, so it can be undone too
+	count, err := database.Revisions.CountByFileID(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	require.NoError(t, files.Restore(t.Context(), database.DB, cfg, file, 2))
+	content, err = database.Files.FindContent(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "oops", string(content))
+}
+
+func TestParseRevisionRange(t *testing.T) {
+	testcases := []struct {
+		input string
+		from  string
+		to    string
+		err   error
+	}{
+		{input: "1...3", from: "1", to: "3"},
+		{input: "2...HEAD", from: "2", to: files.HeadRevision},
+		{input: "2...", from: "2", to: files.HeadRevision},
+		{input: "...2", from: files.HeadRevision, to: "2"},
+		{input: "2", err: files.ErrInvalidRevision},
+		{input: "1..3", err: files.ErrInvalidRevision},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.input, func(t *testing.T) {
+			from, to, err := files.ParseRevisionRange(tc.input)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.from, from)
+				assert.Equal(t, tc.to, to)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	database, _, file := newRevisionedFile(t, "one\ntwo\n", "one\n2\n", "one\n2\nthree\n")
+
+	diff, err := files.Compare(t.Context(), database.DB, file, "0", files.HeadRevision)
+	require.NoError(t, err)
+	// difflib.SplitLines ends with an extra empty line, like recorded revisions
+	assert.Equal(t, "--- "+file.ID+" (v0)\n+++ "+file.ID+" (head)\n@@ -1,3 +1,4 @@\n one\n-two\n+2\n+three\n \n", diff)
+
+	diff, err = files.Compare(t.Context(), database.DB, file, "2", files.HeadRevision)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	_, err = files.Compare(t.Context(), database.DB, file, "latest", "1")
+	assert.ErrorIs(t, err, files.ErrInvalidRevision)
+
+	_, err = files.Compare(t.Context(), database.DB, file, "1", "9")
+	assert.ErrorIs(t, err, files.ErrRevisionNotFound)
+}
+
+func TestPreview(t *testing.T) {
+	file := &snips.File{ID: "abc"}
+
+	diff, err := files.Preview(file, []byte("one\ntwo\n"), []byte("one\n2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "--- abc (head)\n+++ abc (edited)\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n \n", diff)
+
+	diff, err = files.Preview(file, []byte("one\n"), []byte("one\n"))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestUpdateContent_PreconditionFailed(t *testing.T) {
+	database, cfg, file := newRevisionedFile(t, "one\n", "two\n")
+	before := *file
+
+	err := files.UpdateContent(t.Context(), database.DB, cfg, file, []byte("three\n"), "txt", db.IfRevision(0))
+	require.ErrorIs(t, err, db.ErrPreconditionFailed)
+	assert.Equal(t, before, *file)
+
+	count, err := database.Revisions.CountByFileID(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	require.NoError(t, files.UpdateContent(t.Context(), database.DB, cfg, file, []byte("three\n"), "txt", db.IfRevision(1), db.IfUpdatedAt(file.UpdatedAt)))
+	content, _, err := files.ContentAt(t.Context(), database.DB, file, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "three\n", string(content))
+}
+
+func TestAppendContent(t *testing.T) {
+	database, cfg, file := newRevisionedFile(t, "one\n")
+	stale := *file
+
+	require.NoError(t, files.AppendContent(t.Context(), database.DB, cfg, file, []byte("two\n")))
+	assert.Equal(t, uint64(8), file.Size)
+
+	// appending through an outdated copy of the file keeps the other append
+	require.NoError(t, files.AppendContent(t.Context(), database.DB, cfg, &stale, []byte("three\n")))
+	assert.Equal(t, uint64(14), stale.Size)
+
+	content, err := database.Files.FindContent(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree\n", string(content))
+
+	previous, _, err := files.ContentAt(t.Context(), database.DB, &stale, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(previous))
+
+	cfg.Limits.FileSize = 16
+	err = files.AppendContent(t.Context(), database.DB, cfg, &stale, []byte("four\n"))
+	assert.ErrorIs(t, err, files.ErrTooLarge)
+}
+
+func TestAppendContent_Bundle(t *testing.T) {
+	database, cfg, file := newRevisionedFile(t, "one\n")
+	file.Type = snips.FileTypeBundle
+
+	err := files.AppendContent(t.Context(), database.DB, cfg, file, []byte("two\n"))
+	assert.ErrorIs(t, err, files.ErrAppendBundle)
+}
@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(t *testing.T, perMinute, burst int) (*Limiter, *clock) {
+	t.Helper()
+
+	limiter := New(perMinute, burst)
+	require.NotNil(t, limiter)
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter.now = c.Now
+	return limiter, c
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Run("allows a burst then throttles", func(t *testing.T) {
+		limiter, _ := newTestLimiter(t, 60, 3)
+
+		for range 3 {
+			ok, _ := limiter.Allow("key")
+			assert.True(t, ok)
+		}
+
+		ok, wait := limiter.Allow("key")
+		assert.False(t, ok)
+		assert.Equal(t, time.Second, wait)
+	})
+
+	t.Run("refills over time", func(t *testing.T) {
+		limiter, c := newTestLimiter(t, 60, 1)
+
+		ok, _ := limiter.Allow("key")
+		assert.True(t, ok)
+
+		c.Advance(500 * time.Millisecond)
+		ok, wait := limiter.Allow("key")
+		assert.False(t, ok)
+		assert.Equal(t, 500*time.Millisecond, wait)
+
+		c.Advance(500 * time.Millisecond)
+		ok, _ = limiter.Allow("key")
+		assert.True(t, ok)
+	})
+
+	t.Run("never refills past the burst", func(t *testing.T) {
+		limiter, c := newTestLimiter(t, 60, 2)
+
+		c.Advance(time.Hour)
+		for range 2 {
+			ok, _ := limiter.Allow("key")
+			assert.True(t, ok)
+		}
+
+		ok, _ := limiter.Allow("key")
+		assert.False(t, ok)
+	})
+
+	t.Run("keys are limited separately", func(t *testing.T) {
+		limiter, _ := newTestLimiter(t, 60, 1)
+
+		ok, _ := limiter.Allow("a")
+		assert.True(t, ok)
+		ok, _ = limiter.Allow("a")
+		assert.False(t, ok)
+
+		ok, _ = limiter.Allow("b")
+		assert.True(t, ok)
+	})
+
+	t.Run("forgets idle keys", func(t *testing.T) {
+		limiter, c := newTestLimiter(t, 60, 5)
+
+		limiter.Allow("idle")
+		c.Advance(SweepInterval)
+		limiter.Allow("busy")
+		assert.Equal(t, 1, limiter.Len())
+
+		limiter.Allow("busy")
+		c.Advance(SweepInterval / 2)
+		limiter.Allow("other")
+		assert.Equal(t, 2, limiter.Len(), "swept at most once an interval")
+	})
+}
+
+func TestLimiter_Disabled(t *testing.T) {
+	assert.Nil(t, New(0, 10))
+
+	var limiter *Limiter
+	for range 100 {
+		ok, wait := limiter.Allow("key")
+		assert.True(t, ok)
+		assert.Zero(t, wait)
+	}
+	assert.Zero(t, limiter.Len())
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 1, RetryAfter(0))
+	assert.Equal(t, 1, RetryAfter(200*time.Millisecond))
+	assert.Equal(t, 2, RetryAfter(1100*time.Millisecond))
+	assert.Equal(t, 30, RetryAfter(30*time.Second))
+}
@@ -2,9 +2,11 @@ package renderer
 
 import (
 	"net/http"
+	"path"
 	"strings"
 
 	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
@@ -35,3 +37,14 @@ func DetectFileType(content []byte, hint string, useGuesser bool) string {
 
 	return strings.ToLower(lexer.Config().Name)
 }
+
+// BundleMemberType returns the type of a bundle member, using its file name
+// (e.g. "main.go" or "Makefile") as the hint when a lexer recognizes it.
+func BundleMemberType(member snips.BundleMember, useGuesser bool) string {
+	hint := path.Base(member.Name)
+	if lexers.Get(hint) == nil {
+		hint = ""
+	}
+
+	return DetectFileType(member.Content, hint, useGuesser)
+}
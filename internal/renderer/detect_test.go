@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/robherley/snips.sh/internal/renderer"
+	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +47,38 @@ func TestDetectFileType(t *testing.T) {
 		})
 	}
 }
+
+func TestBundleMemberType(t *testing.T) {
+	cases := []struct {
+		name   string
+		member snips.BundleMember
+		want   string
+	}{
+		{
+			name:   "extension",
+			member: snips.BundleMember{Name: "cmd/main.go", Content: []byte("package main")},
+			want:   "go",
+		},
+		{
+			name:   "well-known file name",
+			member: snips.BundleMember{Name: "Makefile", Content: []byte("all:\n\tgo build\n")},
+			want:   "makefile",
+		},
+		{
+			name:   "unknown name falls back to detection",
+			member: snips.BundleMember{Name: "notes", Content: []byte("plain words with no obvious language")},
+			want:   "plaintext",
+		},
+		{
+			name:   "binary content",
+			member: snips.BundleMember{Name: "logo.png", Content: []byte{0x00, 0x01, 0x02, 0x03}},
+			want:   "binary",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, renderer.BundleMemberType(tc.member, false))
+		})
+	}
+}
@@ -24,6 +24,24 @@ var (
 
 // ToSyntaxHighlightedHTML returns HTML of the syntax highlighted code via Chroma
 func ToSyntaxHighlightedHTML(fileType string, fileContent []byte) (template.HTML, error) {
+	return highlightHTML(formatter, fileType, fileContent)
+}
+
+// ToSyntaxHighlightedHTMLWithLinePrefix is like ToSyntaxHighlightedHTML but
+// prefixes line anchors with linePrefix instead of "L", so several highlighted
+// files can share a page without their line links colliding.
+func ToSyntaxHighlightedHTMLWithLinePrefix(fileType string, fileContent []byte, linePrefix string) (template.HTML, error) {
+	prefixed := html.New(
+		html.WithClasses(true),
+		html.WithAllClasses(true),
+		html.WithLineNumbers(true),
+		html.WithLinkableLineNumbers(true, linePrefix),
+	)
+
+	return highlightHTML(prefixed, fileType, fileContent)
+}
+
+func highlightHTML(formatter *html.Formatter, fileType string, fileContent []byte) (template.HTML, error) {
 	lexer := GetLexer(fileType)
 
 	it, err := lexer.Tokenise(nil, string(fileContent))
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"bytes"
+	"strings"
 
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/robherley/snips.sh/internal/snips"
@@ -15,6 +16,10 @@ func ToSyntaxHighlightedTerm(fileType string, fileContent []byte) (string, error
 		return "The file is not displayed because it has been detected as binary data.", nil
 	}
 
+	if fileType == snips.FileTypeBundle {
+		return bundleToTerm(fileContent)
+	}
+
 	lexer := GetLexer(fileType)
 
 	it, err := lexer.Tokenise(nil, string(fileContent))
@@ -30,3 +35,30 @@ func ToSyntaxHighlightedTerm(fileType string, fileContent []byte) (string, error
 
 	return chromaTerm.String(), nil
 }
+
+// bundleToTerm highlights each member of a bundle under a header with its name.
+func bundleToTerm(fileContent []byte) (string, error) {
+	members, err := snips.ParseBundle(fileContent)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, member := range members {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("==> " + member.Name + " <==\n")
+
+		highlighted, err := ToSyntaxHighlightedTerm(BundleMemberType(member, false), member.Content)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(highlighted)
+		if !strings.HasSuffix(highlighted, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"net/url"
 	"strconv"
 	"time"
@@ -93,6 +94,28 @@ func (signer *Signer) VerifyURLAndNotExpired(u url.URL) bool {
 	return expiresUnix > time.Now().Unix()
 }
 
+// SignPayload returns a hex sha256 hmac signature of a payload sent at
+// timestamp. Signing the timestamp along with the payload lets receivers
+// reject replays of old payloads.
+func (signer *Signer) SignPayload(timestamp time.Time, payload []byte) string {
+	return hex.EncodeToString(signer.computeMac(payloadToSign(timestamp, payload)))
+}
+
+// VerifyPayload checks if signature is SignPayload's signature of a payload
+// sent at timestamp.
+func (signer *Signer) VerifyPayload(timestamp time.Time, payload []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(got, signer.computeMac(payloadToSign(timestamp, payload)))
+}
+
+func payloadToSign(timestamp time.Time, payload []byte) string {
+	return strconv.FormatInt(timestamp.Unix(), 10) + "." + string(payload)
+}
+
 func (signer *Signer) computeMac(data string) []byte {
 	mac := hmac.New(sha256.New, signer.key)
 	mac.Write([]byte(data))
@@ -207,3 +207,61 @@ func TestSigner_VerifyURLAndNotExpired(t *testing.T) {
 		})
 	}
 }
+
+func TestSigner_SignPayload(t *testing.T) {
+	signer := signer.New(testKey)
+	timestamp := time.Unix(1700000000, 0)
+	payload := []byte(`{"event":"update"}`)
+
+	signature := signer.SignPayload(timestamp, payload)
+	if signature != signer.SignPayload(timestamp, payload) {
+		t.Errorf("signature is not deterministic")
+	}
+
+	testcases := []struct {
+		name      string
+		timestamp time.Time
+		payload   []byte
+		signature string
+		want      bool
+	}{
+		{
+			name:      "valid",
+			timestamp: timestamp,
+			payload:   payload,
+			signature: signature,
+			want:      true,
+		},
+		{
+			name:      "invalid - different payload",
+			timestamp: timestamp,
+			payload:   []byte(`{"event":"delete"}`),
+			signature: signature,
+			want:      false,
+		},
+		{
+			name:      "invalid - different timestamp",
+			timestamp: timestamp.Add(time.Second),
+			payload:   payload,
+			signature: signature,
+			want:      false,
+		},
+		{
+			name:      "invalid - not hex",
+			timestamp: timestamp,
+			payload:   payload,
+			signature: "not-hex",
+			want:      false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := signer.VerifyPayload(tc.timestamp, tc.payload, tc.signature)
+
+			if got != tc.want {
+				t.Errorf("got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
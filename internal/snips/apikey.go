@@ -5,6 +5,9 @@ import (
 	"crypto/sha512"
 	"encoding/base32"
 	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -12,10 +15,29 @@ const (
 	// APIKeyTokenPrefix prefixes every minted token so keys are recognizable
 	// (and grep-able) in configs and secret scanners.
 	APIKeyTokenPrefix = "snips_"
+	// APIKeyFilesMaxCount is the most files a key can be restricted to.
+	APIKeyFilesMaxCount = 100
 
 	apiKeyTokenBytes = 32
 )
 
+// API key scopes, each granting one kind of access to the user's account.
+const (
+	APIKeyScopeFilesRead   = "files:read"
+	APIKeyScopeFilesWrite  = "files:write"
+	APIKeyScopeFilesDelete = "files:delete"
+	APIKeyScopeSign        = "sign"
+	APIKeyScopeWebhooks    = "webhooks"
+)
+
+// APIKeyScopes are all the scopes a key can be granted.
+var APIKeyScopes = []string{APIKeyScopeFilesRead, APIKeyScopeFilesWrite, APIKeyScopeFilesDelete, APIKeyScopeSign, APIKeyScopeWebhooks}
+
+var (
+	ErrInvalidAPIKeyScope = fmt.Errorf("api key scopes must be one of: %s", strings.Join(APIKeyScopes, ", "))
+	ErrTooManyAPIKeyFiles = fmt.Errorf("api keys can be restricted to at most %d files", APIKeyFilesMaxCount)
+)
+
 // APIKey grants REST API access as a user. Only a hash of the token is
 // stored; the token itself is shown once at mint time.
 type APIKey struct {
@@ -27,6 +49,25 @@ type APIKey struct {
 	UserID     string
 	LastUsedAt *time.Time
 	ExpiresAt  *time.Time // nil = never expires
+	Scopes     []string   // normalized, see NormalizeAPIKeyScopes; empty = every scope
+	FileIDs    []string   // files the key is restricted to; empty = every file
+}
+
+// HasScope reports whether the key grants scope. Keys without scopes, such as
+// those minted before scopes existed, grant them all.
+func (k *APIKey) HasScope(scope string) bool {
+	return len(k.Scopes) == 0 || slices.Contains(k.Scopes, scope)
+}
+
+// IsFileRestricted reports whether the key can only act on certain files,
+// which also rules out account-wide operations like listing files.
+func (k *APIKey) IsFileRestricted() bool {
+	return len(k.FileIDs) > 0
+}
+
+// CanAccessFile reports whether the key may act on the file with fileID.
+func (k *APIKey) CanAccessFile(fileID string) bool {
+	return !k.IsFileRestricted() || slices.Contains(k.FileIDs, fileID)
 }
 
 // IsExpired reports whether the key's optional expiry has passed. Expired
@@ -64,3 +105,45 @@ func HashAPIKeyToken(token string) string {
 	digest := sha512.Sum384([]byte(token))
 	return hex.EncodeToString(digest[:])
 }
+
+// NormalizeAPIKeyScopes lowercases and validates scopes, dropping duplicates,
+// and returns them sorted. Entries may hold several scopes separated by commas
+// or whitespace. No scopes at all normalize to nil, granting every scope.
+func NormalizeAPIKeyScopes(scopes []string) ([]string, error) {
+	var normalized []string
+	for _, entry := range scopes {
+		for _, scope := range strings.FieldsFunc(entry, isTagSeparator) {
+			scope = strings.ToLower(scope)
+			if !slices.Contains(APIKeyScopes, scope) {
+				return nil, ErrInvalidAPIKeyScope
+			}
+
+			if !slices.Contains(normalized, scope) {
+				normalized = append(normalized, scope)
+			}
+		}
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+// NormalizeAPIKeyFileIDs drops duplicate file IDs and returns them sorted.
+// Entries may hold several IDs separated by commas or whitespace.
+func NormalizeAPIKeyFileIDs(fileIDs []string) ([]string, error) {
+	var normalized []string
+	for _, entry := range fileIDs {
+		for _, fileID := range strings.FieldsFunc(entry, isTagSeparator) {
+			if !slices.Contains(normalized, fileID) {
+				normalized = append(normalized, fileID)
+			}
+		}
+	}
+
+	if len(normalized) > APIKeyFilesMaxCount {
+		return nil, ErrTooManyAPIKeyFiles
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
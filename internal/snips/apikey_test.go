@@ -1,10 +1,12 @@
 package snips_test
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 
 	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,3 +16,73 @@ func TestNewAPIKeyToken(t *testing.T) {
 	require.Regexp(t, regexp.MustCompile(`^snips_[A-Z2-7]{52}$`), token)
 	require.Equal(t, snips.HashAPIKeyToken(token), hash)
 }
+
+func TestNormalizeAPIKeyScopes(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input []string
+		want  []string
+		err   error
+	}{
+		{
+			name:  "none",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name:  "sorted and deduplicated",
+			input: []string{"sign", "FILES:WRITE", "sign"},
+			want:  []string{"files:write", "sign"},
+		},
+		{
+			name:  "comma separated",
+			input: []string{"files:read,files:write"},
+			want:  []string{"files:read", "files:write"},
+		},
+		{
+			name:  "unknown",
+			input: []string{"files:admin"},
+			err:   snips.ErrInvalidAPIKeyScope,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := snips.NormalizeAPIKeyScopes(tc.input)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestNormalizeAPIKeyFileIDs(t *testing.T) {
+	got, err := snips.NormalizeAPIKeyFileIDs([]string{"def456, abc123", "abc123"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc123", "def456"}, got)
+
+	tooMany := make([]string, snips.APIKeyFilesMaxCount+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("file%d", i)
+	}
+	_, err = snips.NormalizeAPIKeyFileIDs(tooMany)
+	assert.ErrorIs(t, err, snips.ErrTooManyAPIKeyFiles)
+}
+
+func TestAPIKey_Permissions(t *testing.T) {
+	unscoped := &snips.APIKey{}
+	assert.True(t, unscoped.HasScope(snips.APIKeyScopeFilesDelete))
+	assert.False(t, unscoped.IsFileRestricted())
+	assert.True(t, unscoped.CanAccessFile("abc123"))
+
+	scoped := &snips.APIKey{Scopes: []string{snips.APIKeyScopeFilesWrite}, FileIDs: []string{"abc123"}}
+	assert.True(t, scoped.HasScope(snips.APIKeyScopeFilesWrite))
+	assert.False(t, scoped.HasScope(snips.APIKeyScopeFilesDelete))
+	assert.True(t, scoped.IsFileRestricted())
+	assert.True(t, scoped.CanAccessFile("abc123"))
+	assert.False(t, scoped.CanAccessFile("def456"))
+}
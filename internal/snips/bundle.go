@@ -0,0 +1,146 @@
+package snips
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// BundleMaxMembers is the maximum number of files in a bundle.
+	BundleMaxMembers = 64
+)
+
+var (
+	// ErrInvalidBundle is wrapped by every bundle validation error.
+	ErrInvalidBundle = errors.New("invalid bundle")
+
+	ErrEmptyBundle         = fmt.Errorf("%w: must contain at least one file", ErrInvalidBundle)
+	ErrTooManyMembers      = fmt.Errorf("%w: may contain at most %d files", ErrInvalidBundle, BundleMaxMembers)
+	ErrInvalidMemberName   = fmt.Errorf("%w: file names must be relative paths without '..' segments", ErrInvalidBundle)
+	ErrDuplicateMemberName = fmt.Errorf("%w: file names must be unique", ErrInvalidBundle)
+)
+
+// BundleMember is a single named file within a bundle.
+type BundleMember struct {
+	Name    string
+	Content []byte
+}
+
+// ParseBundle reads the regular files from a tar stream. Directories and other
+// non-regular entries (links, devices, pax headers) are skipped, as are macOS
+// resource forks ("._" files). Member names are validated when the bundle is
+// encoded.
+func ParseBundle(content []byte) ([]BundleMember, error) {
+	tr := tar.NewReader(bytes.NewReader(content))
+
+	members := []BundleMember{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || strings.HasPrefix(path.Base(hdr.Name), "._") {
+			continue
+		}
+
+		if len(members) == BundleMaxMembers {
+			return nil, ErrTooManyMembers
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidBundle, err)
+		}
+
+		members = append(members, BundleMember{Name: hdr.Name, Content: data})
+	}
+
+	return members, nil
+}
+
+// NormalizeBundle parses a tar stream and re-encodes it with EncodeBundle.
+func NormalizeBundle(content []byte) ([]byte, error) {
+	members, err := ParseBundle(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodeBundle(members)
+}
+
+// EncodeBundle validates the members and writes them as a normalized tar
+// stream, dropping ownership, permissions and timestamps. This is the form in
+// which bundles are stored.
+func EncodeBundle(members []BundleMember) ([]byte, error) {
+	if len(members) == 0 {
+		return nil, ErrEmptyBundle
+	}
+
+	if len(members) > BundleMaxMembers {
+		return nil, ErrTooManyMembers
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	seen := make(map[string]struct{}, len(members))
+	for _, member := range members {
+		name, err := NormalizeMemberName(member.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[name]; ok {
+			return nil, ErrDuplicateMemberName
+		}
+		seen[name] = struct{}{}
+
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(member.Content)),
+			ModTime:  time.Unix(0, 0),
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+
+		if _, err := tw.Write(member.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// NormalizeMemberName cleans a bundle member's path, rejecting absolute paths
+// and anything that would escape the bundle.
+func NormalizeMemberName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return "", ErrInvalidMemberName
+	}
+
+	name = path.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", ErrInvalidMemberName
+	}
+
+	return name, nil
+}
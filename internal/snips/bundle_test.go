@@ -0,0 +1,113 @@
+package snips_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTar(t *testing.T, headers ...*tar.Header) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range headers {
+		content := []byte(hdr.Linkname)
+		if hdr.Typeflag == tar.TypeReg {
+			hdr.Size = int64(len(content))
+		} else {
+			content = nil
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	return buf.Bytes()
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	// Linkname doubles as the content of regular files in makeTar.
+	raw := makeTar(t,
+		&tar.Header{Typeflag: tar.TypeDir, Name: "./"},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./main.go", Linkname: "package main"},
+		&tar.Header{Typeflag: tar.TypeDir, Name: "./docs/"},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./docs/README.md", Linkname: "# hi"},
+		&tar.Header{Typeflag: tar.TypeReg, Name: "./docs/._README.md", Linkname: "junk"},
+		&tar.Header{Typeflag: tar.TypeSymlink, Name: "./link", Linkname: "/etc/passwd"},
+	)
+
+	members, err := snips.ParseBundle(raw)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	encoded, err := snips.EncodeBundle(members)
+	require.NoError(t, err)
+
+	members, err = snips.ParseBundle(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, []snips.BundleMember{
+		{Name: "main.go", Content: []byte("package main")},
+		{Name: "docs/README.md", Content: []byte("# hi")},
+	}, members)
+}
+
+func TestParseBundle_NotTar(t *testing.T) {
+	_, err := snips.ParseBundle(bytes.Repeat([]byte("not a tarball "), 64))
+	assert.ErrorIs(t, err, snips.ErrInvalidBundle)
+}
+
+func TestEncodeBundle(t *testing.T) {
+	testcases := []struct {
+		name    string
+		members []snips.BundleMember
+		err     error
+	}{
+		{
+			name:    "empty",
+			members: []snips.BundleMember{},
+			err:     snips.ErrEmptyBundle,
+		},
+		{
+			name:    "too many",
+			members: make([]snips.BundleMember, snips.BundleMaxMembers+1),
+			err:     snips.ErrTooManyMembers,
+		},
+		{
+			name:    "absolute path",
+			members: []snips.BundleMember{{Name: "/etc/passwd"}},
+			err:     snips.ErrInvalidMemberName,
+		},
+		{
+			name:    "parent traversal",
+			members: []snips.BundleMember{{Name: "a/../../b"}},
+			err:     snips.ErrInvalidMemberName,
+		},
+		{
+			name:    "blank name",
+			members: []snips.BundleMember{{Name: " "}},
+			err:     snips.ErrInvalidMemberName,
+		},
+		{
+			name:    "duplicate after cleaning",
+			members: []snips.BundleMember{{Name: "a.txt"}, {Name: "./a.txt"}},
+			err:     snips.ErrDuplicateMemberName,
+		},
+		{
+			name:    "valid",
+			members: []snips.BundleMember{{Name: "a.txt"}, {Name: "dir/b.txt", Content: []byte("b")}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := snips.EncodeBundle(tc.members)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package snips
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"time"
 
 	"github.com/robherley/snips.sh/internal/config"
@@ -12,17 +13,28 @@ import (
 const (
 	FileTypeBinary   = "binary"
 	FileTypeMarkdown = "markdown"
+	FileTypeBundle   = "bundle"
 )
 
 type File struct {
-	ID        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Size      uint64    `json:"size"`
-	Private   bool      `json:"private"`
-	Type      string    `json:"type"`
-	UserID    string    `json:"-"`
-	Name      string    `json:"name,omitempty"`
+	ID            string     `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Size          uint64     `json:"size"`
+	Private       bool       `json:"private"`
+	Type          string     `json:"type"`
+	UserID        string     `json:"-"`
+	Name          string     `json:"name,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	Tags          []string   `json:"tags,omitempty"`            // normalized, see NormalizeTags
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`      // nil = never expires
+	BurnAfterRead bool       `json:"burn_after_read,omitempty"` // deleted by the first successful view
+}
+
+// IsExpired reports whether the file's optional expiry has passed. Expired
+// files are treated as not found until the reaper purges them.
+func (f *File) IsExpired() bool {
+	return f.ExpiresAt != nil && !time.Now().Before(*f.ExpiresAt)
 }
 
 func (f *File) DisplayName() string {
@@ -32,6 +44,18 @@ func (f *File) DisplayName() string {
 	return f.ID
 }
 
+// HasTag reports whether the file is tagged with tag, which must already be
+// normalized.
+func (f *File) HasTag(tag string) bool {
+	return slices.Contains(f.Tags, tag)
+}
+
+// ETag identifies the file's current state for HTTP conditional requests. It
+// changes whenever the file is updated.
+func (f *File) ETag() string {
+	return fmt.Sprintf(`"%x"`, f.UpdatedAt.UnixNano())
+}
+
 func (f *File) IsBinary() bool {
 	return f.Type == FileTypeBinary
 }
@@ -40,6 +64,12 @@ func (f *File) IsMarkdown() bool {
 	return f.Type == FileTypeMarkdown
 }
 
+// IsBundle reports whether the file groups several named members, stored as
+// a tar stream (see ParseBundle).
+func (f *File) IsBundle() bool {
+	return f.Type == FileTypeBundle
+}
+
 func (f *File) GetSignedURL(cfg *config.Config, ttl time.Duration) (url.URL, time.Time) {
 	pathToSign := url.URL{
 		Path: fmt.Sprintf("/f/%s", f.ID),
@@ -1,6 +1,20 @@
 package snips
 
-import "time"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	// KeyLinkTTL is how long a key link code can be claimed for.
+	KeyLinkTTL = 10 * time.Minute
+
+	keyLinkCodeBytes = 5
+)
 
 type PublicKey struct {
 	ID          string
@@ -10,3 +24,38 @@ type PublicKey struct {
 	Type        string
 	UserID      string
 }
+
+// KeyLink is a pending request to attach another public key to a user. Like
+// API keys, only a hash of its code is stored.
+type KeyLink struct {
+	CodeHash  string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// IsExpired reports whether the link can no longer be claimed.
+func (l *KeyLink) IsExpired() bool {
+	return !time.Now().Before(l.ExpiresAt)
+}
+
+// NewKeyLinkCode mints a short, random code to be typed in from another key
+// (e.g. "K3JD-7QXA"), returning it alongside the hash to persist.
+func NewKeyLinkCode() (code string, hash string, err error) {
+	raw := make([]byte, keyLinkCodeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	code = encoded[:4] + "-" + encoded[4:]
+	return code, HashKeyLinkCode(code), nil
+}
+
+// HashKeyLinkCode returns the hex-encoded SHA-256 digest of a code, ignoring
+// case, dashes and spaces so codes can be typed loosely.
+func HashKeyLinkCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	digest := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(digest[:])
+}
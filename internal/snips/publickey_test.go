@@ -0,0 +1,31 @@
+package snips_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeyLinkCode(t *testing.T) {
+	code, hash, err := snips.NewKeyLinkCode()
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^[A-Z2-7]{4}-[A-Z2-7]{4}$`), code)
+	require.Equal(t, snips.HashKeyLinkCode(code), hash)
+
+	// codes are typed by hand, so they're forgiving
+	loose := strings.ToLower(strings.ReplaceAll(code, "-", " "))
+	require.Equal(t, hash, snips.HashKeyLinkCode(loose))
+	require.NotEqual(t, hash, snips.HashKeyLinkCode("AAAA-AAAA"))
+}
+
+func TestKeyLinkIsExpired(t *testing.T) {
+	link := &snips.KeyLink{ExpiresAt: time.Now().Add(time.Minute)}
+	require.False(t, link.IsExpired())
+
+	link.ExpiresAt = time.Now().Add(-time.Minute)
+	require.True(t, link.IsExpired())
+}
@@ -0,0 +1,86 @@
+package snips
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// TagMaxLength is the maximum length of a single tag.
+	TagMaxLength = 32
+	// TagsMaxCount is the maximum number of tags on a file.
+	TagsMaxCount = 16
+	// DescriptionMaxLength is the maximum length of a file's description, in runes.
+	DescriptionMaxLength = 280
+)
+
+var (
+	ErrInvalidTag         = fmt.Errorf("tags must be 1-%d lowercase alphanumeric characters (hyphen, dot, or underscore separators allowed)", TagMaxLength)
+	ErrTooManyTags        = fmt.Errorf("files can have at most %d tags", TagsMaxCount)
+	ErrInvalidDescription = fmt.Errorf("descriptions must be a single line of at most %d characters", DescriptionMaxLength)
+
+	// tagRegex matches the same shape as nameRegex, but tags are compared
+	// case-insensitively so they're stored lowercase.
+	tagRegex = regexp.MustCompile(`^[a-z0-9]+(?:[-._][a-z0-9]+)*$`)
+)
+
+// NormalizeTag lowercases and validates a single tag. A leading "#" is
+// dropped, so "#Go" and "go" are the same tag.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+
+	if tag == "" || len(tag) > TagMaxLength || !tagRegex.MatchString(tag) {
+		return "", ErrInvalidTag
+	}
+
+	return tag, nil
+}
+
+// NormalizeTags normalizes each tag, dropping duplicates, and returns them
+// sorted. Entries may hold several tags separated by commas or whitespace.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	for _, entry := range tags {
+		for _, tag := range strings.FieldsFunc(entry, isTagSeparator) {
+			tag, err := NormalizeTag(tag)
+			if err != nil {
+				return nil, err
+			}
+
+			if !slices.Contains(normalized, tag) {
+				normalized = append(normalized, tag)
+			}
+		}
+	}
+
+	if len(normalized) > TagsMaxCount {
+		return nil, ErrTooManyTags
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
+
+func isTagSeparator(r rune) bool {
+	return r == ',' || unicode.IsSpace(r)
+}
+
+// NormalizeDescription trims and validates a description. An empty
+// description is valid and clears it.
+func NormalizeDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+
+	if utf8.RuneCountInString(description) > DescriptionMaxLength || !utf8.ValidString(description) {
+		return "", ErrInvalidDescription
+	}
+
+	if strings.ContainsFunc(description, unicode.IsControl) {
+		return "", ErrInvalidDescription
+	}
+
+	return description, nil
+}
@@ -0,0 +1,209 @@
+package snips_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{
+			name:  "simple",
+			input: "go",
+			want:  "go",
+		},
+		{
+			name:  "lowercased",
+			input: "NixOS",
+			want:  "nixos",
+		},
+		{
+			name:  "hash prefix",
+			input: "#infra",
+			want:  "infra",
+		},
+		{
+			name:  "separators",
+			input: "k8s.prod-eu_1",
+			want:  "k8s.prod-eu_1",
+		},
+		{
+			name:  "surrounding whitespace",
+			input: "  go  ",
+			want:  "go",
+		},
+		{
+			name:  "empty",
+			input: "",
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "hash only",
+			input: "#",
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "leading hyphen",
+			input: "-go",
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "invalid characters",
+			input: "c++",
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "too long",
+			input: strings.Repeat("a", snips.TagMaxLength+1),
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "max length",
+			input: strings.Repeat("a", snips.TagMaxLength),
+			want:  strings.Repeat("a", snips.TagMaxLength),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := snips.NormalizeTag(tc.input)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, snips.TagsMaxCount+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	testcases := []struct {
+		name  string
+		input []string
+		want  []string
+		err   error
+	}{
+		{
+			name:  "none",
+			input: nil,
+			want:  []string{},
+		},
+		{
+			name:  "sorted",
+			input: []string{"nginx", "config"},
+			want:  []string{"config", "nginx"},
+		},
+		{
+			name:  "deduplicated",
+			input: []string{"Go", "go", "#go"},
+			want:  []string{"go"},
+		},
+		{
+			name:  "comma and space separated",
+			input: []string{"go, infra", "deploy  k8s"},
+			want:  []string{"deploy", "go", "infra", "k8s"},
+		},
+		{
+			name:  "blank entries",
+			input: []string{"", " , "},
+			want:  []string{},
+		},
+		{
+			name:  "invalid tag",
+			input: []string{"go", "c++"},
+			err:   snips.ErrInvalidTag,
+		},
+		{
+			name:  "too many",
+			input: tooMany,
+			err:   snips.ErrTooManyTags,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := snips.NormalizeTags(tc.input)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeDescription(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{
+			name:  "simple",
+			input: "nginx config for the staging box",
+			want:  "nginx config for the staging box",
+		},
+		{
+			name:  "trimmed",
+			input: "  notes \n",
+			want:  "notes",
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  "",
+		},
+		{
+			name:  "multibyte at max length",
+			input: strings.Repeat("é", snips.DescriptionMaxLength),
+			want:  strings.Repeat("é", snips.DescriptionMaxLength),
+		},
+		{
+			name:  "too long",
+			input: strings.Repeat("a", snips.DescriptionMaxLength+1),
+			err:   snips.ErrInvalidDescription,
+		},
+		{
+			name:  "multiple lines",
+			input: "first\nsecond",
+			err:   snips.ErrInvalidDescription,
+		},
+		{
+			name:  "escape sequence",
+			input: "\x1b[31mred",
+			err:   snips.ErrInvalidDescription,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := snips.NormalizeDescription(tc.input)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
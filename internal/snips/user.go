@@ -3,8 +3,14 @@ package snips
 import "time"
 
 type User struct {
-	ID         string    `json:"id"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	ThemeColor string    `json:"-"`
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ThemeColor string     `json:"-"`
+	BannedAt   *time.Time `json:"-"`
+}
+
+// IsBanned reports whether an admin has banned the user.
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
 }
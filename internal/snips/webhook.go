@@ -0,0 +1,136 @@
+package snips
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// WebhookSecretPrefix prefixes every webhook signing secret, so secrets
+	// are recognizable in configs and secret scanners.
+	WebhookSecretPrefix = "whsec_"
+	// WebhookURLMaxLength is the maximum length of a webhook URL.
+	WebhookURLMaxLength = 2048
+
+	webhookSecretBytes = 32
+)
+
+// Webhook events, one per kind of file change a webhook can subscribe to.
+const (
+	WebhookEventCreate = "create"
+	WebhookEventUpdate = "update"
+	WebhookEventDelete = "delete"
+	WebhookEventRename = "rename"
+)
+
+// WebhookEvents are all the events a webhook can subscribe to.
+var WebhookEvents = []string{WebhookEventCreate, WebhookEventUpdate, WebhookEventDelete, WebhookEventRename}
+
+var (
+	ErrInvalidWebhookURL   = fmt.Errorf("webhook urls must be absolute http or https urls of at most %d characters", WebhookURLMaxLength)
+	ErrInvalidWebhookEvent = fmt.Errorf("webhook events must be one of: %s", strings.Join(WebhookEvents, ", "))
+)
+
+// Webhook receives a signed JSON payload for each change to its user's files
+// that it subscribes to.
+type Webhook struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    string    `json:"-"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // normalized, see NormalizeWebhookEvents
+	// Secret is the key payloads are signed with. Unlike API tokens it is
+	// stored as is, since signing needs it, but it's only shown at creation.
+	Secret string `json:"-"`
+}
+
+// Subscribes reports whether the webhook receives event.
+func (w *Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookDeliveryStatus is where a delivery is in its lifecycle.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending deliveries are waiting for their next attempt.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliverySucceeded deliveries got a 2xx response.
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed deliveries ran out of attempts.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook, along with
+// the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID            string                `json:"id"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	WebhookID     string                `json:"webhook_id"`
+	Event         string                `json:"event"`
+	FileID        string                `json:"file_id"`
+	Payload       []byte                `json:"-"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	NextAttemptAt *time.Time            `json:"next_attempt_at,omitempty"` // nil once no more attempts are due
+	ResponseCode  int                   `json:"response_code,omitempty"`   // of the latest attempt, 0 if it got no response
+	Error         string                `json:"error,omitempty"`           // of the latest attempt
+}
+
+// NewWebhookSecret mints a random secret for signing a webhook's payloads.
+func NewWebhookSecret() (string, error) {
+	raw := make([]byte, webhookSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return WebhookSecretPrefix + base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw), nil
+}
+
+// NormalizeWebhookURL trims and validates a webhook URL.
+func NormalizeWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > WebhookURLMaxLength {
+		return "", ErrInvalidWebhookURL
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return "", ErrInvalidWebhookURL
+	}
+
+	return u.String(), nil
+}
+
+// NormalizeWebhookEvents lowercases and validates events, dropping duplicates,
+// and returns them sorted. Entries may hold several events separated by commas
+// or whitespace. No events at all means every event.
+func NormalizeWebhookEvents(events []string) ([]string, error) {
+	normalized := []string{}
+	for _, entry := range events {
+		for _, event := range strings.FieldsFunc(entry, isTagSeparator) {
+			event = strings.ToLower(event)
+			if !slices.Contains(WebhookEvents, event) {
+				return nil, ErrInvalidWebhookEvent
+			}
+
+			if !slices.Contains(normalized, event) {
+				normalized = append(normalized, event)
+			}
+		}
+	}
+
+	if len(normalized) == 0 {
+		normalized = slices.Clone(WebhookEvents)
+	}
+
+	slices.Sort(normalized)
+	return normalized, nil
+}
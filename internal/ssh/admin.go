@@ -0,0 +1,284 @@
+package ssh
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// Admin dispatches the `admin <rm|ban|whois>` command for moderating the
+// instance. It's only available to sessions whose fingerprint is configured as
+// an admin (see config.Config.IsAdmin).
+func (h *SessionHandler) Admin(sesh *UserSession) {
+	if !h.Config.IsAdmin(sesh.PublicKeyFingerprint()) {
+		metrics.IncrCounter([]string{"admin", "denied"}, 1)
+		sesh.Error(ErrNotAdmin, "Unauthorized", "Admin commands are only available to admins.")
+		return
+	}
+
+	args := sesh.Command()[1:]
+	if len(args) == 0 {
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Usage: %s <rm|ban|whois>", AdminCommand)
+		return
+	}
+
+	switch args[0] {
+	case "rm":
+		h.AdminRemoveFile(sesh, args[1:])
+	case "ban":
+		h.AdminBanUser(sesh, args[1:])
+	case "whois":
+		h.AdminWhois(sesh, args[1:])
+	default:
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Unknown subcommand %q, expected <rm|ban|whois>", args[0])
+	}
+}
+
+// AdminRemoveFile deletes any user's file.
+func (h *SessionHandler) AdminRemoveFile(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	flags := DeleteFlags{}
+	if err := flags.Parse(sesh.Stderr(), args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			log.Warn("invalid user specified flags", "err", err)
+		}
+		return
+	}
+
+	fileID := flags.Arg(0)
+	if fileID == "" {
+		sesh.Error(ErrFileIDRequired, "Unable to remove file", "Provide a file, e.g.: %s rm <file id>", AdminCommand)
+		return
+	}
+
+	file, err := h.DB.Files.Find(sesh.Context(), fileID)
+	if err != nil {
+		sesh.Error(err, "Unable to remove file", "There was an error finding file: %q", fileID)
+		return
+	}
+	if file == nil {
+		sesh.Error(ErrFileNotFound, "Unable to remove file", "File not found: %q", fileID)
+		return
+	}
+
+	if !flags.Force {
+		confirm := Confirm{}
+		confirm.Questionf("Are you sure you want to remove %q, owned by user %q?", file.ID, file.UserID)
+
+		confirmed, err := confirm.Prompt(sesh)
+		if err != nil {
+			sesh.Error(err, "Unable to remove file", "There was an error removing file: %q", file.ID)
+			return
+		}
+
+		if !confirmed {
+			noti := Notification{
+				Title: "File Not Removed ℹ️",
+				Color: styles.Colors.Yellow,
+				WithStyle: func(s *lipgloss.Style) {
+					s.MarginTop(1)
+				},
+			}
+			noti.Messagef("Chose not to remove file: %q", file.ID)
+			noti.Render(sesh)
+			return
+		}
+	}
+
+	if err := h.DB.Files.Delete(sesh.Context(), file.ID); err != nil {
+		sesh.Error(err, "Unable to remove file", "There was an error removing file: %q", file.ID)
+		return
+	}
+
+	h.Events.Publish(events.NewEvent(events.KindDelete, file))
+	metrics.IncrCounter([]string{"admin", "file", "delete"}, 1)
+	log.Info("file removed by admin", "file_id", file.ID, "owner_id", file.UserID)
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "File Removed 🗑️",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("Removed file %q, owned by user %q.", file.ID, file.UserID)
+	noti.Render(sesh)
+}
+
+// AdminBanUser bans a user, disconnecting them from SSH and revoking their API
+// keys. Their files are left for AdminRemoveFile.
+func (h *SessionHandler) AdminBanUser(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	flags := BanFlags{}
+	if err := flags.Parse(sesh.Stderr(), args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			log.Warn("invalid user specified flags", "err", err)
+		}
+		return
+	}
+
+	userID := flags.Arg(0)
+	if userID == "" {
+		sesh.Error(ErrUserIDRequired, "Unable to ban user", "Provide a user, e.g.: %s ban <user id> (find a file's owner with: %s whois <file id>)", AdminCommand, AdminCommand)
+		return
+	}
+
+	if userID == sesh.UserID() {
+		sesh.Error(ErrBanSelf, "Unable to ban user", "You can't ban yourself.")
+		return
+	}
+
+	user, err := h.DB.Users.Find(sesh.Context(), userID)
+	if err != nil {
+		sesh.Error(err, "Unable to ban user", "There was an error finding user: %q", userID)
+		return
+	}
+	if user == nil {
+		sesh.Error(ErrUserNotFound, "Unable to ban user", "User not found: %q", userID)
+		return
+	}
+
+	if user.IsBanned() {
+		noti := Notification{
+			Title: "Already Banned ℹ️",
+			Color: styles.Colors.Yellow,
+			WithStyle: func(s *lipgloss.Style) {
+				s.MarginTop(1)
+			},
+		}
+		noti.Messagef("User %q was banned at %s.", user.ID, user.BannedAt.UTC().Format(time.RFC3339))
+		noti.Render(sesh)
+		return
+	}
+
+	if !flags.Force {
+		confirm := Confirm{}
+		confirm.Questionf("Are you sure you want to ban %q?", user.ID)
+
+		confirmed, err := confirm.Prompt(sesh)
+		if err != nil {
+			sesh.Error(err, "Unable to ban user", "There was an error banning user: %q", user.ID)
+			return
+		}
+
+		if !confirmed {
+			noti := Notification{
+				Title: "User Not Banned ℹ️",
+				Color: styles.Colors.Yellow,
+				WithStyle: func(s *lipgloss.Style) {
+					s.MarginTop(1)
+				},
+			}
+			noti.Messagef("Chose not to ban user: %q", user.ID)
+			noti.Render(sesh)
+			return
+		}
+	}
+
+	if _, err := h.DB.Users.Ban(sesh.Context(), user.ID); err != nil {
+		sesh.Error(err, "Unable to ban user", "There was an error banning user: %q", user.ID)
+		return
+	}
+
+	metrics.IncrCounter([]string{"admin", "user", "ban"}, 1)
+	log.Info("user banned by admin", "banned_user_id", user.ID)
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "User Banned 🔨",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("User %q can no longer connect and their API keys were revoked.\nTheir files are untouched, remove them with: %s rm <file id>", user.ID, AdminCommand)
+	noti.Render(sesh)
+}
+
+// AdminWhois shows a file along with its owner and the owner's public keys.
+func (h *SessionHandler) AdminWhois(sesh *UserSession, args []string) {
+	if len(args) == 0 || args[0] == "" {
+		sesh.Error(ErrFileIDRequired, "Unable to look up file", "Provide a file, e.g.: %s whois <file id>", AdminCommand)
+		return
+	}
+
+	file, err := h.DB.Files.Find(sesh.Context(), args[0])
+	if err != nil {
+		sesh.Error(err, "Unable to look up file", "There was an error finding file: %q", args[0])
+		return
+	}
+	if file == nil {
+		sesh.Error(ErrFileNotFound, "Unable to look up file", "File not found: %q", args[0])
+		return
+	}
+
+	owner, err := h.DB.Users.Find(sesh.Context(), file.UserID)
+	if err != nil {
+		sesh.Error(err, "Unable to look up file", "There was an error finding the owner of file: %q", file.ID)
+		return
+	}
+	if owner == nil {
+		sesh.Error(ErrUserNotFound, "Unable to look up file", "The owner of file %q no longer exists.", file.ID)
+		return
+	}
+
+	keys, err := h.DB.PublicKeys.FindByUser(sesh.Context(), owner.ID)
+	if err != nil {
+		sesh.Error(err, "Unable to look up file", "There was an error listing the keys of user: %q", owner.ID)
+		return
+	}
+
+	fileCount, err := h.DB.Files.CountByUser(sesh.Context(), owner.ID)
+	if err != nil {
+		sesh.Error(err, "Unable to look up file", "There was an error counting the files of user: %q", owner.ID)
+		return
+	}
+
+	name := file.Name
+	if name == "" {
+		name = "-"
+	}
+	visibility := "public"
+	if file.Private {
+		visibility = "private"
+	}
+	banned := "no"
+	if owner.IsBanned() {
+		banned = owner.BannedAt.UTC().Format(time.RFC3339)
+	}
+
+	var table strings.Builder
+	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
+	fmt.Fprintf(tabs, "file:\t%s\n", file.ID)
+	fmt.Fprintf(tabs, "name:\t%s\n", name)
+	fmt.Fprintf(tabs, "type:\t%s\n", strings.ToLower(file.Type))
+	fmt.Fprintf(tabs, "size:\t%s\n", humanize.Bytes(file.Size))
+	fmt.Fprintf(tabs, "visibility:\t%s\n", visibility)
+	fmt.Fprintf(tabs, "created:\t%s\n", file.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tabs, "owner:\t%s\n", owner.ID)
+	fmt.Fprintf(tabs, "owner since:\t%s\n", owner.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tabs, "owner files:\t%d\n", fileCount)
+	fmt.Fprintf(tabs, "banned:\t%s\n", banned)
+	fmt.Fprintln(tabs)
+	fmt.Fprintln(tabs, "KEY\tFINGERPRINT\tTYPE\tCREATED")
+	for _, key := range keys {
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", key.ID, key.Fingerprint, key.Type, key.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if err := tabs.Flush(); err != nil {
+		sesh.Error(err, "Unable to look up file", "There was an error looking up file: %q", file.ID)
+		return
+	}
+
+	_, _ = fmt.Fprint(sesh, table.String())
+}
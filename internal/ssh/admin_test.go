@@ -0,0 +1,152 @@
+package ssh_test
+
+import (
+	"bytes"
+	"testing"
+
+	"charm.land/wish/v2/testsession"
+	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/ssh"
+	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/testdata"
+)
+
+// newAdminTestServer starts a server where privateKey belongs to an admin.
+func newAdminTestServer(t *testing.T) (string, *testutil.Database) {
+	t.Helper()
+
+	cfg := newTestConfig(t)
+	cfg.SSH.HostKey = string(testdata.PEMBytes["ed25519"])
+	cfg.Admin.Fingerprints = []string{fingerprint}
+
+	database := testutil.NewDatabase(t, dsn.SQLite, false)
+	service, err := ssh.New(cfg, database.DB, events.NewHub())
+	require.NoError(t, err)
+
+	return testsession.Listen(t, service.Server), database
+}
+
+// runCommand runs cmd on the server as key, returning what it printed to
+// stderr and stdout.
+func runCommand(t *testing.T, addr string, key []byte, cmd string) (string, error) {
+	t.Helper()
+
+	conn, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "testuser",
+		Auth:            []gossh.AuthMethod{testPrivateKeyAuth(key)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(), //nolint:gosec
+		Timeout:         testTimeout,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	session, err := conn.NewSession()
+	require.NoError(t, err)
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	err = session.Run(cmd)
+	return stderr.String() + stdout.String(), err
+}
+
+// newOtherUser creates a user, who isn't an admin, owning a file.
+func newOtherUser(t *testing.T, database *testutil.Database) (*snips.User, *snips.File) {
+	t.Helper()
+
+	signer, err := gossh.ParsePrivateKey(testdata.PEMBytes["rsa"])
+	require.NoError(t, err)
+
+	user, err := database.Users.CreateWithPublicKey(t.Context(), &snips.PublicKey{
+		Fingerprint: gossh.FingerprintSHA256(signer.PublicKey()),
+		Type:        signer.PublicKey().Type(),
+	})
+	require.NoError(t, err)
+
+	file := &snips.File{UserID: user.ID, Name: "spam.txt", Type: "plaintext"}
+	require.NoError(t, database.Files.Create(t.Context(), file, []byte("buy now"), 10))
+
+	return user, file
+}
+
+func TestAdmin(t *testing.T) {
+	addr, database := newAdminTestServer(t)
+	user, file := newOtherUser(t, database)
+
+	t.Run("whois", func(t *testing.T) {
+		out, err := runCommand(t, addr, privateKey, "admin whois "+file.ID)
+		require.NoError(t, err)
+
+		assert.Contains(t, out, "spam.txt")
+		assert.Contains(t, out, user.ID)
+		assert.Contains(t, out, "banned:       no")
+		assert.Contains(t, out, "ssh-rsa")
+	})
+
+	t.Run("rm", func(t *testing.T) {
+		other := &snips.File{UserID: user.ID, Type: "plaintext"}
+		require.NoError(t, database.Files.Create(t.Context(), other, []byte("more spam"), 10))
+
+		out, err := runCommand(t, addr, privateKey, "admin rm -f "+other.ID)
+		require.NoError(t, err)
+		assert.Contains(t, out, "File Removed")
+
+		found, err := database.Files.Find(t.Context(), other.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found)
+
+		_, err = runCommand(t, addr, privateKey, "admin rm -f "+other.ID)
+		assert.Error(t, err)
+	})
+
+	t.Run("ban", func(t *testing.T) {
+		_, err := runCommand(t, addr, testdata.PEMBytes["rsa"], "admin whois "+file.ID)
+		require.Error(t, err, "isn't an admin")
+
+		out, err := runCommand(t, addr, privateKey, "admin ban -f "+user.ID)
+		require.NoError(t, err)
+		assert.Contains(t, out, "User Banned")
+
+		banned, err := database.Users.Find(t.Context(), user.ID)
+		require.NoError(t, err)
+		assert.True(t, banned.IsBanned())
+
+		out, err = runCommand(t, addr, testdata.PEMBytes["rsa"], "search spam")
+		require.Error(t, err)
+		assert.Contains(t, out, "banned")
+
+		// the file is untouched
+		found, err := database.Files.Find(t.Context(), file.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, found)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, cmd := range []string{"admin", "admin nope", "admin rm", "admin ban", "admin whois", "admin whois missing", "admin ban missing"} {
+			_, err := runCommand(t, addr, privateKey, cmd)
+			assert.Error(t, err, cmd)
+		}
+	})
+}
+
+func TestAdmin_BanSelf(t *testing.T) {
+	addr, database := newAdminTestServer(t)
+
+	_, err := runCommand(t, addr, privateKey, "search anything")
+	require.NoError(t, err)
+
+	key, err := database.PublicKeys.FindByFingerprint(t.Context(), fingerprint)
+	require.NoError(t, err)
+	require.NotNil(t, key)
+
+	out, err := runCommand(t, addr, privateKey, "admin ban -f "+key.UserID)
+	require.Error(t, err)
+	assert.Contains(t, out, "You can't ban yourself.")
+}
@@ -11,6 +11,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/armon/go-metrics"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/files"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/styles"
@@ -59,6 +60,29 @@ func (h *SessionHandler) CreateAPIKey(sesh *UserSession, args []string) {
 		return
 	}
 
+	scopes, err := snips.NormalizeAPIKeyScopes(flags.Scopes)
+	if err != nil {
+		sesh.Error(err, "Unable to create api key", "%s", err.Error())
+		return
+	}
+
+	fileIDs, err := snips.NormalizeAPIKeyFileIDs(flags.FileIDs)
+	if err != nil {
+		sesh.Error(err, "Unable to create api key", "%s", err.Error())
+		return
+	}
+
+	if len(fileIDs) > 0 {
+		if _, err := files.FindOwned(sesh.Context(), h.DB, sesh.UserID(), fileIDs); err != nil {
+			if errors.Is(err, files.ErrNotOwned) {
+				sesh.Error(err, "Unable to create api key", "Unable to restrict the api key, %s", err.Error())
+				return
+			}
+			sesh.Error(err, "Unable to create api key", "There was an error creating the api key. Please try again.")
+			return
+		}
+	}
+
 	token, hash, err := snips.NewAPIKeyToken()
 	if err != nil {
 		sesh.Error(err, "Unable to create api key", "There was an error creating the api key. Please try again.")
@@ -69,6 +93,8 @@ func (h *SessionHandler) CreateAPIKey(sesh *UserSession, args []string) {
 		Name:      name,
 		TokenHash: hash,
 		UserID:    sesh.UserID(),
+		Scopes:    scopes,
+		FileIDs:   fileIDs,
 	}
 	if flags.TTL > 0 {
 		expires := time.Now().UTC().Add(flags.TTL)
@@ -98,6 +124,8 @@ func (h *SessionHandler) CreateAPIKey(sesh *UserSession, args []string) {
 	if key.ExpiresAt != nil {
 		created += "\nexpires: " + styles.C(styles.Colors.Yellow, key.ExpiresAt.Format(time.RFC3339))
 	}
+	created += "\nscopes: " + styles.C(styles.Colors.White, apiKeyScopes(key))
+	created += "\nfiles: " + styles.C(styles.Colors.White, apiKeyFiles(key))
 	noti.Messagef("%s", created)
 	noti.Render(sesh)
 
@@ -144,7 +172,7 @@ func (h *SessionHandler) ListAPIKeys(sesh *UserSession) {
 
 	var table strings.Builder
 	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
-	fmt.Fprintln(tabs, "KEY\tCREATED\tLAST USED\tEXPIRES")
+	fmt.Fprintln(tabs, "KEY\tSCOPES\tFILES\tCREATED\tLAST USED\tEXPIRES")
 	for _, key := range keys {
 		lastUsed := "never"
 		if key.LastUsedAt != nil {
@@ -157,7 +185,8 @@ func (h *SessionHandler) ListAPIKeys(sesh *UserSession) {
 		case key.ExpiresAt != nil:
 			expires = key.ExpiresAt.UTC().Format(time.RFC3339)
 		}
-		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", key.DisplayName(), key.CreatedAt.UTC().Format(time.RFC3339), lastUsed, expires)
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\t%s\t%s\n", key.DisplayName(), apiKeyScopes(key), apiKeyFiles(key),
+			key.CreatedAt.UTC().Format(time.RFC3339), lastUsed, expires)
 	}
 	if err := tabs.Flush(); err != nil {
 		sesh.Error(err, "Unable to list api keys", "There was an error listing your api keys. Please try again.")
@@ -214,3 +243,19 @@ func (h *SessionHandler) RemoveAPIKey(sesh *UserSession, args []string) {
 	noti.Messagef("Removed api key: %q", target.DisplayName())
 	noti.Render(sesh)
 }
+
+// apiKeyScopes lists the scopes a key grants, for display.
+func apiKeyScopes(key *snips.APIKey) string {
+	if len(key.Scopes) == 0 {
+		return "all"
+	}
+	return strings.Join(key.Scopes, ",")
+}
+
+// apiKeyFiles lists the files a key is restricted to, for display.
+func apiKeyFiles(key *snips.APIKey) string {
+	if !key.IsFileRestricted() {
+		return "all"
+	}
+	return strings.Join(key.FileIDs, ",")
+}
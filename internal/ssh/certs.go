@@ -0,0 +1,131 @@
+package ssh
+
+import (
+	"bytes"
+	"net"
+	"strings"
+
+	"github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// PrincipalIdentityPrefix marks the identity of a user authenticated by a
+// certificate, stored in place of a key fingerprint so every certificate
+// issued to a principal maps to the same user.
+const PrincipalIdentityPrefix = "principal:"
+
+// sourceAddressOption is the critical option restricting the addresses a
+// certificate may be used from, the only one snips enforces.
+const sourceAddressOption = "source-address"
+
+// UserCertificates validates OpenSSH user certificates signed by a set of
+// trusted certificate authorities. A nil *UserCertificates trusts no CAs.
+type UserCertificates struct {
+	checker *gossh.CertChecker
+}
+
+// NewUserCertificates returns a validator trusting the given CA keys, or nil
+// if there are none.
+func NewUserCertificates(authorities []ssh.PublicKey) *UserCertificates {
+	if len(authorities) == 0 {
+		return nil
+	}
+
+	return &UserCertificates{
+		checker: &gossh.CertChecker{
+			// any other critical option is one we can't enforce, so fails the check
+			SupportedCriticalOptions: []string{sourceAddressOption},
+			IsUserAuthority: func(auth gossh.PublicKey) bool {
+				for _, authority := range authorities {
+					if bytes.Equal(authority.Marshal(), auth.Marshal()) {
+						return true
+					}
+				}
+				return false
+			},
+		},
+	}
+}
+
+// Authenticate reports whether a key may be used to authenticate from remote.
+// Plain keys are always accepted. Certificates must be valid and signed by a
+// trusted CA when any are configured, and be used from an address their
+// source-address option allows; otherwise they're treated like any other key.
+func (uc *UserCertificates) Authenticate(key ssh.PublicKey, remote net.Addr) bool {
+	cert, ok := key.(*gossh.Certificate)
+	if !ok || uc == nil {
+		return true
+	}
+
+	if _, ok := uc.Principal(key); !ok {
+		return false
+	}
+
+	if sources, ok := cert.CriticalOptions[sourceAddressOption]; ok {
+		return allowedSource(remote, sources)
+	}
+
+	return true
+}
+
+// allowedSource reports whether remote is within sources, a comma separated
+// list of addresses and CIDRs as in a certificate's source-address option.
+// A malformed list allows nothing.
+func allowedSource(remote net.Addr, sources string) bool {
+	tcpAddr, ok := remote.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	allowed := false
+	for _, source := range strings.Split(sources, ",") {
+		source = strings.TrimSpace(source)
+		if ip := net.ParseIP(source); ip != nil {
+			allowed = allowed || ip.Equal(tcpAddr.IP)
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(source)
+		if err != nil {
+			return false
+		}
+		allowed = allowed || network.Contains(tcpAddr.IP)
+	}
+
+	return allowed
+}
+
+// Principal returns the principal a certificate was issued to, if the key is
+// a valid user certificate signed by a trusted CA. Certificates naming more
+// than one principal identify as the first.
+func (uc *UserCertificates) Principal(key ssh.PublicKey) (string, bool) {
+	cert, ok := key.(*gossh.Certificate)
+	if !ok || uc == nil {
+		return "", false
+	}
+
+	if cert.CertType != gossh.UserCert || len(cert.ValidPrincipals) == 0 {
+		return "", false
+	}
+
+	if !uc.checker.IsUserAuthority(cert.SignatureKey) {
+		return "", false
+	}
+
+	principal := cert.ValidPrincipals[0]
+	if err := uc.checker.CheckCert(principal, cert); err != nil {
+		return "", false
+	}
+
+	return principal, true
+}
+
+// Identity returns the fingerprint a key is stored under: the principal for
+// a trusted certificate, and the key's SHA256 fingerprint otherwise.
+func (uc *UserCertificates) Identity(key ssh.PublicKey) string {
+	if principal, ok := uc.Principal(key); ok {
+		return PrincipalIdentityPrefix + principal
+	}
+
+	return gossh.FingerprintSHA256(key)
+}
@@ -1,8 +1,14 @@
 package ssh
 
+import "time"
+
 const (
 	UploadBufferSize = 1 * 1024 // 1KB
 
+	// AppendFlushInterval is how often content streamed to f:<id>:append is
+	// written to the file, each write recording a revision.
+	AppendFlushInterval = 2 * time.Second
+
 	LoggerContextKey      = "logger"
 	RequestIDContextKey   = "request_id"
 	FingerprintContextKey = "fingerprint"
@@ -10,6 +16,14 @@ const (
 
 	FileRequestPrefix      = "f:"
 	NamedFileRequestPrefix = "n:"
+	RevisionSeparator      = "@"
+
+	AdminCommand   = "admin"
+	APIKeyCommand  = "api-key"
+	KeysCommand    = "keys"
+	SearchCommand  = "search"
+	WebhookCommand = "webhook"
 
-	APIKeyCommand = "api-key"
+	GitUploadPackCommand  = "git-upload-pack"
+	GitReceivePackCommand = "git-receive-pack"
 )
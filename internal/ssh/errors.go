@@ -3,14 +3,30 @@ package ssh
 import "errors"
 
 var (
-	ErrFileNotFound      = errors.New("file not found")
-	ErrFileTooLarge      = errors.New("file too large")
-	ErrNilProgram        = errors.New("nil program")
-	ErrPrivateFileAccess = errors.New("private file access")
-	ErrUnknownCommand    = errors.New("unknown command")
-	ErrSignPublicFile    = errors.New("unable to sign public file")
-	ErrEmptyContent      = errors.New("empty content")
-	ErrNameRequired      = errors.New("name required")
-	ErrAPIKeyNotFound    = errors.New("api key not found")
-	ErrAPIKeyIDRequired  = errors.New("api key id required")
+	ErrFileNotFound         = errors.New("file not found")
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrNilProgram           = errors.New("nil program")
+	ErrPrivateFileAccess    = errors.New("private file access")
+	ErrUnknownCommand       = errors.New("unknown command")
+	ErrSignPublicFile       = errors.New("unable to sign public file")
+	ErrEmptyContent         = errors.New("empty content")
+	ErrNameRequired         = errors.New("name required")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrAPIKeyIDRequired     = errors.New("api key id required")
+	ErrSearchTermsRequired  = errors.New("search terms required")
+	ErrRevisionRequired     = errors.New("revision required")
+	ErrRevisionReadOnly     = errors.New("revision is read-only")
+	ErrLinkCodeRequired     = errors.New("link code required")
+	ErrPublicKeyIDRequired  = errors.New("public key id required")
+	ErrPublicKeyNotFound    = errors.New("public key not found")
+	ErrCurrentPublicKey     = errors.New("public key is in use")
+	ErrDirectoryUnsupported = errors.New("directories are not supported")
+	ErrGitUnsupported       = errors.New("file can't be served as a git repository")
+	ErrWebhookIDRequired    = errors.New("webhook id required")
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrNotAdmin             = errors.New("not an admin")
+	ErrFileIDRequired       = errors.New("file id required")
+	ErrUserIDRequired       = errors.New("user id required")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrBanSelf              = errors.New("admins can't ban themselves")
 )
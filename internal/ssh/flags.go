@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/robherley/snips.sh/internal/timeutil"
@@ -13,6 +14,7 @@ import (
 var (
 	ErrFlagRequired = errors.New("flag required")
 	ErrFlagParse    = errors.New("parse error")
+	ErrFlagConflict = errors.New("conflicting flags")
 )
 
 type UploadFlags struct {
@@ -22,6 +24,11 @@ type UploadFlags struct {
 	Extension string
 	TTL       time.Duration
 	Name      string
+	Expires   time.Duration
+	Burn      bool
+	Tar       bool
+	Tags      []string
+	Desc      string
 }
 
 func (uf *UploadFlags) Parse(out io.Writer, args []string) error {
@@ -32,6 +39,11 @@ func (uf *UploadFlags) Parse(out io.Writer, args []string) error {
 	uf.StringVar(&uf.Extension, "ext", "", "set the file extension (optional)")
 	addDurationFlag(uf.FlagSet, &uf.TTL, "ttl", 0, "lifetime of the signed url (optional)")
 	uf.StringVar(&uf.Name, "name", "", "human-readable name for the file, must be unique per user (optional)")
+	addDurationFlag(uf.FlagSet, &uf.Expires, "expires", 0, "delete the file after this duration (optional)")
+	uf.BoolVar(&uf.Burn, "burn", false, "delete the file after it is first viewed (optional)")
+	uf.BoolVar(&uf.Tar, "tar", false, "upload a tar stream as a bundle of several files (optional)")
+	uf.Var((*listFlagValue)(&uf.Tags), "tag", "tag the file, repeatable or comma separated (optional)")
+	uf.StringVar(&uf.Desc, "desc", "", "short description of the file (optional)")
 
 	if err := uf.FlagSet.Parse(args); err != nil {
 		return err
@@ -41,14 +53,25 @@ func (uf *UploadFlags) Parse(out io.Writer, args []string) error {
 		return fmt.Errorf("%w: -private", ErrFlagRequired)
 	}
 
+	if uf.Expires < 0 {
+		return fmt.Errorf("%w: -expires", ErrFlagParse)
+	}
+
+	// bundle members are typed by their own file names
+	if uf.Tar && uf.Extension != "" {
+		return fmt.Errorf("%w: -tar and -ext", ErrFlagConflict)
+	}
+
 	return nil
 }
 
 type APIKeyCreateFlags struct {
 	*flag.FlagSet
 
-	Name string
-	TTL  time.Duration
+	Name    string
+	TTL     time.Duration
+	Scopes  []string
+	FileIDs []string
 }
 
 func (af *APIKeyCreateFlags) Parse(out io.Writer, args []string) error {
@@ -57,6 +80,8 @@ func (af *APIKeyCreateFlags) Parse(out io.Writer, args []string) error {
 
 	af.StringVar(&af.Name, "name", "", "human-readable name for the api key")
 	addDurationFlag(af.FlagSet, &af.TTL, "ttl", 0, "lifetime of the api key (optional, never expires when omitted)")
+	af.Var((*listFlagValue)(&af.Scopes), "scope", "scope to grant, repeatable or comma separated (optional, every scope when omitted)")
+	af.Var((*listFlagValue)(&af.FileIDs), "file", "id of a file to restrict the key to, repeatable or comma separated (optional, every file when omitted)")
 
 	if err := af.FlagSet.Parse(args); err != nil {
 		return err
@@ -72,6 +97,31 @@ func (af *APIKeyCreateFlags) Parse(out io.Writer, args []string) error {
 	return nil
 }
 
+type WebhookCreateFlags struct {
+	*flag.FlagSet
+
+	URL    string
+	Events []string
+}
+
+func (wf *WebhookCreateFlags) Parse(out io.Writer, args []string) error {
+	wf.FlagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	wf.SetOutput(out)
+
+	wf.StringVar(&wf.URL, "url", "", "http or https url to send events to")
+	wf.Var((*listFlagValue)(&wf.Events), "event", "event to send, repeatable or comma separated (optional, all events when omitted)")
+
+	if err := wf.FlagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if wf.URL == "" {
+		return fmt.Errorf("%w: -url", ErrFlagRequired)
+	}
+
+	return nil
+}
+
 type RenameFlags struct {
 	*flag.FlagSet
 
@@ -125,10 +175,26 @@ func (df *DeleteFlags) Parse(out io.Writer, args []string) error {
 	return df.FlagSet.Parse(args)
 }
 
+type BanFlags struct {
+	*flag.FlagSet
+
+	Force bool
+}
+
+func (bf *BanFlags) Parse(out io.Writer, args []string) error {
+	bf.FlagSet = flag.NewFlagSet("", flag.ContinueOnError)
+	bf.SetOutput(out)
+
+	bf.BoolVar(&bf.Force, "f", false, "force ban without confirmation")
+
+	return bf.FlagSet.Parse(args)
+}
+
 type UpdateFileContentFlags struct {
 	*flag.FlagSet
 
-	Extension string
+	Extension  string
+	IfRevision int64 // -1 = unconditional
 }
 
 func (uf *UpdateFileContentFlags) Parse(out io.Writer, args []string) error {
@@ -136,8 +202,31 @@ func (uf *UpdateFileContentFlags) Parse(out io.Writer, args []string) error {
 	uf.SetOutput(out)
 
 	uf.StringVar(&uf.Extension, "ext", "", "hint the file extension (optional)")
+	uf.Int64Var(&uf.IfRevision, "if-rev", -1, "only update if the latest revision is still this one, 0 for none (optional)")
 
-	return uf.FlagSet.Parse(args)
+	if err := uf.FlagSet.Parse(args); err != nil {
+		return err
+	}
+
+	if uf.IfRevision < -1 {
+		return fmt.Errorf("%w: -if-rev", ErrFlagParse)
+	}
+
+	return nil
+}
+
+// listFlagValue collects every value of a repeatable flag.
+type listFlagValue []string
+
+// Set implements the flag.Value interface.
+func (l *listFlagValue) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// String implements the flag.Value interface.
+func (l *listFlagValue) String() string {
+	return strings.Join(*l, ",")
 }
 
 // durationFlagValue is a wrapper around time.Duration that implements the flag.Value interface using a custom parser.
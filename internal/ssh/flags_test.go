@@ -78,6 +78,58 @@ func TestUploadFlags(t *testing.T) {
 				Name: "deploy-notes",
 			},
 		},
+		{
+			name: "expires",
+			args: []string{"-expires", "7d"},
+			want: ssh.UploadFlags{
+				Expires: 7 * 24 * time.Hour,
+			},
+		},
+		{
+			name: "expires with private and ttl",
+			args: []string{"-private", "-ttl", "1h", "-expires", "1w"},
+			want: ssh.UploadFlags{
+				Private: true,
+				TTL:     time.Hour,
+				Expires: 7 * 24 * time.Hour,
+			},
+		},
+		{
+			name: "burn",
+			args: []string{"-burn", "-private"},
+			want: ssh.UploadFlags{
+				Private: true,
+				Burn:    true,
+			},
+		},
+		{
+			name: "tar",
+			args: []string{"-tar", "-name", "dotfiles"},
+			want: ssh.UploadFlags{
+				Name: "dotfiles",
+				Tar:  true,
+			},
+		},
+		{
+			name: "tar with ext",
+			args: []string{"-tar", "-ext", "go"},
+			want: ssh.UploadFlags{},
+			err:  ssh.ErrFlagConflict,
+		},
+		{
+			name: "tags and description",
+			args: []string{"-tag", "go", "-tag", "infra,deploy", "-desc", "deploy script"},
+			want: ssh.UploadFlags{
+				Tags: []string{"go", "infra,deploy"},
+				Desc: "deploy script",
+			},
+		},
+		{
+			name: "negative expires",
+			args: []string{"-expires", "-1h"},
+			want: ssh.UploadFlags{},
+			err:  ssh.ErrFlagParse,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -91,6 +143,11 @@ func TestUploadFlags(t *testing.T) {
 				assert.Equal(t, tc.want.Extension, got.Extension)
 				assert.Equal(t, tc.want.Private, got.Private)
 				assert.Equal(t, tc.want.Name, got.Name)
+				assert.Equal(t, tc.want.Expires, got.Expires)
+				assert.Equal(t, tc.want.Burn, got.Burn)
+				assert.Equal(t, tc.want.Tar, got.Tar)
+				assert.Equal(t, tc.want.Tags, got.Tags)
+				assert.Equal(t, tc.want.Desc, got.Desc)
 			}
 		})
 	}
@@ -103,6 +160,28 @@ func TestAPIKeyCreateFlagsRejectsNegativeTTL(t *testing.T) {
 	assert.ErrorIs(t, err, ssh.ErrFlagParse)
 }
 
+func TestAPIKeyCreateFlagsScopes(t *testing.T) {
+	var got ssh.APIKeyCreateFlags
+	err := got.Parse(io.Discard, []string{"-name", "ci", "-scope", "files:read,files:write", "-file", "abc123", "-file", "def456"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", got.Name)
+	assert.Equal(t, []string{"files:read,files:write"}, got.Scopes)
+	assert.Equal(t, []string{"abc123", "def456"}, got.FileIDs)
+}
+
+func TestWebhookCreateFlags(t *testing.T) {
+	var got ssh.WebhookCreateFlags
+	err := got.Parse(io.Discard, []string{"-url", "https://example.com/hook", "-event", "create,update", "-event", "delete"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/hook", got.URL)
+	assert.Equal(t, []string{"create,update", "delete"}, got.Events)
+
+	err = (&ssh.WebhookCreateFlags{}).Parse(io.Discard, []string{"-event", "update"})
+	assert.ErrorIs(t, err, ssh.ErrFlagRequired)
+}
+
 func TestRenameFlags(t *testing.T) {
 	testcases := []struct {
 		name string
@@ -135,6 +214,51 @@ func TestRenameFlags(t *testing.T) {
 	}
 }
 
+func TestUpdateFileContentFlags(t *testing.T) {
+	testcases := []struct {
+		name string
+		args []string
+		want ssh.UpdateFileContentFlags
+		err  error
+	}{
+		{
+			name: "no flags",
+			args: []string{},
+			want: ssh.UpdateFileContentFlags{IfRevision: -1},
+		},
+		{
+			name: "if revision",
+			args: []string{"-ext", "go", "-if-rev", "3"},
+			want: ssh.UpdateFileContentFlags{Extension: "go", IfRevision: 3},
+		},
+		{
+			name: "if no revisions",
+			args: []string{"-if-rev", "0"},
+			want: ssh.UpdateFileContentFlags{IfRevision: 0},
+		},
+		{
+			name: "negative revision",
+			args: []string{"-if-rev", "-2"},
+			err:  ssh.ErrFlagParse,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got ssh.UpdateFileContentFlags
+			err := got.Parse(io.Discard, tc.args)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want.Extension, got.Extension)
+				assert.Equal(t, tc.want.IfRevision, got.IfRevision)
+			}
+		})
+	}
+}
+
 func TestSignFlags(t *testing.T) {
 	testcases := []struct {
 		name string
@@ -0,0 +1,595 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"slices"
+	"strings"
+
+	"charm.land/wish/v2"
+	"github.com/armon/go-metrics"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/format/pktline"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// GitBranch is the only branch of a file's repository.
+const GitBranch = plumbing.ReferenceName("refs/heads/main")
+
+const (
+	// gitPackOverhead is what a pushed packfile may hold on top of the file's
+	// content, for its header, commits and trees.
+	gitPackOverhead = 16 * 1024
+	// gitPackInflation is how many times its size limit a pushed packfile's
+	// objects may add up to once inflated, as a few commits of the same file
+	// compress well against each other.
+	gitPackInflation = 8
+)
+
+// errPackObjectTooLarge stops an object being inflated past its limit.
+var errPackObjectTooLarge = errors.New("object too large")
+
+// Git serves a file's revision history as a git repository, as in `git clone
+// ssh://host/f/<id>.git`, with a commit per revision on GitBranch. The file's
+// owner can push to it, each pushed commit becoming a revision of the file.
+// Commits are regenerated from the revisions, so pushed ones come back with
+// new hashes.
+func (h *SessionHandler) Git(sesh *UserSession) {
+	log := logger.From(sesh.Context())
+	args := sesh.Command()
+
+	if len(args) != 2 {
+		gitFatal(sesh, ErrUnknownCommand, "usage: %s <repository>", args[0])
+		return
+	}
+
+	file, err := h.gitFile(sesh, args[1])
+	if err != nil {
+		gitFatal(sesh, err, "%s: %s", args[1], err)
+		return
+	}
+
+	push := args[0] == GitReceivePackCommand
+	if push && file.UserID != sesh.UserID() {
+		gitFatal(sesh, ErrPrivateFileAccess, "%s: only the file's owner can push", args[1])
+		return
+	}
+
+	history, err := files.History(sesh.Context(), h.DB, file)
+	if err != nil {
+		gitFatal(sesh, err, "%s: unable to read the file's history", args[1])
+		return
+	}
+
+	repo, err := newGitRepository(history, entryName(file), object.Signature{
+		Name:  "snips",
+		Email: "snips@" + h.Config.SSH.External.Hostname(),
+	})
+	if err != nil {
+		gitFatal(sesh, err, "%s: unable to build the repository", args[1])
+		return
+	}
+
+	metrics.IncrCounter([]string{"ssh", "git", args[0]}, 1)
+	log.Info("git session started", "command", args[0], "file_id", file.ID)
+
+	if push {
+		err = h.receivePack(sesh, file, repo)
+	} else {
+		err = repo.uploadPack(sesh)
+	}
+	if err != nil {
+		log.Warn("git session ended with error", "command", args[0], "err", err)
+		wish.Fatalln(sesh, "fatal:", err)
+	}
+}
+
+// gitFile resolves a repository path, f/<id>.git, to a file the user can
+// read.
+func (h *SessionHandler) gitFile(sesh *UserSession, repository string) (*snips.File, error) {
+	id, ok := strings.CutPrefix(path.Clean("/"+repository), "/f/")
+	id = strings.TrimSuffix(id, ".git")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return nil, ErrFileNotFound
+	}
+
+	file, err := h.DB.Files.Find(sesh.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if file == nil || file.IsExpired() || (file.Private && file.UserID != sesh.UserID()) {
+		return nil, ErrFileNotFound
+	}
+
+	// cloning would burn the file, and bundles hold many files but no history
+	if file.BurnAfterRead || file.IsBundle() {
+		return nil, ErrGitUnsupported
+	}
+
+	return file, nil
+}
+
+// gitFatal reports an error before the protocol has started, as an error
+// line git shows the user, and ends the session.
+func gitFatal(sesh *UserSession, err error, format string, v ...any) {
+	logger.From(sesh.Context()).Warn("unable to serve git repository", "err", err)
+
+	line := &pktline.ErrorLine{Text: fmt.Sprintf(format, v...)}
+	_ = line.Encode(sesh)
+	_ = sesh.Exit(1)
+}
+
+// gitRepository is a file's history as an in-memory git repository.
+type gitRepository struct {
+	storage *memory.Storage
+	head    plumbing.Hash
+	path    string // the file's path in each commit's tree
+}
+
+// newGitRepository commits each version of a file in turn, dated when it was
+// made, so the same history always yields the same commits.
+func newGitRepository(history []files.Version, filepath string, signature object.Signature) (*gitRepository, error) {
+	repo := &gitRepository{storage: memory.NewStorage(), path: filepath}
+
+	for i, version := range history {
+		blob, err := repo.blob(version.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		tree, err := repo.encode(&object.Tree{
+			Entries: []object.TreeEntry{{Name: filepath, Mode: filemode.Regular, Hash: blob}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		message := "Update " + filepath + "\n"
+		if i == 0 {
+			message = "Create " + filepath + "\n"
+		}
+		if version.Sequence >= 0 {
+			message += fmt.Sprintf("\nRevision: %d\n", version.Sequence)
+		}
+
+		signature.When = version.CreatedAt.UTC()
+		commit := &object.Commit{
+			Author:    signature,
+			Committer: signature,
+			Message:   message,
+			TreeHash:  tree,
+		}
+		if i > 0 {
+			commit.ParentHashes = []plumbing.Hash{repo.head}
+		}
+
+		repo.head, err = repo.encode(commit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
+
+func (r *gitRepository) encode(obj interface {
+	Encode(plumbing.EncodedObject) error
+}) (plumbing.Hash, error) {
+	encoded := r.storage.NewEncodedObject()
+	if err := obj.Encode(encoded); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return r.storage.SetEncodedObject(encoded)
+}
+
+func (r *gitRepository) blob(content []byte) (plumbing.Hash, error) {
+	encoded := r.storage.NewEncodedObject()
+	encoded.SetType(plumbing.BlobObject)
+
+	w, err := encoded.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return r.storage.SetEncodedObject(encoded)
+}
+
+// advertise sends the repository's only branch, which HEAD points to.
+func (r *gitRepository) advertise(w io.Writer, caps ...capability.Capability) error {
+	refs := packp.NewAdvRefs()
+	for _, c := range caps {
+		if err := refs.Capabilities.Add(c); err != nil {
+			return err
+		}
+	}
+	if err := refs.Capabilities.Set(capability.Agent, capability.DefaultAgent()); err != nil {
+		return err
+	}
+	if err := refs.AddReference(plumbing.NewSymbolicReference(plumbing.HEAD, GitBranch)); err != nil {
+		return err
+	}
+
+	refs.References[GitBranch.String()] = r.head
+	refs.Head = &r.head
+	return refs.Encode(w)
+}
+
+// uploadPack serves a clone or fetch. Without multi_ack, the first common
+// commit the client has is acknowledged and the pack is sent once it's done.
+func (r *gitRepository) uploadPack(rw io.ReadWriter) error {
+	if err := r.advertise(rw, capability.OFSDelta); err != nil {
+		return err
+	}
+
+	scanner := pktline.NewScanner(rw)
+	encoder := pktline.NewEncoder(rw)
+
+	wants := []plumbing.Hash{}
+	for scanner.Scan() {
+		line := string(bytes.TrimSuffix(scanner.Bytes(), []byte("\n")))
+		if line == "" {
+			break
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "want" {
+			return fmt.Errorf("unexpected %q", line)
+		}
+
+		want := plumbing.NewHash(fields[1])
+		if _, err := object.GetCommit(r.storage, want); err != nil {
+			return fmt.Errorf("not our ref %s", fields[1])
+		}
+		wants = append(wants, want)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// the client is up to date, or only listing refs
+	if len(wants) == 0 {
+		return nil
+	}
+
+	common := []plumbing.Hash{}
+	for scanner.Scan() {
+		line := string(bytes.TrimSuffix(scanner.Bytes(), []byte("\n")))
+
+		switch {
+		case line == "":
+			if len(common) == 0 {
+				if err := encoder.EncodeString("NAK\n"); err != nil {
+					return err
+				}
+			}
+		case line == "done":
+			if len(common) == 0 {
+				if err := encoder.EncodeString("NAK\n"); err != nil {
+					return err
+				}
+			}
+			return r.sendPack(rw, wants, common)
+		case strings.HasPrefix(line, "have "):
+			have := plumbing.NewHash(strings.TrimPrefix(line, "have "))
+			if _, err := object.GetCommit(r.storage, have); err != nil {
+				continue
+			}
+			if len(common) == 0 {
+				if err := encoder.Encodef("ACK %s\n", have); err != nil {
+					return err
+				}
+			}
+			common = append(common, have)
+		default:
+			return fmt.Errorf("unexpected %q", line)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (r *gitRepository) sendPack(w io.Writer, wants, common []plumbing.Hash) error {
+	have, err := revlist.Objects(r.storage, common, nil)
+	if err != nil {
+		return err
+	}
+
+	objects, err := revlist.Objects(r.storage, wants, have)
+	if err != nil {
+		return err
+	}
+
+	_, err = packfile.NewEncoder(w, r.storage, false).Encode(objects, 10)
+	return err
+}
+
+// receivePack takes a push to GitBranch, saving each new commit's content as
+// a revision of the file.
+func (h *SessionHandler) receivePack(sesh *UserSession, file *snips.File, repo *gitRepository) error {
+	if err := repo.advertise(sesh, capability.ReportStatus, capability.OFSDelta); err != nil {
+		return err
+	}
+
+	req := packp.NewReferenceUpdateRequest()
+	if err := req.Decode(sesh); err != nil {
+		// the client had nothing to push
+		if errors.Is(err, packp.ErrEmpty) {
+			return nil
+		}
+		return err
+	}
+
+	status := packp.NewReportStatus()
+	status.UnpackStatus = "ok"
+
+	var unpackErr error
+	if req.Packfile != nil {
+		if unpackErr = h.unpack(repo, req.Packfile); unpackErr != nil {
+			status.UnpackStatus = unpackErr.Error()
+		}
+	}
+
+	for _, cmd := range req.Commands {
+		result := "ok"
+		if unpackErr != nil {
+			result = "unpacker error"
+		} else if err := h.applyGitPush(sesh.Context(), file, repo, cmd); err != nil {
+			logger.From(sesh.Context()).Warn("git push rejected", "file_id", file.ID, "err", err)
+			result = err.Error()
+		}
+		status.CommandStatuses = append(status.CommandStatuses, &packp.CommandStatus{
+			ReferenceName: cmd.Name,
+			Status:        result,
+		})
+	}
+
+	if !req.Capabilities.Supports(capability.ReportStatus) {
+		return nil
+	}
+	return status.Encode(sesh)
+}
+
+// unpack stores a pushed packfile's objects in the repository. The packfile
+// is read in full first, failing without storing anything once it's larger
+// than a single file may be, as only GitBranch can be pushed.
+func (h *SessionHandler) unpack(repo *gitRepository, pack io.Reader) error {
+	limit := h.Config.Limits.FileSize + gitPackOverhead
+
+	data, err := io.ReadAll(io.LimitReader(pack, int64(limit)+1))
+	if err != nil {
+		return err
+	}
+	if uint64(len(data)) > limit {
+		return fmt.Errorf("pack %w: max size is %d bytes, push fewer commits at once", ErrFileTooLarge, limit)
+	}
+
+	if err := checkPack(data, h.Config.Limits.FileSize, limit*gitPackInflation); err != nil {
+		return err
+	}
+
+	return packfile.UpdateObjectStorage(repo.storage, bytes.NewReader(data))
+}
+
+// checkPack inflates every object of a packfile, without storing them, so a
+// small pack can't unpack into more than storage should hold: each object,
+// or the object a delta rebuilds, must fit in maxObject bytes, and together
+// in maxTotal.
+func checkPack(data []byte, maxObject, maxTotal uint64) error {
+	scanner := packfile.NewScanner(bytes.NewReader(data))
+	_, objects, err := scanner.Header()
+	if err != nil {
+		return err
+	}
+
+	var total uint64
+	for range objects {
+		header, err := scanner.NextObjectHeader()
+		if err != nil {
+			return err
+		}
+
+		size := uint64(header.Length)
+		if size > maxObject {
+			return fmt.Errorf("pack object %w: max size is %d bytes", ErrFileTooLarge, maxObject)
+		}
+
+		// the declared size isn't trusted, inflating stops once it's passed
+		object := &cappedWriter{remaining: maxObject}
+		if _, _, err := scanner.NextObject(object); err != nil {
+			if errors.Is(err, errPackObjectTooLarge) {
+				return fmt.Errorf("pack object %w: max size is %d bytes", ErrFileTooLarge, maxObject)
+			}
+			return err
+		}
+
+		if header.Type == plumbing.OFSDeltaObject || header.Type == plumbing.REFDeltaObject {
+			// a delta starts with the sizes of its base and of the object it
+			// rebuilds, which is what it takes up once applied
+			_, rest := readDeltaSize(object.head)
+			size, _ = readDeltaSize(rest)
+			if size > maxObject {
+				return fmt.Errorf("pack object %w: max size is %d bytes", ErrFileTooLarge, maxObject)
+			}
+		}
+
+		total += size
+		if total > maxTotal {
+			return fmt.Errorf("pack %w: its objects take up more than %d bytes, push fewer commits at once", ErrFileTooLarge, maxTotal)
+		}
+	}
+
+	return nil
+}
+
+// cappedWriter discards what's written to it, keeping only its first bytes,
+// and fails once more than remaining bytes are written.
+type cappedWriter struct {
+	remaining uint64
+	head      []byte
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if uint64(len(p)) > w.remaining {
+		return 0, errPackObjectTooLarge
+	}
+	w.remaining -= uint64(len(p))
+
+	if keep := 32 - len(w.head); keep > 0 {
+		w.head = append(w.head, p[:min(keep, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// readDeltaSize decodes a size from the start of a delta, as a little-endian
+// base 128 varint, returning it and what follows.
+func readDeltaSize(b []byte) (uint64, []byte) {
+	var size uint64
+	for i, c := range b {
+		if i >= 10 {
+			break
+		}
+		size |= uint64(c&0x7f) << (7 * i)
+		if c&0x80 == 0 {
+			return size, b[i+1:]
+		}
+	}
+	// truncated or overlong, so it can't be applied either
+	return math.MaxUint64, nil
+}
+
+// applyGitPush saves the commits a push adds on top of the current history,
+// oldest first. Commits that don't change the content are skipped.
+func (h *SessionHandler) applyGitPush(ctx context.Context, file *snips.File, repo *gitRepository, cmd *packp.Command) error {
+	if cmd.Name != GitBranch || cmd.Action() != packp.Update {
+		return fmt.Errorf("only %s can be updated", GitBranch.Short())
+	}
+	if cmd.Old != repo.head {
+		return errors.New("fetch first, the file has changed")
+	}
+
+	commits, err := repo.commitsSince(cmd.New, cmd.Old)
+	if err != nil {
+		return err
+	}
+
+	previous, err := repo.content(cmd.Old)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range commits {
+		content, err := repo.content(hash)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(content, previous) {
+			continue
+		}
+
+		if len(content) == 0 {
+			return fmt.Errorf("%s: %w", hash.String()[:7], ErrEmptyContent)
+		}
+		if uint64(len(content)) > h.Config.Limits.FileSize {
+			return fmt.Errorf("%s: %w: max size is %d bytes", hash.String()[:7], ErrFileTooLarge, h.Config.Limits.FileSize)
+		}
+
+		if err := files.UpdateContent(ctx, h.DB, h.Config, file, content, extensionOf(repo.path), db.IfUpdatedAt(file.UpdatedAt)); err != nil {
+			if errors.Is(err, db.ErrPreconditionFailed) {
+				return errors.New("fetch first, the file has changed")
+			}
+			return err
+		}
+
+		h.Events.Publish(events.NewEvent(events.KindUpdate, file))
+		metrics.IncrCounterWithLabels([]string{"file", "update"}, 1, []metrics.Label{
+			{Name: "type", Value: file.Type},
+		})
+		logger.From(ctx).Info("file content updated",
+			"file_id", file.ID,
+			"user_id", file.UserID,
+			"size", file.Size,
+			"file_type", file.Type,
+			"commit", hash.String(),
+			"via", "git",
+		)
+
+		previous = content
+	}
+
+	return nil
+}
+
+// commitsSince walks first parents back from a commit to an ancestor,
+// returning the commits after it, oldest first.
+func (r *gitRepository) commitsSince(from, ancestor plumbing.Hash) ([]plumbing.Hash, error) {
+	commits := []plumbing.Hash{}
+	for hash := from; hash != ancestor; {
+		commit, err := object.GetCommit(r.storage, hash)
+		if err != nil {
+			return nil, err
+		}
+		if len(commit.ParentHashes) == 0 {
+			return nil, errors.New("non-fast-forward")
+		}
+
+		commits = append(commits, hash)
+		hash = commit.ParentHashes[0]
+	}
+
+	slices.Reverse(commits)
+	return commits, nil
+}
+
+// content returns the file's content in a commit, whose tree must hold just
+// the file.
+func (r *gitRepository) content(hash plumbing.Hash) ([]byte, error) {
+	commit, err := object.GetCommit(r.storage, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tree.Entries) != 1 || tree.Entries[0].Name != r.path || !tree.Entries[0].Mode.IsFile() {
+		return nil, fmt.Errorf("%s: the repository can only hold %s", hash.String()[:7], r.path)
+	}
+
+	blob, err := object.GetBlob(r.storage, tree.Entries[0].Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" //nolint:gosec
+	"encoding/binary"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPackObject struct {
+	typ      plumbing.ObjectType
+	declared int
+	content  []byte
+}
+
+// testPack encodes objects as a packfile, each declaring its own size,
+// whatever its content inflates to.
+func testPack(t *testing.T, objects ...testPackObject) []byte {
+	t.Helper()
+
+	pack := &bytes.Buffer{}
+	pack.WriteString("PACK")
+	_ = binary.Write(pack, binary.BigEndian, uint32(2))
+	_ = binary.Write(pack, binary.BigEndian, uint32(len(objects)))
+
+	for _, object := range objects {
+		size := object.declared
+		b := byte(object.typ)<<4 | byte(size&0x0f)
+		for size >>= 4; size > 0; size >>= 7 {
+			pack.WriteByte(b | 0x80)
+			b = byte(size & 0x7f)
+		}
+		pack.WriteByte(b)
+
+		if object.typ == plumbing.REFDeltaObject {
+			pack.Write(plumbing.ZeroHash[:])
+		}
+
+		zw := zlib.NewWriter(pack)
+		_, err := zw.Write(object.content)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+	}
+
+	sum := sha1.Sum(pack.Bytes()) //nolint:gosec
+	pack.Write(sum[:])
+	return pack.Bytes()
+}
+
+func TestCheckPack(t *testing.T) {
+	const maxObject, maxTotal = 1024, 4096
+
+	blob := func(content []byte) testPackObject {
+		return testPackObject{typ: plumbing.BlobObject, declared: len(content), content: content}
+	}
+
+	t.Run("fits", func(t *testing.T) {
+		pack := testPack(t, blob([]byte("package main\n")), blob(bytes.Repeat([]byte("a"), maxObject)))
+		assert.NoError(t, checkPack(pack, maxObject, maxTotal))
+	})
+
+	t.Run("declared too large", func(t *testing.T) {
+		pack := testPack(t, testPackObject{typ: plumbing.BlobObject, declared: maxObject + 1, content: []byte("a")})
+		assert.ErrorIs(t, checkPack(pack, maxObject, maxTotal), ErrFileTooLarge)
+	})
+
+	t.Run("inflates past its declared size", func(t *testing.T) {
+		// a few kilobytes that inflate to 16MB
+		pack := testPack(t, testPackObject{typ: plumbing.BlobObject, declared: 10, content: make([]byte, 16<<20)})
+		require.Less(t, len(pack), 64*1024)
+		assert.ErrorIs(t, checkPack(pack, maxObject, maxTotal), ErrFileTooLarge)
+	})
+
+	t.Run("delta rebuilds too large an object", func(t *testing.T) {
+		// base size 1, target size 1GB, then a single insert
+		delta := []byte{0x01, 0x80, 0x80, 0x80, 0x80, 0x04, 0x01, 'a'}
+		pack := testPack(t, testPackObject{typ: plumbing.REFDeltaObject, declared: len(delta), content: delta})
+		assert.ErrorIs(t, checkPack(pack, maxObject, maxTotal), ErrFileTooLarge)
+	})
+
+	t.Run("objects add up to too much", func(t *testing.T) {
+		objects := []testPackObject{}
+		for range 5 {
+			objects = append(objects, blob(bytes.Repeat([]byte("a"), maxObject)))
+		}
+		assert.ErrorIs(t, checkPack(testPack(t, objects...), maxObject, maxTotal), ErrFileTooLarge)
+	})
+}
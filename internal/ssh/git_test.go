@@ -0,0 +1,177 @@
+package ssh_test
+
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/testdata"
+)
+
+// newGitUser creates the user a key authenticates as, returning its ID and
+// the auth method to clone with.
+func newGitUser(t *testing.T, database *testutil.Database, key []byte) (string, *gitssh.PublicKeys) {
+	t.Helper()
+
+	auth, err := gitssh.NewPublicKeys("git", key, "")
+	require.NoError(t, err)
+	auth.HostKeyCallback = gossh.InsecureIgnoreHostKey() //nolint:gosec
+
+	user, err := database.Users.CreateWithPublicKey(t.Context(), &snips.PublicKey{
+		Fingerprint: gossh.FingerprintSHA256(auth.Signer.PublicKey()),
+		Type:        auth.Signer.PublicKey().Type(),
+	})
+	require.NoError(t, err)
+
+	return user.ID, auth
+}
+
+// newGitFile creates a file through each version in turn, as revisions.
+func newGitFile(t *testing.T, database *testutil.Database, file *snips.File, versions ...string) {
+	t.Helper()
+
+	require.NoError(t, database.Files.Create(t.Context(), file, []byte(versions[0]), 10))
+	for _, version := range versions[1:] {
+		require.NoError(t, files.UpdateContent(t.Context(), database.DB, newTestConfig(t), file, []byte(version), "go"))
+	}
+}
+
+func gitURL(addr string, file *snips.File) string {
+	return "ssh://" + strings.TrimPrefix(addr, "tcp://") + "/f/" + file.ID + ".git"
+}
+
+func TestGit(t *testing.T) {
+	addr, database := newTestServer(t)
+	userID, auth := newGitUser(t, database, privateKey)
+
+	file := &snips.File{UserID: userID, Name: "main.go", Type: "go"}
+	newGitFile(t, database, file, "package main\n", "package main\n\nfunc main() {}\n")
+
+	dir := t.TempDir()
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: gitURL(addr, file), Auth: auth})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+
+	// a commit per revision
+	log, err := repo.Log(&git.LogOptions{})
+	require.NoError(t, err)
+	messages := []string{}
+	require.NoError(t, log.ForEach(func(c *object.Commit) error {
+		messages = append(messages, c.Message)
+		return nil
+	}))
+	assert.Equal(t, []string{"Update main.go\n\nRevision: 1\n", "Create main.go\n\nRevision: 0\n"}, messages)
+
+	// pushed commits become revisions
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	signature := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	for _, version := range []string{"package main\n\nfunc main() { println(1) }\n", "package main\n\nfunc main() { println(2) }\n"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(version), 0o644))
+		_, err = worktree.Add("main.go")
+		require.NoError(t, err)
+		_, err = worktree.Commit("update", &git.CommitOptions{Author: signature})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.Push(&git.PushOptions{Auth: auth}))
+
+	content, err = database.Files.FindContent(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nfunc main() { println(2) }\n", string(content))
+
+	count, err := database.Revisions.CountByFileID(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	// the same history always yields the same commits
+	heads := []string{}
+	for range 2 {
+		clone, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: gitURL(addr, file), Auth: auth})
+		require.NoError(t, err)
+		head, err := clone.Head()
+		require.NoError(t, err)
+		heads = append(heads, head.Hash().String())
+	}
+	assert.Equal(t, heads[0], heads[1])
+}
+
+func TestGit_PushRejected(t *testing.T) {
+	addr, database := newTestServer(t)
+	userID, auth := newGitUser(t, database, privateKey)
+
+	file := &snips.File{UserID: userID, Name: "main.go", Type: "go"}
+	newGitFile(t, database, file, "package main\n")
+
+	dir := t.TempDir()
+	repo, err := git.PlainClone(dir, false, &git.CloneOptions{URL: gitURL(addr, file), Auth: auth})
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	// the repository only holds the file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("extra"), 0o644))
+	_, err = worktree.Add("extra.txt")
+	require.NoError(t, err)
+	_, err = worktree.Commit("extra", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	require.NoError(t, err)
+	assert.Error(t, repo.Push(&git.PushOptions{Auth: auth}))
+
+	// packs larger than a file may be are refused before they're unpacked
+	_, err = worktree.Remove("extra.txt")
+	require.NoError(t, err)
+	large := make([]byte, 32*1024)
+	_, err = rand.Read(large)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), large, 0o644))
+	_, err = worktree.Add("main.go")
+	require.NoError(t, err)
+	_, err = worktree.Commit("large", &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	require.NoError(t, err)
+	err = repo.Push(&git.PushOptions{Auth: auth})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pack file too large")
+
+	// only the owner can push
+	_, otherAuth := newGitUser(t, database, testdata.PEMBytes["rsa"])
+	assert.Error(t, repo.Push(&git.PushOptions{Auth: otherAuth}))
+
+	content, err := database.Files.FindContent(t.Context(), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+}
+
+func TestGit_Access(t *testing.T) {
+	addr, database := newTestServer(t)
+	ownerID, _ := newGitUser(t, database, privateKey)
+	_, otherAuth := newGitUser(t, database, testdata.PEMBytes["rsa"])
+
+	public := &snips.File{UserID: ownerID, Name: "public.txt", Type: "plaintext"}
+	newGitFile(t, database, public, "public\n")
+	private := &snips.File{UserID: ownerID, Name: "private.txt", Type: "plaintext", Private: true}
+	newGitFile(t, database, private, "private\n")
+
+	_, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: gitURL(addr, public), Auth: otherAuth})
+	assert.NoError(t, err)
+
+	_, err = git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: gitURL(addr, private), Auth: otherAuth})
+	assert.ErrorContains(t, err, "file not found")
+
+	_, err = git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: gitURL(addr, &snips.File{ID: "missing"}), Auth: otherAuth})
+	assert.ErrorContains(t, err, "file not found")
+}
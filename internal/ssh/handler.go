@@ -13,11 +13,13 @@ import (
 	"charm.land/lipgloss/v2"
 	"charm.land/wish/v2"
 	wishtea "charm.land/wish/v2/bubbletea"
+	"charm.land/wish/v2/scp"
 	"github.com/armon/go-metrics"
 	"github.com/charmbracelet/ssh"
 	"github.com/dustin/go-humanize"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/files"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/renderer"
@@ -29,6 +31,7 @@ import (
 type SessionHandler struct {
 	Config *config.Config
 	DB     *db.DB
+	Events *events.Hub
 }
 
 func (h *SessionHandler) HandleFunc(_ ssh.Handler) ssh.Handler {
@@ -41,6 +44,18 @@ func (h *SessionHandler) HandleFunc(_ ssh.Handler) ssh.Handler {
 			return
 		}
 
+		// user copying files with scp
+		if scp.GetInfo(userSesh.Command()).Ok {
+			h.SCP(userSesh)
+			return
+		}
+
+		// user cloning, fetching or pushing a file's git repository
+		if args := userSesh.Command(); len(args) > 0 && (args[0] == GitUploadPackCommand || args[0] == GitReceivePackCommand) {
+			h.Git(userSesh)
+			return
+		}
+
 		// user entering interactive session w/ tui
 		if userSesh.IsPTY() {
 			h.Interactive(userSesh)
@@ -53,6 +68,30 @@ func (h *SessionHandler) HandleFunc(_ ssh.Handler) ssh.Handler {
 			return
 		}
 
+		// user managing their ssh keys
+		if args := userSesh.Command(); len(args) > 0 && args[0] == KeysCommand {
+			h.Keys(userSesh)
+			return
+		}
+
+		// user managing their webhooks
+		if args := userSesh.Command(); len(args) > 0 && args[0] == WebhookCommand {
+			h.Webhook(userSesh)
+			return
+		}
+
+		// user searching their files
+		if args := userSesh.Command(); len(args) > 0 && args[0] == SearchCommand {
+			h.Search(userSesh)
+			return
+		}
+
+		// admin moderating the instance
+		if args := userSesh.Command(); len(args) > 0 && args[0] == AdminCommand {
+			h.Admin(userSesh)
+			return
+		}
+
 		// otherwise, it's a file upload
 		h.Upload(userSesh)
 	}
@@ -85,6 +124,7 @@ func (h *SessionHandler) Interactive(sesh *UserSession) {
 			user,
 			sesh.PublicKeyFingerprint(),
 			h.DB,
+			h.Events,
 			files,
 		),
 		wishtea.MakeOptions(sesh)...,
@@ -146,7 +186,7 @@ func (h *SessionHandler) FileRequest(sesh *UserSession) {
 		return
 	}
 
-	if file == nil {
+	if file == nil || file.IsExpired() {
 		sesh.Error(ErrFileNotFound, "Unable to get file", "File not found: %s", identifier)
 		return
 	}
@@ -156,6 +196,15 @@ func (h *SessionHandler) FileRequest(sesh *UserSession) {
 		return
 	}
 
+	if sesh.IsRevisionRequest() {
+		if sesh.IsContentUpdate() || sesh.IsAppend() || len(sesh.Command()) > 0 {
+			sesh.Error(ErrRevisionReadOnly, "Unable to get file", "Revisions can only be downloaded, drop %q to change the file.", RevisionSeparator)
+			return
+		}
+		h.DownloadRevision(sesh, file)
+		return
+	}
+
 	if sesh.IsContentUpdate() {
 		if file.UserID != userID {
 			sesh.Error(ErrFileNotFound, "Unable to get file", "File not found: %s", identifier)
@@ -165,6 +214,19 @@ func (h *SessionHandler) FileRequest(sesh *UserSession) {
 		return
 	}
 
+	if sesh.IsAppend() {
+		if file.UserID != userID {
+			sesh.Error(ErrFileNotFound, "Unable to get file", "File not found: %s", identifier)
+			return
+		}
+		if args := sesh.Command(); len(args) > 0 {
+			sesh.Error(ErrUnknownCommand, "Unknown command", "Appending takes no commands, got: %q", args[0])
+			return
+		}
+		h.AppendFileContent(sesh, file)
+		return
+	}
+
 	args := sesh.Command()
 	if len(args) == 0 {
 		h.DownloadFile(sesh, file)
@@ -183,6 +245,8 @@ func (h *SessionHandler) FileRequest(sesh *UserSession) {
 		h.SignFile(sesh, file)
 	case "rename":
 		h.RenameFile(sesh, file)
+	case "restore":
+		h.RestoreFile(sesh, file)
 	default:
 		sesh.Error(ErrUnknownCommand, "Unknown command", "Unknown command specified: %q", args[0])
 	}
@@ -222,6 +286,7 @@ func (h *SessionHandler) RenameFile(sesh *UserSession, file *snips.File) {
 			return
 		}
 
+		h.Events.Publish(events.NewRenameEvent(file, oldName))
 		metrics.IncrCounter([]string{"file", "rename"}, 1)
 		log.Info("file name removed", "file_id", file.ID, "old_name", oldName)
 
@@ -268,6 +333,7 @@ func (h *SessionHandler) RenameFile(sesh *UserSession, file *snips.File) {
 		return
 	}
 
+	h.Events.Publish(events.NewRenameEvent(file, previous))
 	metrics.IncrCounter([]string{"file", "rename"}, 1)
 	log.Info("file renamed", "file_id", file.ID, "name", file.Name)
 
@@ -284,6 +350,49 @@ func (h *SessionHandler) RenameFile(sesh *UserSession, file *snips.File) {
 	h.renderFileURL(sesh, file)
 }
 
+func (h *SessionHandler) RestoreFile(sesh *UserSession, file *snips.File) {
+	args := sesh.Command()[1:]
+	if len(args) != 1 {
+		sesh.Error(ErrRevisionRequired, "Unable to restore file", "Provide the revision to restore (e.g. %q).", "restore 2")
+		return
+	}
+
+	sequence, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || sequence < 0 {
+		sesh.Error(ErrRevisionRequired, "Unable to restore file", "Invalid revision %q, must be a non-negative integer.", args[0])
+		return
+	}
+
+	if err := files.Restore(sesh.Context(), h.DB, h.Config, file, sequence); err != nil {
+		switch {
+		case errors.Is(err, files.ErrRevisionNotFound):
+			sesh.Error(err, "Unable to restore file", "Revision %d not found for file: %q", sequence, file.ID)
+		case errors.Is(err, files.ErrRevisionUnavailable):
+			sesh.Error(err, "Unable to restore file", "Revision %d can no longer be restored: %s", sequence, err.Error())
+		case errors.Is(err, files.ErrTooLarge):
+			sesh.Error(ErrFileTooLarge, "Unable to restore file", "File too large, max size is %s", humanize.Bytes(h.Config.Limits.FileSize))
+		default:
+			sesh.Error(err, "Unable to restore file", "There was an error restoring file: %q", file.ID)
+		}
+		return
+	}
+
+	h.Events.Publish(events.NewEvent(events.KindUpdate, file))
+
+	h.renderFileResult(sesh, file, "File Restored ⏪")
+
+	noti := Notification{
+		Color: styles.Colors.Cyan,
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("Restored revision %d. The restore is a new revision, so it can be undone too.", sequence)
+	noti.Render(sesh)
+
+	h.renderFileURL(sesh, file)
+}
+
 func (h *SessionHandler) DeleteFile(sesh *UserSession, file *snips.File) {
 	log := logger.From(sesh.Context())
 
@@ -326,6 +435,7 @@ func (h *SessionHandler) DeleteFile(sesh *UserSession, file *snips.File) {
 		return
 	}
 
+	h.Events.Publish(events.NewEvent(events.KindDelete, file))
 	metrics.IncrCounter([]string{"file", "delete"}, 1)
 
 	log.Info("file deleted", "file_id", file.ID)
@@ -391,10 +501,34 @@ func (h *SessionHandler) SignFile(sesh *UserSession, file *snips.File) {
 }
 
 func (h *SessionHandler) DownloadFile(sesh *UserSession, file *snips.File) {
-	content, err := h.DB.Files.FindContent(sesh.Context(), file.ID)
-	if err != nil {
+	content, err := files.View(sesh.Context(), h.DB, h.Events, file)
+	switch {
+	case errors.Is(err, files.ErrBurned):
+		sesh.Error(ErrFileNotFound, "Unable to get file", "File not found: %s", file.ID)
+	case err != nil:
 		sesh.Error(err, "Unable to download file", "There was an error downloading the file: %q", file.ID)
-	} else {
+	default:
+		wish.Print(sesh, string(content))
+	}
+}
+
+func (h *SessionHandler) DownloadRevision(sesh *UserSession, file *snips.File) {
+	rev, _ := sesh.RequestedRevision()
+	sequence, err := strconv.ParseInt(rev, 10, 64)
+	if err != nil || sequence < 0 {
+		sesh.Error(ErrRevisionRequired, "Unable to get file", "Invalid revision %q, must be a non-negative integer.", rev)
+		return
+	}
+
+	content, _, err := files.ContentAt(sesh.Context(), h.DB, file, sequence)
+	switch {
+	case errors.Is(err, files.ErrRevisionNotFound):
+		sesh.Error(err, "Unable to get file", "Revision %d not found for file: %q", sequence, file.ID)
+	case errors.Is(err, files.ErrRevisionUnavailable):
+		sesh.Error(err, "Unable to get file", "Revision %d can no longer be downloaded: %s", sequence, err.Error())
+	case err != nil:
+		sesh.Error(err, "Unable to download file", "There was an error downloading the file: %q", file.ID)
+	default:
 		wish.Print(sesh, string(content))
 	}
 }
@@ -441,6 +575,18 @@ func (h *SessionHandler) renderFileResult(sesh *UserSession, file *snips.File, t
 	if file.Name != "" {
 		kvp["name"] = styles.C(styles.Colors.White, file.Name)
 	}
+	if file.ExpiresAt != nil {
+		kvp["expires"] = styles.C(styles.Colors.Yellow, file.ExpiresAt.Local().Format(time.RFC3339))
+	}
+	if len(file.Tags) > 0 {
+		kvp["tags"] = styles.C(styles.Colors.White, strings.Join(file.Tags, ", "))
+	}
+	if file.Description != "" {
+		kvp["description"] = styles.C(styles.Colors.White, file.Description)
+	}
+	if file.BurnAfterRead {
+		kvp["burn"] = styles.C(styles.Colors.Red, "after first view")
+	}
 	for k, v := range kvp {
 		key := styles.C(styles.Colors.Muted, k+": ")
 		attrs = append(attrs, key+v)
@@ -516,11 +662,32 @@ func (h *SessionHandler) UpdateFileContent(sesh *UserSession, file *snips.File)
 		return
 	}
 
-	if err := files.UpdateContent(sesh.Context(), h.DB, h.Config, file, content, flags.Extension); err != nil {
-		sesh.Error(err, "Unable to update file", "There was an error updating the file: %s", err.Error())
+	var conds []db.PreconditionOption
+	if flags.IfRevision >= 0 {
+		// also pin the file as it was loaded, as its content is written before
+		// the revision recording it
+		conds = append(conds, db.IfRevision(flags.IfRevision), db.IfUpdatedAt(file.UpdatedAt))
+	}
+
+	if err := files.UpdateContent(sesh.Context(), h.DB, h.Config, file, content, flags.Extension, conds...); err != nil {
+		switch {
+		case errors.Is(err, db.ErrPreconditionFailed):
+			latest := int64(0)
+			if revisions, err := h.DB.Revisions.FindByFileID(sesh.Context(), file.ID, db.WithLimit(1)); err == nil && len(revisions) > 0 {
+				latest = revisions[0].Sequence
+			}
+			sesh.Error(err, "Unable to update file", "File %q changed since revision %d (latest is %d), download it again and retry.", file.ID, flags.IfRevision, latest)
+		case errors.Is(err, snips.ErrInvalidBundle):
+			sesh.Error(err, "Unable to update file", "File is a bundle, pipe a tar stream to update it: %s", err.Error())
+		case errors.Is(err, files.ErrTooLarge):
+			sesh.Error(ErrFileTooLarge, "Unable to update file", "File too large, max size is %s", humanize.Bytes(h.Config.Limits.FileSize))
+		default:
+			sesh.Error(err, "Unable to update file", "There was an error updating the file: %s", err.Error())
+		}
 		return
 	}
 
+	h.Events.Publish(events.NewEvent(events.KindUpdate, file))
 	metrics.IncrCounterWithLabels([]string{"file", "update"}, 1, []metrics.Label{
 		{Name: "type", Value: file.Type},
 	})
@@ -536,6 +703,118 @@ func (h *SessionHandler) UpdateFileContent(sesh *UserSession, file *snips.File)
 	h.renderFileURL(sesh, file)
 }
 
+// AppendFileContent streams the session's input onto the end of a file. Input
+// is buffered and written every AppendFlushInterval, so a long-running pipe
+// like tail -f shows up on the file's URL as it arrives.
+func (h *SessionHandler) AppendFileContent(sesh *UserSession, file *snips.File) {
+	log := logger.From(sesh.Context())
+
+	chunks := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, UploadBufferSize)
+			n, err := sesh.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- buf[:n]:
+				case <-sesh.Context().Done():
+					return
+				}
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(AppendFlushInterval)
+	defer ticker.Stop()
+
+	pending := make([]byte, 0)
+	appended := uint64(0)
+	flush := func() bool {
+		if len(pending) == 0 {
+			return true
+		}
+		if err := files.AppendContent(sesh.Context(), h.DB, h.Config, file, pending); err != nil {
+			h.appendError(sesh, file, err)
+			return false
+		}
+		h.Events.Publish(events.NewEvent(events.KindAppend, file))
+		appended += uint64(len(pending))
+		pending = pending[:0]
+		return true
+	}
+
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				done = true
+				break
+			}
+			pending = append(pending, chunk...)
+			if file.Size+uint64(len(pending)) > h.Config.Limits.FileSize {
+				h.appendError(sesh, file, files.ErrTooLarge)
+				return
+			}
+		case <-ticker.C:
+			if !flush() {
+				return
+			}
+		case <-sesh.Context().Done():
+			return
+		}
+	}
+
+	select {
+	case err := <-readErr:
+		sesh.Error(err, "Unable to read content", "There was an error reading the content: %q", err.Error())
+		return
+	default:
+	}
+
+	if !flush() {
+		return
+	}
+
+	if appended == 0 {
+		sesh.Error(ErrEmptyContent, "Unable to update file", "No content provided. Pipe content to append to a file:\n  tail -f <file> | %s", h.Config.SSHCommandForFile(file.ID+":append"))
+		return
+	}
+
+	metrics.IncrCounterWithLabels([]string{"file", "append"}, 1, []metrics.Label{
+		{Name: "type", Value: file.Type},
+	})
+
+	log.Info("file content appended",
+		"file_id", file.ID,
+		"user_id", file.UserID,
+		"appended", appended,
+		"size", file.Size,
+		"file_type", file.Type,
+	)
+
+	h.renderFileResult(sesh, file, "Content Appended 📎")
+	h.renderFileURL(sesh, file)
+}
+
+func (h *SessionHandler) appendError(sesh *UserSession, file *snips.File, err error) {
+	switch {
+	case errors.Is(err, files.ErrAppendBundle):
+		sesh.Error(err, "Unable to update file", "File %q is a bundle, pipe a tar stream to %s to replace it instead.", file.ID, h.Config.SSHCommandForFile(file.ID+":content"))
+	case errors.Is(err, files.ErrTooLarge):
+		sesh.Error(ErrFileTooLarge, "Unable to update file", "File too large, max size is %s", humanize.Bytes(h.Config.Limits.FileSize))
+	default:
+		sesh.Error(err, "Unable to update file", "There was an error updating the file: %s", err.Error())
+	}
+}
+
 func (h *SessionHandler) Upload(sesh *UserSession) {
 	log := logger.From(sesh.Context())
 
@@ -578,13 +857,49 @@ func (h *SessionHandler) Upload(sesh *UserSession) {
 		}
 	}
 
+	tags, err := snips.NormalizeTags(flags.Tags)
+	if err != nil {
+		sesh.Error(err, "Unable to create file", "Invalid tags: %s", err.Error())
+		return
+	}
+
+	description, err := snips.NormalizeDescription(flags.Desc)
+	if err != nil {
+		sesh.Error(err, "Unable to create file", "Invalid description: %s", err.Error())
+		return
+	}
+
+	var fileType string
+	if flags.Tar {
+		content, err = snips.NormalizeBundle(content)
+		if err != nil {
+			sesh.Error(err, "Unable to create file", "Invalid tar stream: %s", err.Error())
+			return
+		}
+		if uint64(len(content)) > h.Config.Limits.FileSize {
+			sesh.Error(ErrFileTooLarge, "Unable to upload file", "File too large, max size is %s", humanize.Bytes(h.Config.Limits.FileSize))
+			return
+		}
+		fileType = snips.FileTypeBundle
+	} else {
+		fileType = renderer.DetectFileType(content, flags.Extension, h.Config.EnableGuesser)
+	}
+
 	size := uint64(len(content))
 	file := snips.File{
-		Private: flags.Private,
-		Size:    size,
-		UserID:  sesh.UserID(),
-		Type:    renderer.DetectFileType(content, flags.Extension, h.Config.EnableGuesser),
-		Name:    name,
+		Private:       flags.Private,
+		Size:          size,
+		UserID:        sesh.UserID(),
+		Type:          fileType,
+		Name:          name,
+		Description:   description,
+		Tags:          tags,
+		BurnAfterRead: flags.Burn,
+	}
+
+	if flags.Expires > 0 {
+		expiresAt := time.Now().UTC().Add(flags.Expires)
+		file.ExpiresAt = &expiresAt
 	}
 
 	if err := h.DB.Files.Create(sesh.Context(), &file, content, h.Config.Limits.FilesPerUser); err != nil {
@@ -596,6 +911,7 @@ func (h *SessionHandler) Upload(sesh *UserSession) {
 		return
 	}
 
+	h.Events.Publish(events.NewEvent(events.KindCreate, &file))
 	metrics.IncrCounterWithLabels([]string{"file", "create"}, 1, []metrics.Label{
 		{Name: "private", Value: strconv.FormatBool(file.Private)},
 		{Name: "type", Value: file.Type},
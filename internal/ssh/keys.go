@@ -0,0 +1,218 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// Keys dispatches the `keys <link|claim|ls|rm>` command for managing the SSH
+// public keys attached to a user.
+func (h *SessionHandler) Keys(sesh *UserSession) {
+	args := sesh.Command()[1:]
+	if len(args) == 0 {
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Usage: %s <link|claim|ls|rm>", KeysCommand)
+		return
+	}
+
+	switch args[0] {
+	case "link":
+		h.LinkKey(sesh)
+	case "claim":
+		h.ClaimKey(sesh, args[1:])
+	case "ls":
+		h.ListKeys(sesh)
+	case "rm":
+		h.RemoveKey(sesh, args[1:])
+	default:
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Unknown subcommand %q, expected <link|claim|ls|rm>", args[0])
+	}
+}
+
+func (h *SessionHandler) LinkKey(sesh *UserSession) {
+	log := logger.From(sesh.Context())
+
+	code, hash, err := snips.NewKeyLinkCode()
+	if err != nil {
+		sesh.Error(err, "Unable to link key", "There was an error creating the link code. Please try again.")
+		return
+	}
+
+	link := &snips.KeyLink{
+		CodeHash:  hash,
+		UserID:    sesh.UserID(),
+		ExpiresAt: time.Now().UTC().Add(snips.KeyLinkTTL),
+	}
+	if err := h.DB.PublicKeys.CreateLink(sesh.Context(), link); err != nil {
+		sesh.Error(err, "Unable to link key", "There was an error creating the link code. Please try again.")
+		return
+	}
+
+	metrics.IncrCounter([]string{"publickey", "link"}, 1)
+	log.Info("public key link created", "user_id", sesh.UserID())
+
+	noti := Notification{
+		Color:   styles.Colors.Blue,
+		Title:   "Link Code 🔗",
+		Message: styles.C(styles.Colors.Yellow, code),
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Render(sesh)
+
+	noti = Notification{
+		Color: styles.Colors.Yellow,
+		Title: "Next Steps ℹ️",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef(
+		"From the key you want to add, run: %s\nThe code expires in %s and can only be used once.",
+		styles.C(styles.Colors.Blue, h.Config.SSHCommandWithArgs(KeysCommand, "claim", code)),
+		snips.KeyLinkTTL,
+	)
+	noti.Render(sesh)
+}
+
+func (h *SessionHandler) ClaimKey(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	if len(args) == 0 || args[0] == "" {
+		sesh.Error(ErrLinkCodeRequired, "Unable to claim key", "Provide a link code, e.g.: %s claim <code> (create one with: %s link)", KeysCommand, KeysCommand)
+		return
+	}
+
+	fingerprint := sesh.PublicKeyFingerprint()
+	previousUserID := sesh.UserID()
+
+	// moving this key away would orphan the current account's files, so it's
+	// only allowed when another key keeps them reachable
+	key, err := h.DB.PublicKeys.ClaimLink(sesh.Context(), snips.HashKeyLinkCode(strings.Join(args, "")), fingerprint)
+	if err != nil {
+		if errors.Is(err, db.ErrLinkInvalid) {
+			sesh.Error(err, "Unable to claim key", "The link code is invalid or expired. Create a new one with: %s link", KeysCommand)
+			return
+		}
+		if errors.Is(err, db.ErrKeyHasFiles) {
+			sesh.Error(err, "Unable to claim key", "This key is the only one for an account with files. Delete them first, or claim from a key that hasn't uploaded anything.")
+			return
+		}
+		sesh.Error(err, "Unable to claim key", "There was an error claiming the link code. Please try again.")
+		return
+	}
+
+	if key.UserID == previousUserID {
+		noti := Notification{
+			Color:   styles.Colors.Yellow,
+			Title:   "Already Linked ℹ️",
+			Message: "This key is already linked to that account.",
+			WithStyle: func(s *lipgloss.Style) {
+				s.MarginTop(1)
+			},
+		}
+		noti.Render(sesh)
+		return
+	}
+
+	metrics.IncrCounter([]string{"publickey", "claim"}, 1)
+	log.Info("public key linked", "public_key_id", key.ID, "user_id", key.UserID, "previous_user_id", previousUserID)
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "Key Linked 🔑",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("%s is now linked to your account.\nReconnect to see your files.", styles.C(styles.Colors.White, key.Fingerprint))
+	noti.Render(sesh)
+}
+
+func (h *SessionHandler) ListKeys(sesh *UserSession) {
+	keys, err := h.DB.PublicKeys.FindByUser(sesh.Context(), sesh.UserID())
+	if err != nil {
+		sesh.Error(err, "Unable to list keys", "There was an error listing your keys. Please try again.")
+		return
+	}
+
+	var table strings.Builder
+	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(tabs, "ID\tTYPE\tFINGERPRINT\tADDED\t")
+	for _, key := range keys {
+		current := ""
+		if key.Fingerprint == sesh.PublicKeyFingerprint() {
+			current = "(this key)"
+		}
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Type, key.Fingerprint, key.CreatedAt.UTC().Format(time.RFC3339), current)
+	}
+	if err := tabs.Flush(); err != nil {
+		sesh.Error(err, "Unable to list keys", "There was an error listing your keys. Please try again.")
+		return
+	}
+
+	_, _ = fmt.Fprint(sesh, table.String())
+}
+
+func (h *SessionHandler) RemoveKey(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	if len(args) == 0 || args[0] == "" {
+		sesh.Error(ErrPublicKeyIDRequired, "Unable to remove key", "Provide a key, e.g.: %s rm <id> (list keys with: %s ls)", KeysCommand, KeysCommand)
+		return
+	}
+
+	// keys are easier to recognize by fingerprint, so accept either
+	keys, err := h.DB.PublicKeys.FindByUser(sesh.Context(), sesh.UserID())
+	if err != nil {
+		sesh.Error(err, "Unable to remove key", "There was an error removing key: %q", args[0])
+		return
+	}
+
+	var target *snips.PublicKey
+	for _, key := range keys {
+		if key.ID == args[0] || key.Fingerprint == args[0] {
+			target = key
+			break
+		}
+	}
+
+	if target == nil {
+		sesh.Error(ErrPublicKeyNotFound, "Unable to remove key", "Key not found: %q", args[0])
+		return
+	}
+
+	if target.Fingerprint == sesh.PublicKeyFingerprint() {
+		sesh.Error(ErrCurrentPublicKey, "Unable to remove key", "You can't remove the key you're connected with. Connect with another key to remove it.")
+		return
+	}
+
+	deleted, err := h.DB.PublicKeys.Delete(sesh.Context(), target.ID, sesh.UserID())
+	if err != nil || !deleted {
+		sesh.Error(err, "Unable to remove key", "There was an error removing key: %q", args[0])
+		return
+	}
+
+	metrics.IncrCounter([]string{"publickey", "delete"}, 1)
+	log.Info("public key deleted", "public_key_id", target.ID, "user_id", sesh.UserID())
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "Key Removed 🗑️",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("Removed key: %q", target.Fingerprint)
+	noti.Render(sesh)
+}
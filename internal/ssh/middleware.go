@@ -11,16 +11,19 @@ import (
 	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 	"github.com/robherley/snips.sh/internal/snips"
-	gossh "golang.org/x/crypto/ssh"
+	"github.com/robherley/snips.sh/internal/tui/styles"
 )
 
-// AssignUser will attempt to match a user with a public key fingerprint.
+// AssignUser will attempt to match a user with a public key fingerprint, or
+// the principal of a certificate signed by a trusted CA.
 // If a user is not found, one will be created with the current fingerprint attached.
-func AssignUser(database *db.DB, externalAddress url.URL) func(next ssh.Handler) ssh.Handler {
+// Banned users are disconnected.
+func AssignUser(database *db.DB, externalAddress url.URL, certs *UserCertificates) func(next ssh.Handler) ssh.Handler {
 	return func(next ssh.Handler) ssh.Handler {
 		return func(sesh ssh.Session) {
-			fingerprint := gossh.FingerprintSHA256(sesh.PublicKey())
+			fingerprint := certs.Identity(sesh.PublicKey())
 			sesh.Context().SetValue(FingerprintContextKey, fingerprint)
 
 			var (
@@ -66,6 +69,13 @@ func AssignUser(database *db.DB, externalAddress url.URL) func(next ssh.Handler)
 				}
 			}
 
+			if user.IsBanned() {
+				logger.From(sesh.Context()).Warn("banned user rejected", "user_id", user.ID)
+				metrics.IncrCounter([]string{"ssh", "session", "banned"}, 1)
+				wish.Fatalln(sesh, "❌ This account has been banned.")
+				return
+			}
+
 			sesh.Context().SetValue(UserIDContextKey, user.ID)
 
 			log := logger.From(sesh.Context()).With("user_id", user.ID)
@@ -129,9 +139,10 @@ func WithSessionMetrics(next ssh.Handler) ssh.Handler {
 	}
 }
 
-// WithAuthorizedKeys will block any SSH connections that aren't using a public key in the authorized key list.
+// WithAuthorizedKeys will block any SSH connections that aren't using a public key in the authorized key list,
+// or a certificate signed by a trusted CA.
 // If authorizedKeys is empty, this middleware will be a no-op.
-func WithAuthorizedKeys(authorizedKeys []ssh.PublicKey) func(next ssh.Handler) ssh.Handler {
+func WithAuthorizedKeys(authorizedKeys []ssh.PublicKey, certs *UserCertificates) func(next ssh.Handler) ssh.Handler {
 	if len(authorizedKeys) == 0 {
 		return func(next ssh.Handler) ssh.Handler {
 			return next
@@ -142,6 +153,11 @@ func WithAuthorizedKeys(authorizedKeys []ssh.PublicKey) func(next ssh.Handler) s
 
 	return func(next ssh.Handler) ssh.Handler {
 		return func(sesh ssh.Session) {
+			if _, ok := certs.Principal(sesh.PublicKey()); ok {
+				next(sesh)
+				return
+			}
+
 			for _, key := range authorizedKeys {
 				if ssh.KeysEqual(key, sesh.PublicKey()) {
 					next(sesh)
@@ -155,3 +171,36 @@ func WithAuthorizedKeys(authorizedKeys []ssh.PublicKey) func(next ssh.Handler) s
 		}
 	}
 }
+
+// WithRateLimit will stop SSH sessions from a public key fingerprint, or
+// certificate principal, that has opened too many of them, telling the user
+// when they can try again.
+// If limiter is nil, this middleware will be a no-op.
+func WithRateLimit(limiter *ratelimit.Limiter, certs *UserCertificates) func(next ssh.Handler) ssh.Handler {
+	if limiter == nil {
+		return func(next ssh.Handler) ssh.Handler {
+			return next
+		}
+	}
+
+	return func(next ssh.Handler) ssh.Handler {
+		return func(sesh ssh.Session) {
+			ok, wait := limiter.Allow(certs.Identity(sesh.PublicKey()))
+			if ok {
+				next(sesh)
+				return
+			}
+
+			metrics.IncrCounter([]string{"ssh", "session", "rate_limited"}, 1)
+			logger.From(sesh.Context()).Warn("rate limited", "retry_after", wait)
+
+			noti := Notification{
+				Color: styles.Colors.Yellow,
+				Title: "Slow Down 🐢",
+			}
+			noti.Messagef("You're doing that too often. Please try again in %ds.", ratelimit.RetryAfter(wait))
+			noti.Render(sesh)
+			_ = sesh.Exit(1)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ssh_test
 
 import (
+	"crypto/rand"
 	"net/url"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	dbmock "github.com/robherley/snips.sh/internal/db/mock"
 	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/ssh"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +41,163 @@ func testPrivateKeyAuth(key []byte) gossh.AuthMethod {
 	return gossh.PublicKeys(signer)
 }
 
+// testCertAuth signs a user certificate for key with the ca key, and returns
+// an auth method presenting it.
+func testCertAuth(t *testing.T, ca, key []byte, edit func(cert *gossh.Certificate)) gossh.AuthMethod {
+	t.Helper()
+
+	caSigner, err := gossh.ParsePrivateKey(ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := gossh.ParsePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := &gossh.Certificate{
+		Key:             signer.PublicKey(),
+		CertType:        gossh.UserCert,
+		KeyId:           "alice@example.com",
+		ValidPrincipals: []string{"alice"},
+		ValidAfter:      uint64(time.Now().Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	if edit != nil {
+		edit(cert)
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatal(err)
+	}
+
+	certSigner, err := gossh.NewCertSigner(cert, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return gossh.PublicKeys(certSigner)
+}
+
+func testCA(t *testing.T, key []byte) *ssh.UserCertificates {
+	t.Helper()
+
+	signer, err := gossh.ParsePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ssh.NewUserCertificates([]cssh.PublicKey{signer.PublicKey()})
+}
+
+func TestUserCertificates(t *testing.T) {
+	ca := testCA(t, testdata.PEMBytes["rsa"])
+
+	tests := []struct {
+		name          string
+		certs         *ssh.UserCertificates
+		auth          gossh.AuthMethod
+		authenticated bool
+	}{
+		{
+			name:          "plain key",
+			certs:         ca,
+			auth:          testPrivateKeyAuth(privateKey),
+			authenticated: true,
+		},
+		{
+			name:          "trusted certificate",
+			certs:         ca,
+			auth:          testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, nil),
+			authenticated: true,
+		},
+		{
+			name:          "no trusted CAs",
+			certs:         nil,
+			auth:          testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, nil),
+			authenticated: true,
+		},
+		{
+			name:  "untrusted CA",
+			certs: ca,
+			auth:  testCertAuth(t, testdata.PEMBytes["ecdsa"], privateKey, nil),
+		},
+		{
+			name:  "expired",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.ValidBefore = uint64(time.Now().Add(-time.Second).Unix())
+			}),
+		},
+		{
+			name:  "host certificate",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.CertType = gossh.HostCert
+			}),
+		},
+		{
+			name:  "no principals",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.ValidPrincipals = nil
+			}),
+		},
+		{
+			name:  "allowed source address",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{"source-address": "198.51.100.7,127.0.0.0/8"}
+			}),
+			authenticated: true,
+		},
+		{
+			name:  "disallowed source address",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{"source-address": "198.51.100.0/24"}
+			}),
+		},
+		{
+			name:  "malformed source address",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{"source-address": "127.0.0.1,nope"}
+			}),
+		},
+		{
+			name:  "unenforceable critical option",
+			certs: ca,
+			auth: testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, func(cert *gossh.Certificate) {
+				cert.CriticalOptions = map[string]string{"force-command": "ls"}
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := testsession.Listen(t, &cssh.Server{
+				Handler: func(_ cssh.Session) {},
+				PublicKeyHandler: func(ctx cssh.Context, key cssh.PublicKey) bool {
+					return tt.certs.Authenticate(key, ctx.RemoteAddr())
+				},
+			})
+
+			_, err := testsession.NewClientSession(t, addr, &gossh.ClientConfig{
+				Auth: []gossh.AuthMethod{
+					tt.auth,
+				},
+				Timeout: testTimeout,
+			})
+			if tt.authenticated {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
 func TestAssignUser(t *testing.T) {
 	t.Run("creates new user", func(t *testing.T) {
 		database := dbmock.NewDB(t)
@@ -59,7 +218,7 @@ func TestAssignUser(t *testing.T) {
 		}
 
 		session := testsession.New(t, &cssh.Server{
-			Handler: ssh.AssignUser(database.DB, *testHost)(nextFunc),
+			Handler: ssh.AssignUser(database.DB, *testHost, nil)(nextFunc),
 			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
 				return true
 			},
@@ -94,7 +253,7 @@ func TestAssignUser(t *testing.T) {
 		}
 
 		session := testsession.New(t, &cssh.Server{
-			Handler: ssh.AssignUser(database.DB, *testHost)(nextFunc),
+			Handler: ssh.AssignUser(database.DB, *testHost, nil)(nextFunc),
 			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
 				return true
 			},
@@ -107,6 +266,78 @@ func TestAssignUser(t *testing.T) {
 
 		_ = session.Run("")
 	})
+
+	t.Run("rejects banned user", func(t *testing.T) {
+		database := dbmock.NewDB(t)
+
+		userID := id.New()
+		bannedAt := time.Now().UTC()
+		database.PublicKeys.EXPECT().FindByFingerprint(
+			mock.Anything, fingerprint).
+			Return(&snips.PublicKey{
+				UserID: userID,
+			}, nil)
+		database.Users.EXPECT().Find(
+			mock.Anything, userID).
+			Return(&snips.User{
+				ID:       userID,
+				BannedAt: &bannedAt,
+			}, nil)
+
+		nextFunc := func(_ cssh.Session) {
+			panic("this should not be called")
+		}
+
+		session := testsession.New(t, &cssh.Server{
+			Handler: ssh.AssignUser(database.DB, *testHost, nil)(nextFunc),
+			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
+				return true
+			},
+		}, &gossh.ClientConfig{
+			Auth: []gossh.AuthMethod{
+				testPrivateKeyAuth(privateKey),
+			},
+			Timeout: testTimeout,
+		})
+
+		err := session.Run("")
+		assert.Error(t, err)
+	})
+}
+
+func TestAssignUser_Certificate(t *testing.T) {
+	database := dbmock.NewDB(t)
+
+	userID := id.New()
+	database.PublicKeys.EXPECT().FindByFingerprint(
+		mock.Anything, "principal:alice").
+		Return(&snips.PublicKey{
+			UserID: userID,
+		}, nil)
+	database.Users.EXPECT().Find(
+		mock.Anything, userID).
+		Return(&snips.User{
+			ID: userID,
+		}, nil)
+
+	nextFunc := func(sesh cssh.Session) {
+		assert.Equal(t, userID, sesh.Context().Value(ssh.UserIDContextKey))
+		assert.Equal(t, "principal:alice", sesh.Context().Value(ssh.FingerprintContextKey))
+	}
+
+	session := testsession.New(t, &cssh.Server{
+		Handler: ssh.AssignUser(database.DB, *testHost, testCA(t, testdata.PEMBytes["rsa"]))(nextFunc),
+		PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
+			return true
+		},
+	}, &gossh.ClientConfig{
+		Auth: []gossh.AuthMethod{
+			testCertAuth(t, testdata.PEMBytes["rsa"], privateKey, nil),
+		},
+		Timeout: testTimeout,
+	})
+
+	_ = session.Run("")
 }
 
 func TestBlockIfNoPublicKey(t *testing.T) {
@@ -186,7 +417,7 @@ func TestWithLogger(t *testing.T) {
 func TestWithAuthorizedKeys(t *testing.T) {
 	t.Run("no authorized keys", func(t *testing.T) {
 		session := testsession.New(t, &cssh.Server{
-			Handler: ssh.WithAuthorizedKeys(nil)(func(_ cssh.Session) {}),
+			Handler: ssh.WithAuthorizedKeys(nil, nil)(func(_ cssh.Session) {}),
 			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
 				return true
 			},
@@ -208,7 +439,7 @@ func TestWithAuthorizedKeys(t *testing.T) {
 
 		session := testsession.New(t, &cssh.Server{
 			Handler: ssh.WithAuthorizedKeys(
-				[]cssh.PublicKey{authorizedKey},
+				[]cssh.PublicKey{authorizedKey}, nil,
 			)(nextFunc),
 			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
 				return true
@@ -227,7 +458,7 @@ func TestWithAuthorizedKeys(t *testing.T) {
 	t.Run("pubkey in authorized keys", func(t *testing.T) {
 		session := testsession.New(t, &cssh.Server{
 			Handler: ssh.WithAuthorizedKeys(
-				[]cssh.PublicKey{authorizedKey},
+				[]cssh.PublicKey{authorizedKey}, nil,
 			)(func(_ cssh.Session) {}),
 			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
 				return true
@@ -242,4 +473,70 @@ func TestWithAuthorizedKeys(t *testing.T) {
 		err := session.Run("")
 		assert.NoError(t, err)
 	})
+
+	t.Run("certificate from trusted CA", func(t *testing.T) {
+		session := testsession.New(t, &cssh.Server{
+			Handler: ssh.WithAuthorizedKeys(
+				[]cssh.PublicKey{authorizedKey}, testCA(t, testdata.PEMBytes["rsa"]),
+			)(func(_ cssh.Session) {}),
+			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
+				return true
+			},
+		}, &gossh.ClientConfig{
+			Auth: []gossh.AuthMethod{
+				testCertAuth(t, testdata.PEMBytes["rsa"], testdata.PEMBytes["ecdsa"], nil),
+			},
+			Timeout: testTimeout,
+		})
+
+		err := session.Run("")
+		assert.NoError(t, err)
+	})
+}
+
+func TestWithRateLimit(t *testing.T) {
+	run := func(t *testing.T, handler cssh.Handler, key []byte) ([]byte, error) {
+		session := testsession.New(t, &cssh.Server{
+			Handler: handler,
+			PublicKeyHandler: func(_ cssh.Context, _ cssh.PublicKey) bool {
+				return true
+			},
+		}, &gossh.ClientConfig{
+			Auth: []gossh.AuthMethod{
+				testPrivateKeyAuth(key),
+			},
+			Timeout: testTimeout,
+		})
+
+		return session.Output("")
+	}
+
+	t.Run("limits sessions by fingerprint", func(t *testing.T) {
+		calls := 0
+		handler := ssh.WithRateLimit(ratelimit.New(1, 1), nil)(func(_ cssh.Session) {
+			calls++
+		})
+
+		_, err := run(t, handler, privateKey)
+		assert.NoError(t, err)
+
+		out, err := run(t, handler, privateKey)
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "Slow Down")
+		assert.Contains(t, string(out), "try again in 60s")
+
+		_, err = run(t, handler, testdata.PEMBytes["rsa"])
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("nil limiter", func(t *testing.T) {
+		handler := ssh.WithRateLimit(nil, nil)(func(_ cssh.Session) {})
+
+		for range 3 {
+			_, err := run(t, handler, privateKey)
+			assert.NoError(t, err)
+		}
+	})
 }
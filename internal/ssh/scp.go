@@ -0,0 +1,134 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"charm.land/wish/v2/scp"
+	"github.com/armon/go-metrics"
+	"github.com/charmbracelet/ssh"
+)
+
+// SCP handles the `scp -t` and `scp -f` commands sent by scp clients using
+// the legacy protocol (`scp -O`, or versions without sftp), so `scp main.go
+// host:` uploads a file named after the local one and `scp host:main.go .`
+// downloads it. Paths resolve as they do over sftp.
+func (h *SessionHandler) SCP(sesh *UserSession) {
+	info := scp.GetInfo(sesh.Command())
+
+	handler := &scpHandler{
+		fs: &sftpFS{
+			h:      h,
+			ctx:    sesh.Context(),
+			userID: sesh.UserID(),
+			via:    "scp",
+		},
+		// remote shells expand ~ to the home directory, which is the root here
+		target: strings.TrimPrefix(info.Path, "~"),
+	}
+
+	metrics.IncrCounter([]string{"ssh", "scp", "session"}, 1)
+	scp.Middleware(handler, handler)(func(ssh.Session) {})(sesh)
+}
+
+// scpHandler serves the user's files to scp. Files are flat, so directories
+// can't be created or copied recursively.
+type scpHandler struct {
+	fs     *sftpFS
+	target string // path given to scp -t or scp -f
+}
+
+func (sh *scpHandler) Glob(_ ssh.Session, pattern string) ([]string, error) {
+	return []string{pattern}, nil
+}
+
+func (sh *scpHandler) WalkDir(_ ssh.Session, _ string, _ fs.WalkDirFunc) error {
+	return ErrDirectoryUnsupported
+}
+
+func (sh *scpHandler) NewDirEntry(_ ssh.Session, _ string) (*scp.DirEntry, error) {
+	return nil, ErrDirectoryUnsupported
+}
+
+func (sh *scpHandler) NewFileEntry(_ ssh.Session, filepath string) (*scp.FileEntry, func() error, error) {
+	p, err := parseSFTPPath(strings.TrimPrefix(filepath, "~"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.isDir() {
+		return nil, nil, ErrDirectoryUnsupported
+	}
+
+	file, content, err := sh.fs.content(p)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%q: %w", filepath, err)
+	}
+
+	name := p.entry
+	mode := fileInfo(name, file).Mode()
+	if p.revisions {
+		name = p.sequence
+		mode = 0o444
+	}
+
+	metrics.IncrCounter([]string{"ssh", "scp", "read"}, 1)
+	return &scp.FileEntry{
+		Name:     name,
+		Filepath: filepath,
+		Mode:     mode,
+		Size:     int64(len(content)),
+		Reader:   bytes.NewReader(content),
+		Atime:    file.UpdatedAt.Unix(),
+		Mtime:    file.UpdatedAt.Unix(),
+	}, nil, nil
+}
+
+func (sh *scpHandler) Mkdir(_ ssh.Session, _ *scp.DirEntry) error {
+	return ErrDirectoryUnsupported
+}
+
+// Write saves an uploaded file. It's named after the local file, unless the
+// target names one, and replaces the content of a file that already has the
+// name.
+func (sh *scpHandler) Write(_ ssh.Session, entry *scp.FileEntry) (int64, error) {
+	target, err := parseSFTPPath(sh.target)
+	if err != nil {
+		return 0, err
+	}
+	if target.revisions {
+		return 0, os.ErrPermission
+	}
+
+	name := target.entry
+	if name == "" {
+		name = entry.Name
+	}
+
+	limit := sh.fs.h.Config.Limits.FileSize
+	if entry.Size < 0 || uint64(entry.Size) > limit {
+		return 0, fmt.Errorf("%w: max size is %d bytes", ErrFileTooLarge, limit)
+	}
+
+	content, err := io.ReadAll(entry.Reader)
+	if err != nil {
+		return 0, err
+	}
+	if len(content) == 0 {
+		return 0, ErrEmptyContent
+	}
+
+	file, err := sh.fs.find(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	if err := sh.fs.save(file, name, content); err != nil {
+		return 0, err
+	}
+	return int64(len(content)), nil
+}
@@ -0,0 +1,81 @@
+package ssh_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// runSCP runs an scp command on the server, feeding it the client's side of
+// the protocol, and returns what the server sent back.
+func runSCP(t *testing.T, conn *gossh.Client, cmd, input string) (string, error) {
+	t.Helper()
+
+	session, err := conn.NewSession()
+	require.NoError(t, err)
+	defer session.Close()
+
+	var stdout bytes.Buffer
+	session.Stdin = strings.NewReader(input)
+	session.Stdout = &stdout
+
+	err = session.Run(cmd)
+	return stdout.String(), err
+}
+
+func TestSCP(t *testing.T) {
+	conn, database := dialTestServer(t)
+
+	// files are named after the local file, which also hints the type
+	_, err := runSCP(t, conn, "scp -t .", "C0644 13 main.go\npackage main\n\x00")
+	require.NoError(t, err)
+
+	file, err := database.Files.FindByName(t.Context(), ownerOf(t, database, "main.go"), "main.go")
+	require.NoError(t, err)
+	require.NotNil(t, file)
+	assert.Equal(t, "go", file.Type)
+
+	// unless the target names one
+	_, err = runSCP(t, conn, "scp -t ~/notes", "C0644 6 main.go\nhello\n\x00")
+	require.NoError(t, err)
+
+	notes, err := database.Files.FindByName(t.Context(), file.UserID, "notes")
+	require.NoError(t, err)
+	require.NotNil(t, notes)
+
+	// copying over a file replaces its content
+	_, err = runSCP(t, conn, "scp -t .", "C0644 14 main.go\npackage main2\n\x00")
+	require.NoError(t, err)
+
+	updated, err := database.Files.FindByName(t.Context(), file.UserID, "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, file.ID, updated.ID)
+
+	out, err := runSCP(t, conn, "scp -f main.go", "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "C0644 14 main.go\npackage main2\n\x00")
+
+	out, err = runSCP(t, conn, "scp -f .revisions/main.go/0", "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "C0444 13 0\npackage main\n\x00")
+}
+
+func TestSCP_Errors(t *testing.T) {
+	conn, _ := dialTestServer(t)
+
+	_, err := runSCP(t, conn, "scp -f missing", "")
+	assert.Error(t, err)
+
+	_, err = runSCP(t, conn, "scp -r -t .", "D0755 0 dir\nE\n")
+	assert.Error(t, err)
+
+	_, err = runSCP(t, conn, "scp -t .", "C0644 2048 big.txt\n"+strings.Repeat("a", 2048)+"\x00")
+	assert.Error(t, err)
+
+	_, err = runSCP(t, conn, "scp -t .revisions", "C0644 5 a.txt\nhello\x00")
+	assert.Error(t, err)
+}
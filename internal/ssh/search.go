@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// Search handles the `search <terms>` command, listing the user's files whose
+// name or content contains every term.
+func (h *SessionHandler) Search(sesh *UserSession) {
+	query := strings.Join(sesh.Command()[1:], " ")
+	if len(db.SearchTerms(query)) == 0 {
+		sesh.Error(ErrSearchTermsRequired, "Unable to search", "Provide some words to search for, e.g.: %s nginx config", SearchCommand)
+		return
+	}
+
+	files, err := h.DB.Files.Search(sesh.Context(), sesh.UserID(), query)
+	if err != nil {
+		sesh.Error(err, "Unable to search", "There was an error searching your files. Please try again.")
+		return
+	}
+
+	if len(files) == 0 {
+		noti := Notification{
+			Color: styles.Colors.Yellow,
+			Title: "No Matches ℹ️",
+			WithStyle: func(s *lipgloss.Style) {
+				s.MarginTop(1)
+			},
+		}
+		noti.Messagef("None of your files contain %q.", query)
+		noti.Render(sesh)
+		return
+	}
+
+	var table strings.Builder
+	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(tabs, "ID\tNAME\tTYPE\tSIZE\tUPDATED")
+	for _, file := range files {
+		name := file.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\t%s\n", file.ID, name, strings.ToLower(file.Type), humanize.Bytes(file.Size), file.UpdatedAt.UTC().Format(time.RFC3339))
+	}
+	if err := tabs.Flush(); err != nil {
+		sesh.Error(err, "Unable to search", "There was an error searching your files. Please try again.")
+		return
+	}
+
+	_, _ = fmt.Fprint(sesh, table.String())
+}
@@ -5,47 +5,62 @@ import (
 	"github.com/charmbracelet/ssh"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 )
 
 type Service struct {
 	*ssh.Server
 }
 
-func New(cfg *config.Config, db *db.DB) (*Service, error) {
+func New(cfg *config.Config, db *db.DB, hub *events.Hub) (*Service, error) {
 	sessionHandler := &SessionHandler{
 		Config: cfg,
 		DB:     db,
+		Events: hub,
 	}
 
 	authorizedKeys, err := cfg.SSHAuthorizedKeys()
 	if err != nil {
 		return nil, err
 	}
+	trustedUserCAKeys, err := cfg.SSHTrustedUserCAKeys()
+	if err != nil {
+		return nil, err
+	}
+	certs := NewUserCertificates(trustedUserCAKeys)
+
 	hostKeyOption := wish.WithHostKeyPEM([]byte(cfg.SSH.HostKey))
 	if cfg.SSH.HostKey == "" {
 		hostKeyOption = wish.WithHostKeyPath(cfg.SSH.HostKeyPath)
 	}
 
+	// note: middleware is evaluated in reverse order
+	middleware := []wish.Middleware{
+		AssignUser(db, cfg.HTTP.External, certs),
+		WithRateLimit(ratelimit.New(cfg.RateLimit.Fingerprint, cfg.RateLimit.FingerprintBurst), certs),
+		WithAuthorizedKeys(authorizedKeys, certs),
+		BlockIfNoPublicKey,
+		WithLogger,
+		WithRequestID,
+		WithSessionMetrics,
+	}
+
 	sshServer, err := wish.NewServer(
 		wish.WithAddress(cfg.SSH.Internal.Host),
 		hostKeyOption,
-		wish.WithPublicKeyAuth(func(_ ssh.Context, _ ssh.PublicKey) bool {
-			return true
+		wish.WithPublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
+			// certificates from an untrusted CA are refused here, so clients
+			// can fall back to their other keys
+			return certs.Authenticate(key, ctx.RemoteAddr())
 		}),
 		wish.WithPasswordAuth(func(_ ssh.Context, _ string) bool {
 			// accept pw auth so we can display a helpful message
 			return true
 		}),
-		// note: middleware is evaluated in reverse order
-		wish.WithMiddleware(
-			sessionHandler.HandleFunc,
-			AssignUser(db, cfg.HTTP.External),
-			WithAuthorizedKeys(authorizedKeys),
-			BlockIfNoPublicKey,
-			WithLogger,
-			WithRequestID,
-			WithSessionMetrics,
-		),
+		wish.WithMiddleware(append([]wish.Middleware{sessionHandler.HandleFunc}, middleware...)...),
+		// subsystems bypass the middleware above, so they're wrapped in it
+		wish.WithSubsystem("sftp", withMiddleware(sessionHandler.SFTP, middleware...)),
 	)
 	if err != nil {
 		return nil, err
@@ -53,3 +68,12 @@ func New(cfg *config.Config, db *db.DB) (*Service, error) {
 
 	return &Service{sshServer}, nil
 }
+
+// withMiddleware wraps a handler in middleware the way wish does, the last
+// middleware being the outermost.
+func withMiddleware(handler ssh.Handler, middleware ...wish.Middleware) ssh.SubsystemHandler {
+	for _, m := range middleware {
+		handler = m(handler)
+	}
+	return ssh.SubsystemHandler(handler)
+}
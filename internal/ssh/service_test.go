@@ -8,6 +8,7 @@ import (
 	dbmock "github.com/robherley/snips.sh/internal/db/mock"
 
 	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/ssh"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,7 +35,7 @@ func TestNew_HostKey(t *testing.T) {
 
 		database := dbmock.NewDB(t)
 
-		service, err := ssh.New(cfg, database.DB)
+		service, err := ssh.New(cfg, database.DB, events.NewHub())
 		require.NoError(t, err)
 
 		require.Len(t, service.HostSigners, 1)
@@ -47,7 +48,7 @@ func TestNew_HostKey(t *testing.T) {
 
 		database := dbmock.NewDB(t)
 
-		_, err := ssh.New(cfg, database.DB)
+		_, err := ssh.New(cfg, database.DB, events.NewHub())
 		assert.Error(t, err)
 	})
 
@@ -57,7 +58,7 @@ func TestNew_HostKey(t *testing.T) {
 
 		database := dbmock.NewDB(t)
 
-		service, err := ssh.New(cfg, database.DB)
+		service, err := ssh.New(cfg, database.DB, events.NewHub())
 		require.NoError(t, err)
 
 		require.Len(t, service.HostSigners, 1)
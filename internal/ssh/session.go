@@ -37,18 +37,42 @@ func (sesh *UserSession) IsContentUpdate() bool {
 	return strings.HasSuffix(sesh.User(), ":content")
 }
 
+// IsAppend reports whether content piped to f:<id>:append should be added to
+// the end of the file instead of replacing it.
+func (sesh *UserSession) IsAppend() bool {
+	return strings.HasSuffix(sesh.User(), ":append")
+}
+
+// IsRevisionRequest reports whether a file was requested at a revision, as in
+// f:<id>@<rev> (the ssh client splits the host off at the last @).
+func (sesh *UserSession) IsRevisionRequest() bool {
+	_, ok := sesh.RequestedRevision()
+	return ok
+}
+
+func (sesh *UserSession) RequestedRevision() (string, bool) {
+	_, rev, ok := strings.Cut(trimUserSuffix(sesh.User()), RevisionSeparator)
+	return rev, ok
+}
+
 func (sesh *UserSession) RequestedFileID() string {
-	id := strings.TrimPrefix(sesh.User(), FileRequestPrefix)
-	id = strings.TrimSuffix(id, ":content")
+	id := trimUserSuffix(strings.TrimPrefix(sesh.User(), FileRequestPrefix))
+	id, _, _ = strings.Cut(id, RevisionSeparator)
 	return id
 }
 
 func (sesh *UserSession) RequestedFileName() string {
-	name := strings.TrimPrefix(sesh.User(), NamedFileRequestPrefix)
-	name = strings.TrimSuffix(name, ":content")
+	name := trimUserSuffix(strings.TrimPrefix(sesh.User(), NamedFileRequestPrefix))
+	name, _, _ = strings.Cut(name, RevisionSeparator)
 	return name
 }
 
+// trimUserSuffix drops the :content or :append mode from a file request user.
+func trimUserSuffix(user string) string {
+	user = strings.TrimSuffix(user, ":content")
+	return strings.TrimSuffix(user, ":append")
+}
+
 func (sesh *UserSession) Error(err error, title string, f string, v ...interface{}) {
 	log := logger.From(sesh.Context())
 	log.Error(title, "err", err)
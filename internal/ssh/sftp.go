@@ -0,0 +1,637 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/charmbracelet/ssh"
+	"github.com/pkg/sftp"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/renderer"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// SFTPRevisionsDir is the read-only directory holding each file's past
+// revisions, as .revisions/<file>/<sequence>.
+const SFTPRevisionsDir = ".revisions"
+
+// SFTP serves the user's files as a virtual filesystem over the sftp
+// subsystem, so sshfs, scp and editors with remote-file support can browse and
+// edit them. Named files are listed by name and others by ID.
+func (h *SessionHandler) SFTP(sesh ssh.Session) {
+	userSesh := &UserSession{sesh}
+	log := logger.From(sesh.Context())
+
+	fs := &sftpFS{
+		h:       h,
+		ctx:     sesh.Context(),
+		userID:  userSesh.UserID(),
+		via:     "sftp",
+		pending: map[string]*sftpWriter{},
+	}
+
+	server := sftp.NewRequestServer(sesh, sftp.Handlers{
+		FileGet:  fs,
+		FilePut:  fs,
+		FileCmd:  fs,
+		FileList: fs,
+	})
+
+	metrics.IncrCounter([]string{"ssh", "sftp", "session"}, 1)
+	log.Info("sftp session started")
+
+	if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+		log.Warn("sftp session ended with error", "err", err)
+	}
+	_ = server.Close()
+}
+
+// sftpFS implements the sftp request handlers on top of the user's files. scp
+// shares it, so paths resolve the same way for both.
+type sftpFS struct {
+	h      *SessionHandler
+	ctx    context.Context
+	userID string
+	via    string // transport, for logging
+
+	mu      sync.Mutex
+	pending map[string]*sftpWriter // files being written, by path
+}
+
+// sftpPath is a parsed path in the virtual filesystem:
+//
+//	/                              the user's files
+//	/<entry>                       a file, by name or ID
+//	/.revisions                    a directory per file
+//	/.revisions/<entry>            a file's revisions
+//	/.revisions/<entry>/<sequence> a file's content at a revision
+type sftpPath struct {
+	entry     string
+	revisions bool
+	sequence  string
+}
+
+func (p sftpPath) isDir() bool {
+	return p.entry == "" || (p.revisions && p.sequence == "")
+}
+
+func parseSFTPPath(filepath string) (sftpPath, error) {
+	cleaned := strings.Trim(path.Clean("/"+filepath), "/")
+	if cleaned == "" {
+		return sftpPath{}, nil
+	}
+
+	parts := strings.Split(cleaned, "/")
+	if parts[0] != SFTPRevisionsDir {
+		if len(parts) > 1 {
+			return sftpPath{}, os.ErrNotExist
+		}
+		return sftpPath{entry: parts[0]}, nil
+	}
+
+	switch len(parts) {
+	case 1:
+		return sftpPath{revisions: true}, nil
+	case 2:
+		return sftpPath{revisions: true, entry: parts[1]}, nil
+	case 3:
+		return sftpPath{revisions: true, entry: parts[1], sequence: parts[2]}, nil
+	default:
+		return sftpPath{}, os.ErrNotExist
+	}
+}
+
+// entryName is how a file is listed: by name if it has one, otherwise by ID.
+func entryName(file *snips.File) string {
+	if file.Name != "" {
+		return file.Name
+	}
+	return file.ID
+}
+
+// listable reports whether a file is shown over sftp. Burn-after-read files
+// are left out, since merely browsing them would burn them.
+func listable(file *snips.File) bool {
+	return !file.BurnAfterRead && !file.IsExpired()
+}
+
+// find resolves an entry to one of the user's files, by name and then by ID.
+func (fs *sftpFS) find(entry string) (*snips.File, error) {
+	file, err := fs.h.DB.Files.FindByName(fs.ctx, fs.userID, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if file == nil {
+		file, err = fs.h.DB.Files.Find(fs.ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if file == nil || file.UserID != fs.userID || !listable(file) {
+		return nil, os.ErrNotExist
+	}
+
+	return file, nil
+}
+
+func (fs *sftpFS) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	p, err := parseSFTPPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	if p.isDir() {
+		return nil, os.ErrInvalid
+	}
+
+	_, content, err := fs.content(p)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.IncrCounter([]string{"ssh", "sftp", "read"}, 1)
+	return strings.NewReader(string(content)), nil
+}
+
+// content resolves a file path to its file and content, which is the content
+// at a revision for paths under the revisions directory.
+func (fs *sftpFS) content(p sftpPath) (*snips.File, []byte, error) {
+	file, err := fs.find(p.entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var content []byte
+	if p.revisions {
+		content, err = fs.revisionContent(file, p.sequence)
+	} else {
+		content, err = fs.h.DB.Files.FindContent(fs.ctx, file.ID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, content, nil
+}
+
+func (fs *sftpFS) revisionContent(file *snips.File, sequence string) ([]byte, error) {
+	seq, err := strconv.ParseInt(sequence, 10, 64)
+	if err != nil || seq < 0 {
+		return nil, os.ErrNotExist
+	}
+
+	content, _, err := files.ContentAt(fs.ctx, fs.h.DB, file, seq)
+	if errors.Is(err, files.ErrRevisionNotFound) {
+		return nil, os.ErrNotExist
+	}
+	return content, err
+}
+
+func (fs *sftpFS) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	p, err := parseSFTPPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	if p.revisions {
+		return nil, os.ErrPermission
+	}
+	if p.isDir() {
+		return nil, os.ErrInvalid
+	}
+
+	file, err := fs.find(p.entry)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	w := &sftpWriter{fs: fs, path: r.Filepath, entry: p.entry, file: file}
+
+	// without truncation, writes land on top of the existing content
+	if file != nil && !r.Pflags().Trunc {
+		w.content, err = fs.h.DB.Files.FindContent(fs.ctx, file.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if file == nil {
+		if _, err := snips.NormalizeName(p.entry); err != nil {
+			return nil, err
+		}
+	}
+
+	fs.mu.Lock()
+	fs.pending[r.Filepath] = w
+	fs.mu.Unlock()
+
+	return w, nil
+}
+
+func (fs *sftpFS) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat":
+		// modes and times aren't stored, but clients like scp set them after
+		// every upload, so accept it rather than fail the transfer
+		return nil
+	case "Remove":
+		return fs.remove(r.Filepath)
+	case "Rename":
+		return fs.rename(r.Filepath, r.Target)
+	default:
+		return sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (fs *sftpFS) remove(filepath string) error {
+	p, err := parseSFTPPath(filepath)
+	if err != nil {
+		return err
+	}
+	if p.revisions || p.isDir() {
+		return os.ErrPermission
+	}
+
+	file, err := fs.find(p.entry)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.h.DB.Files.Delete(fs.ctx, file.ID); err != nil {
+		return err
+	}
+
+	fs.h.Events.Publish(events.NewEvent(events.KindDelete, file))
+	metrics.IncrCounter([]string{"file", "delete"}, 1)
+	logger.From(fs.ctx).Info("file deleted", "file_id", file.ID, "via", fs.via)
+	return nil
+}
+
+// rename names a file. Renaming onto another file replaces that file's
+// content instead, keeping its ID and history, which is how editors that save
+// through a temporary file expect to overwrite it.
+func (fs *sftpFS) rename(from, to string) error {
+	src, err := parseSFTPPath(from)
+	if err != nil {
+		return err
+	}
+	dst, err := parseSFTPPath(to)
+	if err != nil {
+		return err
+	}
+	if src.revisions || dst.revisions || src.isDir() || dst.isDir() {
+		return os.ErrPermission
+	}
+
+	file, err := fs.find(src.entry)
+	if err != nil {
+		return err
+	}
+
+	target, err := fs.find(dst.entry)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if target != nil {
+		if target.ID == file.ID {
+			return nil
+		}
+
+		content, err := fs.h.DB.Files.FindContent(fs.ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		if err := fs.update(target, content, dst.entry); err != nil {
+			return err
+		}
+		return fs.remove(from)
+	}
+
+	name, err := snips.NormalizeName(dst.entry)
+	if err != nil {
+		return err
+	}
+
+	previous := file.Name
+	file.Name = name
+	if err := fs.h.DB.Files.Update(fs.ctx, file); err != nil {
+		file.Name = previous
+		return err
+	}
+
+	fs.h.Events.Publish(events.NewRenameEvent(file, previous))
+	metrics.IncrCounter([]string{"file", "rename"}, 1)
+	logger.From(fs.ctx).Info("file renamed", "file_id", file.ID, "name", file.Name, "via", fs.via)
+	return nil
+}
+
+func (fs *sftpFS) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	p, err := parseSFTPPath(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		if !p.isDir() {
+			return nil, os.ErrInvalid
+		}
+		return fs.list(p)
+	case "Stat":
+		info, err := fs.stat(r.Filepath, p)
+		if err != nil {
+			return nil, err
+		}
+		return sftpListerAt{info}, nil
+	default:
+		return nil, sftp.ErrSSHFxOpUnsupported
+	}
+}
+
+func (fs *sftpFS) list(p sftpPath) (sftp.ListerAt, error) {
+	if p.entry != "" {
+		file, err := fs.find(p.entry)
+		if err != nil {
+			return nil, err
+		}
+		return fs.listRevisions(file)
+	}
+
+	userFiles, err := fs.h.DB.Files.FindByUser(fs.ctx, fs.userID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []os.FileInfo{}
+	if !p.revisions {
+		infos = append(infos, dirInfo(SFTPRevisionsDir))
+	}
+	for _, file := range userFiles {
+		if !listable(file) {
+			continue
+		}
+		if p.revisions {
+			infos = append(infos, dirInfo(entryName(file)))
+		} else {
+			infos = append(infos, fileInfo(entryName(file), file))
+		}
+	}
+
+	return sftpListerAt(infos), nil
+}
+
+// listRevisions lists a file's kept revisions, plus the content from before
+// the oldest one. Revisions that can no longer be reconstructed are skipped.
+func (fs *sftpFS) listRevisions(file *snips.File) (sftp.ListerAt, error) {
+	revisions, err := fs.h.DB.Revisions.FindByFileID(fs.ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []os.FileInfo{}
+	if len(revisions) == 0 {
+		return sftpListerAt(infos), nil
+	}
+
+	oldest := revisions[len(revisions)-1]
+	if content, err := fs.revisionContent(file, strconv.FormatInt(oldest.Sequence-1, 10)); err == nil {
+		infos = append(infos, &sftpFileInfo{
+			name:    strconv.FormatInt(oldest.Sequence-1, 10),
+			size:    int64(len(content)),
+			mode:    0o444,
+			modTime: file.CreatedAt,
+		})
+	}
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		infos = append(infos, &sftpFileInfo{
+			name:    strconv.FormatInt(revision.Sequence, 10),
+			size:    int64(revision.Size),
+			mode:    0o444,
+			modTime: revision.CreatedAt,
+		})
+	}
+
+	return sftpListerAt(infos), nil
+}
+
+func (fs *sftpFS) stat(filepath string, p sftpPath) (os.FileInfo, error) {
+	if p.entry == "" {
+		return dirInfo(path.Base(path.Clean("/" + filepath))), nil
+	}
+
+	// files still being written exist to the writer, e.g. for an fstat
+	fs.mu.Lock()
+	w, pending := fs.pending[filepath]
+	fs.mu.Unlock()
+	if pending {
+		return w.info(), nil
+	}
+
+	file, err := fs.find(p.entry)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !p.revisions:
+		return fileInfo(p.entry, file), nil
+	case p.sequence == "":
+		return dirInfo(p.entry), nil
+	}
+
+	content, err := fs.revisionContent(file, p.sequence)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpFileInfo{name: p.sequence, size: int64(len(content)), mode: 0o444, modTime: file.UpdatedAt}, nil
+}
+
+// update replaces a file's content, as the SSH and HTTP frontends do. The
+// entry's extension, if any, hints the file type.
+func (fs *sftpFS) update(file *snips.File, content []byte, entry string) error {
+	if err := files.UpdateContent(fs.ctx, fs.h.DB, fs.h.Config, file, content, extensionOf(entry)); err != nil {
+		return err
+	}
+
+	fs.h.Events.Publish(events.NewEvent(events.KindUpdate, file))
+	metrics.IncrCounterWithLabels([]string{"file", "update"}, 1, []metrics.Label{
+		{Name: "type", Value: file.Type},
+	})
+	logger.From(fs.ctx).Info("file content updated",
+		"file_id", file.ID,
+		"user_id", file.UserID,
+		"size", file.Size,
+		"file_type", file.Type,
+		"via", fs.via,
+	)
+	return nil
+}
+
+// save writes content to a file, creating one named entry if file is nil.
+func (fs *sftpFS) save(file *snips.File, entry string, content []byte) error {
+	if file != nil {
+		return fs.update(file, content, entry)
+	}
+
+	if err := fs.create(entry, content); err != nil {
+		if errors.Is(err, db.ErrNameTaken) {
+			return fmt.Errorf("you already have a file named %q", entry)
+		}
+		return err
+	}
+	return nil
+}
+
+// create uploads a new file named entry.
+func (fs *sftpFS) create(entry string, content []byte) error {
+	name, err := snips.NormalizeName(entry)
+	if err != nil {
+		return err
+	}
+
+	file := &snips.File{
+		Size:   uint64(len(content)),
+		UserID: fs.userID,
+		Type:   renderer.DetectFileType(content, extensionOf(entry), fs.h.Config.EnableGuesser),
+		Name:   name,
+	}
+
+	if err := fs.h.DB.Files.Create(fs.ctx, file, content, fs.h.Config.Limits.FilesPerUser); err != nil {
+		return err
+	}
+
+	fs.h.Events.Publish(events.NewEvent(events.KindCreate, file))
+	metrics.IncrCounterWithLabels([]string{"file", "create"}, 1, []metrics.Label{
+		{Name: "private", Value: strconv.FormatBool(file.Private)},
+		{Name: "type", Value: file.Type},
+	})
+	logger.From(fs.ctx).Info("file uploaded",
+		"file_id", file.ID,
+		"user_id", file.UserID,
+		"size", file.Size,
+		"private", file.Private,
+		"file_type", file.Type,
+		"via", fs.via,
+	)
+	return nil
+}
+
+// extensionOf returns a name's extension without the dot, used as a type hint.
+func extensionOf(name string) string {
+	return strings.TrimPrefix(path.Ext(name), ".")
+}
+
+// sftpWriter buffers a file's content while it's written, saving it when the
+// handle is closed.
+type sftpWriter struct {
+	fs    *sftpFS
+	path  string
+	entry string
+	file  *snips.File // nil when creating a new file
+
+	mu      sync.Mutex
+	content []byte
+	written bool
+}
+
+func (w *sftpWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	end := off + int64(len(p))
+	if off < 0 {
+		return 0, os.ErrInvalid
+	}
+	if uint64(end) > w.fs.h.Config.Limits.FileSize {
+		return 0, fmt.Errorf("%w: max size is %d bytes", ErrFileTooLarge, w.fs.h.Config.Limits.FileSize)
+	}
+
+	if end > int64(len(w.content)) {
+		w.content = append(w.content, make([]byte, end-int64(len(w.content)))...)
+	}
+	w.written = true
+	return copy(w.content[off:], p), nil
+}
+
+func (w *sftpWriter) Close() error {
+	w.fs.mu.Lock()
+	delete(w.fs.pending, w.path)
+	w.fs.mu.Unlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// a handle opened on a file but never written to leaves it as it was
+	if w.file != nil && !w.written && len(w.content) > 0 {
+		return nil
+	}
+
+	if len(w.content) == 0 {
+		return ErrEmptyContent
+	}
+
+	return w.fs.save(w.file, w.entry, w.content)
+}
+
+func (w *sftpWriter) info() os.FileInfo {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return &sftpFileInfo{name: w.entry, size: int64(len(w.content)), mode: 0o644, modTime: time.Now()}
+}
+
+type sftpFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi *sftpFileInfo) Name() string       { return fi.name }
+func (fi *sftpFileInfo) Size() int64        { return fi.size }
+func (fi *sftpFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *sftpFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *sftpFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *sftpFileInfo) Sys() any           { return nil }
+
+func dirInfo(name string) os.FileInfo {
+	return &sftpFileInfo{name: name, mode: os.ModeDir | 0o755, modTime: time.Now()}
+}
+
+func fileInfo(name string, file *snips.File) os.FileInfo {
+	mode := os.FileMode(0o644)
+	if file.Private {
+		mode = 0o600
+	}
+	return &sftpFileInfo{name: name, size: int64(file.Size), mode: mode, modTime: file.UpdatedAt}
+}
+
+// sftpListerAt serves a fixed directory listing.
+type sftpListerAt []os.FileInfo
+
+func (l sftpListerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,197 @@
+package ssh_test
+
+import (
+	"io"
+	"os"
+	"sort"
+	"testing"
+
+	"charm.land/wish/v2/testsession"
+	"github.com/pkg/sftp"
+	"github.com/robherley/snips.sh/internal/db/dsn"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/ssh"
+	"github.com/robherley/snips.sh/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/testdata"
+)
+
+// newTestServer starts a service backed by a real database, for tests that
+// speak a protocol rather than drive a session.
+func newTestServer(t *testing.T) (string, *testutil.Database) {
+	t.Helper()
+
+	cfg := newTestConfig(t)
+	cfg.SSH.HostKey = string(testdata.PEMBytes["ed25519"])
+	cfg.Limits.FileSize = 1024
+	cfg.Limits.FilesPerUser = 10
+	cfg.Limits.RevisionsPerFile = 10
+
+	database := testutil.NewDatabase(t, dsn.SQLite, false)
+	service, err := ssh.New(cfg, database.DB, events.NewHub())
+	require.NoError(t, err)
+
+	return testsession.Listen(t, service.Server), database
+}
+
+func dialTestServer(t *testing.T) (*gossh.Client, *testutil.Database) {
+	t.Helper()
+
+	addr, database := newTestServer(t)
+	conn, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "testuser",
+		Auth:            []gossh.AuthMethod{testPrivateKeyAuth(privateKey)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(), //nolint:gosec
+		Timeout:         testTimeout,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn, database
+}
+
+func newSFTPClient(t *testing.T) (*sftp.Client, *testutil.Database) {
+	t.Helper()
+
+	conn, database := dialTestServer(t)
+	client, err := sftp.NewClient(conn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, database
+}
+
+func writeSFTPFile(t *testing.T, client *sftp.Client, name, content string) {
+	t.Helper()
+
+	f, err := client.Create(name)
+	require.NoError(t, err)
+	_, err = f.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func readSFTPFile(t *testing.T, client *sftp.Client, name string) string {
+	t.Helper()
+
+	f, err := client.Open(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func listSFTPDir(t *testing.T, client *sftp.Client, dir string) []string {
+	t.Helper()
+
+	infos, err := client.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSFTP(t *testing.T) {
+	client, database := newSFTPClient(t)
+
+	assert.Equal(t, []string{ssh.SFTPRevisionsDir}, listSFTPDir(t, client, "/"))
+
+	writeSFTPFile(t, client, "notes.md", "# hello\n")
+	assert.Equal(t, "# hello\n", readSFTPFile(t, client, "notes.md"))
+
+	info, err := client.Stat("/notes.md")
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), info.Size())
+	assert.False(t, info.IsDir())
+
+	// writing again records a revision
+	writeSFTPFile(t, client, "notes.md", "# hello\nworld\n")
+	assert.Equal(t, "# hello\nworld\n", readSFTPFile(t, client, "notes.md"))
+
+	assert.Equal(t, []string{"notes.md"}, listSFTPDir(t, client, "/"+ssh.SFTPRevisionsDir))
+	assert.Equal(t, []string{"0", "1"}, listSFTPDir(t, client, "/.revisions/notes.md"))
+	assert.Equal(t, "# hello\n", readSFTPFile(t, client, "/.revisions/notes.md/0"))
+	assert.Equal(t, "# hello\nworld\n", readSFTPFile(t, client, "/.revisions/notes.md/1"))
+
+	_, err = client.Create("/.revisions/notes.md/2")
+	assert.Error(t, err)
+
+	// unnamed files are listed by ID
+	unnamed := &snips.File{UserID: ownerOf(t, database, "notes.md"), Type: "plaintext"}
+	require.NoError(t, database.Files.Create(t.Context(), unnamed, []byte("unnamed"), 10))
+	assert.ElementsMatch(t, []string{ssh.SFTPRevisionsDir, "notes.md", unnamed.ID}, listSFTPDir(t, client, "/"))
+	assert.Equal(t, "unnamed", readSFTPFile(t, client, unnamed.ID))
+
+	require.NoError(t, client.Rename("/notes.md", "/readme.md"))
+	assert.ElementsMatch(t, []string{ssh.SFTPRevisionsDir, "readme.md", unnamed.ID}, listSFTPDir(t, client, "/"))
+
+	require.NoError(t, client.Remove("/readme.md"))
+	_, err = client.Stat("/readme.md")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSFTP_RenameOverwrites(t *testing.T) {
+	client, database := newSFTPClient(t)
+
+	writeSFTPFile(t, client, "config.yaml", "a: 1\n")
+	file, err := database.Files.FindByName(t.Context(), ownerOf(t, database, "config.yaml"), "config.yaml")
+	require.NoError(t, err)
+
+	// editors save through a temporary file renamed over the original
+	writeSFTPFile(t, client, "config.yaml.tmp", "a: 2\n")
+	require.NoError(t, client.Rename("config.yaml.tmp", "config.yaml"))
+
+	assert.Equal(t, []string{ssh.SFTPRevisionsDir, "config.yaml"}, listSFTPDir(t, client, "/"))
+	assert.Equal(t, "a: 2\n", readSFTPFile(t, client, "config.yaml"))
+
+	renamed, err := database.Files.FindByName(t.Context(), file.UserID, "config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, file.ID, renamed.ID)
+}
+
+func TestSFTP_Errors(t *testing.T) {
+	client, _ := newSFTPClient(t)
+
+	_, err := client.Open("/missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// names must be valid
+	f, err := client.Create("/.hidden")
+	if err == nil {
+		_ = f.Close()
+	}
+	assert.Error(t, err)
+
+	// nothing is written past the size limit
+	f, err = client.Create("/big.txt")
+	require.NoError(t, err)
+	_, err = f.Write(make([]byte, 2048))
+	assert.Error(t, err)
+	_ = f.Close()
+
+	// empty files aren't uploaded
+	f, err = client.Create("/empty.txt")
+	require.NoError(t, err)
+	assert.Error(t, f.Close())
+
+	assert.Error(t, client.Mkdir("/dir"))
+}
+
+// ownerOf returns the user that owns a named file, which the test ssh client
+// can't know up front since it's created on connect.
+func ownerOf(t *testing.T, database *testutil.Database, name string) string {
+	t.Helper()
+
+	var userID string
+	require.NoError(t, database.SQL.QueryRowContext(t.Context(), "SELECT user_id FROM files WHERE name = ?", name).Scan(&userID))
+	return userID
+}
@@ -0,0 +1,260 @@
+package ssh
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// WebhookLogSize is how many of a webhook's latest deliveries `webhook log`
+// lists.
+const WebhookLogSize = 20
+
+// Webhook dispatches the `webhook <create|ls|rm|log>` command for managing the
+// webhooks sent a user's file events.
+func (h *SessionHandler) Webhook(sesh *UserSession) {
+	args := sesh.Command()[1:]
+	if len(args) == 0 {
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Usage: %s <create|ls|rm|log>", WebhookCommand)
+		return
+	}
+
+	switch args[0] {
+	case "create":
+		h.CreateWebhook(sesh, args[1:])
+	case "ls":
+		h.ListWebhooks(sesh)
+	case "rm":
+		h.RemoveWebhook(sesh, args[1:])
+	case "log":
+		h.WebhookLog(sesh, args[1:])
+	default:
+		sesh.Error(ErrUnknownCommand, "Unknown command", "Unknown subcommand %q, expected <create|ls|rm|log>", args[0])
+	}
+}
+
+func (h *SessionHandler) CreateWebhook(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	flags := WebhookCreateFlags{}
+	if err := flags.Parse(sesh.Stderr(), args); err != nil {
+		switch {
+		case errors.Is(err, flag.ErrHelp):
+		case errors.Is(err, ErrFlagRequired):
+			sesh.Error(err, "Unable to create webhook", "A url is required, e.g.: webhook create -url https://example.com/hook")
+		default:
+			log.Warn("invalid user specified flags", "err", err)
+			sesh.Error(err, "Error parsing flag", "Error: %q", err.Error())
+		}
+		return
+	}
+
+	url, err := snips.NormalizeWebhookURL(flags.URL)
+	if err != nil {
+		sesh.Error(err, "Unable to create webhook", "%s", err.Error())
+		return
+	}
+
+	events, err := snips.NormalizeWebhookEvents(flags.Events)
+	if err != nil {
+		sesh.Error(err, "Unable to create webhook", "%s", err.Error())
+		return
+	}
+
+	secret, err := snips.NewWebhookSecret()
+	if err != nil {
+		sesh.Error(err, "Unable to create webhook", "There was an error creating the webhook. Please try again.")
+		return
+	}
+
+	webhook := &snips.Webhook{
+		UserID: sesh.UserID(),
+		URL:    url,
+		Events: events,
+		Secret: secret,
+	}
+
+	if err := h.DB.Webhooks.Create(sesh.Context(), webhook, h.Config.Limits.WebhooksPerUser); err != nil {
+		if errors.Is(err, db.ErrWebhookLimit) {
+			sesh.Error(err, "Unable to create webhook", "You already have the maximum of %d webhooks. Remove one with: webhook rm <id>", h.Config.Limits.WebhooksPerUser)
+			return
+		}
+		sesh.Error(err, "Unable to create webhook", "There was an error creating the webhook. Please try again.")
+		return
+	}
+
+	metrics.IncrCounter([]string{"webhook", "create"}, 1)
+	log.Info("webhook created", "webhook_id", webhook.ID, "user_id", webhook.UserID)
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "Webhook Created 🪝",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("id: %s\nurl: %s\nevents: %s",
+		styles.C(styles.Colors.White, webhook.ID),
+		styles.C(styles.Colors.White, webhook.URL),
+		styles.C(styles.Colors.White, strings.Join(webhook.Events, ", ")),
+	)
+	noti.Render(sesh)
+
+	noti = Notification{
+		Color:   styles.Colors.Blue,
+		Title:   "Signing Secret 🔐",
+		Message: styles.C(styles.Colors.Yellow, secret),
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Render(sesh)
+
+	noti = Notification{
+		Color:   styles.Colors.Red,
+		Title:   "Heads Up ⚠️",
+		Message: "Save this secret now, it will not be shown again.\nUse it to verify the X-Snips-Signature header of each delivery.",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Render(sesh)
+}
+
+func (h *SessionHandler) ListWebhooks(sesh *UserSession) {
+	webhooks, err := h.DB.Webhooks.FindByUser(sesh.Context(), sesh.UserID())
+	if err != nil {
+		sesh.Error(err, "Unable to list webhooks", "There was an error listing your webhooks. Please try again.")
+		return
+	}
+
+	if len(webhooks) == 0 {
+		noti := Notification{
+			Color:   styles.Colors.Yellow,
+			Title:   "No Webhooks ℹ️",
+			Message: "Create one with: webhook create -url <url>",
+			WithStyle: func(s *lipgloss.Style) {
+				s.MarginTop(1)
+			},
+		}
+		noti.Render(sesh)
+		return
+	}
+
+	var table strings.Builder
+	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(tabs, "ID\tURL\tEVENTS\tCREATED")
+	for _, webhook := range webhooks {
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\n", webhook.ID, webhook.URL, strings.Join(webhook.Events, ","), webhook.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	if err := tabs.Flush(); err != nil {
+		sesh.Error(err, "Unable to list webhooks", "There was an error listing your webhooks. Please try again.")
+		return
+	}
+
+	_, _ = fmt.Fprint(sesh, table.String())
+}
+
+func (h *SessionHandler) RemoveWebhook(sesh *UserSession, args []string) {
+	log := logger.From(sesh.Context())
+
+	if len(args) == 0 || args[0] == "" {
+		sesh.Error(ErrWebhookIDRequired, "Unable to remove webhook", "Provide a webhook, e.g.: webhook rm <id> (list webhooks with: webhook ls)")
+		return
+	}
+
+	deleted, err := h.DB.Webhooks.Delete(sesh.Context(), args[0], sesh.UserID())
+	if err != nil {
+		sesh.Error(err, "Unable to remove webhook", "There was an error removing webhook: %q", args[0])
+		return
+	}
+	if !deleted {
+		sesh.Error(ErrWebhookNotFound, "Unable to remove webhook", "Webhook not found: %q", args[0])
+		return
+	}
+
+	metrics.IncrCounter([]string{"webhook", "delete"}, 1)
+	log.Info("webhook deleted", "webhook_id", args[0], "user_id", sesh.UserID())
+
+	noti := Notification{
+		Color: styles.Colors.Green,
+		Title: "Webhook Removed 🗑️",
+		WithStyle: func(s *lipgloss.Style) {
+			s.MarginTop(1)
+		},
+	}
+	noti.Messagef("Removed webhook: %q", args[0])
+	noti.Render(sesh)
+}
+
+// WebhookLog lists a webhook's latest deliveries, newest first.
+func (h *SessionHandler) WebhookLog(sesh *UserSession, args []string) {
+	if len(args) == 0 || args[0] == "" {
+		sesh.Error(ErrWebhookIDRequired, "Unable to show webhook log", "Provide a webhook, e.g.: webhook log <id> (list webhooks with: webhook ls)")
+		return
+	}
+
+	webhook, err := h.DB.Webhooks.Find(sesh.Context(), args[0])
+	if err != nil {
+		sesh.Error(err, "Unable to show webhook log", "There was an error finding webhook: %q", args[0])
+		return
+	}
+	if webhook == nil || webhook.UserID != sesh.UserID() {
+		sesh.Error(ErrWebhookNotFound, "Unable to show webhook log", "Webhook not found: %q", args[0])
+		return
+	}
+
+	deliveries, err := h.DB.WebhookDeliveries.FindByWebhook(sesh.Context(), webhook.ID, WebhookLogSize)
+	if err != nil {
+		sesh.Error(err, "Unable to show webhook log", "There was an error listing deliveries for webhook: %q", args[0])
+		return
+	}
+
+	if len(deliveries) == 0 {
+		noti := Notification{
+			Color:   styles.Colors.Yellow,
+			Title:   "No Deliveries ℹ️",
+			Message: "Events are delivered as your files are created, updated, renamed and deleted.",
+			WithStyle: func(s *lipgloss.Style) {
+				s.MarginTop(1)
+			},
+		}
+		noti.Render(sesh)
+		return
+	}
+
+	var table strings.Builder
+	tabs := tabwriter.NewWriter(&table, 1, 0, 2, ' ', 0)
+	fmt.Fprintln(tabs, "DELIVERY\tEVENT\tFILE\tSTATUS\tATTEMPTS\tRESPONSE\tCREATED\tNEXT ATTEMPT")
+	for _, delivery := range deliveries {
+		response := "-"
+		switch {
+		case delivery.ResponseCode != 0:
+			response = fmt.Sprintf("%d", delivery.ResponseCode)
+		case delivery.Error != "":
+			response = delivery.Error
+		}
+		next := "-"
+		if delivery.NextAttemptAt != nil {
+			next = delivery.NextAttemptAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tabs, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", delivery.ID, delivery.Event, delivery.FileID,
+			delivery.Status, delivery.Attempts, response, delivery.CreatedAt.UTC().Format(time.RFC3339), next)
+	}
+	if err := tabs.Flush(); err != nil {
+		sesh.Error(err, "Unable to show webhook log", "There was an error listing deliveries for webhook: %q", args[0])
+		return
+	}
+
+	_, _ = fmt.Fprint(sesh, table.String())
+}
@@ -0,0 +1,29 @@
+package styles
+
+import "strings"
+
+// Diff colors a unified diff's lines by what they do.
+func Diff(diff string) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	rendered := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "\t", "    ")
+
+		color := Colors.White
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color = Colors.Muted
+		case strings.HasPrefix(line, "@@"):
+			color = Colors.Blue
+		case strings.HasPrefix(line, "+"):
+			color = Colors.Green
+		case strings.HasPrefix(line, "-"):
+			color = Colors.Red
+		}
+
+		rendered = append(rendered, C(color, line))
+	}
+
+	return strings.Join(rendered, "\n")
+}
@@ -12,6 +12,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
 	"github.com/robherley/snips.sh/internal/tui/msgs"
@@ -19,8 +20,10 @@ import (
 	"github.com/robherley/snips.sh/internal/tui/views"
 	"github.com/robherley/snips.sh/internal/tui/views/browser"
 	"github.com/robherley/snips.sh/internal/tui/views/code"
+	"github.com/robherley/snips.sh/internal/tui/views/editor"
 	"github.com/robherley/snips.sh/internal/tui/views/options"
 	"github.com/robherley/snips.sh/internal/tui/views/prompt"
+	"github.com/robherley/snips.sh/internal/tui/views/revisions"
 	"github.com/robherley/snips.sh/internal/tui/views/settings"
 )
 
@@ -41,7 +44,7 @@ type TUI struct {
 	theme  color.Color
 }
 
-func New(ctx context.Context, cfg *config.Config, width, height int, user *snips.User, fingerprint string, database *db.DB, files []*snips.File) TUI {
+func New(ctx context.Context, cfg *config.Config, width, height int, user *snips.User, fingerprint string, database *db.DB, hub *events.Hub, files []*snips.File) TUI {
 	t := TUI{
 		UserID:      user.ID,
 		Fingerprint: fingerprint,
@@ -60,11 +63,13 @@ func New(ctx context.Context, cfg *config.Config, width, height int, user *snips
 	t.theme = theme
 
 	t.models = []views.Model{
-		views.Browser:  browser.New(cfg, width, t.innerViewHeight(), files, theme),
-		views.Code:     code.New(width, t.innerViewHeight(), theme),
-		views.Options:  options.New(cfg, width, t.innerViewHeight(), theme),
-		views.Prompt:   prompt.New(ctx, cfg, database, width, t.innerViewHeight(), theme),
-		views.Settings: settings.New(ctx, cfg, width, t.innerViewHeight(), database, user, fingerprint),
+		views.Browser:   browser.New(ctx, cfg, database, user.ID, width, t.innerViewHeight(), files, theme),
+		views.Code:      code.New(width, t.innerViewHeight(), theme),
+		views.Options:   options.New(cfg, width, t.innerViewHeight(), theme),
+		views.Prompt:    prompt.New(ctx, cfg, database, hub, width, t.innerViewHeight(), theme),
+		views.Settings:  settings.New(ctx, cfg, width, t.innerViewHeight(), database, hub, user, fingerprint),
+		views.Editor:    editor.New(ctx, cfg, database, hub, width, t.innerViewHeight(), theme),
+		views.Revisions: revisions.New(ctx, database, width, t.innerViewHeight(), theme),
 	}
 
 	t.help.Styles = styles.Help
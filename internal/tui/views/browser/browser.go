@@ -1,6 +1,7 @@
 package browser
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 
@@ -9,6 +10,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
 	"github.com/robherley/snips.sh/internal/tui/msgs"
@@ -18,15 +20,21 @@ import (
 )
 
 type Browser struct {
+	ctx    context.Context
 	cfg    *config.Config
+	db     *db.DB
+	userID string
 	list   list.Model
+	marked map[string]bool // IDs of files marked for bulk actions
 	height int
 	width  int
 	theme  color.Color
 }
 
-func New(cfg *config.Config, width, height int, files []*snips.File, theme color.Color) Browser {
-	l := list.New(toItems(files), newItemDelegate(theme), width, height)
+func New(ctx context.Context, cfg *config.Config, database *db.DB, userID string, width, height int, files []*snips.File, theme color.Color) Browser {
+	marked := map[string]bool{}
+	l := list.New(toItems(files), newItemDelegate(theme, marked), width, height)
+	l.Filter = searchFilter(ctx, database, userID, files)
 	l.SetShowTitle(false)
 	l.SetShowHelp(false)
 	l.SetShowStatusBar(false)
@@ -38,8 +46,12 @@ func New(cfg *config.Config, width, height int, files []*snips.File, theme color
 	l.Paginator.InactiveDot = lipgloss.NewStyle().Foreground(styles.Colors.Muted).Render("▪")
 
 	return Browser{
+		ctx:    ctx,
 		cfg:    cfg,
+		db:     database,
+		userID: userID,
 		list:   l,
+		marked: marked,
 		width:  width,
 		height: height,
 		theme:  theme,
@@ -77,16 +89,33 @@ func (bwsr Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds.SelectFile(file.ID),
 				cmds.PushView(views.Code),
 			)
-		case "x":
-			file := bwsr.selectedFile()
-			if file == nil {
-				return bwsr, nil
+		case "space":
+			if file := bwsr.selectedFile(); file != nil {
+				bwsr.toggleMark(file)
+				bwsr.list.CursorDown()
 			}
-			return bwsr, tea.Batch(
-				cmds.SelectFile(file.ID),
-				prompt.SetPromptKindCmd(prompt.DeleteFile, ""),
-				cmds.PushView(views.Prompt),
-			)
+			return bwsr, nil
+		case "shift+down", "shift+up":
+			// extend the marks from the highlighted file in that direction
+			if file := bwsr.selectedFile(); file != nil {
+				bwsr.marked[file.ID] = true
+				if msg.String() == "shift+down" {
+					bwsr.list.CursorDown()
+				} else {
+					bwsr.list.CursorUp()
+				}
+				bwsr.marked[bwsr.selectedFile().ID] = true
+			}
+			return bwsr, nil
+		case "a":
+			bwsr.toggleMarkAll()
+			return bwsr, nil
+		case "x":
+			return bwsr, bwsr.openPrompt(prompt.DeleteFile, prompt.BulkDelete)
+		case "v":
+			return bwsr, bwsr.openPrompt(prompt.ChangeVisibility, prompt.BulkVisibility)
+		case "t":
+			return bwsr, bwsr.openPrompt(prompt.EditTags, prompt.BulkTags)
 		case "s":
 			file := bwsr.selectedFile()
 			if file == nil || !file.Private {
@@ -105,10 +134,12 @@ func (bwsr Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// and a gap above the help bar
 		bwsr.list.SetSize(msg.Width, max(msg.Height-2, 0))
 	case msgs.ReloadFiles:
+		bwsr.pruneMarks(msg.Files)
+		bwsr.list.Filter = searchFilter(bwsr.ctx, bwsr.db, bwsr.userID, msg.Files)
 		bwsr.list.SetItems(toItems(msg.Files))
 	case msgs.ThemeChanged:
 		bwsr.theme = msg.Color
-		bwsr.list.SetDelegate(newItemDelegate(msg.Color))
+		bwsr.list.SetDelegate(newItemDelegate(msg.Color, bwsr.marked))
 		bwsr.list.Paginator.ActiveDot = lipgloss.NewStyle().Foreground(msg.Color).Render("■")
 	}
 
@@ -168,6 +199,10 @@ func (bwsr Browser) statusBar() string {
 		status += fmt.Sprintf("  •  %d filtered", filtered)
 	}
 
+	if len(bwsr.marked) > 0 {
+		status += fmt.Sprintf("  •  %d marked", len(bwsr.marked))
+	}
+
 	pagination := ""
 	if bwsr.list.Paginator.TotalPages > 1 {
 		pagination = "  " + bwsr.list.Paginator.View()
@@ -196,3 +231,83 @@ func (bwsr Browser) selectedFile() *snips.File {
 	}
 	return item.file
 }
+
+// openPrompt opens the bulk prompt for the marked files, or the single file
+// prompt for the highlighted file when none are marked.
+func (bwsr Browser) openPrompt(single, bulk prompt.Kind) tea.Cmd {
+	if marked := bwsr.markedFiles(); len(marked) > 0 {
+		return tea.Batch(
+			prompt.SetBulkPromptKindCmd(bulk, marked),
+			cmds.PushView(views.Prompt),
+		)
+	}
+
+	file := bwsr.selectedFile()
+	if file == nil {
+		return nil
+	}
+	return tea.Batch(
+		cmds.SelectFile(file.ID),
+		prompt.SetPromptKindCmd(single, ""),
+		cmds.PushView(views.Prompt),
+	)
+}
+
+// markedFiles returns the marked files in list order.
+func (bwsr Browser) markedFiles() []*snips.File {
+	var files []*snips.File
+	for _, item := range bwsr.list.Items() {
+		if item, ok := item.(fileItem); ok && bwsr.marked[item.file.ID] {
+			files = append(files, item.file)
+		}
+	}
+	return files
+}
+
+func (bwsr Browser) toggleMark(file *snips.File) {
+	if bwsr.marked[file.ID] {
+		delete(bwsr.marked, file.ID)
+	} else {
+		bwsr.marked[file.ID] = true
+	}
+}
+
+// toggleMarkAll marks every visible file, or clears all marks if they're all
+// marked already.
+func (bwsr Browser) toggleMarkAll() {
+	visible := bwsr.list.VisibleItems()
+
+	all := len(visible) > 0
+	for _, item := range visible {
+		if item, ok := item.(fileItem); ok && !bwsr.marked[item.file.ID] {
+			all = false
+			break
+		}
+	}
+
+	if all {
+		clear(bwsr.marked)
+		return
+	}
+
+	for _, item := range visible {
+		if item, ok := item.(fileItem); ok {
+			bwsr.marked[item.file.ID] = true
+		}
+	}
+}
+
+// pruneMarks drops the marks of files that are gone, such as after a bulk
+// delete.
+func (bwsr Browser) pruneMarks(files []*snips.File) {
+	kept := make(map[string]bool, len(files))
+	for _, file := range files {
+		kept[file.ID] = true
+	}
+
+	for id := range bwsr.marked {
+		if !kept[id] {
+			delete(bwsr.marked, id)
+		}
+	}
+}
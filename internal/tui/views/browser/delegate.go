@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"charm.land/bubbles/v2/list"
 	tea "charm.land/bubbletea/v2"
@@ -30,16 +31,30 @@ func (i fileItem) Description() string {
 	if i.file.Private {
 		visibility = "private"
 	}
-	return strings.Join([]string{
+	parts := []string{
 		strings.ToLower(i.file.Type),
 		humanize.Bytes(i.file.Size),
 		humanize.Time(i.file.UpdatedAt),
 		visibility,
-	}, " · ")
+	}
+	if len(i.file.Tags) > 0 {
+		parts = append(parts, i.hashtags())
+	}
+	return strings.Join(parts, " · ")
 }
 
+// FilterValue is the display name and type, then the file's tags as hashtags
+// so "#go" filters to files tagged go.
 func (i fileItem) FilterValue() string {
-	return i.file.DisplayName() + " " + strings.ToLower(i.file.Type)
+	value := i.file.DisplayName() + " " + strings.ToLower(i.file.Type)
+	if len(i.file.Tags) > 0 {
+		value += " " + i.hashtags()
+	}
+	return value
+}
+
+func (i fileItem) hashtags() string {
+	return "#" + strings.Join(i.file.Tags, " #")
 }
 
 func toItems(files []*snips.File) []list.Item {
@@ -50,12 +65,16 @@ func toItems(files []*snips.File) []list.Item {
 	return items
 }
 
+// markPrefix is shown before the names of files marked for bulk actions.
+const markPrefix = "✓ "
+
 type fileDelegate struct {
 	styles list.DefaultItemStyles
 	theme  color.Color
+	marked map[string]bool // shared with the browser, which updates it
 }
 
-func newItemDelegate(theme color.Color) fileDelegate {
+func newItemDelegate(theme color.Color, marked map[string]bool) fileDelegate {
 	s := list.NewDefaultItemStyles(true)
 
 	s.NormalTitle = s.NormalTitle.Foreground(styles.Colors.Muted)
@@ -78,7 +97,7 @@ func newItemDelegate(theme color.Color) fileDelegate {
 
 	s.FilterMatch = lipgloss.NewStyle().Underline(true)
 
-	return fileDelegate{styles: s, theme: theme}
+	return fileDelegate{styles: s, theme: theme, marked: marked}
 }
 
 func (d fileDelegate) Height() int                             { return 2 }
@@ -103,6 +122,15 @@ func titleMatchIdx(matched []int, name string) []int {
 	return out
 }
 
+// shiftIdx moves match positions past a prefix of n runes.
+func shiftIdx(idx []int, n int) []int {
+	out := make([]int, len(idx))
+	for i, v := range idx {
+		out[i] = v + n
+	}
+	return out
+}
+
 // descTypeMatchIdx returns fuzzy-match positions translated to the type span at
 // the start of the description string.
 func descTypeMatchIdx(matched []int, name, typ string) []int {
@@ -144,6 +172,18 @@ func (d fileDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 	title := fileItem.Title()
 	desc := fileItem.Description()
 
+	marked := d.marked[fileItem.file.ID]
+	if marked {
+		title = markPrefix + title
+	}
+	titleIdx := func(matched []int) []int {
+		idx := titleMatchIdx(matched, fileItem.file.DisplayName())
+		if marked {
+			idx = shiftIdx(idx, utf8.RuneCountInString(markPrefix))
+		}
+		return idx
+	}
+
 	width := m.Width()
 	if width <= 0 {
 		return
@@ -172,7 +212,7 @@ func (d fileDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		title = ansi.Truncate(title, contentWidth, ellipsis)
 		if isFiltered {
 			unmatched := d.styles.SelectedTitle.Inline(true)
-			title = lipgloss.StyleRunes(title, titleMatchIdx(matchedRunes, fileItem.file.DisplayName()), d.matchHighlight(), unmatched)
+			title = lipgloss.StyleRunes(title, titleIdx(matchedRunes), d.matchHighlight(), unmatched)
 		}
 		hint := d.itemHint(fileItem.file)
 		gap := contentWidth - lipgloss.Width(title) - lipgloss.Width(hint)
@@ -196,10 +236,15 @@ func (d fileDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		desc = d.styles.SelectedDesc.Render(desc)
 
 	default:
+		titleStyle := d.styles.NormalTitle
+		if marked {
+			titleStyle = titleStyle.Foreground(styles.Colors.White)
+		}
+
 		title = ansi.Truncate(title, contentWidth, ellipsis)
 		if isFiltered {
-			unmatched := d.styles.NormalTitle.Inline(true)
-			title = lipgloss.StyleRunes(title, titleMatchIdx(matchedRunes, fileItem.file.DisplayName()), d.matchHighlight(), unmatched)
+			unmatched := titleStyle.Inline(true)
+			title = lipgloss.StyleRunes(title, titleIdx(matchedRunes), d.matchHighlight(), unmatched)
 		}
 		if isFiltered {
 			descIdx := descTypeMatchIdx(matchedRunes, fileItem.file.DisplayName(), fileItem.file.Type)
@@ -209,7 +254,7 @@ func (d fileDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 			}
 		}
 		desc = ansi.Truncate(desc, contentWidth, ellipsis)
-		title = d.styles.NormalTitle.Render(title)
+		title = titleStyle.Render(title)
 		desc = d.styles.NormalDesc.Render(desc)
 	}
 
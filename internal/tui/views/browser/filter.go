@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"context"
+	"log/slog"
+
+	"charm.land/bubbles/v2/list"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// searchFilter returns a list filter that fuzzy matches names, IDs and types
+// like the default filter, then appends any files whose content matches the
+// term. Content matches have no highlighted runes since the match isn't visible
+// in the list. files must be the slice the list's items were built from.
+func searchFilter(ctx context.Context, database *db.DB, userID string, files []*snips.File) list.FilterFunc {
+	return func(term string, targets []string) []list.Rank {
+		ranks := list.DefaultFilter(term, targets)
+		if database == nil || len(db.SearchTerms(term)) == 0 {
+			return ranks
+		}
+
+		matches, err := database.Files.Search(ctx, userID, term)
+		if err != nil {
+			slog.Warn("unable to search file content", "user", userID, "err", err)
+			return ranks
+		}
+
+		if len(matches) == 0 {
+			return ranks
+		}
+
+		matched := make(map[string]bool, len(matches))
+		for _, file := range matches {
+			matched[file.ID] = true
+		}
+
+		ranked := make(map[int]bool, len(ranks))
+		for _, rank := range ranks {
+			ranked[rank.Index] = true
+		}
+
+		for i, target := range targets {
+			// the list may have been reloaded since this filter was built
+			if i >= len(files) || target != (fileItem{file: files[i]}).FilterValue() {
+				break
+			}
+
+			if matched[files[i].ID] && !ranked[i] {
+				ranks = append(ranks, list.Rank{Index: i})
+			}
+		}
+
+		return ranks
+	}
+}
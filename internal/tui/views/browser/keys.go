@@ -3,30 +3,37 @@ package browser
 import "charm.land/bubbles/v2/key"
 
 type keyMap struct {
-	Up       key.Binding
-	Down     key.Binding
-	Left     key.Binding
-	Right    key.Binding
-	Filter   key.Binding
-	Enter    key.Binding
-	Tab      key.Binding
-	Delete   key.Binding
-	Sign     key.Binding
-	Settings key.Binding
-	Help     key.Binding
-	Quit     key.Binding
+	Up         key.Binding
+	Down       key.Binding
+	Left       key.Binding
+	Right      key.Binding
+	Filter     key.Binding
+	Enter      key.Binding
+	Tab        key.Binding
+	Delete     key.Binding
+	Sign       key.Binding
+	Mark       key.Binding
+	MarkRange  key.Binding
+	MarkAll    key.Binding
+	Visibility key.Binding
+	Tags       key.Binding
+	Settings   key.Binding
+	Help       key.Binding
+	Quit       key.Binding
 }
 
 // Sign and Delete are omitted here: they're hinted inline on the highlighted
 // file row instead, and remain in FullHelp.
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Enter, k.Tab, k.Filter, k.Settings, k.Help, k.Quit}
+	return []key.Binding{k.Enter, k.Tab, k.Filter, k.Mark, k.Settings, k.Help, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right},
-		{k.Enter, k.Tab, k.Filter, k.Sign, k.Delete, k.Settings, k.Help, k.Quit},
+		{k.Mark, k.MarkRange, k.MarkAll},
+		{k.Delete, k.Visibility, k.Tags},
+		{k.Enter, k.Tab, k.Filter, k.Sign, k.Settings, k.Help, k.Quit},
 	}
 }
 
@@ -67,6 +74,26 @@ var keys = keyMap{
 		key.WithKeys("s"),
 		key.WithHelp("s", "sign url"),
 	),
+	Mark: key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "mark"),
+	),
+	MarkRange: key.NewBinding(
+		key.WithKeys("shift+up", "shift+down"),
+		key.WithHelp("shift+↑/↓", "mark range"),
+	),
+	MarkAll: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "mark all"),
+	),
+	Visibility: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "visibility"),
+	),
+	Tags: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "tags"),
+	),
 	Settings: key.NewBinding(
 		key.WithKeys("ctrl+p"),
 		key.WithHelp("ctrl+p", "settings"),
@@ -14,8 +14,10 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/robherley/snips.sh/internal/renderer"
 	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/cmds"
 	"github.com/robherley/snips.sh/internal/tui/msgs"
 	"github.com/robherley/snips.sh/internal/tui/styles"
+	"github.com/robherley/snips.sh/internal/tui/views"
 )
 
 type Code struct {
@@ -51,6 +53,11 @@ func (m Code) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Bottom):
 			m.viewport.GotoBottom()
 			return m, nil
+		case key.Matches(msg, keys.Edit):
+			if m.file == nil {
+				return m, nil
+			}
+			return m, cmds.PushView(views.Editor)
 		}
 	case tea.WindowSizeMsg:
 		w, h := frameFit(msg.Width, msg.Height)
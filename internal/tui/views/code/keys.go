@@ -7,6 +7,7 @@ type keyMap struct {
 	Down     key.Binding
 	Top      key.Binding
 	Bottom   key.Binding
+	Edit     key.Binding
 	Escape   key.Binding
 	Settings key.Binding
 	Help     key.Binding
@@ -14,12 +15,13 @@ type keyMap struct {
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Escape, k.Up, k.Down}
+	return []key.Binding{k.Help, k.Escape, k.Up, k.Down, k.Edit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Top, k.Bottom},
+		{k.Edit},
 		{k.Help, k.Escape, k.Settings, k.Quit},
 	}
 }
@@ -41,6 +43,10 @@ var keys = keyMap{
 		key.WithKeys("G", "shift+g", "end"),
 		key.WithHelp("G", "go to bottom"),
 	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
+	),
 	Escape: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "go back"),
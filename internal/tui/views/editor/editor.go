@@ -0,0 +1,351 @@
+package editor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textarea"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/cmds"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+	"github.com/robherley/snips.sh/internal/tui/msgs"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+	"github.com/robherley/snips.sh/internal/tui/views"
+)
+
+// tabPlaceholder stands in for tabs while editing: the textarea expands typed
+// and pasted tabs to spaces, which would rewrite a file's indentation.
+const tabPlaceholder = "⇥"
+
+// maxLines is the most lines the textarea holds, files with more can't be
+// edited in it.
+const maxLines = 9999
+
+type state int
+
+const (
+	closed state = iota
+	editing
+	previewing
+	// unavailable shows why the file can't be edited, instead of an editor
+	unavailable
+)
+
+// Editor edits the content of the selected file in a textarea, then previews
+// the changes as a diff before saving them as a new revision.
+type Editor struct {
+	ctx    context.Context
+	cfg    *config.Config
+	db     *db.DB
+	events *events.Hub
+	width  int
+	height int
+	theme  color.Color
+
+	file     *snips.File
+	content  []byte // as loaded, what the preview diffs against
+	edited   []byte
+	state    state
+	textarea textarea.Model
+	diff     viewport.Model
+	feedback feedback.Feedback
+}
+
+func New(ctx context.Context, cfg *config.Config, database *db.DB, hub *events.Hub, width, height int, theme color.Color) Editor {
+	ta := textarea.New()
+	ta.Prompt = ""
+	ta.MaxHeight = maxLines
+	ta.MaxWidth = 0
+	ta.SetStyles(textareaStyles(theme))
+
+	e := Editor{
+		ctx:      ctx,
+		cfg:      cfg,
+		db:       database,
+		events:   hub,
+		width:    width,
+		height:   height,
+		theme:    theme,
+		textarea: ta,
+		diff:     viewport.New(),
+	}
+	e.resize()
+	return e
+}
+
+// frameFit is the space inside the frame, less a row for the status line.
+func frameFit(width, height int) (int, int) {
+	return max(width-2, 0), max(height-5, 0)
+}
+
+func textareaStyles(theme color.Color) textarea.Styles {
+	s := textarea.DefaultDarkStyles()
+	s.Focused.LineNumber = lipgloss.NewStyle().Foreground(styles.Colors.Muted)
+	s.Focused.CursorLineNumber = lipgloss.NewStyle().Foreground(theme)
+	s.Focused.CursorLine = lipgloss.NewStyle()
+	s.Focused.Text = lipgloss.NewStyle().Foreground(styles.Colors.White)
+	s.Blurred = s.Focused
+	s.Cursor.Color = theme
+	return s
+}
+
+func (e *Editor) resize() {
+	w, h := frameFit(e.width, e.height)
+	e.textarea.SetWidth(w)
+	e.textarea.SetHeight(h)
+	e.diff.SetWidth(w)
+	e.diff.SetHeight(h)
+}
+
+func (e Editor) Init() tea.Cmd {
+	return nil
+}
+
+func (e Editor) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		keys := newKeyMap(e.state)
+		switch e.state {
+		case editing:
+			e.feedback = feedback.Feedback{}
+			switch {
+			case key.Matches(msg, keys.Cancel):
+				return e, cmds.PopView()
+			case key.Matches(msg, keys.Preview):
+				e.preview()
+				return e, nil
+			case msg.Code == tea.KeyTab:
+				e.textarea.InsertString(tabPlaceholder)
+				return e, nil
+			}
+		case previewing:
+			switch {
+			case key.Matches(msg, keys.Escape):
+				e.state = editing
+				e.feedback = feedback.Feedback{}
+				return e, e.textarea.Focus()
+			case key.Matches(msg, keys.Save):
+				return e, e.save()
+			}
+
+			var cmd tea.Cmd
+			e.diff, cmd = e.diff.Update(msg)
+			return e, cmd
+		}
+	case tea.WindowSizeMsg:
+		e.width, e.height = msg.Width, msg.Height
+		e.resize()
+		return e, nil
+	case msgs.FileLoaded:
+		e.file = msg.File
+		e.content = msg.Content
+		return e, nil
+	case msgs.FileDeselected:
+		e.file = nil
+		e.content = nil
+		return e, nil
+	case msgs.PushView:
+		if msg.View == views.Editor {
+			return e, e.open()
+		}
+		return e, nil
+	case msgs.ThemeChanged:
+		e.theme = msg.Color
+		e.textarea.SetStyles(textareaStyles(msg.Color))
+		return e, nil
+	}
+
+	// keystrokes, pastes and cursor blinks belong to the textarea
+	if e.state != editing {
+		return e, nil
+	}
+
+	var cmd tea.Cmd
+	e.textarea, cmd = e.textarea.Update(msg)
+	return e, cmd
+}
+
+// open starts editing the loaded file, unless it holds content the textarea
+// can't give back unchanged.
+func (e *Editor) open() tea.Cmd {
+	e.edited = nil
+	e.feedback = feedback.Feedback{}
+	e.diff.SetContent("")
+	e.textarea.Blur()
+
+	switch {
+	case e.file == nil:
+		e.state = closed
+		return nil
+	case e.file.IsBinary() || e.file.IsBundle():
+		e.state = unavailable
+		e.feedback = feedback.Error("Binary files and bundles can't be edited here, upload a new version over ssh instead.")
+		return nil
+	}
+
+	e.textarea.SetValue(strings.ReplaceAll(string(e.content), "\t", tabPlaceholder))
+	if e.value() != string(e.content) {
+		// carriage returns, control characters, invalid utf-8 and the like
+		// are dropped by the textarea, as are lines past maxLines
+		e.state = unavailable
+		e.feedback = feedback.Error("This file has content the editor can't preserve, upload a new version over ssh instead.")
+		return nil
+	}
+
+	e.textarea.MoveToBegin()
+	e.state = editing
+	return e.textarea.Focus()
+}
+
+// value is the textarea's content with tabs put back.
+func (e Editor) value() string {
+	return strings.ReplaceAll(e.textarea.Value(), tabPlaceholder, "\t")
+}
+
+// preview diffs the edited content against the loaded content, moving on to
+// confirm the save if there's anything to save.
+func (e *Editor) preview() {
+	edited := []byte(e.value())
+
+	if len(edited) == 0 {
+		e.feedback = feedback.Error("File content can't be empty.")
+		return
+	}
+
+	if limit := e.cfg.Limits.FileSize; uint64(len(edited)) > limit {
+		e.feedback = feedback.Error(fmt.Sprintf("File too large, max size is %s.", humanize.Bytes(limit)))
+		return
+	}
+
+	diff, err := files.Preview(e.file, e.content, edited)
+	if err != nil {
+		e.feedback = feedback.Error(fmt.Sprintf("Unable to diff changes: %s", err.Error()))
+		return
+	}
+
+	if diff == "" {
+		e.feedback = feedback.Error("Nothing to save, the content is unchanged.")
+		return
+	}
+
+	e.edited = edited
+	e.diff.SetContent(styles.Diff(diff))
+	e.diff.GotoTop()
+	e.textarea.Blur()
+	e.state = previewing
+}
+
+// save writes the edited content as a new revision, as long as the file hasn't
+// changed since it was loaded.
+func (e *Editor) save() tea.Cmd {
+	file := e.file
+
+	err := files.UpdateContent(e.ctx, e.db, e.cfg, file, e.edited, file.Type, db.IfUpdatedAt(file.UpdatedAt))
+	if err != nil {
+		if errors.Is(err, db.ErrPreconditionFailed) {
+			e.feedback = feedback.Error(fmt.Sprintf("File %q changed since it was opened, reopen it to edit the latest content.", file.ID))
+		} else {
+			e.feedback = feedback.Error(fmt.Sprintf("Unable to save file: %s", err.Error()))
+		}
+		return nil
+	}
+
+	e.events.Publish(events.NewEvent(events.KindUpdate, file))
+	metrics.IncrCounterWithLabels([]string{"file", "update"}, 1, []metrics.Label{
+		{Name: "type", Value: file.Type},
+	})
+
+	logger.From(e.ctx).Info("file content updated",
+		"file_id", file.ID,
+		"user_id", file.UserID,
+		"size", file.Size,
+		"file_type", file.Type,
+		"via", "tui",
+	)
+
+	e.state = closed
+	return tea.Batch(
+		cmds.LoadFile(e.db, file.ID),
+		cmds.ReloadFiles(e.db, file.UserID),
+		cmds.PopView(),
+	)
+}
+
+func (e Editor) View() tea.View {
+	w, h := frameFit(e.width, e.height)
+
+	var body string
+	switch e.state {
+	case editing:
+		body = e.textarea.View()
+	case previewing:
+		body = e.diff.View()
+	case unavailable:
+		body = lipgloss.NewStyle().Width(w).Padding(1, 2).Render(e.feedback.View())
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Top,
+		lipgloss.NewStyle().Width(w).Height(h).MaxHeight(h).Render(body),
+		lipgloss.NewStyle().Width(w).MaxHeight(1).Render(e.statusLine()),
+	)
+
+	return tea.NewView(styles.Frame(e.theme, e.titleRow(), content))
+}
+
+func (e Editor) Keys() help.KeyMap {
+	return newKeyMap(e.state)
+}
+
+func (e Editor) IsCapturing() bool {
+	// esc belongs to the editor while editing or previewing, to step back
+	// without leaving
+	return e.state == editing || e.state == previewing
+}
+
+func (e Editor) titleRow() string {
+	if e.file == nil {
+		return ""
+	}
+
+	action := "edit"
+	if e.state == previewing {
+		action = "preview"
+	}
+
+	return styles.BC(e.theme, action) + styles.C(styles.Colors.Muted, " · "+e.file.DisplayName())
+}
+
+func (e Editor) statusLine() string {
+	if e.state == unavailable {
+		// the feedback is the body
+		return ""
+	}
+
+	if !e.feedback.Empty() {
+		return " " + e.feedback.View()
+	}
+
+	switch e.state {
+	case editing:
+		return styles.C(styles.Colors.Muted, fmt.Sprintf(" %d:%d · %s", e.textarea.Line()+1, e.textarea.Column()+1, humanize.Bytes(uint64(len(e.value())))))
+	case previewing:
+		return styles.C(styles.Colors.Muted, " Save these changes as a new revision?")
+	}
+
+	return ""
+}
@@ -0,0 +1,82 @@
+package editor
+
+import "charm.land/bubbles/v2/key"
+
+type keyMap struct {
+	state state
+
+	Preview key.Binding
+	Save    key.Binding
+	Up      key.Binding
+	Down    key.Binding
+	Escape  key.Binding
+	Cancel  key.Binding
+	Help    key.Binding
+	Quit    key.Binding
+}
+
+func (km keyMap) ShortHelp() []key.Binding {
+	switch km.state {
+	case editing:
+		return []key.Binding{km.Cancel, km.Preview}
+	case previewing:
+		return []key.Binding{km.Escape, km.Save, km.Up, km.Down}
+	}
+	return []key.Binding{km.Help, km.Escape}
+}
+
+func (km keyMap) FullHelp() [][]key.Binding {
+	switch km.state {
+	case editing:
+		return [][]key.Binding{
+			{km.Cancel, km.Preview},
+		}
+	case previewing:
+		return [][]key.Binding{
+			{km.Up, km.Down},
+			{km.Escape, km.Save},
+		}
+	}
+	return [][]key.Binding{
+		{km.Escape},
+		{km.Help, km.Quit},
+	}
+}
+
+func newKeyMap(s state) keyMap {
+	return keyMap{
+		state: s,
+		Preview: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "preview changes"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("enter", "y"),
+			key.WithHelp("enter", "save"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "move up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "move down"),
+		),
+		Escape: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "go back"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "discard changes"),
+		),
+		Help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "help"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"charm.land/bubbles/v2/help"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/dustin/go-humanize"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/snips"
@@ -21,9 +22,13 @@ import (
 
 const Selector = "→ "
 
+// descriptionMaxWidth is how much of a description the details table shows.
+const descriptionMaxWidth = 48
+
 type option struct {
 	name   string
 	prompt prompt.Kind
+	view   views.Kind // opened instead, for options without a prompt
 	danger bool
 }
 
@@ -32,10 +37,22 @@ var options = []option{
 		name:   "rename file",
 		prompt: prompt.Rename,
 	},
+	{
+		name:   "edit description",
+		prompt: prompt.EditDescription,
+	},
+	{
+		name:   "edit tags",
+		prompt: prompt.EditTags,
+	},
 	{
 		name:   "edit extension",
 		prompt: prompt.ChangeExtension,
 	},
+	{
+		name: "view revisions",
+		view: views.Revisions,
+	},
 	{
 		name:   "generate signed url",
 		prompt: prompt.GenerateSignedURL,
@@ -59,8 +76,14 @@ func available(file *snips.File) []option {
 
 	var opts []option
 	for _, o := range options {
-		if file.IsBinary() && o.prompt == prompt.ChangeExtension {
-			// don't allow changing extension for binary files
+		if (file.IsBinary() || file.IsBundle()) && o.prompt == prompt.ChangeExtension {
+			// don't allow changing extension for binary files, or bundles
+			// (whose members are typed by their own names)
+			continue
+		}
+
+		if (file.IsBinary() || file.IsBundle()) && o.view == views.Revisions {
+			// binary files and bundles don't record revisions
 			continue
 		}
 
@@ -120,6 +143,9 @@ func (o Options) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if len(opts) == 0 {
 				return o, nil
 			}
+			if opts[o.index].prompt == prompt.None {
+				return o, cmds.PushView(opts[o.index].view)
+			}
 			return o, tea.Batch(
 				prompt.SetPromptKindCmd(opts[o.index].prompt, "options"),
 				cmds.PushView(views.Prompt),
@@ -201,9 +227,22 @@ func (o Options) renderDetails() string {
 		name = file.Name
 	}
 
+	description := styles.C(styles.Colors.Muted, "<none>")
+	if file.Description != "" {
+		// descriptions can be long, keep the modal a sensible width
+		description = ansi.Truncate(file.Description, descriptionMaxWidth, "…")
+	}
+
+	tags := styles.C(styles.Colors.Muted, "<none>")
+	if len(file.Tags) > 0 {
+		tags = strings.Join(file.Tags, ", ")
+	}
+
 	values := [][2]string{
 		{"id", file.ID},
 		{"name", name},
+		{"description", description},
+		{"tags", tags},
 		{"size", humanize.Bytes(file.Size)},
 		{"created", fmt.Sprintf("%s (%s)", file.CreatedAt.Format(time.RFC3339), humanize.Time(file.CreatedAt))},
 		{"modified", fmt.Sprintf("%s (%s)", file.UpdatedAt.Format(time.RFC3339), humanize.Time(file.UpdatedAt))},
@@ -0,0 +1,205 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/cmds"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+)
+
+// bulkDialog is the base for dialogs acting on every file marked in the
+// browser at once.
+type bulkDialog struct {
+	textDialog
+	files []*snips.File
+}
+
+func newBulkDialog(files []*snips.File) bulkDialog {
+	return bulkDialog{textDialog: newTextDialog(), files: files}
+}
+
+// count describes how many files the dialog acts on, e.g. "3 files".
+func (d *bulkDialog) count() string {
+	if len(d.files) == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", len(d.files))
+}
+
+// apply runs action on each file, carrying on past failures, and reports how
+// it went. done formats the report, given how many files were acted on.
+func (d *bulkDialog) apply(e env, done string, action func(file *snips.File) error) tea.Cmd {
+	var failed []error
+	for _, file := range d.files {
+		if err := action(file); err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", file.ID, err))
+		}
+	}
+
+	msg := feedback.Success(fmt.Sprintf(done, d.count()))
+	if len(failed) > 0 {
+		partial := fmt.Sprintf("%d of %s", len(d.files)-len(failed), d.count())
+		msg = feedback.Error(fmt.Sprintf(done, partial) + ", the rest failed:\n" + errors.Join(failed...).Error())
+	}
+	return tea.Batch(cmds.ReloadFiles(e.db, d.files[0].UserID), SetPromptFeedbackCmd(msg, true))
+}
+
+// bulkDeleteDialog deletes the marked files after the user types "delete" to
+// confirm.
+type bulkDeleteDialog struct {
+	bulkDialog
+}
+
+func newBulkDeleteDialog(files []*snips.File) *bulkDeleteDialog {
+	return &bulkDeleteDialog{newBulkDialog(files)}
+}
+
+func (d *bulkDeleteDialog) title() string {
+	return "delete files"
+}
+
+func (d *bulkDeleteDialog) question(*snips.File) string {
+	return fmt.Sprintf("Are you sure you want to delete %s?\nType \"delete\" to confirm.", d.count())
+}
+
+func (d *bulkDeleteDialog) submit(e env) tea.Cmd {
+	if d.value() != "delete" {
+		return SetPromptErrorCmd(errors.New("please type \"delete\" to confirm"))
+	}
+
+	return d.apply(e, "deleted %s", func(file *snips.File) error {
+		if err := e.db.Files.Delete(e.ctx, file.ID); err != nil {
+			return err
+		}
+		e.events.Publish(events.NewEvent(events.KindDelete, file))
+
+		metrics.IncrCounter([]string{"file", "delete"}, 1)
+		logger.From(e.ctx).Info("file deleted", "file_id", file.ID, "bulk", true)
+		return nil
+	})
+}
+
+// bulkVisibilityDialog makes the marked files public or private.
+type bulkVisibilityDialog struct {
+	bulkDialog
+}
+
+func newBulkVisibilityDialog(files []*snips.File) *bulkVisibilityDialog {
+	return &bulkVisibilityDialog{newBulkDialog(files)}
+}
+
+func (d *bulkVisibilityDialog) title() string {
+	return "change visibility"
+}
+
+func (d *bulkVisibilityDialog) question(*snips.File) string {
+	return fmt.Sprintf("Should %s be public or private?\n(public/private)", d.count())
+}
+
+func (d *bulkVisibilityDialog) submit(e env) tea.Cmd {
+	var private bool
+	switch strings.ToLower(strings.TrimSpace(d.value())) {
+	case "public":
+		private = false
+	case "private":
+		private = true
+	default:
+		return SetPromptErrorCmd(errors.New("please specify public or private"))
+	}
+
+	done := "made %s public"
+	if private {
+		done = "made %s private"
+	}
+
+	return d.apply(e, done, func(file *snips.File) error {
+		if file.Private == private {
+			return nil
+		}
+
+		file.Private = private
+		if err := e.db.Files.Update(e.ctx, file); err != nil {
+			file.Private = !private
+			return err
+		}
+
+		metrics.IncrCounterWithLabels([]string{"file", "change", "private"}, 1, []metrics.Label{
+			{Name: "new", Value: strconv.FormatBool(file.Private)},
+		})
+		logger.From(e.ctx).Info("updated file visibility", "file", file.ID, "private", file.Private, "bulk", true)
+		return nil
+	})
+}
+
+// bulkTagsDialog adds tags to, or removes tags from, the marked files.
+type bulkTagsDialog struct {
+	bulkDialog
+}
+
+func newBulkTagsDialog(files []*snips.File) *bulkTagsDialog {
+	d := &bulkTagsDialog{newBulkDialog(files)}
+	// room for every tag plus a "-" and a ", " separator
+	d.input.CharLimit = snips.TagsMaxCount * (snips.TagMaxLength + 3)
+	return d
+}
+
+func (d *bulkTagsDialog) title() string {
+	return "tag files"
+}
+
+func (d *bulkTagsDialog) question(*snips.File) string {
+	return fmt.Sprintf("What tags should %s get?\n(comma separated, prefix a tag with - to remove it)", d.count())
+}
+
+func (d *bulkTagsDialog) submit(e env) tea.Cmd {
+	var add, remove []string
+	for _, entry := range strings.FieldsFunc(d.value(), func(r rune) bool { return r == ',' || r == ' ' }) {
+		tag, removing := strings.CutPrefix(entry, "-")
+
+		tag, err := snips.NormalizeTag(tag)
+		if err != nil {
+			return SetPromptErrorCmd(err)
+		}
+
+		if removing {
+			remove = append(remove, tag)
+		} else {
+			add = append(add, tag)
+		}
+	}
+
+	if len(add) == 0 && len(remove) == 0 {
+		return SetPromptErrorCmd(errors.New("please specify tags to add or remove"))
+	}
+
+	return d.apply(e, "retagged %s", func(file *snips.File) error {
+		tags := slices.DeleteFunc(slices.Concat(file.Tags, add), func(tag string) bool {
+			return slices.Contains(remove, tag)
+		})
+
+		tags, err := snips.NormalizeTags(tags)
+		if err != nil {
+			return err
+		}
+
+		previous := file.Tags
+		file.Tags = tags
+		if err := e.db.Files.Update(e.ctx, file); err != nil {
+			file.Tags = previous
+			return err
+		}
+
+		metrics.IncrCounter([]string{"file", "tag"}, 1)
+		logger.From(e.ctx).Info("file tags updated", "file", file.ID, "tags", file.Tags, "bulk", true)
+		return nil
+	})
+}
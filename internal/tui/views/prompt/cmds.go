@@ -2,6 +2,7 @@ package prompt
 
 import (
 	tea "charm.land/bubbletea/v2"
+	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/feedback"
 )
 
@@ -16,6 +17,16 @@ func SetPromptKindCmd(pk Kind, breadcrumb string) tea.Cmd {
 	}
 }
 
+// SetBulkPromptKindCmd opens the dialog for a bulk kind, acting on files.
+func SetBulkPromptKindCmd(pk Kind, files []*snips.File) tea.Cmd {
+	return func() tea.Msg {
+		return KindSetMsg{
+			Kind:  pk,
+			Files: files,
+		}
+	}
+}
+
 func SetPromptFeedbackCmd(fb feedback.Feedback, finished bool) tea.Cmd {
 	return func() tea.Msg {
 		return FeedbackMsg{
@@ -6,6 +6,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
@@ -38,6 +39,7 @@ func (d *deleteDialog) submit(e env) tea.Cmd {
 		return SetPromptErrorCmd(err)
 	}
 
+	e.events.Publish(events.NewEvent(events.KindDelete, e.file))
 	metrics.IncrCounter([]string{"file", "delete"}, 1)
 	logger.From(e.ctx).Info("file deleted", "file_id", e.file.ID)
 
@@ -0,0 +1,64 @@
+package prompt
+
+import (
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/cmds"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+)
+
+// descriptionDialog sets or clears a file's description.
+type descriptionDialog struct {
+	textDialog
+}
+
+func newDescriptionDialog(width int) *descriptionDialog {
+	d := &descriptionDialog{newTextDialog()}
+	d.input.CharLimit = snips.DescriptionMaxLength
+	d.resize(width)
+	return d
+}
+
+func (d *descriptionDialog) title() string {
+	return "edit description"
+}
+
+func (d *descriptionDialog) question(file *snips.File) string {
+	if file.Description != "" {
+		return fmt.Sprintf("How would you describe %q? Currently: %q\n(submit empty to remove the description)", file.ID, file.Description)
+	}
+	return fmt.Sprintf("How would you describe %q?", file.ID)
+}
+
+func (d *descriptionDialog) resize(width int) {
+	// descriptions are sentences, so use the whole modal instead of the
+	// default name-sized input
+	d.input.SetWidth(max(width-4, 20))
+}
+
+func (d *descriptionDialog) submit(e env) tea.Cmd {
+	description, err := snips.NormalizeDescription(d.value())
+	if err != nil {
+		return SetPromptErrorCmd(err)
+	}
+
+	previous := e.file.Description
+	e.file.Description = description
+	if err := e.db.Files.Update(e.ctx, e.file); err != nil {
+		e.file.Description = previous
+		return SetPromptErrorCmd(err)
+	}
+
+	metrics.IncrCounter([]string{"file", "describe"}, 1)
+	logger.From(e.ctx).Info("file description updated", "file", e.file.ID)
+
+	msg := feedback.Success(fmt.Sprintf("file %q no longer has a description", e.file.ID))
+	if description != "" {
+		msg = feedback.Success(fmt.Sprintf("file %q is now described as %q", e.file.ID, description))
+	}
+	return tea.Batch(cmds.ReloadFiles(e.db, e.file.UserID), SetPromptFeedbackCmd(msg, true))
+}
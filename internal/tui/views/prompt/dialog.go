@@ -7,6 +7,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/styles"
 )
@@ -33,14 +34,16 @@ type dialog interface {
 
 // env is the shared context a dialog needs to perform its action.
 type env struct {
-	ctx  context.Context
-	cfg  *config.Config
-	db   *db.DB
-	file *snips.File
+	ctx    context.Context
+	cfg    *config.Config
+	db     *db.DB
+	events *events.Hub
+	file   *snips.File
 }
 
-// newDialog builds a fresh dialog for the kind, or nil for None.
-func newDialog(kind Kind, width int) dialog {
+// newDialog builds a fresh dialog for the kind, or nil for None. Bulk kinds
+// act on files instead of the selected file.
+func newDialog(kind Kind, width int, files []*snips.File) dialog {
 	switch kind {
 	case ChangeExtension:
 		return newExtensionDialog(width)
@@ -52,6 +55,16 @@ func newDialog(kind Kind, width int) dialog {
 		return newDeleteDialog()
 	case Rename:
 		return newRenameDialog()
+	case EditDescription:
+		return newDescriptionDialog(width)
+	case EditTags:
+		return newTagsDialog()
+	case BulkDelete:
+		return newBulkDeleteDialog(files)
+	case BulkVisibility:
+		return newBulkVisibilityDialog(files)
+	case BulkTags:
+		return newBulkTagsDialog(files)
 	default:
 		return nil
 	}
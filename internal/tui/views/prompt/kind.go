@@ -9,4 +9,9 @@ const (
 	GenerateSignedURL
 	DeleteFile
 	Rename
+	EditDescription
+	EditTags
+	BulkDelete
+	BulkVisibility
+	BulkTags
 )
@@ -1,10 +1,14 @@
 package prompt
 
-import "github.com/robherley/snips.sh/internal/tui/feedback"
+import (
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+)
 
 type KindSetMsg struct {
 	Kind       Kind
 	Breadcrumb string
+	Files      []*snips.File // for bulk kinds
 }
 
 type FeedbackMsg struct {
@@ -9,6 +9,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
 	"github.com/robherley/snips.sh/internal/tui/feedback"
@@ -22,11 +23,13 @@ type Prompt struct {
 	ctx    context.Context
 	cfg    *config.Config
 	db     *db.DB
+	events *events.Hub
 	width  int
 	height int
 	theme  color.Color
 
 	file       *snips.File
+	files      []*snips.File // marked files, for bulk dialogs
 	dialog     dialog
 	breadcrumb string
 	feedback   feedback.Feedback
@@ -39,11 +42,12 @@ func contentWidth(width int) int {
 	return max(styles.ModalMinWidth, min(width-10, 76))
 }
 
-func New(ctx context.Context, cfg *config.Config, db *db.DB, width, height int, theme color.Color) Prompt {
+func New(ctx context.Context, cfg *config.Config, db *db.DB, hub *events.Hub, width, height int, theme color.Color) Prompt {
 	return Prompt{
 		ctx:    ctx,
 		cfg:    cfg,
 		db:     db,
+		events: hub,
 		width:  width,
 		height: height,
 		theme:  theme,
@@ -69,7 +73,8 @@ func (p Prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return p, nil
 	case KindSetMsg:
 		// each open gets a fresh dialog, so no input state leaks between uses
-		p.dialog = newDialog(msg.Kind, contentWidth(p.width))
+		p.dialog = newDialog(msg.Kind, contentWidth(p.width), msg.Files)
+		p.files = msg.Files
 		p.breadcrumb = msg.Breadcrumb
 		if p.dialog != nil {
 			return p, p.dialog.init()
@@ -80,6 +85,7 @@ func (p Prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return p, nil
 	case msgs.PopView:
 		p.dialog = nil
+		p.files = nil
 		p.breadcrumb = ""
 		p.feedback = feedback.Feedback{}
 		p.finished = false
@@ -105,20 +111,21 @@ func (p Prompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (p Prompt) submit() tea.Cmd {
-	if p.finished || p.dialog == nil || p.file == nil {
+	if p.finished || p.dialog == nil || !p.hasTarget() {
 		return nil
 	}
 
 	return p.dialog.submit(env{
-		ctx:  p.ctx,
-		cfg:  p.cfg,
-		db:   p.db,
-		file: p.file,
+		ctx:    p.ctx,
+		cfg:    p.cfg,
+		db:     p.db,
+		events: p.events,
+		file:   p.file,
 	})
 }
 
 func (p Prompt) View() tea.View {
-	if p.file == nil || p.dialog == nil {
+	if !p.hasTarget() || p.dialog == nil {
 		return tea.NewView(lipgloss.Place(p.width, p.height, lipgloss.Left, lipgloss.Top, ""))
 	}
 
@@ -130,6 +137,11 @@ func (p Prompt) View() tea.View {
 	return tea.NewView(styles.ModalBody(p.theme, title, p.renderPrompt()))
 }
 
+// hasTarget reports whether there's a file, or marked files, to act on.
+func (p Prompt) hasTarget() bool {
+	return p.file != nil || len(p.files) > 0
+}
+
 func (p Prompt) Keys() help.KeyMap {
 	return newKeyMap(p.finished)
 }
@@ -8,6 +8,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"github.com/armon/go-metrics"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
@@ -56,6 +57,7 @@ func (d *renameDialog) submit(e env) tea.Cmd {
 		return SetPromptErrorCmd(err)
 	}
 
+	e.events.Publish(events.NewRenameEvent(e.file, previous))
 	metrics.IncrCounter([]string{"file", "rename"}, 1)
 	logger.From(e.ctx).Info("file renamed", "file", e.file.ID, "name", e.file.Name)
 
@@ -75,6 +77,7 @@ func (d *renameDialog) removeName(e env) tea.Cmd {
 		return SetPromptErrorCmd(err)
 	}
 
+	e.events.Publish(events.NewRenameEvent(e.file, old))
 	metrics.IncrCounter([]string{"file", "rename"}, 1)
 	logger.From(e.ctx).Info("file name removed", "file", e.file.ID, "old_name", old)
 
@@ -0,0 +1,59 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/cmds"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+)
+
+// tagsDialog replaces a file's tags.
+type tagsDialog struct {
+	textDialog
+}
+
+func newTagsDialog() *tagsDialog {
+	d := &tagsDialog{newTextDialog()}
+	// room for every tag plus a ", " separator
+	d.input.CharLimit = snips.TagsMaxCount * (snips.TagMaxLength + 2)
+	return d
+}
+
+func (d *tagsDialog) title() string {
+	return "edit tags"
+}
+
+func (d *tagsDialog) question(file *snips.File) string {
+	if len(file.Tags) > 0 {
+		return fmt.Sprintf("What should %q be tagged with? Currently: %s\n(comma separated, submit empty to remove all tags)", file.ID, strings.Join(file.Tags, ", "))
+	}
+	return fmt.Sprintf("What should %q be tagged with?\n(comma separated)", file.ID)
+}
+
+func (d *tagsDialog) submit(e env) tea.Cmd {
+	tags, err := snips.NormalizeTags([]string{d.value()})
+	if err != nil {
+		return SetPromptErrorCmd(err)
+	}
+
+	previous := e.file.Tags
+	e.file.Tags = tags
+	if err := e.db.Files.Update(e.ctx, e.file); err != nil {
+		e.file.Tags = previous
+		return SetPromptErrorCmd(err)
+	}
+
+	metrics.IncrCounter([]string{"file", "tag"}, 1)
+	logger.From(e.ctx).Info("file tags updated", "file", e.file.ID, "tags", e.file.Tags)
+
+	msg := feedback.Success(fmt.Sprintf("file %q is no longer tagged", e.file.ID))
+	if len(tags) > 0 {
+		msg = feedback.Success(fmt.Sprintf("file %q is now tagged %s", e.file.ID, strings.Join(tags, ", ")))
+	}
+	return tea.Batch(cmds.ReloadFiles(e.db, e.file.UserID), SetPromptFeedbackCmd(msg, true))
+}
@@ -0,0 +1,56 @@
+package revisions
+
+import "charm.land/bubbles/v2/key"
+
+type keyMap struct {
+	Newer    key.Binding
+	Older    key.Binding
+	PageDown key.Binding
+	PageUp   key.Binding
+	Escape   key.Binding
+	Help     key.Binding
+	Quit     key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Help, k.Escape, k.Newer, k.Older}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Newer, k.Older},
+		{k.PageDown, k.PageUp},
+		{k.Help, k.Escape, k.Quit},
+	}
+}
+
+var keys = keyMap{
+	Newer: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "newer"),
+	),
+	Older: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "older"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown", "space", "f"),
+		key.WithHelp("f/pgdn", "scroll diff down"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup", "b"),
+		key.WithHelp("b/pgup", "scroll diff up"),
+	),
+	Escape: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "go back"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
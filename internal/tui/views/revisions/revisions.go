@@ -0,0 +1,285 @@
+package revisions
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+	"github.com/robherley/snips.sh/internal/tui/msgs"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+	"github.com/robherley/snips.sh/internal/tui/views"
+)
+
+const selector = "→ "
+
+// loadedMsg carries a file's revisions, newest first.
+type loadedMsg struct {
+	fileID    string
+	revisions []*snips.Revision
+	err       error
+}
+
+// diffLoadedMsg carries the diff a revision recorded.
+type diffLoadedMsg struct {
+	revisionID string
+	diff       string
+	err        error
+}
+
+// Revisions lists the selected file's revisions above the diff of the
+// highlighted one, so moving through the list steps through its history.
+type Revisions struct {
+	ctx    context.Context
+	db     *db.DB
+	width  int
+	height int
+	theme  color.Color
+
+	file      *snips.File
+	revisions []*snips.Revision
+	loaded    bool
+	index     int
+	diffs     map[string]string // rendered diffs by revision ID
+	diff      viewport.Model
+	feedback  feedback.Feedback
+}
+
+func New(ctx context.Context, database *db.DB, width, height int, theme color.Color) Revisions {
+	vp := viewport.New()
+	// up and down step between revisions, so the diff only pages
+	vp.KeyMap.Up = key.NewBinding(key.WithDisabled())
+	vp.KeyMap.Down = key.NewBinding(key.WithDisabled())
+
+	m := Revisions{
+		ctx:    ctx,
+		db:     database,
+		width:  width,
+		height: height,
+		theme:  theme,
+		diffs:  map[string]string{},
+		diff:   vp,
+	}
+	m.resize()
+	return m
+}
+
+func frameFit(width, height int) (int, int) {
+	return max(width-2, 0), max(height-4, 0)
+}
+
+// listHeight is how many rows of the frame the revision list takes, leaving
+// the rest (less a separator) to the diff.
+func (m Revisions) listHeight() int {
+	_, h := frameFit(m.width, m.height)
+	return min(len(m.revisions), max(h/3, 3))
+}
+
+func (m *Revisions) resize() {
+	w, h := frameFit(m.width, m.height)
+	m.diff.SetWidth(w)
+	m.diff.SetHeight(max(h-m.listHeight()-1, 0))
+}
+
+func (m Revisions) Init() tea.Cmd {
+	return nil
+}
+
+func (m Revisions) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, keys.Newer):
+			if m.index > 0 {
+				m.index--
+				return m, m.show()
+			}
+			return m, nil
+		case key.Matches(msg, keys.Older):
+			if m.index < len(m.revisions)-1 {
+				m.index++
+				return m, m.show()
+			}
+			return m, nil
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+	case msgs.FileLoaded:
+		m.file = msg.File
+		return m, nil
+	case msgs.FileDeselected:
+		m.file = nil
+		return m, nil
+	case msgs.PushView:
+		if msg.View == views.Revisions {
+			return m, m.open()
+		}
+		return m, nil
+	case loadedMsg:
+		if m.file == nil || msg.fileID != m.file.ID {
+			return m, nil
+		}
+		m.loaded = true
+		if msg.err != nil {
+			m.feedback = feedback.Error(fmt.Sprintf("Unable to load revisions: %s", msg.err.Error()))
+			return m, nil
+		}
+		m.revisions = msg.revisions
+		m.resize()
+		return m, m.show()
+	case diffLoadedMsg:
+		if msg.err != nil {
+			m.feedback = feedback.Error(fmt.Sprintf("Unable to load diff: %s", msg.err.Error()))
+			return m, nil
+		}
+		m.diffs[msg.revisionID] = styles.Diff(msg.diff)
+		if rev := m.selected(); rev != nil && rev.ID == msg.revisionID {
+			m.diff.SetContent(m.diffs[msg.revisionID])
+			m.diff.GotoTop()
+		}
+		return m, nil
+	case msgs.ThemeChanged:
+		m.theme = msg.Color
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.diff, cmd = m.diff.Update(msg)
+	return m, cmd
+}
+
+// open starts over for the loaded file, fetching its revisions.
+func (m *Revisions) open() tea.Cmd {
+	m.revisions = nil
+	m.loaded = false
+	m.index = 0
+	m.diffs = map[string]string{}
+	m.feedback = feedback.Feedback{}
+	m.diff.SetContent("")
+	m.resize()
+
+	if m.file == nil {
+		return nil
+	}
+
+	ctx, database, fileID := m.ctx, m.db, m.file.ID
+	return func() tea.Msg {
+		revisions, err := database.Revisions.FindByFileID(ctx, fileID)
+		return loadedMsg{fileID: fileID, revisions: revisions, err: err}
+	}
+}
+
+// show puts the highlighted revision's diff in the viewport, fetching it the
+// first time it's shown.
+func (m *Revisions) show() tea.Cmd {
+	rev := m.selected()
+	if rev == nil {
+		return nil
+	}
+
+	m.feedback = feedback.Feedback{}
+	if diff, ok := m.diffs[rev.ID]; ok {
+		m.diff.SetContent(diff)
+		m.diff.GotoTop()
+		return nil
+	}
+
+	m.diff.SetContent(styles.C(styles.Colors.Muted, "Loading diff…"))
+
+	ctx, database, revisionID := m.ctx, m.db, rev.ID
+	return func() tea.Msg {
+		diff, err := database.Revisions.FindDiff(ctx, revisionID)
+		return diffLoadedMsg{revisionID: revisionID, diff: string(diff), err: err}
+	}
+}
+
+func (m Revisions) selected() *snips.Revision {
+	if m.index < 0 || m.index >= len(m.revisions) {
+		return nil
+	}
+	return m.revisions[m.index]
+}
+
+func (m Revisions) View() tea.View {
+	w, h := frameFit(m.width, m.height)
+	body := lipgloss.NewStyle().Width(w).Height(h).MaxHeight(h).Render(m.body(w))
+	return tea.NewView(styles.Frame(m.theme, m.titleRow(), body))
+}
+
+func (m Revisions) Keys() help.KeyMap {
+	return keys
+}
+
+func (m Revisions) IsCapturing() bool {
+	return false
+}
+
+func (m Revisions) titleRow() string {
+	if m.file == nil {
+		return ""
+	}
+
+	title := styles.BC(m.theme, "revisions") + styles.C(styles.Colors.Muted, " · "+m.file.DisplayName())
+	if m.loaded && len(m.revisions) > 0 {
+		title += styles.C(styles.Colors.Muted, fmt.Sprintf(" · %d of %d", m.index+1, len(m.revisions)))
+	}
+	return title
+}
+
+func (m Revisions) body(width int) string {
+	padded := lipgloss.NewStyle().Padding(1, 2).Width(width)
+
+	switch {
+	case !m.feedback.Empty():
+		return padded.Render(m.feedback.View())
+	case !m.loaded:
+		return padded.Render(styles.C(styles.Colors.Muted, "Loading revisions…"))
+	case len(m.revisions) == 0:
+		return padded.Render(styles.C(styles.Colors.Muted, "No revisions yet, one is recorded each time the file's content changes."))
+	}
+
+	separator := styles.C(styles.Colors.Muted, strings.Repeat("─", width))
+	return lipgloss.JoinVertical(lipgloss.Top, m.renderList(), separator, m.diff.View())
+}
+
+// renderList renders the window of the revision list around the highlighted
+// revision.
+func (m Revisions) renderList() string {
+	height := m.listHeight()
+	start := max(0, m.index-height+1)
+	end := min(len(m.revisions), start+height)
+	digits := len(fmt.Sprintf("%d", m.revisions[0].Sequence))
+
+	rows := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		rev := m.revisions[i]
+
+		prefix := "  "
+		color := styles.Colors.Muted
+		if i == m.index {
+			prefix = selector
+			color = styles.Colors.White
+		}
+
+		row := fmt.Sprintf("rev %-*d  %-16s  %8s  %s",
+			digits, rev.Sequence,
+			humanize.Time(rev.CreatedAt),
+			humanize.Bytes(rev.Size),
+			strings.ToLower(rev.Type),
+		)
+		rows = append(rows, styles.C(m.theme, prefix)+styles.C(color, row))
+	}
+
+	return strings.Join(rows, "\n")
+}
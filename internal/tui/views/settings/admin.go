@@ -0,0 +1,269 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// AdminRecentFiles is how many of the instance's latest uploads the admin
+// page lists.
+const AdminRecentFiles = 20
+
+// adminView is the admin page, only shown to admins: the latest uploads
+// across every user, for removing abusive files or banning their owners.
+type adminView struct {
+	deps
+
+	list     []*snips.File
+	cursor   int
+	armed    string // "x" or "b" when the selected file's action is armed (press twice)
+	armedID  string // file id the armed action applies to
+	feedback feedback.Feedback
+}
+
+func newAdminView(d deps) adminView {
+	return adminView{deps: d}
+}
+
+// enter loads the latest uploads and resets the page state.
+func (m adminView) enter() (adminView, error) {
+	m.cursor = 0
+	m.armed, m.armedID = "", ""
+	m.feedback = feedback.Feedback{}
+
+	if err := m.reload(&m); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// reload refreshes the upload list from the database.
+func (m adminView) reload(into *adminView) error {
+	files, err := m.db.Files.FindRecent(m.ctx, db.WithLimit(AdminRecentFiles))
+	if err != nil {
+		return fmt.Errorf("failed to load recent uploads: %w", err)
+	}
+
+	into.list = files
+	if into.cursor >= len(files) {
+		into.cursor = max(0, len(files)-1)
+	}
+
+	return nil
+}
+
+func (m adminView) update(msg tea.KeyPressMsg) (adminView, result) {
+	// any key other than the armed one disarms a pending action
+	if msg.String() != m.armed {
+		m.armed, m.armedID = "", ""
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.list)-1 {
+			m.cursor++
+		}
+	case "r":
+		if err := m.reload(&m); err != nil {
+			m.feedback = feedback.Error(err.Error())
+		}
+	case "x":
+		return m.removeFile()
+	case "b":
+		return m.banOwner()
+	case "esc":
+		return m, result{back: true}
+	case "q":
+		// the view captures input on deeper pages, so quit needs handling here
+		return m, result{quit: true}
+	}
+	return m, result{}
+}
+
+// arm reports whether action was already armed for the selected file, arming
+// it otherwise so it runs on the next press.
+func (m *adminView) arm(action string, file *snips.File, prompt string) bool {
+	if m.armed == action && m.armedID == file.ID {
+		m.armed, m.armedID = "", ""
+		return true
+	}
+
+	m.armed, m.armedID = action, file.ID
+	m.feedback = feedback.Error(prompt)
+	return false
+}
+
+// removeFile deletes the selected file, requiring x to be pressed twice.
+func (m adminView) removeFile() (adminView, result) {
+	if len(m.list) == 0 {
+		return m, result{}
+	}
+
+	selected := m.list[m.cursor]
+	if !m.arm("x", selected, "press x again to remove "+selected.ID) {
+		return m, result{}
+	}
+
+	if err := m.db.Files.Delete(m.ctx, selected.ID); err != nil {
+		m.feedback = feedback.Error("failed to remove file: " + err.Error())
+		return m, result{}
+	}
+
+	m.events.Publish(events.NewEvent(events.KindDelete, selected))
+	metrics.IncrCounter([]string{"admin", "file", "delete"}, 1)
+	logger.From(m.ctx).Info("file removed by admin", "file_id", selected.ID, "owner_id", selected.UserID, "admin_id", m.user.ID)
+
+	m.feedback = feedback.Success(fmt.Sprintf("removed file %q", selected.ID))
+	if err := m.reload(&m); err != nil {
+		m.feedback = feedback.Error(err.Error())
+	}
+	return m, result{}
+}
+
+// banOwner bans the selected file's owner, requiring b to be pressed twice.
+// Admins can't ban themselves.
+func (m adminView) banOwner() (adminView, result) {
+	if len(m.list) == 0 {
+		return m, result{}
+	}
+
+	selected := m.list[m.cursor]
+	if selected.UserID == m.user.ID {
+		m.feedback = feedback.Error("can't ban yourself")
+		return m, result{}
+	}
+
+	if !m.arm("b", selected, "press b again to ban user "+selected.UserID) {
+		return m, result{}
+	}
+
+	banned, err := m.db.Users.Ban(m.ctx, selected.UserID)
+	if err != nil || !banned {
+		msg := "failed to ban user"
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		m.feedback = feedback.Error(msg)
+		return m, result{}
+	}
+
+	metrics.IncrCounter([]string{"admin", "user", "ban"}, 1)
+	logger.From(m.ctx).Info("user banned by admin", "user_id", selected.UserID, "admin_id", m.user.ID)
+
+	m.feedback = feedback.Success(fmt.Sprintf("banned user %q, their files are untouched", selected.UserID))
+	return m, result{}
+}
+
+// rows renders the latest uploads, newest first.
+func (m adminView) rows() []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(styles.Colors.Muted)
+
+	rows := []string{}
+	if len(m.list) == 0 {
+		rows = append(rows, mutedStyle.Render("no uploads yet"))
+	}
+
+	for i, file := range m.list {
+		cursor := "  "
+		nameStyle := mutedStyle
+		if i == m.cursor {
+			cursor = styles.BC(m.accent(), "→ ")
+			nameStyle = lipgloss.NewStyle().Foreground(styles.Colors.White).Bold(true)
+		}
+
+		details := []string{"by " + file.UserID, strings.ToLower(file.Type), humanize.Bytes(file.Size), humanize.Time(file.CreatedAt)}
+		if file.Name != "" {
+			details = append([]string{file.Name}, details...)
+		}
+		if file.Private {
+			details = append(details, "private")
+		}
+
+		row := cursor + nameStyle.Render(file.ID) + mutedStyle.Render("  ·  "+strings.Join(details, "  ·  "))
+		if m.armedID == file.ID {
+			row += "  " + styles.C(styles.Colors.Red, "(press "+m.armed+" again)")
+		}
+		rows = append(rows, row)
+	}
+
+	if !m.feedback.Empty() {
+		rows = append(rows, "", m.feedback.View())
+	}
+
+	return rows
+}
+
+func (m adminView) keys() help.KeyMap {
+	return adminKeys
+}
+
+// adminKeyMap is shown while navigating the admin page.
+type adminKeyMap struct {
+	Up      key.Binding
+	Down    key.Binding
+	Refresh key.Binding
+	Remove  key.Binding
+	Ban     key.Binding
+	Esc     key.Binding
+	Quit    key.Binding
+}
+
+func (k adminKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Remove, k.Ban, k.Esc, k.Quit}
+}
+
+func (k adminKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down, k.Refresh},
+		{k.Remove, k.Ban, k.Esc, k.Quit},
+	}
+}
+
+var adminKeys = adminKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Remove: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "remove file"),
+	),
+	Ban: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "ban owner"),
+	),
+	Esc: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
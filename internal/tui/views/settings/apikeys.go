@@ -13,6 +13,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/armon/go-metrics"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/files"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/timeutil"
@@ -21,7 +22,7 @@ import (
 )
 
 // apiKeysView is the api key management page: a list of the user's keys,
-// with a two-step (name, optional expiry) creation flow.
+// with a four-step (name, optional expiry, scopes and files) creation flow.
 type apiKeysView struct {
 	deps
 
@@ -29,9 +30,15 @@ type apiKeysView struct {
 	cursor        int
 	naming        bool            // typing the name for a new key
 	expiring      bool            // typing the optional expiry for a new key
+	scoping       bool            // typing the optional scopes for a new key
+	restricting   bool            // typing the optional files for a new key
 	nameInput     textinput.Model // name input for a new key
 	ttlInput      textinput.Model // expiry input for a new key
+	scopesInput   textinput.Model // scopes input for a new key
+	filesInput    textinput.Model // file ids input for a new key
 	pendingName   string          // name entered for the key being created
+	pendingTTL    time.Duration   // expiry entered for the key being created, 0 for none
+	pendingScopes []string        // scopes entered for the key being created
 	newToken      string          // token of the key just created, shown once
 	armedDeleteID string          // key id armed for deletion (press x twice)
 	feedback      feedback.Feedback
@@ -50,18 +57,39 @@ func newAPIKeysView(d deps) apiKeysView {
 	ttl.Prompt = styles.BC(styles.Colors.Yellow, "> ")
 	ttl.Placeholder = "expiry, e.g. 30d (optional)"
 
+	scopes := textinput.New()
+	scopes.CharLimit = 128
+	scopes.SetWidth(40)
+	scopes.Prompt = styles.BC(styles.Colors.Yellow, "> ")
+	scopes.Placeholder = "scopes, e.g. files:read, sign (optional)"
+
+	fileIDs := textinput.New()
+	fileIDs.CharLimit = 1024
+	fileIDs.SetWidth(40)
+	fileIDs.Prompt = styles.BC(styles.Colors.Yellow, "> ")
+	fileIDs.Placeholder = "file ids to restrict to (optional)"
+
 	return apiKeysView{
-		deps:      d,
-		nameInput: name,
-		ttlInput:  ttl,
+		deps:        d,
+		nameInput:   name,
+		ttlInput:    ttl,
+		scopesInput: scopes,
+		filesInput:  fileIDs,
 	}
 }
 
+// creating reports whether one of the creation inputs is focused.
+func (m apiKeysView) creating() bool {
+	return m.naming || m.expiring || m.scoping || m.restricting
+}
+
 // enter loads the user's keys and resets the page state.
 func (m apiKeysView) enter() (apiKeysView, error) {
 	m.cursor = 0
 	m.naming = false
 	m.expiring = false
+	m.scoping = false
+	m.restricting = false
 	m.newToken = ""
 	m.armedDeleteID = ""
 	m.feedback = feedback.Feedback{}
@@ -97,6 +125,14 @@ func (m apiKeysView) update(msg tea.KeyPressMsg) (apiKeysView, result) {
 		return m.updateExpiring(msg)
 	}
 
+	if m.scoping {
+		return m.updateScoping(msg)
+	}
+
+	if m.restricting {
+		return m.updateRestricting(msg)
+	}
+
 	// any key other than a second x disarms a pending deletion
 	if msg.String() != "x" {
 		m.armedDeleteID = ""
@@ -168,7 +204,20 @@ func (m apiKeysView) updateNaming(msg tea.KeyPressMsg) (apiKeysView, result) {
 func (m apiKeysView) updateExpiring(msg tea.KeyPressMsg) (apiKeysView, result) {
 	switch msg.String() {
 	case "enter":
-		return m.create()
+		m.pendingTTL = 0
+		if raw := strings.TrimSpace(m.ttlInput.Value()); raw != "" {
+			ttl, err := timeutil.ParseDuration(raw)
+			if err != nil || ttl <= 0 {
+				m.feedback = feedback.Error("invalid expiry: use a duration like 30d or 12h")
+				return m, result{}
+			}
+			m.pendingTTL = ttl
+		}
+		m.expiring = false
+		m.scoping = true
+		m.feedback = feedback.Feedback{}
+		m.scopesInput.Reset()
+		return m, result{cmd: m.scopesInput.Focus()}
 	case "esc":
 		m.expiring = false
 		m.feedback = feedback.Feedback{}
@@ -181,16 +230,70 @@ func (m apiKeysView) updateExpiring(msg tea.KeyPressMsg) (apiKeysView, result) {
 	return m, result{cmd: cmd}
 }
 
-// create mints a key named in the previous step, showing the token once.
+// updateScoping handles the third creation step: the optional scopes, every
+// scope when left empty.
+func (m apiKeysView) updateScoping(msg tea.KeyPressMsg) (apiKeysView, result) {
+	switch msg.String() {
+	case "enter":
+		scopes, err := snips.NormalizeAPIKeyScopes([]string{m.scopesInput.Value()})
+		if err != nil {
+			m.feedback = feedback.Error(err.Error())
+			return m, result{}
+		}
+		m.pendingScopes = scopes
+		m.scoping = false
+		m.restricting = true
+		m.feedback = feedback.Feedback{}
+		m.filesInput.Reset()
+		return m, result{cmd: m.filesInput.Focus()}
+	case "esc":
+		m.scoping = false
+		m.feedback = feedback.Feedback{}
+		return m, result{}
+	}
+
+	// everything else is typed into the scopes input
+	var cmd tea.Cmd
+	m.scopesInput, cmd = m.scopesInput.Update(msg)
+	return m, result{cmd: cmd}
+}
+
+// updateRestricting handles the last creation step: the optional files the
+// key is restricted to, every file when left empty.
+func (m apiKeysView) updateRestricting(msg tea.KeyPressMsg) (apiKeysView, result) {
+	switch msg.String() {
+	case "enter":
+		return m.create()
+	case "esc":
+		m.restricting = false
+		m.feedback = feedback.Feedback{}
+		return m, result{}
+	}
+
+	// everything else is typed into the files input
+	var cmd tea.Cmd
+	m.filesInput, cmd = m.filesInput.Update(msg)
+	return m, result{cmd: cmd}
+}
+
+// create mints a key as entered in the previous steps, showing the token once.
 func (m apiKeysView) create() (apiKeysView, result) {
-	var expiresAt *time.Time
-	if raw := strings.TrimSpace(m.ttlInput.Value()); raw != "" {
-		ttl, err := timeutil.ParseDuration(raw)
-		if err != nil || ttl <= 0 {
-			m.feedback = feedback.Error("invalid expiry: use a duration like 30d or 12h")
+	fileIDs, err := snips.NormalizeAPIKeyFileIDs([]string{m.filesInput.Value()})
+	if err != nil {
+		m.feedback = feedback.Error(err.Error())
+		return m, result{}
+	}
+
+	if len(fileIDs) > 0 {
+		if _, err := files.FindOwned(m.ctx, m.db, m.user.ID, fileIDs); err != nil {
+			m.feedback = feedback.Error(err.Error())
 			return m, result{}
 		}
-		expires := time.Now().UTC().Add(ttl)
+	}
+
+	var expiresAt *time.Time
+	if m.pendingTTL > 0 {
+		expires := time.Now().UTC().Add(m.pendingTTL)
 		expiresAt = &expires
 	}
 
@@ -205,6 +308,8 @@ func (m apiKeysView) create() (apiKeysView, result) {
 		TokenHash: hash,
 		UserID:    m.user.ID,
 		ExpiresAt: expiresAt,
+		Scopes:    m.pendingScopes,
+		FileIDs:   fileIDs,
 	}
 
 	if err := m.db.APIKeys.Create(m.ctx, key, m.cfg.Limits.APIKeysPerUser); err != nil {
@@ -219,7 +324,7 @@ func (m apiKeysView) create() (apiKeysView, result) {
 	metrics.IncrCounter([]string{"apikey", "create"}, 1)
 	logger.From(m.ctx).Info("api key created", "api_key_id", key.ID, "user_id", key.UserID)
 
-	m.expiring = false
+	m.restricting = false
 	m.newToken = token
 	m.feedback = feedback.Success(fmt.Sprintf("created api key %q", key.DisplayName()))
 
@@ -285,7 +390,7 @@ func (m apiKeysView) rows() []string {
 	for i, key := range m.list {
 		cursor := "  "
 		nameStyle := mutedStyle
-		if i == m.cursor && !m.naming && !m.expiring {
+		if i == m.cursor && !m.creating() {
 			cursor = styles.BC(m.accent(), "→ ")
 			nameStyle = lipgloss.NewStyle().Foreground(styles.Colors.White).Bold(true)
 		}
@@ -303,6 +408,15 @@ func (m apiKeysView) rows() []string {
 		case key.ExpiresAt != nil:
 			row += mutedStyle.Render("  ·  expires " + key.ExpiresAt.UTC().Format("2006-01-02"))
 		}
+		if len(key.Scopes) > 0 {
+			row += mutedStyle.Render("  ·  " + strings.Join(key.Scopes, ", "))
+		}
+		switch {
+		case len(key.FileIDs) == 1:
+			row += mutedStyle.Render("  ·  1 file")
+		case key.IsFileRestricted():
+			row += mutedStyle.Render(fmt.Sprintf("  ·  %d files", len(key.FileIDs)))
+		}
 		if m.armedDeleteID == key.ID {
 			row += "  " + styles.C(styles.Colors.Red, "(press x again)")
 		}
@@ -317,6 +431,25 @@ func (m apiKeysView) rows() []string {
 		rows = append(rows, "", mutedStyle.Render("name: ")+m.pendingName, m.ttlInput.View())
 	}
 
+	if m.scoping || m.restricting {
+		rows = append(rows, "", mutedStyle.Render("name: ")+m.pendingName)
+		if m.pendingTTL > 0 {
+			rows = append(rows, mutedStyle.Render("expires in: ")+strings.TrimSpace(m.ttlInput.Value()))
+		}
+	}
+
+	if m.scoping {
+		rows = append(rows, mutedStyle.Render("scopes: "+strings.Join(snips.APIKeyScopes, ", ")), m.scopesInput.View())
+	}
+
+	if m.restricting {
+		scopes := "all"
+		if len(m.pendingScopes) > 0 {
+			scopes = strings.Join(m.pendingScopes, ", ")
+		}
+		rows = append(rows, mutedStyle.Render("scopes: ")+scopes, m.filesInput.View())
+	}
+
 	if m.newToken != "" {
 		rows = append(rows,
 			"",
@@ -333,7 +466,7 @@ func (m apiKeysView) rows() []string {
 }
 
 func (m apiKeysView) keys() help.KeyMap {
-	if m.naming || m.expiring {
+	if m.creating() {
 		return apiKeyNamingKeys
 	}
 	return apiKeysKeys
@@ -8,6 +8,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/tui/cmds"
 	"github.com/robherley/snips.sh/internal/tui/feedback"
@@ -68,12 +69,17 @@ func (m deleteView) update(msg tea.KeyPressMsg) (deleteView, result) {
 }
 
 func (m deleteView) deleteEverything() (deleteView, result) {
-	count, err := m.db.Files.DeleteByUser(m.ctx, m.user.ID)
+	files, err := m.db.Files.DeleteByUser(m.ctx, m.user.ID)
 	if err != nil {
 		m.feedback = feedback.Error("failed to delete: " + err.Error())
 		return m, result{}
 	}
 
+	for _, file := range files {
+		m.events.Publish(events.NewEvent(events.KindDelete, file))
+	}
+
+	count := len(files)
 	metrics.IncrCounter([]string{"file", "delete", "all"}, 1)
 	logger.From(m.ctx).Info("deleted all user files", "user_id", m.user.ID, "count", count)
 
@@ -3,12 +3,14 @@ package settings
 import (
 	"context"
 	"image/color"
+	"slices"
 
 	"charm.land/bubbles/v2/help"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/tui/feedback"
 	"github.com/robherley/snips.sh/internal/tui/msgs"
@@ -24,7 +26,9 @@ const (
 	rootPage page = iota
 	themePage
 	apiKeysPage
+	sshKeysPage
 	deletePage
+	adminPage
 )
 
 // entry is a selectable row on the root page that opens a deeper page.
@@ -38,16 +42,21 @@ type entry struct {
 var entries = []entry{
 	{label: "theme color", page: themePage},
 	{label: "api keys", page: apiKeysPage},
+	{label: "ssh keys", page: sshKeysPage},
 	{label: "delete all my data", page: deletePage, danger: true},
 }
 
+// adminEntry is added to the root menu for admins (see config.Config.IsAdmin).
+var adminEntry = entry{label: "admin: recent uploads", page: adminPage}
+
 // deps are the shared dependencies settings pages need to act. Pages embed
 // them by value; every field is a reference, so copies stay in sync.
 type deps struct {
-	ctx  context.Context
-	cfg  *config.Config
-	db   *db.DB
-	user *snips.User
+	ctx    context.Context
+	cfg    *config.Config
+	db     *db.DB
+	events *events.Hub
+	user   *snips.User
 }
 
 // accent is the user's chosen theme color, used to highlight the modal.
@@ -66,6 +75,7 @@ type result struct {
 type Settings struct {
 	deps
 	fingerprint string
+	isAdmin     bool
 
 	width  int
 	height int
@@ -76,26 +86,40 @@ type Settings struct {
 
 	theme   themeView
 	apiKeys apiKeysView
+	sshKeys sshKeysView
 	delete  deleteView
+	admin   adminView
 }
 
-func New(ctx context.Context, cfg *config.Config, width, height int, database *db.DB, user *snips.User, fingerprint string) Settings {
+func New(ctx context.Context, cfg *config.Config, width, height int, database *db.DB, hub *events.Hub, user *snips.User, fingerprint string) Settings {
 	d := deps{
-		ctx:  ctx,
-		cfg:  cfg,
-		db:   database,
-		user: user,
+		ctx:    ctx,
+		cfg:    cfg,
+		db:     database,
+		events: hub,
+		user:   user,
 	}
 
 	return Settings{
 		deps:        d,
 		fingerprint: fingerprint,
+		isAdmin:     cfg.IsAdmin(fingerprint),
 		width:       width,
 		height:      height,
 		theme:       newThemeView(d),
 		apiKeys:     newAPIKeysView(d),
+		sshKeys:     newSSHKeysView(d, fingerprint),
 		delete:      newDeleteView(d),
+		admin:       newAdminView(d),
+	}
+}
+
+// entries lists the root menu, with the admin page for admins.
+func (s Settings) entries() []entry {
+	if s.isAdmin {
+		return append(slices.Clone(entries), adminEntry)
 	}
+	return entries
 }
 
 func (s Settings) Init() tea.Cmd {
@@ -122,8 +146,12 @@ func (s Settings) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			s.theme, res = s.theme.update(msg)
 		case apiKeysPage:
 			s.apiKeys, res = s.apiKeys.update(msg)
+		case sshKeysPage:
+			s.sshKeys, res = s.sshKeys.update(msg)
 		case deletePage:
 			s.delete, res = s.delete.update(msg)
+		case adminPage:
+			s.admin, res = s.admin.update(msg)
 		default:
 			return s.updateRootPage(msg)
 		}
@@ -153,11 +181,11 @@ func (s Settings) updateRootPage(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
 			s.cursor--
 		}
 	case "down", "j":
-		if s.cursor < len(entries)-1 {
+		if s.cursor < len(s.entries())-1 {
 			s.cursor++
 		}
 	case "enter":
-		return s.open(entries[s.cursor].page)
+		return s.open(s.entries()[s.cursor].page)
 	}
 	return s, nil
 }
@@ -177,8 +205,12 @@ func (s Settings) open(p page) (tea.Model, tea.Cmd) {
 		s.theme = s.theme.enter()
 	case apiKeysPage:
 		s.apiKeys, err = s.apiKeys.enter()
+	case sshKeysPage:
+		s.sshKeys, err = s.sshKeys.enter()
 	case deletePage:
 		s.delete, cmd, err = s.delete.enter()
+	case adminPage:
+		s.admin, err = s.admin.enter()
 	}
 
 	if err != nil {
@@ -200,9 +232,15 @@ func (s Settings) View() tea.View {
 	case apiKeysPage:
 		title = "settings / api keys"
 		rows = s.apiKeys.rows()
+	case sshKeysPage:
+		title = "settings / ssh keys"
+		rows = s.sshKeys.rows()
 	case deletePage:
 		title = "settings / delete all my data"
 		rows = s.delete.rows()
+	case adminPage:
+		title = "settings / admin: recent uploads"
+		rows = s.admin.rows()
 	default:
 		title = "settings"
 		rows = s.rootRows()
@@ -220,7 +258,7 @@ func (s Settings) rootRows() []string {
 		"",
 	}
 
-	for i, e := range entries {
+	for i, e := range s.entries() {
 		rows = append(rows, s.entryRow(e, i == s.cursor))
 	}
 
@@ -259,8 +297,12 @@ func (s Settings) Keys() help.KeyMap {
 		return themeKeys
 	case apiKeysPage:
 		return s.apiKeys.keys()
+	case sshKeysPage:
+		return s.sshKeys.keys()
 	case deletePage:
 		return deleteKeys
+	case adminPage:
+		return s.admin.keys()
 	default:
 		return keys
 	}
@@ -0,0 +1,259 @@
+package settings
+
+import (
+	"fmt"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/tui/feedback"
+	"github.com/robherley/snips.sh/internal/tui/styles"
+)
+
+// sshKeysView is the ssh key management page: a list of the public keys
+// linked to the user, and link codes for adding another.
+type sshKeysView struct {
+	deps
+	fingerprint string // key of the current session, which can't be removed
+
+	list          []*snips.PublicKey
+	cursor        int
+	linkCode      string    // code just created, shown until it expires
+	linkExpiresAt time.Time // when linkCode stops being claimable
+	armedDeleteID string    // key id armed for deletion (press x twice)
+	feedback      feedback.Feedback
+}
+
+func newSSHKeysView(d deps, fingerprint string) sshKeysView {
+	return sshKeysView{
+		deps:        d,
+		fingerprint: fingerprint,
+	}
+}
+
+// enter loads the user's keys and resets the page state.
+func (m sshKeysView) enter() (sshKeysView, error) {
+	m.cursor = 0
+	m.linkCode = ""
+	m.armedDeleteID = ""
+	m.feedback = feedback.Feedback{}
+
+	if err := m.reload(&m); err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// reload refreshes the key list from the database.
+func (m sshKeysView) reload(into *sshKeysView) error {
+	keys, err := m.db.PublicKeys.FindByUser(m.ctx, m.user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load ssh keys: %w", err)
+	}
+
+	into.list = keys
+	if into.cursor >= len(keys) {
+		into.cursor = max(0, len(keys)-1)
+	}
+
+	return nil
+}
+
+func (m sshKeysView) update(msg tea.KeyPressMsg) (sshKeysView, result) {
+	// any key other than a second x disarms a pending deletion
+	if msg.String() != "x" {
+		m.armedDeleteID = ""
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.list)-1 {
+			m.cursor++
+		}
+	case "l":
+		return m.link()
+	case "x":
+		return m.deleteKey()
+	case "esc":
+		return m, result{back: true}
+	case "q":
+		// the view captures input on deeper pages, so quit needs handling here
+		return m, result{quit: true}
+	}
+	return m, result{}
+}
+
+// link creates a code that another key can claim to join this user.
+func (m sshKeysView) link() (sshKeysView, result) {
+	code, hash, err := snips.NewKeyLinkCode()
+	if err != nil {
+		m.feedback = feedback.Error("failed to create link code: " + err.Error())
+		return m, result{}
+	}
+
+	link := &snips.KeyLink{
+		CodeHash:  hash,
+		UserID:    m.user.ID,
+		ExpiresAt: time.Now().UTC().Add(snips.KeyLinkTTL),
+	}
+	if err := m.db.PublicKeys.CreateLink(m.ctx, link); err != nil {
+		m.feedback = feedback.Error("failed to create link code: " + err.Error())
+		return m, result{}
+	}
+
+	metrics.IncrCounter([]string{"publickey", "link"}, 1)
+	logger.From(m.ctx).Info("public key link created", "user_id", m.user.ID)
+
+	m.linkCode = code
+	m.linkExpiresAt = link.ExpiresAt
+	m.feedback = feedback.Feedback{}
+	return m, result{}
+}
+
+// deleteKey removes the selected key, requiring x to be pressed twice. The
+// key of the current session is never removed.
+func (m sshKeysView) deleteKey() (sshKeysView, result) {
+	if len(m.list) == 0 {
+		return m, result{}
+	}
+
+	selected := m.list[m.cursor]
+	if selected.Fingerprint == m.fingerprint {
+		m.feedback = feedback.Error("can't delete the key you're connected with")
+		return m, result{}
+	}
+
+	if m.armedDeleteID != selected.ID {
+		m.armedDeleteID = selected.ID
+		m.feedback = feedback.Error("press x again to delete " + selected.Fingerprint)
+		return m, result{}
+	}
+
+	deleted, err := m.db.PublicKeys.Delete(m.ctx, selected.ID, m.user.ID)
+	if err != nil || !deleted {
+		msg := "failed to delete ssh key"
+		if err != nil {
+			msg += ": " + err.Error()
+		}
+		m.feedback = feedback.Error(msg)
+		return m, result{}
+	}
+
+	metrics.IncrCounter([]string{"publickey", "delete"}, 1)
+	logger.From(m.ctx).Info("public key deleted", "public_key_id", selected.ID, "user_id", m.user.ID)
+
+	m.armedDeleteID = ""
+	m.feedback = feedback.Success(fmt.Sprintf("deleted ssh key %q", selected.Fingerprint))
+
+	if err := m.reload(&m); err != nil {
+		m.feedback = feedback.Error(err.Error())
+	}
+	return m, result{}
+}
+
+// rows renders the key list and the pending link code, if any.
+func (m sshKeysView) rows() []string {
+	mutedStyle := lipgloss.NewStyle().Foreground(styles.Colors.Muted)
+
+	rows := []string{}
+
+	for i, key := range m.list {
+		cursor := "  "
+		nameStyle := mutedStyle
+		if i == m.cursor {
+			cursor = styles.BC(m.accent(), "→ ")
+			nameStyle = lipgloss.NewStyle().Foreground(styles.Colors.White).Bold(true)
+		}
+
+		row := cursor + nameStyle.Render(key.Fingerprint) +
+			mutedStyle.Render(fmt.Sprintf("  ·  %s  ·  added %s", key.Type, key.CreatedAt.UTC().Format("2006-01-02")))
+		if key.Fingerprint == m.fingerprint {
+			row += "  " + styles.C(styles.Colors.Green, "(this key)")
+		}
+		if m.armedDeleteID == key.ID {
+			row += "  " + styles.C(styles.Colors.Red, "(press x again)")
+		}
+		rows = append(rows, row)
+	}
+
+	if m.linkCode != "" {
+		if time.Now().Before(m.linkExpiresAt) {
+			rows = append(rows,
+				"",
+				styles.C(styles.Colors.Yellow, "from the key you want to add, run:"),
+				styles.C(styles.Colors.White, m.cfg.SSHCommandWithArgs("keys", "claim", m.linkCode)),
+				mutedStyle.Render("expires at "+m.linkExpiresAt.Local().Format(time.Kitchen)+", single use"),
+			)
+		} else {
+			rows = append(rows, "", mutedStyle.Render("link code expired — press l for a new one"))
+		}
+	}
+
+	if !m.feedback.Empty() {
+		rows = append(rows, "", m.feedback.View())
+	}
+
+	return rows
+}
+
+func (m sshKeysView) keys() help.KeyMap {
+	return sshKeysKeys
+}
+
+// sshKeysKeyMap is shown while navigating the ssh keys page.
+type sshKeysKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Link   key.Binding
+	Delete key.Binding
+	Esc    key.Binding
+	Quit   key.Binding
+}
+
+func (k sshKeysKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Link, k.Delete, k.Esc, k.Quit}
+}
+
+func (k sshKeysKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Up, k.Down},
+		{k.Link, k.Delete, k.Esc, k.Quit},
+	}
+}
+
+var sshKeysKeys = sshKeysKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/k", "move up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Link: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "link another key"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "delete key"),
+	),
+	Esc: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("q", "quit"),
+	),
+}
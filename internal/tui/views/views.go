@@ -13,6 +13,8 @@ const (
 	Options
 	Prompt
 	Settings
+	Editor
+	Revisions
 )
 
 type Model interface {
@@ -1,12 +1,16 @@
 package web
 
 import (
+	"bytes"
 	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -15,15 +19,19 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/files"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/renderer"
 	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/timeutil"
 	"gopkg.in/yaml.v3"
 )
 
 const (
 	APIMaxSignTTLSeconds int64 = (1<<63 - 1) / int64(time.Second)
+	// APIMaxBatchSize is the most files a batch request can act on.
+	APIMaxBatchSize = 100
 )
 
 var (
@@ -39,17 +47,28 @@ var (
 )
 
 type API struct {
-	cfg *config.Config
-	db  *db.DB
+	cfg    *config.Config
+	db     *db.DB
+	events *events.Hub
+
+	// patterns registered behind WithAuthentication
+	authenticated map[string]bool
+}
+
+func NewAPI(cfg *config.Config, database *db.DB, hub *events.Hub) *API {
+	return &API{cfg: cfg, db: database, events: hub, authenticated: map[string]bool{}}
 }
 
-func NewAPI(cfg *config.Config, database *db.DB) *API {
-	return &API{cfg: cfg, db: database}
+// Authenticates reports whether the route registered with pattern requires
+// an API key.
+func (a *API) Authenticates(pattern string) bool {
+	return a.authenticated[pattern]
 }
 
 func (a *API) Register(mux *http.ServeMux) {
-	authed := func(next http.HandlerFunc) http.HandlerFunc {
-		return WithAuthentication(a.db, next)
+	authed := func(pattern, scope string, next http.HandlerFunc) {
+		a.authenticated[pattern] = true
+		mux.HandleFunc(pattern, WithAuthentication(a.db, scope, next))
 	}
 
 	mux.Handle("GET /meta.json", http.RedirectHandler("/api/v1/meta", http.StatusMovedPermanently))
@@ -59,17 +78,29 @@ func (a *API) Register(mux *http.ServeMux) {
 	mux.HandleFunc("GET /openapi.yml", a.OpenAPI)
 
 	mux.HandleFunc("GET /api/v1/meta", a.Meta)
-	mux.HandleFunc("GET /api/v1/user", authed(a.User))
-	mux.HandleFunc("GET /api/v1/files", authed(a.ListFiles))
-	mux.HandleFunc("POST /api/v1/files", authed(a.CreateFile))
-	mux.HandleFunc("GET /api/v1/files/{fileID}", authed(a.GetFile))
-	mux.HandleFunc("PATCH /api/v1/files/{fileID}", authed(a.UpdateFile))
-	mux.HandleFunc("DELETE /api/v1/files/{fileID}", authed(a.DeleteFile))
-	mux.HandleFunc("GET /api/v1/files/{fileID}/content", authed(a.GetFileContent))
-	mux.HandleFunc("PUT /api/v1/files/{fileID}/content", authed(a.UpdateFileContent))
-	mux.HandleFunc("GET /api/v1/files/{fileID}/revisions", authed(a.ListRevisions))
-	mux.HandleFunc("GET /api/v1/files/{fileID}/revisions/{sequence}", authed(a.GetRevision))
-	mux.HandleFunc("POST /api/v1/files/{fileID}/sign", authed(a.SignFile))
+	authed("GET /api/v1/user", "", a.User)
+	authed("GET /api/v1/files", snips.APIKeyScopeFilesRead, a.ListFiles)
+	authed("POST /api/v1/files", snips.APIKeyScopeFilesWrite, a.CreateFile)
+	authed("POST /api/v1/files:batchGet", snips.APIKeyScopeFilesRead, a.BatchGetFiles)
+	authed("POST /api/v1/files:batchUpdate", snips.APIKeyScopeFilesWrite, a.BatchUpdateFiles)
+	authed("POST /api/v1/files:batchDelete", snips.APIKeyScopeFilesDelete, a.BatchDeleteFiles)
+	authed("GET /api/v1/files/{fileID}", snips.APIKeyScopeFilesRead, a.GetFile)
+	authed("PATCH /api/v1/files/{fileID}", snips.APIKeyScopeFilesWrite, a.UpdateFile)
+	authed("DELETE /api/v1/files/{fileID}", snips.APIKeyScopeFilesDelete, a.DeleteFile)
+	authed("GET /api/v1/files/{fileID}/content", snips.APIKeyScopeFilesRead, a.GetFileContent)
+	authed("PUT /api/v1/files/{fileID}/content", snips.APIKeyScopeFilesWrite, a.UpdateFileContent)
+	authed("POST /api/v1/files/{fileID}/content:append", snips.APIKeyScopeFilesWrite, a.AppendFileContent)
+	authed("GET /api/v1/files/{fileID}/revisions", snips.APIKeyScopeFilesRead, a.ListRevisions)
+	authed("GET /api/v1/files/{fileID}/revisions/{sequence}", snips.APIKeyScopeFilesRead, a.GetRevision)
+	authed("GET /api/v1/files/{fileID}/revisions/{sequence}/content", snips.APIKeyScopeFilesRead, a.GetRevisionContent)
+	authed("POST /api/v1/files/{fileID}/revisions/{sequence}/restore", snips.APIKeyScopeFilesWrite, a.RestoreRevision)
+	authed("GET /api/v1/files/{fileID}/compare/{revisions}", snips.APIKeyScopeFilesRead, a.CompareRevisions)
+	authed("POST /api/v1/files/{fileID}/sign", snips.APIKeyScopeSign, a.SignFile)
+	authed("GET /api/v1/webhooks", snips.APIKeyScopeWebhooks, a.ListWebhooks)
+	authed("POST /api/v1/webhooks", snips.APIKeyScopeWebhooks, a.CreateWebhook)
+	authed("GET /api/v1/webhooks/{webhookID}", snips.APIKeyScopeWebhooks, a.GetWebhook)
+	authed("DELETE /api/v1/webhooks/{webhookID}", snips.APIKeyScopeWebhooks, a.DeleteWebhook)
+	authed("GET /api/v1/webhooks/{webhookID}/deliveries", snips.APIKeyScopeWebhooks, a.ListWebhookDeliveries)
 }
 
 func mustYAMLToJSON(in []byte) []byte {
@@ -98,6 +129,17 @@ func (a *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(openapiYAML)
 }
 
+// etagMatches reports whether an If-Match header lists etag. Weak tags never
+// match, as If-Match uses strong comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimSpace(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -108,9 +150,14 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 }
 
 // findFile resolves {fileID} and enforces visibility: a file that doesn't
-// exist is a 404, and so is another user's file when it's private (or when
-// the operation is owner-only), so existence isn't leaked.
+// exist (or has expired) is a 404, and so is another user's file when it's
+// private (or when the operation is owner-only), so existence isn't leaked.
 func (a *API) findFile(w http.ResponseWriter, r *http.Request, ownerOnly bool) *snips.File {
+	if !keyAllowsFile(r, r.PathValue("fileID")) {
+		http.Error(w, "api key cannot access this file", http.StatusForbidden)
+		return nil
+	}
+
 	file, err := a.db.Files.Find(r.Context(), r.PathValue("fileID"))
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -118,7 +165,7 @@ func (a *API) findFile(w http.ResponseWriter, r *http.Request, ownerOnly bool) *
 	}
 
 	userID, _ := UserID(r.Context())
-	if file == nil || (file.UserID != userID && (ownerOnly || file.Private)) {
+	if !fileVisible(file, userID, ownerOnly) {
 		http.Error(w, "file not found", http.StatusNotFound)
 		return nil
 	}
@@ -126,6 +173,51 @@ func (a *API) findFile(w http.ResponseWriter, r *http.Request, ownerOnly bool) *
 	return file
 }
 
+// fileVisible reports whether userID may act on file. Expired files and other
+// users' private files are never visible, nor are other users' public files
+// for owner-only operations.
+func fileVisible(file *snips.File, userID string, ownerOnly bool) bool {
+	return file != nil && !file.IsExpired() && (file.UserID == userID || (!ownerOnly && !file.Private))
+}
+
+// keyAllowsFile reports whether the request's api key may act on fileID.
+func keyAllowsFile(r *http.Request, fileID string) bool {
+	key, ok := APIKey(r.Context())
+	return ok && key.CanAccessFile(fileID)
+}
+
+// requireAccountAccess rejects keys restricted to certain files from
+// account-wide operations, reporting whether the request may proceed.
+func requireAccountAccess(w http.ResponseWriter, r *http.Request) bool {
+	key, ok := APIKey(r.Context())
+	if !ok || key.IsFileRestricted() {
+		http.Error(w, "api key is restricted to specific files", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// findFiles resolves the IDs of a batch request, enforcing visibility and the
+// api key's files as findFile does. IDs missing from the result are reported
+// with missingResult.
+func (a *API) findFiles(r *http.Request, ids []string, ownerOnly bool) (map[string]*snips.File, error) {
+	found, err := a.db.Files.FindMany(r.Context(), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, _ := UserID(r.Context())
+	visible := make(map[string]*snips.File, len(found))
+	for _, file := range found {
+		if fileVisible(file, userID, ownerOnly) && keyAllowsFile(r, file.ID) {
+			visible[file.ID] = file
+		}
+	}
+
+	return visible, nil
+}
+
 // readContent reads the raw request body, enforcing the file size limit.
 func (a *API) readContent(r *http.Request) ([]byte, error) {
 	maxSize := a.cfg.Limits.FileSize
@@ -146,6 +238,70 @@ func (a *API) readContent(r *http.Request) ([]byte, error) {
 	return content, nil
 }
 
+// readBundle encodes an upload as a bundle when its Content-Type asks for
+// one: a multipart/form-data body (one member per file part) or a tar
+// stream. ok is false for any other Content-Type.
+func (a *API) readBundle(r *http.Request, content []byte) (bundle []byte, ok bool, err error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false, nil
+	}
+
+	switch mediaType {
+	case "multipart/form-data":
+		bundle, err = readMultipartBundle(content, params["boundary"])
+	case "application/x-tar":
+		bundle, err = snips.NormalizeBundle(content)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	if uint64(len(bundle)) > a.cfg.Limits.FileSize {
+		return nil, true, errAPIContentTooLarge
+	}
+
+	return bundle, true, nil
+}
+
+// readMultipartBundle encodes the file parts of a multipart/form-data body as
+// a bundle, ignoring plain form fields.
+func readMultipartBundle(body []byte, boundary string) ([]byte, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("%w: missing multipart boundary", snips.ErrInvalidBundle)
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	members := []snips.BundleMember{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", snips.ErrInvalidBundle, err)
+		}
+
+		// part.FileName() strips directories, which bundles keep
+		_, params, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		name := params["filename"]
+		if name == "" {
+			continue
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", snips.ErrInvalidBundle, err)
+		}
+
+		members = append(members, snips.BundleMember{Name: name, Content: data})
+	}
+
+	return snips.EncodeBundle(members)
+}
+
 func (a *API) Meta(w http.ResponseWriter, r *http.Request) {
 	metadata := map[string]any{
 		"limits": map[string]any{
@@ -156,6 +312,7 @@ func (a *API) Meta(w http.ResponseWriter, r *http.Request) {
 			"files_per_user":     a.cfg.Limits.FilesPerUser,
 			"revisions_per_file": a.cfg.Limits.RevisionsPerFile,
 			"api_keys_per_user":  a.cfg.Limits.APIKeysPerUser,
+			"webhooks_per_user":  a.cfg.Limits.WebhooksPerUser,
 			"session_duration": map[string]any{
 				"seconds": a.cfg.Limits.SessionDuration.Seconds(),
 				"human":   a.cfg.Limits.SessionDuration.String(),
@@ -190,6 +347,10 @@ func (a *API) ListFiles(w http.ResponseWriter, r *http.Request) {
 		NextCursor string        `json:"next_cursor,omitempty"`
 	}
 
+	if !requireAccountAccess(w, r) {
+		return
+	}
+
 	userID, _ := UserID(r.Context())
 
 	// names are unique per user, so a name filter returns at most one file
@@ -220,10 +381,32 @@ func (a *API) ListFiles(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// fetch one extra row to learn whether another page exists
-	userFiles, err := a.db.Files.FindByUser(r.Context(), userID,
-		db.WithLimit(limit+1),
+	page := []db.PageOption{
+		db.WithLimit(limit + 1),
 		db.WithCursor(db.Cursor{Offset: cursor.Offset, ID: cursor.ID}),
-	)
+	}
+
+	q, tag := r.URL.Query().Get("q"), r.URL.Query().Get("tag")
+	if q != "" && tag != "" {
+		http.Error(w, "q and tag cannot be combined", http.StatusBadRequest)
+		return
+	}
+
+	var userFiles []*snips.File
+	var err error
+	switch {
+	case q != "":
+		userFiles, err = a.db.Files.Search(r.Context(), userID, q, page...)
+	case tag != "":
+		tag, err = snips.NormalizeTag(tag)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		userFiles, err = a.db.Files.FindByTag(r.Context(), userID, tag, page...)
+	default:
+		userFiles, err = a.db.Files.FindByUser(r.Context(), userID, page...)
+	}
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
@@ -246,6 +429,10 @@ func (a *API) ListFiles(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *API) CreateFile(w http.ResponseWriter, r *http.Request) {
+	if !requireAccountAccess(w, r) {
+		return
+	}
+
 	content, err := a.readContent(r)
 	if err != nil {
 		switch {
@@ -279,13 +466,66 @@ func (a *API) CreateFile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	burn := false
+	if rawBurn := query.Get("burn"); rawBurn != "" {
+		burn, err = strconv.ParseBool(rawBurn)
+		if err != nil {
+			http.Error(w, "burn must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tags, err := snips.NormalizeTags(query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	description, err := snips.NormalizeDescription(query.Get("desc"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt *time.Time
+	if rawExpires := query.Get("expires"); rawExpires != "" {
+		expires, err := timeutil.ParseDuration(rawExpires)
+		if err != nil || expires <= 0 {
+			http.Error(w, "expires must be a positive duration (e.g. 1h, 7d)", http.StatusBadRequest)
+			return
+		}
+		t := time.Now().UTC().Add(expires)
+		expiresAt = &t
+	}
+
+	bundle, isBundle, err := a.readBundle(r, content)
+	switch {
+	case errors.Is(err, errAPIContentTooLarge):
+		http.Error(w, "content exceeds the file size limit", http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fileType := snips.FileTypeBundle
+	if isBundle {
+		content = bundle
+	} else {
+		fileType = renderer.DetectFileType(content, query.Get("ext"), a.cfg.EnableGuesser)
+	}
+
 	userID, _ := UserID(r.Context())
 	file := &snips.File{
-		Private: private,
-		Size:    uint64(len(content)),
-		UserID:  userID,
-		Type:    renderer.DetectFileType(content, query.Get("ext"), a.cfg.EnableGuesser),
-		Name:    name,
+		Private:       private,
+		Size:          uint64(len(content)),
+		UserID:        userID,
+		Type:          fileType,
+		Name:          name,
+		Description:   description,
+		Tags:          tags,
+		ExpiresAt:     expiresAt,
+		BurnAfterRead: burn,
 	}
 
 	if err := a.db.Files.Create(r.Context(), file, content, a.cfg.Limits.FilesPerUser); err != nil {
@@ -300,12 +540,14 @@ func (a *API) CreateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	a.events.Publish(events.NewEvent(events.KindCreate, file))
 	metrics.IncrCounterWithLabels([]string{"file", "create"}, 1, []metrics.Label{
 		{Name: "private", Value: strconv.FormatBool(file.Private)},
 		{Name: "type", Value: file.Type},
 	})
 	logger.From(r.Context()).Info("file uploaded", "file_id", file.ID, "user_id", file.UserID, "size", file.Size, "private", file.Private, "file_type", file.Type)
 
+	w.Header().Set("ETag", file.ETag())
 	writeJSON(w, http.StatusCreated, file)
 }
 
@@ -315,6 +557,7 @@ func (a *API) GetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", file.ETag())
 	writeJSON(w, http.StatusOK, file)
 }
 
@@ -325,9 +568,11 @@ func (a *API) UpdateFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var patch struct {
-		Name    *string `json:"name"`
-		Private *bool   `json:"private"`
-		Type    *string `json:"type"`
+		Name        *string   `json:"name"`
+		Private     *bool     `json:"private"`
+		Type        *string   `json:"type"`
+		Description *string   `json:"description"`
+		Tags        *[]string `json:"tags"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -337,11 +582,12 @@ func (a *API) UpdateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if patch.Name == nil && patch.Private == nil && patch.Type == nil {
-		http.Error(w, "nothing to update: provide name, private, and/or type", http.StatusBadRequest)
+	if patch.Name == nil && patch.Private == nil && patch.Type == nil && patch.Description == nil && patch.Tags == nil {
+		http.Error(w, "nothing to update: provide name, private, type, description, and/or tags", http.StatusBadRequest)
 		return
 	}
 
+	previous := *file
 	if patch.Name != nil {
 		// an empty name removes it
 		name := ""
@@ -360,6 +606,26 @@ func (a *API) UpdateFile(w http.ResponseWriter, r *http.Request) {
 		file.Private = *patch.Private
 	}
 
+	if patch.Description != nil {
+		// an empty description removes it
+		description, err := snips.NormalizeDescription(*patch.Description)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file.Description = description
+	}
+
+	if patch.Tags != nil {
+		// tags are replaced as a set, so an empty list removes them
+		tags, err := snips.NormalizeTags(*patch.Tags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file.Tags = tags
+	}
+
 	if patch.Type != nil {
 		extension := strings.TrimSpace(*patch.Type)
 		if extension == "" {
@@ -367,6 +633,11 @@ func (a *API) UpdateFile(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if file.IsBundle() {
+			http.Error(w, "bundle members are typed by their file names", http.StatusBadRequest)
+			return
+		}
+
 		content, err := a.db.Files.FindContent(r.Context(), file.ID)
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
@@ -384,12 +655,28 @@ func (a *API) UpdateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if file.Name != previous.Name {
+		a.events.Publish(events.NewRenameEvent(file, previous.Name))
+	}
+	if detailsChanged(&previous, file) {
+		a.events.Publish(events.NewEvent(events.KindUpdate, file))
+	}
 	metrics.IncrCounter([]string{"file", "update"}, 1)
 	logger.From(r.Context()).Info("file updated", "file_id", file.ID, "user_id", file.UserID)
 
+	w.Header().Set("ETag", file.ETag())
 	writeJSON(w, http.StatusOK, file)
 }
 
+// detailsChanged reports whether an update changed more of a file than its
+// name, which is published as a rename of its own.
+func detailsChanged(before, after *snips.File) bool {
+	return before.Private != after.Private ||
+		before.Type != after.Type ||
+		before.Description != after.Description ||
+		!slices.Equal(before.Tags, after.Tags)
+}
+
 func (a *API) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	file := a.findFile(w, r, true)
 	if file == nil {
@@ -401,13 +688,263 @@ func (a *API) DeleteFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	a.events.Publish(events.NewEvent(events.KindDelete, file))
 	metrics.IncrCounter([]string{"file", "delete"}, 1)
 	logger.From(r.Context()).Info("file deleted", "file_id", file.ID, "user_id", file.UserID)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// batchRequest is the body shared by batch requests: the files to act on.
+type batchRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func (b *batchRequest) fileIDs() []string {
+	return b.IDs
+}
+
+// batchResult is the outcome for one file of a batch request. Status is the
+// code the single-file endpoint would have responded with.
+type batchResult struct {
+	ID     string      `json:"id"`
+	Status int         `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	File   *snips.File `json:"file,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+}
+
+// decodeBatch decodes a batch request body into req and checks its IDs,
+// reporting failure with a 400.
+func decodeBatch(w http.ResponseWriter, r *http.Request, req interface{ fileIDs() []string }) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return false
+	}
+
+	ids := req.fileIDs()
+	if len(ids) == 0 || len(ids) > APIMaxBatchSize {
+		http.Error(w, "ids must list between 1 and "+strconv.Itoa(APIMaxBatchSize)+" file ids", http.StatusBadRequest)
+		return false
+	}
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			http.Error(w, "ids must be unique", http.StatusBadRequest)
+			return false
+		}
+		seen[id] = true
+	}
+
+	return true
+}
+
+// missingResult is the result for an ID findFiles didn't resolve: forbidden
+// when the api key can't access the file, otherwise not found.
+func missingResult(r *http.Request, id string) batchResult {
+	if !keyAllowsFile(r, id) {
+		return batchResult{ID: id, Status: http.StatusForbidden, Error: "api key cannot access this file"}
+	}
+
+	return batchResult{ID: id, Status: http.StatusNotFound, Error: "file not found"}
+}
+
+func (a *API) BatchGetFiles(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if !decodeBatch(w, r, &req) {
+		return
+	}
+
+	visible, err := a.findFiles(r, req.IDs, false)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	resp := batchResponse{Results: make([]batchResult, 0, len(req.IDs))}
+	for _, id := range req.IDs {
+		if file, ok := visible[id]; ok {
+			resp.Results = append(resp.Results, batchResult{ID: id, Status: http.StatusOK, File: file})
+		} else {
+			resp.Results = append(resp.Results, missingResult(r, id))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *API) BatchUpdateFiles(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		batchRequest
+		Name       *string   `json:"name"`
+		Private    *bool     `json:"private"`
+		Tags       *[]string `json:"tags"`
+		AddTags    []string  `json:"add_tags"`
+		RemoveTags []string  `json:"remove_tags"`
+	}
+	if !decodeBatch(w, r, &req) {
+		return
+	}
+
+	if req.Name == nil && req.Private == nil && req.Tags == nil && req.AddTags == nil && req.RemoveTags == nil {
+		http.Error(w, "nothing to update: provide name, private, tags, add_tags, and/or remove_tags", http.StatusBadRequest)
+		return
+	}
+
+	// names are unique per user, so one name can't be given to many files
+	if req.Name != nil && *req.Name != "" {
+		http.Error(w, "names can only be removed in a batch, set name to an empty string", http.StatusBadRequest)
+		return
+	}
+
+	if req.Tags != nil && (req.AddTags != nil || req.RemoveTags != nil) {
+		http.Error(w, "tags cannot be combined with add_tags or remove_tags", http.StatusBadRequest)
+		return
+	}
+
+	var tags []string
+	if req.Tags != nil {
+		var err error
+		if tags, err = snips.NormalizeTags(*req.Tags); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	for _, list := range [][]string{req.AddTags, req.RemoveTags} {
+		for i, tag := range list {
+			normalized, err := snips.NormalizeTag(tag)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			list[i] = normalized
+		}
+	}
+
+	visible, err := a.findFiles(r, req.IDs, true)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]batchResult, 0, len(req.IDs))
+	updated := make([]*snips.File, 0, len(visible))
+	previous := make(map[string]snips.File, len(visible))
+	for _, id := range req.IDs {
+		file, ok := visible[id]
+		if !ok {
+			results = append(results, missingResult(r, id))
+			continue
+		}
+		previous[file.ID] = *file
+
+		fileTags := file.Tags
+		switch {
+		case req.Tags != nil:
+			fileTags = tags
+		case req.AddTags != nil || req.RemoveTags != nil:
+			fileTags = slices.DeleteFunc(slices.Concat(file.Tags, req.AddTags), func(tag string) bool {
+				return slices.Contains(req.RemoveTags, tag)
+			})
+
+			// adding tags can take a file past the limit
+			if fileTags, err = snips.NormalizeTags(fileTags); err != nil {
+				results = append(results, batchResult{ID: id, Status: http.StatusBadRequest, Error: err.Error()})
+				continue
+			}
+		}
+
+		file.Tags = fileTags
+		if req.Name != nil {
+			file.Name = ""
+		}
+		if req.Private != nil {
+			file.Private = *req.Private
+		}
+
+		results = append(results, batchResult{ID: id, Status: http.StatusOK, File: file})
+		updated = append(updated, file)
+	}
+
+	if len(updated) > 0 {
+		if err := a.db.Files.UpdateMany(r.Context(), updated); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		for _, file := range updated {
+			before := previous[file.ID]
+			if file.Name != before.Name {
+				a.events.Publish(events.NewRenameEvent(file, before.Name))
+			}
+			if detailsChanged(&before, file) {
+				a.events.Publish(events.NewEvent(events.KindUpdate, file))
+			}
+		}
+
+		userID, _ := UserID(r.Context())
+		metrics.IncrCounter([]string{"file", "update"}, float32(len(updated)))
+		logger.From(r.Context()).Info("files updated", "count", len(updated), "user_id", userID)
+	}
+
+	writeJSON(w, http.StatusOK, batchResponse{Results: results})
+}
+
+func (a *API) BatchDeleteFiles(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if !decodeBatch(w, r, &req) {
+		return
+	}
+
+	visible, err := a.findFiles(r, req.IDs, true)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([]batchResult, 0, len(req.IDs))
+	deleted := make([]string, 0, len(visible))
+	for _, id := range req.IDs {
+		if _, ok := visible[id]; !ok {
+			results = append(results, missingResult(r, id))
+			continue
+		}
+
+		results = append(results, batchResult{ID: id, Status: http.StatusNoContent})
+		deleted = append(deleted, id)
+	}
+
+	if len(deleted) > 0 {
+		if _, err := a.db.Files.DeleteMany(r.Context(), deleted); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		for _, id := range deleted {
+			a.events.Publish(events.NewEvent(events.KindDelete, visible[id]))
+		}
+
+		userID, _ := UserID(r.Context())
+		metrics.IncrCounter([]string{"file", "delete"}, float32(len(deleted)))
+		logger.From(r.Context()).Info("files deleted", "file_ids", deleted, "user_id", userID)
+	}
+
+	writeJSON(w, http.StatusOK, batchResponse{Results: results})
+}
+
 func (a *API) GetFileContent(w http.ResponseWriter, r *http.Request) {
+	if !keyAllowsFile(r, r.PathValue("fileID")) {
+		http.Error(w, "api key cannot access this file", http.StatusForbidden)
+		return
+	}
+
 	file, content, err := a.db.Files.FindWithContent(r.Context(), r.PathValue("fileID"))
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -415,13 +952,33 @@ func (a *API) GetFileContent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID, _ := UserID(r.Context())
-	if file == nil || (file.UserID != userID && file.Private) {
+	if file == nil || file.IsExpired() || (file.UserID != userID && file.Private) {
 		http.Error(w, "file not found", http.StatusNotFound)
 		return
 	}
 
+	if file.BurnAfterRead {
+		content, err = files.View(r.Context(), a.db, a.events, file)
+		if err != nil {
+			if errors.Is(err, files.ErrBurned) {
+				http.Error(w, "file not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// a burned file is gone, so it can't be updated conditionally
+	if !file.BurnAfterRead {
+		w.Header().Set("ETag", file.ETag())
+	}
+
 	contentType := "text/plain; charset=utf-8"
-	if file.IsBinary() {
+	switch {
+	case file.IsBundle():
+		contentType = "application/x-tar"
+	case file.IsBinary():
 		contentType = "application/octet-stream"
 	}
 
@@ -435,6 +992,16 @@ func (a *API) UpdateFileContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var conds []db.PreconditionOption
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		if !etagMatches(ifMatch, file.ETag()) {
+			http.Error(w, "file was modified", http.StatusPreconditionFailed)
+			return
+		}
+		// the file could still change before it's written
+		conds = append(conds, db.IfUpdatedAt(file.UpdatedAt))
+	}
+
 	content, err := a.readContent(r)
 	if err != nil {
 		switch {
@@ -448,16 +1015,68 @@ func (a *API) UpdateFileContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := files.UpdateContent(r.Context(), a.db, a.cfg, file, content, r.URL.Query().Get("ext")); err != nil {
-		http.Error(w, "internal error", http.StatusInternalServerError)
+	if err := files.UpdateContent(r.Context(), a.db, a.cfg, file, content, r.URL.Query().Get("ext"), conds...); err != nil {
+		switch {
+		case errors.Is(err, db.ErrPreconditionFailed):
+			http.Error(w, "file was modified", http.StatusPreconditionFailed)
+		case errors.Is(err, snips.ErrInvalidBundle):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, files.ErrTooLarge):
+			http.Error(w, "content exceeds the file size limit", http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	a.events.Publish(events.NewEvent(events.KindUpdate, file))
 	metrics.IncrCounterWithLabels([]string{"file", "update"}, 1, []metrics.Label{
 		{Name: "type", Value: file.Type},
 	})
 	logger.From(r.Context()).Info("file content updated", "file_id", file.ID, "user_id", file.UserID, "size", file.Size, "file_type", file.Type)
 
+	w.Header().Set("ETag", file.ETag())
+	writeJSON(w, http.StatusOK, file)
+}
+
+func (a *API) AppendFileContent(w http.ResponseWriter, r *http.Request) {
+	file := a.findFile(w, r, true)
+	if file == nil {
+		return
+	}
+
+	content, err := a.readContent(r)
+	if err != nil {
+		switch {
+		case errors.Is(err, errAPIContentTooLarge):
+			http.Error(w, "content exceeds the file size limit", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, errAPIContentEmpty):
+			http.Error(w, "content is empty", http.StatusBadRequest)
+		default:
+			http.Error(w, "unable to read content", http.StatusBadRequest)
+		}
+		return
+	}
+
+	if err := files.AppendContent(r.Context(), a.db, a.cfg, file, content); err != nil {
+		switch {
+		case errors.Is(err, files.ErrAppendBundle):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, files.ErrTooLarge):
+			http.Error(w, "content exceeds the file size limit", http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	a.events.Publish(events.NewEvent(events.KindAppend, file))
+	metrics.IncrCounterWithLabels([]string{"file", "append"}, 1, []metrics.Label{
+		{Name: "type", Value: file.Type},
+	})
+	logger.From(r.Context()).Info("file content appended", "file_id", file.ID, "user_id", file.UserID, "appended", len(content), "size", file.Size, "file_type", file.Type)
+
+	w.Header().Set("ETag", file.ETag())
 	writeJSON(w, http.StatusOK, file)
 }
 
@@ -543,6 +1162,99 @@ func (a *API) GetRevision(w http.ResponseWriter, r *http.Request) {
 	}{rev, string(diff)})
 }
 
+func (a *API) GetRevisionContent(w http.ResponseWriter, r *http.Request) {
+	file := a.findFile(w, r, false)
+	if file == nil {
+		return
+	}
+
+	// 0 is the content before the first revision
+	sequence, err := strconv.ParseInt(r.PathValue("sequence"), 10, 64)
+	if err != nil || sequence < 0 {
+		http.Error(w, "sequence must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	content, _, err := files.ContentAt(r.Context(), a.db, file, sequence)
+	if err != nil {
+		switch {
+		case errors.Is(err, files.ErrRevisionNotFound):
+			http.Error(w, "revision not found", http.StatusNotFound)
+		case errors.Is(err, files.ErrRevisionUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// revisions are only recorded for text content
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(content)
+}
+
+func (a *API) CompareRevisions(w http.ResponseWriter, r *http.Request) {
+	file := a.findFile(w, r, false)
+	if file == nil {
+		return
+	}
+
+	from, to, err := files.ParseRevisionRange(r.PathValue("revisions"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diff, err := files.Compare(r.Context(), a.db, file, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, files.ErrInvalidRevision):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, files.ErrRevisionNotFound):
+			http.Error(w, "revision not found", http.StatusNotFound)
+		case errors.Is(err, files.ErrRevisionUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	_, _ = w.Write([]byte(diff))
+}
+
+func (a *API) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	file := a.findFile(w, r, true)
+	if file == nil {
+		return
+	}
+
+	// 0 is the content before the first revision
+	sequence, err := strconv.ParseInt(r.PathValue("sequence"), 10, 64)
+	if err != nil || sequence < 0 {
+		http.Error(w, "sequence must be a non-negative integer", http.StatusBadRequest)
+		return
+	}
+
+	if err := files.Restore(r.Context(), a.db, a.cfg, file, sequence); err != nil {
+		switch {
+		case errors.Is(err, files.ErrRevisionNotFound):
+			http.Error(w, "revision not found", http.StatusNotFound)
+		case errors.Is(err, files.ErrRevisionUnavailable):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	a.events.Publish(events.NewEvent(events.KindUpdate, file))
+
+	w.Header().Set("ETag", file.ETag())
+	writeJSON(w, http.StatusOK, file)
+}
+
 func (a *API) SignFile(w http.ResponseWriter, r *http.Request) {
 	file := a.findFile(w, r, true)
 	if file == nil {
@@ -1,11 +1,15 @@
 package web_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -13,6 +17,7 @@ import (
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
 	dbmock "github.com/robherley/snips.sh/internal/db/mock"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
 	"github.com/robherley/snips.sh/internal/web"
@@ -26,6 +31,7 @@ type APISuite struct {
 	config *config.Config
 	assets web.Assets
 	mockDB *dbmock.Database
+	hub    *events.Hub
 	server *httptest.Server
 
 	token  string
@@ -47,8 +53,9 @@ func (suite *APISuite) SetupSuite() {
 
 func (suite *APISuite) SetupTest() {
 	suite.mockDB = dbmock.NewDB(suite.T())
+	suite.hub = events.NewHub()
 
-	service, err := web.New(suite.config, suite.mockDB.DB, suite.assets)
+	service, err := web.New(suite.config, suite.mockDB.DB, suite.assets, suite.hub)
 	suite.Require().NoError(err)
 
 	suite.server = httptest.NewServer(service.Handler)
@@ -89,6 +96,19 @@ func (suite *APISuite) request(method, path string, body io.Reader, authed bool)
 	return res
 }
 
+func (suite *APISuite) upload(path, contentType string, body io.Reader) *http.Response {
+	req, err := http.NewRequest("POST", suite.server.URL+path, body)
+	suite.Require().NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+suite.token)
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := suite.server.Client().Do(req)
+	suite.Require().NoError(err)
+
+	return res
+}
+
 func (suite *APISuite) decode(res *http.Response, v any) {
 	defer res.Body.Close()
 	suite.Require().NoError(json.NewDecoder(res.Body).Decode(v))
@@ -148,6 +168,33 @@ func (suite *APISuite) TestUnauthorized() {
 	}
 }
 
+func (suite *APISuite) TestUnauthorized_RateLimitedByIP() {
+	cfg := *suite.config
+	cfg.RateLimit.IP, cfg.RateLimit.IPBurst = 1, 1
+
+	service, err := web.New(&cfg, suite.mockDB.DB, suite.assets, suite.hub)
+	suite.Require().NoError(err)
+	server := httptest.NewServer(service.Handler)
+	defer server.Close()
+
+	suite.mockDB.APIKeys.EXPECT().FindByTokenHash(mock.Anything, mock.Anything).Return(nil, nil)
+
+	// a fresh made up token each time still counts against the ip
+	for _, status := range []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		token, _, err := snips.NewAPIKeyToken()
+		suite.Require().NoError(err)
+
+		req, err := http.NewRequest("GET", server.URL+"/api/v1/user", nil)
+		suite.Require().NoError(err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		res, err := server.Client().Do(req)
+		suite.Require().NoError(err)
+		res.Body.Close()
+		suite.Equal(status, res.StatusCode)
+	}
+}
+
 func (suite *APISuite) TestGetUser() {
 	suite.expectAuth()
 	suite.mockDB.Users.EXPECT().Find(mock.Anything, suite.userID).Return(&snips.User{ID: suite.userID, CreatedAt: time.Now().UTC()}, nil).Once()
@@ -196,6 +243,43 @@ func (suite *APISuite) TestListFiles_Paginated() {
 	suite.Empty(page.NextCursor)
 }
 
+func (suite *APISuite) TestListFiles_Search() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Search(mock.Anything, suite.userID, "nginx config", mock.Anything, mock.Anything).Return([]*snips.File{suite.file("file1", false)}, nil).Once()
+
+	res := suite.request("GET", "/api/v1/files?q=nginx+config", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	page := struct {
+		Files []map[string]any `json:"files"`
+	}{}
+	suite.decode(res, &page)
+	suite.Require().Len(page.Files, 1)
+	suite.Equal("file1", page.Files[0]["id"])
+}
+
+func (suite *APISuite) TestListFiles_Tag() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindByTag(mock.Anything, suite.userID, "infra", mock.Anything, mock.Anything).Return([]*snips.File{suite.file("file1", false)}, nil).Once()
+
+	res := suite.request("GET", "/api/v1/files?tag=%23Infra", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	page := struct {
+		Files []map[string]any `json:"files"`
+	}{}
+	suite.decode(res, &page)
+	suite.Require().Len(page.Files, 1)
+	suite.Equal("file1", page.Files[0]["id"])
+
+	for _, query := range []string{"tag=c%2B%2B", "tag=go&q=nginx"} {
+		suite.expectAuth()
+		res = suite.request("GET", "/api/v1/files?"+query, nil, true)
+		res.Body.Close()
+		suite.Equal(http.StatusBadRequest, res.StatusCode, query)
+	}
+}
+
 func (suite *APISuite) TestListFiles_BadCursorAndLimit() {
 	suite.expectAuth()
 	res := suite.request("GET", "/api/v1/files?cursor=!!!", nil, true)
@@ -244,6 +328,152 @@ func (suite *APISuite) TestCreateFile() {
 	suite.Equal(true, file["private"])
 }
 
+func (suite *APISuite) TestCreateFile_TagsAndDescription() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Create(mock.Anything, mock.MatchedBy(func(file *snips.File) bool {
+		return file.Description == "deploy script" && slices.Equal(file.Tags, []string{"deploy", "go", "infra"})
+	}), []byte("hello world"), suite.config.Limits.FilesPerUser).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files?tag=go&tag=infra,deploy&desc=deploy+script", strings.NewReader("hello world"), true)
+	suite.Equal(http.StatusCreated, res.StatusCode)
+
+	file := map[string]any{}
+	suite.decode(res, &file)
+	suite.Equal("deploy script", file["description"])
+	suite.Equal([]any{"deploy", "go", "infra"}, file["tags"])
+
+	suite.expectAuth()
+	res = suite.request("POST", "/api/v1/files?tag=c%2B%2B", strings.NewReader("hello world"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (suite *APISuite) TestCreateFile_Expires() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Create(mock.Anything, mock.Anything, []byte("hello world"), suite.config.Limits.FilesPerUser).RunAndReturn(
+		func(_ context.Context, file *snips.File, _ []byte, _ uint64) error {
+			suite.Require().NotNil(file.ExpiresAt)
+			suite.WithinDuration(time.Now().Add(7*24*time.Hour), *file.ExpiresAt, time.Minute)
+			file.ID = "newfile"
+			return nil
+		}).Once()
+
+	res := suite.request("POST", "/api/v1/files?expires=7d", strings.NewReader("hello world"), true)
+	suite.Equal(http.StatusCreated, res.StatusCode)
+
+	file := map[string]any{}
+	suite.decode(res, &file)
+	suite.Contains(file, "expires_at")
+
+	// invalid durations are rejected
+	for _, expires := range []string{"soon", "-1h", "0s"} {
+		suite.expectAuth()
+		res = suite.request("POST", "/api/v1/files?expires="+expires, strings.NewReader("hi"), true)
+		res.Body.Close()
+		suite.Equal(http.StatusBadRequest, res.StatusCode, expires)
+	}
+}
+
+func (suite *APISuite) TestCreateFile_Burn() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Create(mock.Anything, mock.MatchedBy(func(file *snips.File) bool {
+		return file.BurnAfterRead
+	}), []byte("secret"), suite.config.Limits.FilesPerUser).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files?burn=true", strings.NewReader("secret"), true)
+	suite.Equal(http.StatusCreated, res.StatusCode)
+
+	file := map[string]any{}
+	suite.decode(res, &file)
+	suite.Equal(true, file["burn_after_read"])
+
+	suite.expectAuth()
+	res = suite.request("POST", "/api/v1/files?burn=maybe", strings.NewReader("secret"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (suite *APISuite) TestCreateFile_Multipart() {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("files", "cmd/main.go")
+	suite.Require().NoError(err)
+	_, _ = part.Write([]byte("package main"))
+	part, err = mw.CreateFormFile("files", "README.md")
+	suite.Require().NoError(err)
+	_, _ = part.Write([]byte("# hi"))
+	suite.Require().NoError(mw.WriteField("ignored", "not a file"))
+	suite.Require().NoError(mw.Close())
+
+	want, err := snips.EncodeBundle([]snips.BundleMember{
+		{Name: "cmd/main.go", Content: []byte("package main")},
+		{Name: "README.md", Content: []byte("# hi")},
+	})
+	suite.Require().NoError(err)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Create(mock.Anything, mock.MatchedBy(func(file *snips.File) bool {
+		return file.IsBundle() && file.Size == uint64(len(want))
+	}), want, suite.config.Limits.FilesPerUser).Return(nil).Once()
+
+	res := suite.upload("/api/v1/files?name=example", mw.FormDataContentType(), &body)
+	suite.Equal(http.StatusCreated, res.StatusCode)
+
+	file := map[string]any{}
+	suite.decode(res, &file)
+	suite.Equal("bundle", file["type"])
+}
+
+func (suite *APISuite) TestCreateFile_Tar() {
+	tarball, err := snips.EncodeBundle([]snips.BundleMember{{Name: "a.txt", Content: []byte("a")}})
+	suite.Require().NoError(err)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Create(mock.Anything, mock.MatchedBy(func(file *snips.File) bool {
+		return file.IsBundle()
+	}), tarball, suite.config.Limits.FilesPerUser).Return(nil).Once()
+
+	res := suite.upload("/api/v1/files", "application/x-tar", bytes.NewReader(tarball))
+	res.Body.Close()
+	suite.Equal(http.StatusCreated, res.StatusCode)
+}
+
+func (suite *APISuite) TestCreateFile_InvalidBundle() {
+	multipartBody := func(filename string) (string, io.Reader) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		if filename != "" {
+			part, err := mw.CreateFormFile("files", filename)
+			suite.Require().NoError(err)
+			_, _ = part.Write([]byte("hi"))
+		} else {
+			suite.Require().NoError(mw.WriteField("field", "hi"))
+		}
+		suite.Require().NoError(mw.Close())
+		return mw.FormDataContentType(), &body
+	}
+
+	// no file parts
+	suite.expectAuth()
+	contentType, body := multipartBody("")
+	res := suite.upload("/api/v1/files", contentType, body)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	// path traversal
+	suite.expectAuth()
+	contentType, body = multipartBody("../escape.txt")
+	res = suite.upload("/api/v1/files", contentType, body)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	// not a tar stream
+	suite.expectAuth()
+	res = suite.upload("/api/v1/files", "application/x-tar", strings.NewReader(strings.Repeat("not a tarball ", 64)))
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
 func (suite *APISuite) TestCreateFile_Errors() {
 	// empty body
 	suite.expectAuth()
@@ -311,10 +541,22 @@ func (suite *APISuite) TestGetFile_Visibility() {
 	res = suite.request("GET", "/api/v1/files/nope", nil, true)
 	res.Body.Close()
 	suite.Equal(http.StatusNotFound, res.StatusCode)
+
+	// expired file is a 404, even to its owner
+	expired := suite.file("expired", false)
+	past := time.Now().Add(-time.Minute)
+	expired.ExpiresAt = &past
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "expired").Return(expired, nil).Once()
+	res = suite.request("GET", "/api/v1/files/expired", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
 }
 
 func (suite *APISuite) TestUpdateFile() {
 	file := suite.file("file1", false)
+	stream, unsubscribe := suite.hub.Subscribe("file1")
+	defer unsubscribe()
 
 	suite.expectAuth()
 	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
@@ -327,6 +569,55 @@ func (suite *APISuite) TestUpdateFile() {
 	suite.decode(res, &updated)
 	suite.Equal("renamed", updated["name"])
 	suite.Equal(true, updated["private"])
+
+	// the rename and the visibility change are published separately
+	suite.Require().Len(stream, 2)
+	suite.Equal(events.KindRename, (<-stream).Kind)
+	suite.Equal(events.KindUpdate, (<-stream).Kind)
+}
+
+func (suite *APISuite) TestUpdateFile_TagsAndDescription() {
+	file := suite.file("file1", false)
+	file.Description = "old"
+	file.Tags = []string{"old"}
+	stream, unsubscribe := suite.hub.Subscribe("file1")
+	defer unsubscribe()
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+
+	res := suite.request("PATCH", "/api/v1/files/file1", strings.NewReader(`{"description":"nginx config","tags":["Nginx","#infra"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	updated := map[string]any{}
+	suite.decode(res, &updated)
+	suite.Equal("nginx config", updated["description"])
+	suite.Equal([]any{"infra", "nginx"}, updated["tags"])
+	suite.Require().Len(stream, 1)
+	suite.Equal(events.KindUpdate, (<-stream).Kind)
+
+	// empty values clear them
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().Update(mock.Anything, mock.Anything).Return(nil).Once()
+
+	res = suite.request("PATCH", "/api/v1/files/file1", strings.NewReader(`{"description":"","tags":[]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	updated = map[string]any{}
+	suite.decode(res, &updated)
+	suite.NotContains(updated, "description")
+	suite.NotContains(updated, "tags")
+	suite.Require().Len(stream, 1)
+	suite.Equal(events.KindUpdate, (<-stream).Kind)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+
+	res = suite.request("PATCH", "/api/v1/files/file1", strings.NewReader(`{"tags":["c++"]}`), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
 }
 
 func (suite *APISuite) TestUpdateFile_MutationsAreOwnerOnly() {
@@ -364,6 +655,169 @@ func (suite *APISuite) TestDeleteFile() {
 	suite.Equal(http.StatusNoContent, res.StatusCode)
 }
 
+type batchResults struct {
+	Results []struct {
+		ID     string         `json:"id"`
+		Status int            `json:"status"`
+		Error  string         `json:"error"`
+		File   map[string]any `json:"file"`
+	} `json:"results"`
+}
+
+func (suite *APISuite) TestBatchGetFiles() {
+	mine := suite.file("mine", true)
+	public := suite.file("theirs-public", false)
+	public.UserID = "someone-else"
+	hidden := suite.file("theirs-private", true)
+	hidden.UserID = "someone-else"
+
+	ids := []string{"mine", "theirs-public", "theirs-private", "nope"}
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, ids).Return([]*snips.File{hidden, public, mine}, nil).Once()
+
+	res := suite.request("POST", "/api/v1/files:batchGet", strings.NewReader(`{"ids":["mine","theirs-public","theirs-private","nope"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 4)
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusNotFound, http.StatusNotFound} {
+		suite.Equal(ids[i], body.Results[i].ID)
+		suite.Equal(want, body.Results[i].Status)
+	}
+	suite.Equal("mine", body.Results[0].File["id"])
+	suite.Nil(body.Results[2].File)
+	suite.Equal("file not found", body.Results[2].Error)
+}
+
+func (suite *APISuite) TestBatchUpdateFiles() {
+	first := suite.file("first", false)
+	first.Name = "first"
+	first.Tags = []string{"old", "keep"}
+	second := suite.file("second", false)
+	second.Tags = []string{"keep"}
+	public := suite.file("theirs", false)
+	public.UserID = "someone-else"
+	firstStream, unsubscribeFirst := suite.hub.Subscribe("first")
+	defer unsubscribeFirst()
+	secondStream, unsubscribeSecond := suite.hub.Subscribe("second")
+	defer unsubscribeSecond()
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, []string{"first", "second", "theirs"}).Return([]*snips.File{first, second, public}, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateMany(mock.Anything, []*snips.File{first, second}).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files:batchUpdate", strings.NewReader(`{
+		"ids": ["first", "second", "theirs"],
+		"private": true,
+		"name": "",
+		"add_tags": ["New"],
+		"remove_tags": ["old"]
+	}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 3)
+	suite.Equal(http.StatusOK, body.Results[0].Status)
+	suite.Equal(http.StatusOK, body.Results[1].Status)
+	suite.Equal(http.StatusNotFound, body.Results[2].Status)
+
+	suite.True(first.Private)
+	suite.Empty(first.Name)
+	suite.Equal([]string{"keep", "new"}, first.Tags)
+	suite.Equal([]string{"keep", "new"}, second.Tags)
+	suite.False(public.Private)
+
+	// only the named file is renamed, both are updated
+	suite.Require().Len(firstStream, 2)
+	suite.Equal(events.KindRename, (<-firstStream).Kind)
+	suite.Equal(events.KindUpdate, (<-firstStream).Kind)
+	suite.Require().Len(secondStream, 1)
+	suite.Equal(events.KindUpdate, (<-secondStream).Kind)
+}
+
+func (suite *APISuite) TestBatchUpdateFiles_TagLimit() {
+	full := suite.file("full", false)
+	for i := range snips.TagsMaxCount {
+		full.Tags = append(full.Tags, "tag"+strconv.Itoa(i))
+	}
+	roomy := suite.file("roomy", false)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, []string{"full", "roomy"}).Return([]*snips.File{full, roomy}, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateMany(mock.Anything, []*snips.File{roomy}).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files:batchUpdate", strings.NewReader(`{"ids":["full","roomy"],"add_tags":["extra"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 2)
+	suite.Equal(http.StatusBadRequest, body.Results[0].Status)
+	suite.NotEmpty(body.Results[0].Error)
+	suite.Equal(http.StatusOK, body.Results[1].Status)
+	suite.Len(full.Tags, snips.TagsMaxCount)
+}
+
+func (suite *APISuite) TestBatchUpdateFiles_Errors() {
+	tooMany := make([]string, web.APIMaxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = `"file` + strconv.Itoa(i) + `"`
+	}
+
+	for name, body := range map[string]string{
+		"no ids":         `{"private":true}`,
+		"too many ids":   `{"ids":[` + strings.Join(tooMany, ",") + `],"private":true}`,
+		"duplicate ids":  `{"ids":["a","a"],"private":true}`,
+		"nothing to do":  `{"ids":["a"]}`,
+		"naming":         `{"ids":["a"],"name":"shared"}`,
+		"tags and add":   `{"ids":["a"],"tags":["x"],"add_tags":["y"]}`,
+		"invalid tag":    `{"ids":["a"],"remove_tags":["c++"]}`,
+		"unknown fields": `{"ids":["a"],"type":"go"}`,
+	} {
+		suite.Run(name, func() {
+			suite.expectAuth()
+			res := suite.request("POST", "/api/v1/files:batchUpdate", strings.NewReader(body), true)
+			res.Body.Close()
+			suite.Equal(http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
+
+func (suite *APISuite) TestBatchDeleteFiles() {
+	mine := suite.file("mine", false)
+	public := suite.file("theirs", false)
+	public.UserID = "someone-else"
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, []string{"mine", "theirs", "nope"}).Return([]*snips.File{mine, public}, nil).Once()
+	suite.mockDB.Files.EXPECT().DeleteMany(mock.Anything, []string{"mine"}).Return(1, nil).Once()
+
+	res := suite.request("POST", "/api/v1/files:batchDelete", strings.NewReader(`{"ids":["mine","theirs","nope"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 3)
+	suite.Equal(http.StatusNoContent, body.Results[0].Status)
+	suite.Equal(http.StatusNotFound, body.Results[1].Status)
+	suite.Equal(http.StatusNotFound, body.Results[2].Status)
+}
+
+func (suite *APISuite) TestBatchDeleteFiles_NoneOwned() {
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, []string{"nope"}).Return([]*snips.File{}, nil).Once()
+
+	res := suite.request("POST", "/api/v1/files:batchDelete", strings.NewReader(`{"ids":["nope"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 1)
+	suite.Equal(http.StatusNotFound, body.Results[0].Status)
+}
+
 func (suite *APISuite) TestGetFileContent() {
 	file := suite.file("file1", false)
 
@@ -380,6 +834,65 @@ func (suite *APISuite) TestGetFileContent() {
 	suite.Equal("hello world", string(body))
 }
 
+func (suite *APISuite) TestGetFileContent_Bundle() {
+	file := suite.file("file1", false)
+	file.Type = snips.FileTypeBundle
+	tarball, err := snips.EncodeBundle([]snips.BundleMember{{Name: "a.txt", Content: []byte("a")}})
+	suite.Require().NoError(err)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindWithContent(mock.Anything, "file1").Return(file, tarball, nil).Once()
+
+	res := suite.request("GET", "/api/v1/files/file1/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.Equal("application/x-tar", res.Header.Get("Content-Type"))
+}
+
+func (suite *APISuite) TestGetFileContent_BurnAfterRead() {
+	file := suite.file("file1", false)
+	file.BurnAfterRead = true
+	stream, unsubscribe := suite.hub.Subscribe("file1")
+	defer unsubscribe()
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindWithContent(mock.Anything, "file1").Return(file, []byte("secret"), nil).Once()
+	suite.mockDB.Files.EXPECT().FindWithContentAndDelete(mock.Anything, "file1").Return(file, []byte("secret"), nil).Once()
+
+	res := suite.request("GET", "/api/v1/files/file1/content", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	suite.Require().NoError(err)
+	suite.Equal("secret", string(body))
+
+	// the burn is a delete
+	suite.Require().Len(stream, 1)
+	suite.Equal(events.KindDelete, (<-stream).Kind)
+
+	// a concurrent reader burned it first
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindWithContent(mock.Anything, "file1").Return(file, []byte("secret"), nil).Once()
+	suite.mockDB.Files.EXPECT().FindWithContentAndDelete(mock.Anything, "file1").Return(nil, nil, nil).Once()
+
+	res = suite.request("GET", "/api/v1/files/file1/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *APISuite) TestGetFileContent_Expired() {
+	file := suite.file("file1", false)
+	past := time.Now().Add(-time.Minute)
+	file.ExpiresAt = &past
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindWithContent(mock.Anything, "file1").Return(file, []byte("hello world"), nil).Once()
+
+	res := suite.request("GET", "/api/v1/files/file1/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
 func (suite *APISuite) TestUpdateFileContent() {
 	file := suite.file("file1", false)
 
@@ -398,6 +911,168 @@ func (suite *APISuite) TestUpdateFileContent() {
 	suite.Equal(float64(15), updated["size"])
 }
 
+func (suite *APISuite) TestUpdateFileContent_IfMatch() {
+	file := suite.file("file1", false)
+	etag := file.ETag()
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	res := suite.request("GET", "/api/v1/files/file1", nil, true)
+	res.Body.Close()
+	suite.Equal(etag, res.Header.Get("ETag"))
+
+	put := func(ifMatch string) *http.Response {
+		req, err := http.NewRequest("PUT", suite.server.URL+"/api/v1/files/file1/content", strings.NewReader("hello new world"))
+		suite.Require().NoError(err)
+		req.Header.Set("Authorization", "Bearer "+suite.token)
+		req.Header.Set("If-Match", ifMatch)
+
+		res, err := suite.server.Client().Do(req)
+		suite.Require().NoError(err)
+		return res
+	}
+
+	// stale etags are rejected before anything is read or written
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	res = put(`"stale"`)
+	res.Body.Close()
+	suite.Equal(http.StatusPreconditionFailed, res.StatusCode)
+
+	// the file can still change between the check and the write
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello world"), nil).Once()
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(0, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, file, []byte("hello new world"), mock.Anything).Return(db.ErrPreconditionFailed).Once()
+	res = put(`"stale", ` + etag)
+	res.Body.Close()
+	suite.Equal(http.StatusPreconditionFailed, res.StatusCode)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello world"), nil).Once()
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(0, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, file, []byte("hello new world"), mock.Anything).
+		RunAndReturn(func(_ context.Context, file *snips.File, _ []byte, _ ...db.PreconditionOption) error {
+			file.UpdatedAt = file.UpdatedAt.Add(time.Second)
+			return nil
+		}).Once()
+	suite.mockDB.Revisions.EXPECT().Create(mock.Anything, mock.Anything, mock.Anything, suite.config.Limits.RevisionsPerFile).Return(nil).Once()
+	res = put(etag)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.NotEmpty(res.Header.Get("ETag"))
+	suite.NotEqual(etag, res.Header.Get("ETag"))
+}
+
+func (suite *APISuite) TestUpdateFileContent_Bundle() {
+	file := suite.file("file1", false)
+	file.Type = snips.FileTypeBundle
+
+	// bundles only take tar streams
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+
+	res := suite.request("PUT", "/api/v1/files/file1/content", strings.NewReader("hello new world"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	tarball, err := snips.EncodeBundle([]snips.BundleMember{{Name: "a.txt", Content: []byte("a")}})
+	suite.Require().NoError(err)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, mock.Anything, tarball).Return(nil).Once()
+
+	res = suite.request("PUT", "/api/v1/files/file1/content", bytes.NewReader(tarball), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	updated := map[string]any{}
+	suite.decode(res, &updated)
+	suite.Equal("bundle", updated["type"])
+}
+
+func (suite *APISuite) TestAppendFileContent() {
+	file := suite.file("file1", false)
+	stream, unsubscribe := suite.hub.Subscribe("file1")
+	defer unsubscribe()
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello world\n"), nil).Times(2)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(0, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, file, []byte("hello world\nmore\n"), mock.Anything).Return(nil).Once()
+	suite.mockDB.Revisions.EXPECT().Create(mock.Anything, mock.Anything, mock.Anything, suite.config.Limits.RevisionsPerFile).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files/file1/content:append", strings.NewReader("more\n"), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.NotEmpty(res.Header.Get("ETag"))
+
+	updated := map[string]any{}
+	suite.decode(res, &updated)
+	suite.Equal(float64(17), updated["size"])
+	suite.Equal("plaintext", updated["type"])
+
+	// live viewers are told about the new content
+	suite.Require().Len(stream, 1)
+	event := <-stream
+	suite.Equal(events.KindAppend, event.Kind)
+	suite.Equal(uint64(17), event.Size)
+}
+
+func (suite *APISuite) TestAppendFileContent_Concurrent() {
+	file := suite.file("file1", false)
+	latest := suite.file("file1", false)
+	latest.UpdatedAt = file.UpdatedAt.Add(time.Second)
+
+	// another append lands between reading and writing, so it's retried
+	// against the latest content
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("a\n"), nil).Times(2)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(0, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, mock.Anything, []byte("a\nc\n"), mock.Anything).Return(db.ErrPreconditionFailed).Once()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(latest, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("a\nb\n"), nil).Times(2)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(1, nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, mock.Anything, []byte("a\nb\nc\n"), mock.Anything).Return(nil).Once()
+	suite.mockDB.Revisions.EXPECT().Create(mock.Anything, mock.Anything, mock.Anything, suite.config.Limits.RevisionsPerFile).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files/file1/content:append", strings.NewReader("c\n"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (suite *APISuite) TestAppendFileContent_Errors() {
+	file := suite.file("file1", false)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return(bytes.Repeat([]byte("a"), int(suite.config.Limits.FileSize)-1), nil).Once()
+
+	res := suite.request("POST", "/api/v1/files/file1/content:append", strings.NewReader("more"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusRequestEntityTooLarge, res.StatusCode)
+
+	bundle := suite.file("file1", false)
+	bundle.Type = snips.FileTypeBundle
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(bundle, nil).Once()
+
+	res = suite.request("POST", "/api/v1/files/file1/content:append", strings.NewReader("more"), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+
+	res = suite.request("POST", "/api/v1/files/file1/content:append", strings.NewReader(""), true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
 func (suite *APISuite) TestListRevisions() {
 	file := suite.file("file1", false)
 	revisions := []*snips.Revision{
@@ -439,6 +1114,134 @@ func (suite *APISuite) TestGetRevision() {
 	suite.Equal("+hello", revision["diff"])
 }
 
+func (suite *APISuite) TestGetRevisionContent() {
+	file := suite.file("file1", false)
+	file.UserID = "other"
+	revisions := []*snips.Revision{
+		{ID: "rev2", Sequence: 2, FileID: "file1", Size: 5},
+		{ID: "rev1", Sequence: 1, FileID: "file1", Size: 2},
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return(revisions, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello"), nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, "rev2").Return([]byte("--- file1 (v1)\n+++ file1 (v2)\n@@ -1 +1 @@\n-hi\n+hello\n"), nil).Once()
+
+	res := suite.request("GET", "/api/v1/files/file1/revisions/1/content", nil, true)
+	defer res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.Equal("text/plain; charset=utf-8", res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	suite.Require().NoError(err)
+	suite.Equal("hi", string(body))
+}
+
+func (suite *APISuite) TestGetRevisionContent_Errors() {
+	file := suite.file("file1", false)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	res := suite.request("GET", "/api/v1/files/file1/revisions/nope/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return([]*snips.Revision{}, nil).Once()
+	res = suite.request("GET", "/api/v1/files/file1/revisions/0/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+
+	private := suite.file("file2", true)
+	private.UserID = "other"
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file2").Return(private, nil).Once()
+	res = suite.request("GET", "/api/v1/files/file2/revisions/1/content", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *APISuite) TestCompareRevisions() {
+	file := suite.file("file1", false)
+	revisions := []*snips.Revision{
+		{ID: "rev1", Sequence: 1, FileID: "file1", Size: 5},
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return(revisions, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello"), nil).Times(2)
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, "rev1").Return([]byte("--- file1 (v0)\n+++ file1 (v1)\n@@ -1 +1 @@\n-hi\n+hello\n"), nil).Once()
+
+	res := suite.request("GET", "/api/v1/files/file1/compare/0...head", nil, true)
+	defer res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+	suite.Equal("text/x-diff; charset=utf-8", res.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(res.Body)
+	suite.Require().NoError(err)
+	suite.Equal("--- file1 (v0)\n+++ file1 (head)\n@@ -1 +1 @@\n-hi\n+hello\n", string(body))
+}
+
+func (suite *APISuite) TestCompareRevisions_Errors() {
+	file := suite.file("file1", false)
+
+	for _, revisions := range []string{"1..2", "latest...head"} {
+		suite.expectAuth()
+		suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+		res := suite.request("GET", "/api/v1/files/file1/compare/"+revisions, nil, true)
+		res.Body.Close()
+		suite.Equal(http.StatusBadRequest, res.StatusCode, revisions)
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return([]*snips.Revision{}, nil).Once()
+	res := suite.request("GET", "/api/v1/files/file1/compare/1...head", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *APISuite) TestRestoreRevision() {
+	file := suite.file("file1", false)
+	rev := &snips.Revision{ID: "rev1", Sequence: 1, FileID: "file1", Size: 5, Type: "plaintext"}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return([]*snips.Revision{rev}, nil).Once()
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, "file1").Return([]byte("hello"), nil).Times(2)
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, "rev1").Return([]byte("--- file1 (v0)\n+++ file1 (v1)\n@@ -1 +1 @@\n-hi\n+hello\n"), nil).Once()
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, "file1").Return(int64(1), nil).Once()
+	suite.mockDB.Revisions.EXPECT().Create(mock.Anything, mock.Anything, mock.Anything, suite.config.Limits.RevisionsPerFile).Return(nil).Once()
+	suite.mockDB.Files.EXPECT().UpdateContent(mock.Anything, file, []byte("hi")).Return(nil).Once()
+
+	res := suite.request("POST", "/api/v1/files/file1/revisions/0/restore", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	restored := map[string]any{}
+	suite.decode(res, &restored)
+	suite.Equal(float64(2), restored["size"])
+}
+
+func (suite *APISuite) TestRestoreRevision_Errors() {
+	file := suite.file("file1", false)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	res := suite.request("POST", "/api/v1/files/file1/revisions/-1/restore", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "file1").Return(file, nil).Once()
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, "file1").Return([]*snips.Revision{}, nil).Once()
+	res = suite.request("POST", "/api/v1/files/file1/revisions/1/restore", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
 func (suite *APISuite) TestSignFile() {
 	private := suite.file("file1", true)
 
@@ -477,6 +1280,167 @@ func (suite *APISuite) TestSignFile_TTLOverflowRejected() {
 	suite.Equal(http.StatusBadRequest, res.StatusCode)
 }
 
+func (suite *APISuite) TestCreateWebhook() {
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Create(mock.Anything, mock.Anything, suite.config.Limits.WebhooksPerUser).RunAndReturn(
+		func(_ context.Context, webhook *snips.Webhook, _ uint64) error {
+			suite.Equal(suite.userID, webhook.UserID)
+			suite.Equal("https://example.com/hook", webhook.URL)
+			suite.Equal([]string{"delete", "update"}, webhook.Events)
+			webhook.ID = "hook1"
+			return nil
+		}).Once()
+
+	res := suite.request("POST", "/api/v1/webhooks", strings.NewReader(`{"url":"https://example.com/hook","events":["update","delete"]}`), true)
+	suite.Equal(http.StatusCreated, res.StatusCode)
+
+	created := map[string]any{}
+	suite.decode(res, &created)
+	suite.Equal("hook1", created["id"])
+	suite.Equal([]any{"delete", "update"}, created["events"])
+	suite.True(strings.HasPrefix(created["secret"].(string), snips.WebhookSecretPrefix))
+}
+
+func (suite *APISuite) TestCreateWebhook_Errors() {
+	for body, status := range map[string]int{
+		`{"url":"ftp://example.com/hook"}`:                      http.StatusBadRequest,
+		`{"url":"https://example.com/hook","events":["bogus"]}`: http.StatusBadRequest,
+		`{"url":"https://example.com/hook","secret":"mine"}`:    http.StatusBadRequest,
+	} {
+		suite.expectAuth()
+		res := suite.request("POST", "/api/v1/webhooks", strings.NewReader(body), true)
+		res.Body.Close()
+		suite.Equal(status, res.StatusCode, body)
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Create(mock.Anything, mock.Anything, mock.Anything).Return(db.ErrWebhookLimit).Once()
+	res := suite.request("POST", "/api/v1/webhooks", strings.NewReader(`{"url":"https://example.com/hook"}`), true)
+	res.Body.Close()
+	suite.Equal(http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func (suite *APISuite) TestGetWebhook() {
+	mine := &snips.Webhook{ID: "hook1", UserID: suite.userID, URL: "https://example.com/hook", Secret: "whsec_secret"}
+	theirs := &snips.Webhook{ID: "hook2", UserID: "someone-else"}
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Find(mock.Anything, "hook1").Return(mine, nil).Once()
+	res := suite.request("GET", "/api/v1/webhooks/hook1", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	got := map[string]any{}
+	suite.decode(res, &got)
+	suite.Equal("hook1", got["id"])
+	suite.NotContains(got, "secret")
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Find(mock.Anything, "hook2").Return(theirs, nil).Once()
+	res = suite.request("GET", "/api/v1/webhooks/hook2", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func (suite *APISuite) TestDeleteWebhook() {
+	webhook := &snips.Webhook{ID: "hook1", UserID: suite.userID}
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Find(mock.Anything, "hook1").Return(webhook, nil).Once()
+	suite.mockDB.Webhooks.EXPECT().Delete(mock.Anything, "hook1", suite.userID).Return(true, nil).Once()
+
+	res := suite.request("DELETE", "/api/v1/webhooks/hook1", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusNoContent, res.StatusCode)
+}
+
+func (suite *APISuite) TestListWebhookDeliveries() {
+	webhook := &snips.Webhook{ID: "hook1", UserID: suite.userID}
+	deliveries := []*snips.WebhookDelivery{
+		{ID: "delivery1", WebhookID: "hook1", Event: snips.WebhookEventUpdate, Status: snips.WebhookDeliveryFailed, Attempts: 8, ResponseCode: 500},
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Find(mock.Anything, "hook1").Return(webhook, nil).Once()
+	suite.mockDB.WebhookDeliveries.EXPECT().FindByWebhook(mock.Anything, "hook1", uint64(5)).Return(deliveries, nil).Once()
+
+	res := suite.request("GET", "/api/v1/webhooks/hook1/deliveries?limit=5", nil, true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var got []map[string]any
+	suite.decode(res, &got)
+	suite.Require().Len(got, 1)
+	suite.Equal("failed", got[0]["status"])
+	suite.EqualValues(500, got[0]["response_code"])
+
+	suite.expectAuth()
+	suite.mockDB.Webhooks.EXPECT().Find(mock.Anything, "hook1").Return(webhook, nil).Once()
+	res = suite.request("GET", "/api/v1/webhooks/hook1/deliveries?limit=0", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (suite *APISuite) TestScopedAPIKey() {
+	suite.apiKey.Scopes = []string{snips.APIKeyScopeFilesRead}
+
+	// a scope the key lacks is rejected before the file is looked up
+	suite.expectAuth()
+	res := suite.request("DELETE", "/api/v1/files/mine", nil, true)
+	suite.Equal(http.StatusForbidden, res.StatusCode)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	suite.Equal("api key lacks the files:delete scope\n", string(body))
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "mine").Return(suite.file("mine", true), nil).Once()
+	res = suite.request("GET", "/api/v1/files/mine", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	// identifying the key's user needs no scope
+	suite.expectAuth()
+	suite.mockDB.Users.EXPECT().Find(mock.Anything, suite.userID).Return(&snips.User{ID: suite.userID}, nil).Once()
+	res = suite.request("GET", "/api/v1/user", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+}
+
+func (suite *APISuite) TestFileRestrictedAPIKey() {
+	suite.apiKey.FileIDs = []string{"mine"}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, "mine").Return(suite.file("mine", true), nil).Once()
+	res := suite.request("GET", "/api/v1/files/mine", nil, true)
+	res.Body.Close()
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	for _, req := range []struct{ method, path, body string }{
+		{"GET", "/api/v1/files/other", ""},
+		{"GET", "/api/v1/files/other/content", ""},
+		{"GET", "/api/v1/files", ""},
+		{"POST", "/api/v1/files", "hello"},
+		{"GET", "/api/v1/webhooks", ""},
+		{"POST", "/api/v1/webhooks", `{"url":"https://example.com/hook"}`},
+		{"GET", "/api/v1/webhooks/hook1", ""},
+	} {
+		suite.expectAuth()
+		res := suite.request(req.method, req.path, strings.NewReader(req.body), true)
+		res.Body.Close()
+		suite.Equal(http.StatusForbidden, res.StatusCode, req.method+" "+req.path)
+	}
+
+	suite.expectAuth()
+	suite.mockDB.Files.EXPECT().FindMany(mock.Anything, []string{"mine", "other"}).Return([]*snips.File{suite.file("mine", false), suite.file("other", false)}, nil).Once()
+	res = suite.request("POST", "/api/v1/files:batchGet", strings.NewReader(`{"ids":["mine","other"]}`), true)
+	suite.Equal(http.StatusOK, res.StatusCode)
+
+	var body batchResults
+	suite.decode(res, &body)
+	suite.Require().Len(body.Results, 2)
+	suite.Equal(http.StatusOK, body.Results[0].Status)
+	suite.Equal(http.StatusForbidden, body.Results[1].Status)
+	suite.Equal("api key cannot access this file", body.Results[1].Error)
+}
+
 func (suite *APISuite) TestOpenAPISpec() {
 	for path, contentType := range map[string]string{
 		"/openapi.yaml": "text/plain; charset=utf-8",
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/robherley/snips.sh/internal/renderer"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+// bundleMember is a bundle member rendered for the file page.
+type bundleMember struct {
+	Name   string
+	Anchor string
+	Type   string
+	Size   string
+	HTML   template.HTML
+}
+
+// renderBundle renders each member of a bundle with its own syntax
+// highlighting. Members get an anchor derived from their name, which also
+// prefixes their line anchors so that lines in different members don't
+// collide.
+func renderBundle(content []byte, useGuesser bool) ([]bundleMember, error) {
+	members, err := snips.ParseBundle(content)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(members))
+	rendered := make([]bundleMember, 0, len(members))
+	for _, member := range members {
+		// names that differ only by punctuation, e.g. "a.go" and "a-go"
+		base := bundleAnchor(member.Name)
+		anchor := base
+		for n := 2; used[anchor]; n++ {
+			anchor = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[anchor] = true
+
+		fileType := renderer.BundleMemberType(member, useGuesser)
+
+		var html template.HTML
+		switch fileType {
+		case snips.FileTypeBinary:
+			html = renderer.BinaryHTMLPlaceholder
+		case snips.FileTypeMarkdown:
+			html, err = renderer.ToMarkdown(member.Content)
+		default:
+			html, err = renderer.ToSyntaxHighlightedHTMLWithLinePrefix(fileType, member.Content, anchor+"-L")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		rendered = append(rendered, bundleMember{
+			Name:   member.Name,
+			Anchor: anchor,
+			Type:   fileType,
+			Size:   humanize.Bytes(uint64(len(member.Content))),
+			HTML:   html,
+		})
+	}
+
+	return rendered, nil
+}
+
+// bundleAnchor turns a member name like "cmd/Main.go" into "file-cmd-main-go".
+// Line anchors append "-L<n>", so the anchor itself never contains an "L".
+func bundleAnchor(name string) string {
+	var sb strings.Builder
+	sb.WriteString("file")
+
+	dash := true
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash {
+				sb.WriteByte('-')
+				dash = false
+			}
+			sb.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,202 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/files"
+	"github.com/robherley/snips.sh/internal/logger"
+)
+
+// compareContext is the number of unchanged lines kept around each change.
+const compareContext = 3
+
+// wordRegex splits a line into words, runs of whitespace and single symbols
+// for intra-line highlighting.
+var wordRegex = regexp.MustCompile(`\w+|\s+|[^\w\s]`)
+
+type splitCell struct {
+	// Number is the 1-based line number, or 0 for an empty cell.
+	Number int
+	Class  string
+	HTML   template.HTML
+}
+
+type splitRow struct {
+	// Hunk is set on separator rows between groups of changes.
+	Hunk  string
+	Left  splitCell
+	Right splitCell
+}
+
+func (ui *UI) Compare(w http.ResponseWriter, r *http.Request) {
+	log := logger.From(r.Context())
+
+	from, to, err := files.ParseRevisionRange(r.PathValue("revisions"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := ui.findFile(r)
+	if err != nil {
+		log.Error("unable to lookup file", "err", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	if file == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if file.Private && !ui.signer.VerifyURLAndNotExpired(*r.URL) {
+		log.Warn("attempted to access private file comparison")
+		http.NotFound(w, r)
+		return
+	}
+
+	contents := make([][]byte, 2)
+	for i, ref := range []string{from, to} {
+		contents[i], err = files.ContentAtRef(r.Context(), ui.db, file, ref)
+		if err != nil {
+			switch {
+			case errors.Is(err, files.ErrRevisionNotFound), errors.Is(err, files.ErrInvalidRevision):
+				http.NotFound(w, r)
+			case errors.Is(err, files.ErrRevisionUnavailable):
+				log.Warn("unable to reconstruct revision", "err", err)
+				http.Error(w, "revision content is no longer available", http.StatusConflict)
+			default:
+				log.Error("unable to reconstruct revision", "err", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	path := filePath(r, file)
+	vars := map[string]interface{}{
+		"FileID":    file.ID,
+		"FilePath":  path,
+		"Private":   file.Private,
+		"From":      from,
+		"To":        to,
+		"FromHREF":  revisionHref(path, from),
+		"ToHREF":    revisionHref(path, to),
+		"SwapHREF":  fmt.Sprintf("%s/compare/%s...%s", path, to, from),
+		"Rows":      splitDiff(contents[0], contents[1]),
+		"CommitSHA": config.BuildCommit(),
+	}
+
+	err = ui.assets.Template("compare.go.html").Execute(w, vars)
+	if err != nil {
+		log.Error("unable to render template", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// revisionHref links to a revision's content, or the file itself for head.
+func revisionHref(path, ref string) string {
+	if ref == files.HeadRevision {
+		return path
+	}
+	return fmt.Sprintf("%s/rev/%s/view", path, ref)
+}
+
+// splitDiff lays out the changes between a and b side by side, with removed
+// lines on the left and added lines on the right. Replaced lines are paired
+// up and their changed words highlighted.
+func splitDiff(a, b []byte) []splitRow {
+	// unlike difflib.SplitLines, no extra empty line is added at the end
+	aLines := strings.SplitAfter(string(a), "\n")
+	bLines := strings.SplitAfter(string(b), "\n")
+	if aLines[len(aLines)-1] == "" {
+		aLines = aLines[:len(aLines)-1]
+	}
+	if bLines[len(bLines)-1] == "" {
+		bLines = bLines[:len(bLines)-1]
+	}
+
+	rows := make([]splitRow, 0)
+	matcher := difflib.NewMatcher(aLines, bLines)
+	for _, group := range matcher.GetGroupedOpCodes(compareContext) {
+		first, last := group[0], group[len(group)-1]
+		rows = append(rows, splitRow{
+			Hunk: fmt.Sprintf("@@ -%d,%d +%d,%d @@", first.I1+1, last.I2-first.I1, first.J1+1, last.J2-first.J1),
+		})
+
+		for _, op := range group {
+			switch op.Tag {
+			case 'e':
+				for i := range op.I2 - op.I1 {
+					line := escapeLine(aLines[op.I1+i])
+					rows = append(rows, splitRow{
+						Left:  splitCell{Number: op.I1 + i + 1, Class: "diff-ctx", HTML: line},
+						Right: splitCell{Number: op.J1 + i + 1, Class: "diff-ctx", HTML: line},
+					})
+				}
+			case 'd', 'i', 'r':
+				for i := 0; i < max(op.I2-op.I1, op.J2-op.J1); i++ {
+					row := splitRow{}
+					ai, bi := op.I1+i, op.J1+i
+					switch {
+					case ai < op.I2 && bi < op.J2:
+						left, right := highlightWords(trimNewline(aLines[ai]), trimNewline(bLines[bi]))
+						row.Left = splitCell{Number: ai + 1, Class: "diff-del", HTML: left}
+						row.Right = splitCell{Number: bi + 1, Class: "diff-add", HTML: right}
+					case ai < op.I2:
+						row.Left = splitCell{Number: ai + 1, Class: "diff-del", HTML: escapeLine(aLines[ai])}
+					default:
+						row.Right = splitCell{Number: bi + 1, Class: "diff-add", HTML: escapeLine(bLines[bi])}
+					}
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+
+	return rows
+}
+
+// highlightWords escapes both lines, wrapping the words that differ between
+// them in diff-word spans.
+func highlightWords(a, b string) (template.HTML, template.HTML) {
+	aWords := wordRegex.FindAllString(a, -1)
+	bWords := wordRegex.FindAllString(b, -1)
+
+	var left, right strings.Builder
+	matcher := difflib.NewMatcher(aWords, bWords)
+	for _, op := range matcher.GetOpCodes() {
+		aText := template.HTMLEscapeString(strings.Join(aWords[op.I1:op.I2], ""))
+		bText := template.HTMLEscapeString(strings.Join(bWords[op.J1:op.J2], ""))
+		if op.Tag == 'e' {
+			left.WriteString(aText)
+			right.WriteString(bText)
+			continue
+		}
+
+		if aText != "" {
+			left.WriteString(`<span class="diff-word">` + aText + `</span>`)
+		}
+		if bText != "" {
+			right.WriteString(`<span class="diff-word">` + bText + `</span>`)
+		}
+	}
+
+	return template.HTML(left.String()), template.HTML(right.String())
+}
+
+func escapeLine(line string) template.HTML {
+	return template.HTML(template.HTMLEscapeString(trimNewline(line)))
+}
+
+func trimNewline(line string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
@@ -5,6 +5,9 @@ type ContextKey string
 const (
 	RequestIDContextKey ContextKey = "request_id"
 	UserIDContextKey    ContextKey = "user_id"
+	APIKeyContextKey    ContextKey = "api_key"
+
+	rateLimitsContextKey ContextKey = "rate_limits"
 
 	RequestIDHeader = "X-Request-ID"
 )
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/logger"
+)
+
+// eventsKeepAlive is how often an idle event stream is written to, so proxies
+// don't close it.
+const eventsKeepAlive = 30 * time.Second
+
+// FileEvents streams a file's changes as server-sent events, one per
+// update, append, rename or delete, until the file is deleted or the client
+// leaves.
+func (ui *UI) FileEvents(w http.ResponseWriter, r *http.Request) {
+	log := logger.From(r.Context())
+
+	file, err := ui.findFile(r)
+	if err != nil {
+		log.Error("unable to lookup file", "err", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	if file == nil || file.BurnAfterRead {
+		http.NotFound(w, r)
+		return
+	}
+
+	if file.Private && !ui.signer.VerifyURLAndNotExpired(*r.URL) {
+		log.Warn("attempted to access private file events")
+		http.NotFound(w, r)
+		return
+	}
+
+	stream, unsubscribe := ui.events.Subscribe(file.ID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Error("unable to stream events", "err", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Error("unable to encode event", "err", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Kind, data); err != nil {
+				return
+			}
+			if event.Kind == events.KindDelete {
+				_ = rc.Flush()
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
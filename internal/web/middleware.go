@@ -5,6 +5,9 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +15,7 @@ import (
 	"github.com/robherley/snips.sh/internal/db"
 	"github.com/robherley/snips.sh/internal/id"
 	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 	"github.com/robherley/snips.sh/internal/snips"
 )
 
@@ -24,8 +28,11 @@ var DefaultMiddleware = []Middleware{
 	WithRequestID,
 }
 
+// WithMiddleware wraps handler in middlewares, the last being the outermost,
+// all within DefaultMiddleware, so what they respond with is still recovered,
+// logged and measured.
 func WithMiddleware(handler http.Handler, middlewares ...Middleware) http.Handler {
-	middlewares = append(DefaultMiddleware, middlewares...)
+	middlewares = append(slices.Clone(middlewares), DefaultMiddleware...)
 
 	withMiddleware := handler
 	for i := range middlewares {
@@ -42,6 +49,13 @@ func UserID(ctx context.Context) (string, bool) {
 	return userID, ok
 }
 
+// APIKey extracts the API key a request was authenticated with from the
+// request context, placed there by WithAuthentication.
+func APIKey(ctx context.Context) (*snips.APIKey, bool) {
+	key, ok := ctx.Value(APIKeyContextKey).(*snips.APIKey)
+	return key, ok
+}
+
 // WithLocalhostOnly rejects requests that do not originate from a loopback address.
 func WithLocalhostOnly(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -59,13 +73,27 @@ func WithLocalhostOnly(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// WithAuthentication authenticates a request with a bearer token.
-func WithAuthentication(database *db.DB, next http.HandlerFunc) http.HandlerFunc {
+// WithAuthentication authenticates a request with a bearer token, rejecting
+// keys that don't grant scope. An empty scope only requires a valid key.
+//
+// Behind WithRateLimit, requests are limited here rather than there: by their
+// key once it's found to be real, or by their client IP otherwise, so made up
+// tokens can't dodge the IP limit.
+func WithAuthentication(database *db.DB, scope string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// the zero value, without limiters, when not behind WithRateLimit
+		limits, _ := r.Context().Value(rateLimitsContextKey).(rateLimits)
+		unauthorized := func(msg string) {
+			if !allowRequest(w, r, "ip", limits.ip, limits.clientIP) {
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="snips.sh api"`)
+			http.Error(w, msg, http.StatusUnauthorized)
+		}
+
 		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
 		if !ok || !strings.HasPrefix(token, snips.APIKeyTokenPrefix) {
-			w.Header().Set("WWW-Authenticate", `Bearer realm="snips.sh api"`)
-			http.Error(w, "missing or malformed api key", http.StatusUnauthorized)
+			unauthorized("missing or malformed api key")
 			return
 		}
 
@@ -76,14 +104,16 @@ func WithAuthentication(database *db.DB, next http.HandlerFunc) http.HandlerFunc
 		}
 
 		if key == nil {
-			w.Header().Set("WWW-Authenticate", `Bearer realm="snips.sh api"`)
-			http.Error(w, "unknown api key", http.StatusUnauthorized)
+			unauthorized("unknown api key")
 			return
 		}
 
 		if key.IsExpired() {
-			w.Header().Set("WWW-Authenticate", `Bearer realm="snips.sh api"`)
-			http.Error(w, "expired api key", http.StatusUnauthorized)
+			unauthorized("expired api key")
+			return
+		}
+
+		if !allowRequest(w, r, "api_key", limits.apiKey, key.ID) {
 			return
 		}
 
@@ -91,11 +121,115 @@ func WithAuthentication(database *db.DB, next http.HandlerFunc) http.HandlerFunc
 			logger.From(r.Context()).Warn("unable to touch api key", "err", err, "api_key_id", key.ID)
 		}
 
+		if scope != "" && !key.HasScope(scope) {
+			http.Error(w, "api key lacks the "+scope+" scope", http.StatusForbidden)
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), UserIDContextKey, key.UserID)
+		ctx = context.WithValue(ctx, APIKeyContextKey, key)
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// rateLimits hands WithRateLimit's limiters, and the client IP it resolved, to
+// WithAuthentication, for requests whose limit depends on whether their API key
+// is real.
+type rateLimits struct {
+	apiKey, ip *ratelimit.Limiter
+	clientIP   string
+}
+
+// allowRequest takes a request from limiter's bucket for key, responding 429
+// with a Retry-After header and reporting false once it's empty.
+func allowRequest(w http.ResponseWriter, r *http.Request, limit string, limiter *ratelimit.Limiter, key string) bool {
+	ok, wait := limiter.Allow(key)
+	if ok {
+		return true
+	}
+
+	metrics.IncrCounterWithLabels([]string{"http", "rate_limited"}, 1, []metrics.Label{{Name: "limit", Value: limit}})
+	logger.From(r.Context()).Warn("rate limited", "limit", limit, "retry_after", wait)
+
+	w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+	http.Error(w, "rate limit exceeded, try again later", http.StatusTooManyRequests)
+	return false
+}
+
+// WithRateLimit throttles requests by their client IP, see clientIP, responding
+// 429 with a Retry-After header once a client runs out. Requests to routes
+// behind WithAuthentication, as reported by authenticated, are left for it to
+// limit, by API key if theirs is real. Assets and health checks aren't limited,
+// as every page view loads the former. A nil limiter disables its limit.
+func WithRateLimit(apiKeys, ips *ratelimit.Limiter, trustedProxies []netip.Prefix, authenticated func(*http.Request) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, trustedProxies)
+
+			if authenticated(r) {
+				limits := rateLimits{apiKey: apiKeys, ip: ips, clientIP: ip}
+				ctx := context.WithValue(r.Context(), rateLimitsContextKey, limits)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if r.URL.Path == "/health" || strings.HasPrefix(r.URL.Path, "/assets/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowRequest(w, r, "ip", ips, ip) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the IP a request came from. That's the host of its remote
+// address, unless the remote address is one of trustedProxies: then it's the
+// nearest untrusted address in X-Forwarded-For, read right to left since
+// clients can prepend whatever they like, or else X-Real-IP.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	trusted := func(ip string) (netip.Addr, bool) {
+		addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = addr.Unmap()
+		return addr, slices.ContainsFunc(trustedProxies, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+	}
+
+	if _, ok := trusted(host); !ok {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for _, hop := range slices.Backward(forwarded) {
+		addr, ok := trusted(hop)
+		if !addr.IsValid() {
+			break
+		}
+		if !ok {
+			return addr.String()
+		}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String()
+	}
+
+	return host
+}
+
 // WithRequestID adds a unique request ID to the request context, and echoes
 // it as a response header so clients can reference it when reporting issues.
 func WithRequestID(next http.Handler) http.Handler {
@@ -4,10 +4,13 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
 	dbmock "github.com/robherley/snips.sh/internal/db/mock"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/web"
 	"github.com/stretchr/testify/assert"
@@ -39,7 +42,7 @@ func TestWithAuthentication(t *testing.T) {
 				database := dbmock.NewDB(t)
 
 				nextCalled := false
-				handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+				handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 					nextCalled = true
 				})
 
@@ -67,7 +70,7 @@ func TestWithAuthentication(t *testing.T) {
 		database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash).Return(nil, nil).Once()
 
 		nextCalled := false
-		handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+		handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 			nextCalled = true
 		})
 
@@ -91,7 +94,7 @@ func TestWithAuthentication(t *testing.T) {
 		database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash).Return(nil, errors.New("boom")).Once()
 
 		nextCalled := false
-		handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+		handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 			nextCalled = true
 		})
 
@@ -118,7 +121,7 @@ func TestWithAuthentication(t *testing.T) {
 			gotUserID string
 			gotOK     bool
 		)
-		handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+		handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 			gotUserID, gotOK = web.UserID(r.Context())
 		})
 
@@ -142,7 +145,7 @@ func TestWithAuthentication(t *testing.T) {
 		database.APIKeys.EXPECT().Touch(mock.Anything, "key123").Return(errors.New("boom")).Once()
 
 		nextCalled := false
-		handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+		handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 			nextCalled = true
 		})
 
@@ -155,6 +158,39 @@ func TestWithAuthentication(t *testing.T) {
 		assert.True(t, nextCalled)
 		assert.Equal(t, http.StatusOK, rec.Code)
 	})
+
+	t.Run("enforces the key's scopes", func(t *testing.T) {
+		token, hash := newToken(t)
+		key := &snips.APIKey{ID: "key123", TokenHash: hash, UserID: "user123", Scopes: []string{snips.APIKeyScopeFilesRead}}
+
+		for scope, expected := range map[string]int{
+			snips.APIKeyScopeFilesRead:   http.StatusOK,
+			snips.APIKeyScopeFilesDelete: http.StatusForbidden,
+		} {
+			database := dbmock.NewDB(t)
+			database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash).Return(key, nil).Once()
+			database.APIKeys.EXPECT().Touch(mock.Anything, "key123").Return(nil).Once()
+
+			var gotKey *snips.APIKey
+			handler := web.WithAuthentication(database.DB, scope, func(w http.ResponseWriter, r *http.Request) {
+				gotKey, _ = web.APIKey(r.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/api/v1/files", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			assert.Equal(t, expected, rec.Code, scope)
+			if expected == http.StatusOK {
+				assert.Equal(t, key, gotKey)
+			} else {
+				assert.Nil(t, gotKey)
+				assert.Equal(t, "api key lacks the files:delete scope\n", rec.Body.String())
+			}
+		}
+	})
 }
 
 func TestWithLocalhostOnly(t *testing.T) {
@@ -188,6 +224,148 @@ func TestWithLocalhostOnly(t *testing.T) {
 	}
 }
 
+func TestWithRateLimit(t *testing.T) {
+	request := func(handler http.Handler, path, remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// routes under /api/v1/ stand in for those behind WithAuthentication
+	authenticated := func(r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, "/api/v1/")
+	}
+
+	newToken := func(t *testing.T) (string, string) {
+		token, hash, err := snips.NewAPIKeyToken()
+		require.NoError(t, err)
+		return token, hash
+	}
+
+	t.Run("limits anonymous requests by ip", func(t *testing.T) {
+		handler := web.WithRateLimit(nil, ratelimit.New(1, 2), nil, authenticated)(next)
+
+		for range 2 {
+			assert.Equal(t, http.StatusOK, request(handler, "/f/abc", "203.0.113.1:1234", "").Code)
+		}
+
+		// the port doesn't matter, only the ip
+		rec := request(handler, "/f/abc", "203.0.113.1:5678", "")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		assert.Equal(t, "rate limit exceeded, try again later\n", rec.Body.String())
+
+		assert.Equal(t, http.StatusOK, request(handler, "/f/abc", "203.0.113.2:1234", "").Code)
+	})
+
+	t.Run("limits tokens to unauthenticated routes by ip", func(t *testing.T) {
+		handler := web.WithRateLimit(ratelimit.New(1, 10), ratelimit.New(1, 1), nil, authenticated)(next)
+
+		token, _ := newToken(t)
+		assert.Equal(t, http.StatusOK, request(handler, "/f/abc", "203.0.113.1:1234", token).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(handler, "/f/abc", "203.0.113.1:1234", token).Code)
+	})
+
+	t.Run("skips assets and health checks", func(t *testing.T) {
+		handler := web.WithRateLimit(nil, ratelimit.New(1, 1), nil, authenticated)(next)
+
+		assert.Equal(t, http.StatusOK, request(handler, "/f/abc", "203.0.113.1:1234", "").Code)
+		for range 3 {
+			assert.Equal(t, http.StatusOK, request(handler, "/assets/index.js", "203.0.113.1:1234", "").Code)
+			assert.Equal(t, http.StatusOK, request(handler, "/health", "203.0.113.1:1234", "").Code)
+		}
+	})
+
+	t.Run("limits real api keys by key, not ip", func(t *testing.T) {
+		token1, hash1 := newToken(t)
+		token2, hash2 := newToken(t)
+
+		database := dbmock.NewDB(t)
+		database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash1).Return(&snips.APIKey{ID: "key1", UserID: "user123"}, nil)
+		database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash2).Return(&snips.APIKey{ID: "key2", UserID: "user123"}, nil)
+		database.APIKeys.EXPECT().Touch(mock.Anything, mock.Anything).Return(nil)
+
+		ips := ratelimit.New(1, 1)
+		handler := web.WithRateLimit(ratelimit.New(1, 1), ips, nil, authenticated)(web.WithAuthentication(database.DB, "", next))
+
+		assert.Equal(t, http.StatusOK, request(handler, "/api/v1/files", "203.0.113.1:1234", token1).Code)
+		assert.Equal(t, http.StatusTooManyRequests, request(handler, "/api/v1/files", "203.0.113.2:1234", token1).Code)
+
+		assert.Equal(t, http.StatusOK, request(handler, "/api/v1/files", "203.0.113.1:1234", token2).Code)
+		assert.Equal(t, 0, ips.Len(), "real keys don't draw from the ip limit")
+	})
+
+	t.Run("limits rotating fake tokens by ip", func(t *testing.T) {
+		database := dbmock.NewDB(t)
+		database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, mock.Anything).Return(nil, nil)
+
+		apiKeys := ratelimit.New(1, 1)
+		handler := web.WithRateLimit(apiKeys, ratelimit.New(1, 2), nil, authenticated)(web.WithAuthentication(database.DB, "", next))
+
+		for range 2 {
+			token, _ := newToken(t)
+			assert.Equal(t, http.StatusUnauthorized, request(handler, "/api/v1/files", "203.0.113.1:1234", token).Code)
+		}
+
+		for range 3 {
+			token, _ := newToken(t)
+			assert.Equal(t, http.StatusTooManyRequests, request(handler, "/api/v1/files", "203.0.113.1:1234", token).Code)
+		}
+
+		// neither is a missing token any different
+		assert.Equal(t, http.StatusTooManyRequests, request(handler, "/api/v1/files", "203.0.113.1:1234", "").Code)
+		assert.Equal(t, 0, apiKeys.Len(), "fake tokens never reach the api key limit")
+	})
+
+	t.Run("reads client ips from trusted proxies", func(t *testing.T) {
+		proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+		handler := web.WithRateLimit(nil, ratelimit.New(1, 1), proxies, authenticated)(next)
+
+		forwarded := func(remoteAddr string, headers map[string]string) int {
+			req := httptest.NewRequest("GET", "/f/abc", nil)
+			req.RemoteAddr = remoteAddr
+			for key, value := range headers {
+				req.Header.Set(key, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		// clients behind the same proxy are limited separately
+		assert.Equal(t, http.StatusOK, forwarded("10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.1"}))
+		assert.Equal(t, http.StatusOK, forwarded("10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.2, 10.0.0.2"}))
+		assert.Equal(t, http.StatusOK, forwarded("10.0.0.1:1234", map[string]string{"X-Real-IP": "203.0.113.3"}))
+		assert.Equal(t, http.StatusTooManyRequests, forwarded("10.0.0.1:1234", map[string]string{"X-Real-IP": "203.0.113.1"}))
+
+		// a client can't spoof its way out by prepending addresses
+		assert.Equal(t, http.StatusTooManyRequests, forwarded("10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.2"}))
+
+		// nor are untrusted remotes' headers believed
+		assert.Equal(t, http.StatusOK, forwarded("203.0.113.4:1234", map[string]string{"X-Forwarded-For": "198.51.100.2"}))
+		assert.Equal(t, http.StatusTooManyRequests, forwarded("203.0.113.4:1234", map[string]string{"X-Forwarded-For": "198.51.100.3"}))
+	})
+
+	t.Run("nil limiters disable limits", func(t *testing.T) {
+		handler := web.WithRateLimit(nil, nil, nil, authenticated)(next)
+
+		for range 10 {
+			assert.Equal(t, http.StatusOK, request(handler, "/f/abc", "203.0.113.1:1234", "").Code)
+		}
+	})
+}
+
 func TestUserID(t *testing.T) {
 	// unauthenticated contexts report !ok rather than panicking
 	req := httptest.NewRequest("GET", "/", nil)
@@ -207,7 +385,7 @@ func TestWithAuthentication_ExpiredKey(t *testing.T) {
 	database.APIKeys.EXPECT().FindByTokenHash(mock.Anything, hash).Return(key, nil).Once()
 
 	nextCalled := false
-	handler := web.WithAuthentication(database.DB, func(w http.ResponseWriter, r *http.Request) {
+	handler := web.WithAuthentication(database.DB, "", func(w http.ResponseWriter, r *http.Request) {
 		nextCalled = true
 	})
 
@@ -5,28 +5,43 @@ import (
 
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/ratelimit"
 )
 
 type Service struct {
 	*http.Server
 }
 
-func New(cfg *config.Config, database *db.DB, assets Assets) (*Service, error) {
+func New(cfg *config.Config, database *db.DB, assets Assets, hub *events.Hub) (*Service, error) {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /health", HealthHandler)
 
-	NewUI(cfg, database, assets).Register(mux)
-	NewAPI(cfg, database).Register(mux)
+	NewUI(cfg, database, assets, hub).Register(mux)
+	api := NewAPI(cfg, database, hub)
+	api.Register(mux)
 
 	if cfg.Debug {
 		mux.HandleFunc("/_debug/pprof/{profile}", WithLocalhostOnly(ProfileHandler))
 	}
 
-	return &Service{
-		&http.Server{
-			Addr:    cfg.HTTP.Internal.Host,
-			Handler: WithMiddleware(mux),
+	rateLimit := WithRateLimit(
+		ratelimit.New(cfg.RateLimit.APIKey, cfg.RateLimit.APIKeyBurst),
+		ratelimit.New(cfg.RateLimit.IP, cfg.RateLimit.IPBurst),
+		cfg.HTTP.TrustedProxies,
+		func(r *http.Request) bool {
+			_, pattern := mux.Handler(r)
+			return api.Authenticates(pattern)
 		},
-	}, nil
+	)
+
+	server := &http.Server{
+		Addr:    cfg.HTTP.Internal.Host,
+		Handler: WithMiddleware(mux, rateLimit),
+	}
+	// event streams never finish on their own, so end them to let shutdown drain
+	server.RegisterOnShutdown(hub.Close)
+
+	return &Service{server}, nil
 }
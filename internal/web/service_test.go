@@ -2,17 +2,21 @@
 package web_test
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/robherley/snips.sh/internal/config"
 	dbmock "github.com/robherley/snips.sh/internal/db/mock"
+	"github.com/robherley/snips.sh/internal/events"
 	"github.com/robherley/snips.sh/internal/signer"
+	"github.com/robherley/snips.sh/internal/snips"
 	"github.com/robherley/snips.sh/internal/testutil"
 	"github.com/robherley/snips.sh/internal/web"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +29,7 @@ type HTTPServiceSuite struct {
 	config  *config.Config
 	assets  web.Assets
 	mockDB  *dbmock.Database
+	hub     *events.Hub
 	service *web.Service
 }
 
@@ -42,9 +47,10 @@ func (suite *HTTPServiceSuite) SetupSuite() {
 
 func (suite *HTTPServiceSuite) SetupTest() {
 	suite.mockDB = dbmock.NewDB(suite.T())
+	suite.hub = events.NewHub()
 
 	var err error
-	suite.service, err = web.New(suite.config, suite.mockDB.DB, suite.assets)
+	suite.service, err = web.New(suite.config, suite.mockDB.DB, suite.assets, suite.hub)
 	suite.Require().NoError(err)
 }
 
@@ -230,6 +236,91 @@ func (suite *HTTPServiceSuite) TestHTTPServer() {
 				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
 			},
 		},
+		{
+			name:     "expired file",
+			method:   "GET",
+			path:     "/f/xPiReDfile",
+			expected: 404,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "xPiReDfile"
+				expiresAt := time.Now().Add(-time.Second)
+				file.ExpiresAt = &expiresAt
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+			},
+		},
+		{
+			name:     "burn after read file reveal page",
+			method:   "GET",
+			path:     "/f/bUrNaFtErR",
+			expected: 200,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "bUrNaFtErR"
+				file.BurnAfterRead = true
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+			},
+		},
+		{
+			name:     "burn after read file head",
+			method:   "HEAD",
+			path:     "/f/bUrNaFtErR?r=1",
+			expected: 200,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "bUrNaFtErR"
+				file.BurnAfterRead = true
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+			},
+		},
+		{
+			name:     "burn after read file",
+			method:   "POST",
+			path:     "/f/bUrNaFtErR",
+			expected: 200,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "bUrNaFtErR"
+				file.BurnAfterRead = true
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+				suite.mockDB.Files.EXPECT().FindWithContentAndDelete(mock.Anything, file.ID).Return(&file, []byte("secret"), nil)
+			},
+		},
+		{
+			name:     "bundle file",
+			method:   "GET",
+			path:     "/f/bUnDlEfIlE",
+			expected: 200,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "bUnDlEfIlE"
+				file.Type = snips.FileTypeBundle
+				tarball, err := snips.EncodeBundle([]snips.BundleMember{{Name: "main.go", Content: []byte("package main")}})
+				suite.Require().NoError(err)
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+				suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return(tarball, nil)
+				suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, file.ID).Return(0, nil)
+			},
+		},
+		{
+			name:     "burn after read file already viewed",
+			method:   "POST",
+			path:     "/f/bUrNeDfile?r=1",
+			expected: 404,
+			setup: func() {
+				file := testutil.Fixtures.File(suite.T())
+				file.ID = "bUrNeDfile"
+				file.BurnAfterRead = true
+
+				suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+				suite.mockDB.Files.EXPECT().FindWithContentAndDelete(mock.Anything, file.ID).Return(nil, nil, nil)
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -352,6 +443,34 @@ func (suite *HTTPServiceSuite) TestFileMarkdownAccept() {
 		suite.Require().Contains(content, "# Hello\n\nworld\n")
 	})
 
+	suite.Run("bundle returns a section per member", func() {
+		file := testutil.Fixtures.File(suite.T())
+		file.ID = "mdtest4"
+		file.Type = snips.FileTypeBundle
+		tarball, err := snips.EncodeBundle([]snips.BundleMember{
+			{Name: "main.go", Content: []byte("package main\n")},
+			{Name: "README.md", Content: []byte("# Hello\n")},
+		})
+		suite.Require().NoError(err)
+		suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+		suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return(tarball, nil)
+
+		req, err := http.NewRequest("GET", ts.URL+"/f/"+file.ID, nil)
+		suite.Require().NoError(err)
+		req.Header.Set("Accept", "text/markdown")
+
+		resp, err := ts.Client().Do(req)
+		suite.Require().NoError(err)
+		suite.Require().Equal(200, resp.StatusCode)
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		content := string(body)
+		suite.Require().Contains(content, "type: bundle")
+		suite.Require().Contains(content, "## main.go\n\n```go\npackage main\n```\n")
+		suite.Require().Contains(content, "## README.md\n\n# Hello\n")
+	})
+
 	suite.Run("binary file returns frontmatter and placeholder", func() {
 		file := testutil.Fixtures.File(suite.T())
 		file.ID = "mdtest3"
@@ -458,6 +577,56 @@ func (suite *HTTPServiceSuite) TestFilePreviewMetadata() {
 	}
 }
 
+func (suite *HTTPServiceSuite) TestBurnAfterReadReveal() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "rEvEaLfIlE"
+	file.BurnAfterRead = true
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+
+	// what link previews, prefetches and crawlers send
+	requests := []struct {
+		method    string
+		path      string
+		userAgent string
+		contains  string
+	}{
+		{method: "HEAD", path: "/f/" + file.ID},
+		{method: "GET", path: "/f/" + file.ID, userAgent: "Slackbot-LinkExpanding 1.0", contains: `method="post" action="/f/rEvEaLfIlE"`},
+		{method: "GET", path: "/f/" + file.ID + "?r=1", contains: `curl -X POST "http://localhost:8080/f/rEvEaLfIlE?r=1"`},
+	}
+
+	for _, tt := range requests {
+		req, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+		suite.Require().NoError(err)
+		req.Header.Set("User-Agent", tt.userAgent)
+
+		resp, err := ts.Client().Do(req)
+		suite.Require().NoError(err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		suite.Require().NoError(err)
+
+		suite.Equal(http.StatusOK, resp.StatusCode, tt.method+" "+tt.path)
+		suite.Equal("no-store", resp.Header.Get("Cache-Control"))
+		suite.Contains(string(body), tt.contains)
+	}
+
+	suite.mockDB.Files.AssertNotCalled(suite.T(), "FindWithContentAndDelete", mock.Anything, mock.Anything)
+
+	suite.mockDB.Files.EXPECT().FindWithContentAndDelete(mock.Anything, file.ID).Return(&file, []byte("secret"), nil).Once()
+
+	resp, err := ts.Client().Post(ts.URL+"/f/"+file.ID+"?r=1", "", nil)
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("secret", string(body))
+}
+
 func (suite *HTTPServiceSuite) TestPprofEndpoints() {
 	suite.Run("pprof unavailable when debug is off", func() {
 		// Default config has Debug=false, so the route is not registered.
@@ -476,7 +645,7 @@ func (suite *HTTPServiceSuite) TestPprofEndpoints() {
 		debugCfg := *suite.config
 		debugCfg.Debug = true
 
-		svc, err := web.New(&debugCfg, suite.mockDB.DB, suite.assets)
+		svc, err := web.New(&debugCfg, suite.mockDB.DB, suite.assets, events.NewHub())
 		suite.Require().NoError(err)
 
 		ts := httptest.NewServer(svc.Handler)
@@ -602,3 +771,217 @@ func (suite *HTTPServiceSuite) TestAssetCaching() {
 		suite.Require().Equal(304, resp2.StatusCode)
 	})
 }
+
+func (suite *HTTPServiceSuite) TestFileDescriptionAndTags() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "describedfile"
+	file.Type = "go"
+	file.Description = "deploy script"
+	file.Tags = []string{"go", "infra"}
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return([]byte("package deploy"), nil)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, file.ID).Return(int64(0), nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID)
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	html := string(body)
+	suite.Contains(html, `property="og:description" content="deploy script · describedfile · go ·`)
+	suite.Contains(html, `<span>deploy script</span>`)
+	suite.Contains(html, `go, infra`)
+}
+
+func (suite *HTTPServiceSuite) TestRevisionRestoreCommand() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "restorefile"
+	rev := &snips.Revision{ID: "rev2", Sequence: 2, FileID: file.ID, CreatedAt: time.Now().UTC()}
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Revisions.EXPECT().FindByFileIDAndSequence(mock.Anything, file.ID, int64(2)).Return(rev, nil)
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, rev.ID).Return([]byte("-hi\n+hello\n"), nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID + "/rev/2")
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	suite.Contains(string(body), `data-copy-text="`+suite.config.SSHCommandForFile(file.ID)+` -- restore 2"`)
+}
+
+func (suite *HTTPServiceSuite) TestRevisionContent() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "revcontent"
+	file.Type = "go"
+	revisions := []*snips.Revision{
+		{ID: "rev2", Sequence: 2, FileID: file.ID, Size: 17, Type: "go"},
+		{ID: "rev1", Sequence: 1, FileID: file.ID, Size: 12, Type: "go"},
+	}
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, file.ID).Return(revisions, nil)
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return([]byte("package snipsmain"), nil)
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, "rev2").Return([]byte("--- a\n+++ b\n@@ -1 +1 @@\n-package main\n+package snipsmain\n"), nil)
+	suite.mockDB.Revisions.EXPECT().FindByFileIDAndSequence(mock.Anything, file.ID, int64(1)).Return(revisions[1], nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID + "/rev/1/raw")
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("package main", string(body))
+
+	resp, err = ts.Client().Get(ts.URL + "/f/" + file.ID + "/rev/1/view")
+	suite.Require().NoError(err)
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Equal(http.StatusOK, resp.StatusCode)
+	suite.Contains(string(body), `<pre id="raw-content" hidden aria-hidden="true">package main</pre>`)
+	suite.Contains(string(body), `href="/f/revcontent/rev/1/raw"`)
+	suite.Contains(string(body), `href="/f/revcontent/rev/1"`)
+
+	resp, err = ts.Client().Get(ts.URL + "/f/" + file.ID + "/rev/3/raw")
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (suite *HTTPServiceSuite) TestCompare() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "comparefile"
+	file.Type = "go"
+	revisions := []*snips.Revision{
+		{ID: "rev1", Sequence: 1, FileID: file.ID, Size: 21, Type: "go"},
+	}
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Revisions.EXPECT().FindByFileID(mock.Anything, file.ID).Return(revisions, nil)
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return([]byte("a := 1\nb := <two>\nc\n"), nil)
+	suite.mockDB.Revisions.EXPECT().FindDiff(mock.Anything, "rev1").Return([]byte("--- a\n+++ b\n@@ -1,4 +1,4 @@\n a := 1\n-b := 2\n+b := <two>\n c\n \n"), nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID + "/compare/0...head")
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	html := string(body)
+	suite.Contains(html, `<td class="diff-split-line diff-del">b := <span class="diff-word">2</span></td>`)
+	suite.Contains(html, `<td class="diff-split-line diff-add">b := <span class="diff-word">&lt;two&gt;</span></td>`)
+	suite.Contains(html, `<td class="diff-split-line diff-ctx">a := 1</td>`)
+	suite.Contains(html, `href="/f/comparefile/compare/head...0"`)
+
+	for _, path := range []string{"/compare/0..head", "/compare/0...9", "/compare/x...head"} {
+		resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID + path)
+		suite.Require().NoError(err)
+		resp.Body.Close()
+		suite.Equal(http.StatusNotFound, resp.StatusCode, path)
+	}
+}
+
+func (suite *HTTPServiceSuite) TestFileEvents() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "livefile"
+	file.Type = "plaintext"
+	file.Private = false
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return([]byte("building..."), nil)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, file.ID).Return(int64(0), nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID)
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Contains(string(body), `data-events-href="/f/livefile/events"`)
+
+	resp, err = ts.Client().Get(ts.URL + "/f/" + file.ID + "/events")
+	suite.Require().NoError(err)
+	defer resp.Body.Close()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+	suite.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the stream is subscribed once its headers are sent
+	file.Size = 42
+	suite.hub.Publish(events.NewEvent(events.KindAppend, &file))
+	suite.hub.Publish(events.NewEvent(events.KindUpdate, &snips.File{ID: "otherfile"}))
+	suite.hub.Publish(events.NewEvent(events.KindDelete, &file))
+
+	stream, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	frames := strings.Split(strings.TrimSpace(string(stream)), "\n\n")
+	suite.Require().Len(frames, 2)
+	suite.True(strings.HasPrefix(frames[0], "event: append\ndata: {"))
+	suite.Contains(frames[0], `"file_id":"livefile"`)
+	suite.Contains(frames[0], `"size":42`)
+	suite.True(strings.HasPrefix(frames[1], "event: delete\n"))
+}
+
+func (suite *HTTPServiceSuite) TestFileEvents_Private() {
+	ts := httptest.NewServer(suite.service.Handler)
+	defer ts.Close()
+
+	file := testutil.Fixtures.File(suite.T())
+	file.ID = "privatelive"
+	file.Type = "plaintext"
+	file.Private = true
+
+	suite.mockDB.Files.EXPECT().Find(mock.Anything, file.ID).Return(&file, nil)
+	suite.mockDB.Files.EXPECT().FindContent(mock.Anything, file.ID).Return([]byte("secret"), nil)
+	suite.mockDB.Revisions.EXPECT().CountByFileID(mock.Anything, file.ID).Return(int64(0), nil)
+
+	resp, err := ts.Client().Get(ts.URL + "/f/" + file.ID + "/events")
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusNotFound, resp.StatusCode)
+
+	// the page links to an events stream signed for the same viewer
+	hmacSigner := signer.New(suite.config.HMACKey)
+	signed, _ := hmacSigner.SignURLWithTTL(url.URL{Path: "/f/" + file.ID}, time.Minute)
+
+	resp, err = ts.Client().Get(ts.URL + signed.String())
+	suite.Require().NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	suite.Require().NoError(err)
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	match := regexp.MustCompile(`data-events-href="([^"]+)"`).FindStringSubmatch(string(body))
+	suite.Require().Len(match, 2)
+	eventsHref := strings.ReplaceAll(match[1], "&amp;", "&")
+	suite.True(strings.HasPrefix(eventsHref, "/f/privatelive/events?"))
+
+	ctx, cancel := context.WithCancel(suite.T().Context())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+eventsHref, nil)
+	suite.Require().NoError(err)
+	resp, err = ts.Client().Do(req)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"html/template"
 	"image/png"
@@ -14,6 +15,8 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/robherley/snips.sh/internal/config"
 	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/files"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/opengraph"
 	"github.com/robherley/snips.sh/internal/renderer"
@@ -27,15 +30,17 @@ type UI struct {
 	assets Assets
 	signer *signer.Signer
 	og     *opengraph.Renderer
+	events *events.Hub
 }
 
-func NewUI(cfg *config.Config, database *db.DB, assets Assets) *UI {
+func NewUI(cfg *config.Config, database *db.DB, assets Assets, hub *events.Hub) *UI {
 	return &UI{
 		cfg:    cfg,
 		db:     database,
 		assets: assets,
 		signer: signer.New(cfg.HMACKey),
 		og:     newOG(assets),
+		events: hub,
 	}
 }
 
@@ -45,12 +50,22 @@ func (ui *UI) Register(mux *http.ServeMux) {
 	mux.HandleFunc("GET /og.png", ui.DocOGImage)
 	mux.HandleFunc("GET /docs/{name}/og.png", ui.DocOGImage)
 	mux.HandleFunc("GET /f/{fileID}", ui.File)
+	mux.HandleFunc("POST /f/{fileID}", ui.File)
+	mux.HandleFunc("GET /f/{fileID}/events", ui.FileEvents)
 	mux.HandleFunc("GET /f/{fileID}/rev", ui.Revisions)
 	mux.HandleFunc("GET /f/{fileID}/rev/{revisionID}", ui.RevisionDiff)
+	mux.HandleFunc("GET /f/{fileID}/rev/{revisionID}/view", ui.RevisionFile)
+	mux.HandleFunc("GET /f/{fileID}/rev/{revisionID}/raw", ui.RevisionRaw)
+	mux.HandleFunc("GET /f/{fileID}/compare/{revisions}", ui.Compare)
 	mux.HandleFunc("GET /f/{fileID}/og.png", ui.OGImage)
 	mux.HandleFunc("GET /f/{fileID}/n/{name}", ui.File)
+	mux.HandleFunc("POST /f/{fileID}/n/{name}", ui.File)
+	mux.HandleFunc("GET /f/{fileID}/n/{name}/events", ui.FileEvents)
 	mux.HandleFunc("GET /f/{fileID}/n/{name}/rev", ui.Revisions)
 	mux.HandleFunc("GET /f/{fileID}/n/{name}/rev/{revisionID}", ui.RevisionDiff)
+	mux.HandleFunc("GET /f/{fileID}/n/{name}/rev/{revisionID}/view", ui.RevisionFile)
+	mux.HandleFunc("GET /f/{fileID}/n/{name}/rev/{revisionID}/raw", ui.RevisionRaw)
+	mux.HandleFunc("GET /f/{fileID}/n/{name}/compare/{revisions}", ui.Compare)
 	mux.HandleFunc("GET /f/{fileID}/n/{name}/og.png", ui.OGImage)
 	mux.HandleFunc("GET /assets/{asset...}", ui.assets.Serve)
 }
@@ -196,6 +211,7 @@ func (ui *UI) DocOGImage(w http.ResponseWriter, r *http.Request) {
 // findFile resolves the {fileID} path segment. When the route carries an
 // /n/{name} segment, it must match the file's name (case-insensitively)
 // or the file is treated as not found, so named links can't be spoofed.
+// Expired files are also treated as not found.
 func (ui *UI) findFile(r *http.Request) (*snips.File, error) {
 	fileID := r.PathValue("fileID")
 	if fileID == "" {
@@ -207,6 +223,11 @@ func (ui *UI) findFile(r *http.Request) (*snips.File, error) {
 		return nil, err
 	}
 
+	// expired files linger until the reaper purges them, but are gone to readers
+	if file.IsExpired() {
+		return nil, nil
+	}
+
 	if name := r.PathValue("name"); name != "" {
 		if file.Name == "" || !strings.EqualFold(name, file.Name) {
 			return nil, nil
@@ -254,8 +275,19 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	content, err := ui.db.Files.FindContent(r.Context(), file.ID)
+	// link previews and prefetches would burn the file, so it's only read
+	// once someone asks for it with a POST, from the reveal page
+	if file.BurnAfterRead && r.Method != http.MethodPost {
+		ui.reveal(w, r, file)
+		return
+	}
+
+	content, err := files.View(r.Context(), ui.db, ui.events, file)
 	if err != nil {
+		if errors.Is(err, files.ErrBurned) {
+			http.NotFound(w, r)
+			return
+		}
 		log.Error("unable to get file content", "err", err)
 		http.Error(w, "unable to get file content", http.StatusInternalServerError)
 		return
@@ -270,14 +302,22 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if ShouldSendRaw(r) {
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if file.IsBundle() {
+			w.Header().Set("Content-Type", "application/x-tar")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.ID+".tar"))
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(content)
 		return
 	}
 
+	// a burned file is already gone, so there's nothing left to link to
 	rawHref := "?r=1"
-	if isSignedAndNotExpired {
+	if file.BurnAfterRead {
+		rawHref = ""
+	} else if isSignedAndNotExpired {
 		q := r.URL.Query()
 		q.Del("sig")
 		q.Add("r", "1")
@@ -291,12 +331,37 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		rawHref = signedRawURL.String()
 	}
 
+	// bundles and binaries can't be live-tailed, and burned files won't change
+	eventsHref := ""
+	if !file.BurnAfterRead && !file.IsBundle() && !file.IsBinary() {
+		eventsHref = r.URL.Path + "/events"
+		if isSignedAndNotExpired {
+			q := r.URL.Query()
+			q.Del("sig")
+
+			signedEventsURL := ui.signer.SignURL(url.URL{
+				Path:     eventsHref,
+				RawQuery: q.Encode(),
+			})
+			eventsHref = signedEventsURL.String()
+		}
+	}
+
 	var (
-		html template.HTML
-		css  template.CSS
+		html    template.HTML
+		css     template.CSS
+		members []bundleMember
 	)
 
 	switch file.Type {
+	case snips.FileTypeBundle:
+		members, err = renderBundle(content, ui.cfg.EnableGuesser)
+		if err != nil {
+			log.Error("unable to parse bundle", "err", err)
+			http.Error(w, "unable to parse file", http.StatusInternalServerError)
+			return
+		}
+		css = renderer.GetSyntaxCSS()
 	case snips.FileTypeBinary:
 		html = renderer.BinaryHTMLPlaceholder
 	case snips.FileTypeMarkdown:
@@ -317,9 +382,12 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		css = renderer.GetSyntaxCSS()
 	}
 
-	revisionCount, err := ui.db.Revisions.CountByFileID(r.Context(), file.ID)
-	if err != nil {
-		log.Warn("unable to count revisions", "err", err)
+	var revisionCount int64
+	if !file.BurnAfterRead {
+		revisionCount, err = ui.db.Revisions.CountByFileID(r.Context(), file.ID)
+		if err != nil {
+			log.Warn("unable to count revisions", "err", err)
+		}
 	}
 
 	path := filePath(r, file)
@@ -330,10 +398,20 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		previewName = file.Name
 	}
 	ogDescription := fmt.Sprintf("%s · %s · %s · %s", previewName, strings.ToLower(file.Type), humanize.Bytes(file.Size), humanize.Time(file.UpdatedAt))
+	if file.Description != "" {
+		ogDescription = file.Description + " · " + ogDescription
+	}
+
+	expiresAt := ""
+	if file.ExpiresAt != nil {
+		expiresAt = humanize.Time(*file.ExpiresAt)
+	}
 
 	vars := map[string]interface{}{
 		"FileID":        file.ID,
 		"FileName":      file.Name,
+		"Description":   file.Description,
+		"Tags":          file.Tags,
 		"PreviewName":   previewName,
 		"FilePath":      path,
 		"FileSize":      humanize.Bytes(file.Size),
@@ -341,6 +419,7 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		"UpdatedAt":     humanize.Time(file.UpdatedAt),
 		"FileType":      strings.ToLower(file.Type),
 		"RawHREF":       rawHref,
+		"EventsHREF":    eventsHref,
 		"RawContent":    string(content),
 		"HTML":          html,
 		"CSS":           css,
@@ -350,6 +429,9 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 		"OGURL":         previewURL,
 		"OGDescription": ogDescription,
 		"RevisionCount": revisionCount,
+		"ExpiresAt":     expiresAt,
+		"Burned":        file.BurnAfterRead,
+		"Members":       members,
 	}
 
 	err = ui.assets.Template("file.go.html").Execute(w, vars)
@@ -360,6 +442,33 @@ func (ui *UI) File(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// reveal serves the page a burn after read file is read from, posting back to
+// the same URL, or instructions to do so for raw requests.
+func (ui *UI) reveal(w http.ResponseWriter, r *http.Request, file *snips.File) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if AcceptsMarkdown(r) || ShouldSendRaw(r) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fileURL := fmt.Sprintf("%s://%s%s", ui.cfg.HTTP.External.Scheme, ui.cfg.HTTP.External.Host, r.URL.RequestURI())
+		_, _ = fmt.Fprintf(w, "this file is deleted as soon as it's read, read it with a POST: curl -X POST %q\n", fileURL)
+		return
+	}
+
+	vars := map[string]interface{}{
+		"FileID":   file.ID,
+		"FileType": strings.ToLower(file.Type),
+		"FileSize": humanize.Bytes(file.Size),
+		"Private":  file.Private,
+		"Action":   r.URL.RequestURI(),
+	}
+
+	if err := ui.assets.Template("reveal.go.html").Execute(w, vars); err != nil {
+		logger.From(r.Context()).Error("unable to render template", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
 func newOG(assets Assets) *opengraph.Renderer {
 	loadFont := func(name string) []byte {
 		data, ok := assets.StaticFile(name)
@@ -567,6 +676,7 @@ func (ui *UI) RevisionDiff(w http.ResponseWriter, r *http.Request) {
 		"RevSize":          humanize.Bytes(revision.Size),
 		"RevType":          strings.ToLower(revision.Type),
 		"DiffLines":        diffLines,
+		"RestoreCommand":   fmt.Sprintf("%s -- restore %d", ui.cfg.SSHCommandForFile(file.ID), revision.Sequence),
 		"CommitSHA":        config.BuildCommit(),
 	}
 
@@ -578,6 +688,137 @@ func (ui *UI) RevisionDiff(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// findRevisionContent looks up the requested file and reconstructs its content
+// at the requested revision. If ok is false, a response was already written.
+func (ui *UI) findRevisionContent(w http.ResponseWriter, r *http.Request) (file *snips.File, seq int64, content []byte, fileType string, ok bool) {
+	log := logger.From(r.Context())
+
+	seq, err := strconv.ParseInt(r.PathValue("revisionID"), 10, 64)
+	if err != nil || seq < 0 {
+		http.NotFound(w, r)
+		return nil, 0, nil, "", false
+	}
+
+	file, err = ui.findFile(r)
+	if err != nil {
+		log.Error("unable to lookup file", "err", err)
+		http.NotFound(w, r)
+		return nil, 0, nil, "", false
+	}
+
+	if file == nil {
+		http.NotFound(w, r)
+		return nil, 0, nil, "", false
+	}
+
+	if file.Private && !ui.signer.VerifyURLAndNotExpired(*r.URL) {
+		log.Warn("attempted to access private file revision")
+		http.NotFound(w, r)
+		return nil, 0, nil, "", false
+	}
+
+	content, fileType, err = files.ContentAt(r.Context(), ui.db, file, seq)
+	if err != nil {
+		switch {
+		case errors.Is(err, files.ErrRevisionNotFound):
+			http.NotFound(w, r)
+		case errors.Is(err, files.ErrRevisionUnavailable):
+			log.Warn("unable to reconstruct revision", "err", err)
+			http.Error(w, "revision content is no longer available", http.StatusConflict)
+		default:
+			log.Error("unable to reconstruct revision", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return nil, 0, nil, "", false
+	}
+
+	// the type before the oldest kept revision isn't recorded
+	if fileType == "" {
+		fileType = file.Type
+	}
+
+	return file, seq, content, fileType, true
+}
+
+func (ui *UI) RevisionRaw(w http.ResponseWriter, r *http.Request) {
+	_, _, content, _, ok := ui.findRevisionContent(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+func (ui *UI) RevisionFile(w http.ResponseWriter, r *http.Request) {
+	log := logger.From(r.Context())
+
+	file, seq, content, fileType, ok := ui.findRevisionContent(w, r)
+	if !ok {
+		return
+	}
+
+	var (
+		html template.HTML
+		err  error
+	)
+	if fileType == snips.FileTypeMarkdown {
+		html, err = renderer.ToMarkdown(content)
+	} else {
+		html, err = renderer.ToSyntaxHighlightedHTML(fileType, content)
+	}
+	if err != nil {
+		log.Error("unable to parse file", "err", err)
+		http.Error(w, "unable to parse file", http.StatusInternalServerError)
+		return
+	}
+
+	path := filePath(r, file)
+	revisionPath := fmt.Sprintf("%s/rev/%d", path, seq)
+
+	// the state before the oldest kept revision has no diff of its own
+	diffHref := revisionPath
+	if revision, err := ui.db.Revisions.FindByFileIDAndSequence(r.Context(), file.ID, seq); err != nil || revision == nil {
+		diffHref = ""
+	}
+
+	rawHref := revisionPath + "/raw"
+	if file.Private {
+		q := r.URL.Query()
+		q.Del("sig")
+
+		signedRawURL := ui.signer.SignURL(url.URL{
+			Path:     strings.TrimSuffix(r.URL.Path, "/view") + "/raw",
+			RawQuery: q.Encode(),
+		})
+		rawHref = signedRawURL.String()
+	}
+
+	vars := map[string]interface{}{
+		"FileID":           file.ID,
+		"FilePath":         path,
+		"FileType":         strings.ToLower(fileType),
+		"FileSize":         humanize.Bytes(uint64(len(content))),
+		"Private":          file.Private,
+		"RevisionSequence": seq,
+		"DiffHREF":         diffHref,
+		"RawHREF":          rawHref,
+		"RawContent":       string(content),
+		"HTML":             html,
+		"CSS":              renderer.GetSyntaxCSS(),
+		"RestoreCommand":   fmt.Sprintf("%s -- restore %d", ui.cfg.SSHCommandForFile(file.ID), seq),
+		"CommitSHA":        config.BuildCommit(),
+	}
+
+	err = ui.assets.Template("revision_file.go.html").Execute(w, vars)
+	if err != nil {
+		log.Error("unable to render template", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+}
+
 type diffLine struct {
 	Class   string
 	Content string
@@ -636,24 +877,50 @@ func FileToMarkdown(cfg *config.Config, file *snips.File, content []byte) []byte
 	fmt.Fprintf(&buf, "type: %s\n", strings.ToLower(file.Type))
 	fmt.Fprintf(&buf, "created: %s\n", file.CreatedAt.UTC().Format(time.RFC3339))
 	fmt.Fprintf(&buf, "updated: %s\n", file.UpdatedAt.UTC().Format(time.RFC3339))
+	if file.ExpiresAt != nil {
+		fmt.Fprintf(&buf, "expires: %s\n", file.ExpiresAt.UTC().Format(time.RFC3339))
+	}
 	fmt.Fprintf(&buf, "source: %s://%s/f/%s\n", cfg.HTTP.External.Scheme, cfg.HTTP.External.Host, file.ID)
 	fmt.Fprintf(&buf, "---\n\n")
 
-	switch file.Type {
+	if !file.IsBundle() {
+		writeMarkdownBody(&buf, file.Type, content)
+		return buf.Bytes()
+	}
+
+	members, err := snips.ParseBundle(content)
+	if err != nil {
+		buf.WriteString("_Unreadable bundle._\n")
+		return buf.Bytes()
+	}
+
+	for i, member := range members {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "## %s\n\n", member.Name)
+		writeMarkdownBody(&buf, renderer.BundleMemberType(member, cfg.EnableGuesser), member.Content)
+	}
+
+	return buf.Bytes()
+}
+
+// writeMarkdownBody writes content of the given type as markdown: markdown as
+// is, and anything else as a fenced code block.
+func writeMarkdownBody(buf *bytes.Buffer, fileType string, content []byte) {
+	switch fileType {
 	case snips.FileTypeBinary:
 		buf.WriteString("_Binary file._\n")
 	case snips.FileTypeMarkdown:
 		buf.Write(content)
 	default:
-		fmt.Fprintf(&buf, "```%s\n", file.Type)
+		fmt.Fprintf(buf, "```%s\n", fileType)
 		buf.Write(content)
 		if len(content) > 0 && content[len(content)-1] != '\n' {
 			buf.WriteByte('\n')
 		}
 		buf.WriteString("```\n")
 	}
-
-	return buf.Bytes()
 }
 
 func DocToMarkdown(cfg *config.Config, name string, content []byte) []byte {
@@ -0,0 +1,174 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/logger"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+const (
+	// APIDefaultWebhookDeliveries is how many deliveries are listed when no
+	// limit is given, and APIMaxWebhookDeliveries the most that can be.
+	APIDefaultWebhookDeliveries = 20
+	APIMaxWebhookDeliveries     = 100
+)
+
+// findWebhook resolves {webhookID} to one of the user's webhooks. Another
+// user's webhook is a 404, so existence isn't leaked.
+func (a *API) findWebhook(w http.ResponseWriter, r *http.Request) *snips.Webhook {
+	// webhooks are sent events for every file, so restricted keys can't see them
+	if !requireAccountAccess(w, r) {
+		return nil
+	}
+
+	userID, _ := UserID(r.Context())
+
+	webhook, err := a.db.Webhooks.Find(r.Context(), r.PathValue("webhookID"))
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return nil
+	}
+
+	if webhook == nil || webhook.UserID != userID {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return nil
+	}
+
+	return webhook
+}
+
+func (a *API) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !requireAccountAccess(w, r) {
+		return
+	}
+
+	userID, _ := UserID(r.Context())
+
+	webhooks, err := a.db.Webhooks.FindByUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+func (a *API) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireAccountAccess(w, r) {
+		return
+	}
+
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	url, err := snips.NormalizeWebhookURL(req.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// no events subscribes to every event
+	events, err := snips.NormalizeWebhookEvents(req.Events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret, err := snips.NewWebhookSecret()
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	userID, _ := UserID(r.Context())
+	webhook := &snips.Webhook{
+		UserID: userID,
+		URL:    url,
+		Events: events,
+		Secret: secret,
+	}
+
+	if err := a.db.Webhooks.Create(r.Context(), webhook, a.cfg.Limits.WebhooksPerUser); err != nil {
+		if errors.Is(err, db.ErrWebhookLimit) {
+			http.Error(w, "webhook limit reached", http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	metrics.IncrCounter([]string{"webhook", "create"}, 1)
+	logger.From(r.Context()).Info("webhook created", "webhook_id", webhook.ID, "user_id", userID)
+
+	// the secret is only ever shown here
+	writeJSON(w, http.StatusCreated, struct {
+		*snips.Webhook
+		Secret string `json:"secret"`
+	}{webhook, webhook.Secret})
+}
+
+func (a *API) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := a.findWebhook(w, r)
+	if webhook == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+func (a *API) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := a.findWebhook(w, r)
+	if webhook == nil {
+		return
+	}
+
+	if _, err := a.db.Webhooks.Delete(r.Context(), webhook.ID, webhook.UserID); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	metrics.IncrCounter([]string{"webhook", "delete"}, 1)
+	logger.From(r.Context()).Info("webhook deleted", "webhook_id", webhook.ID, "user_id", webhook.UserID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *API) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook := a.findWebhook(w, r)
+	if webhook == nil {
+		return
+	}
+
+	limit := uint64(APIDefaultWebhookDeliveries)
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.ParseUint(rawLimit, 10, 64)
+		if err != nil || parsed == 0 || parsed > APIMaxWebhookDeliveries {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(APIMaxWebhookDeliveries), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := a.db.WebhookDeliveries.FindByWebhook(r.Context(), webhook.ID, limit)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
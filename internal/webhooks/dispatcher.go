@@ -0,0 +1,447 @@
+// Package webhooks sends file events to the webhooks users register. Each
+// delivery is persisted before it's sent, so failed ones are retried with
+// exponential backoff and every attempt is kept in the webhook's log.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/signer"
+	"github.com/robherley/snips.sh/internal/snips"
+)
+
+const (
+	// EventHeader names the event a delivery is for.
+	EventHeader = "X-Snips-Event"
+	// DeliveryHeader is the delivery's ID, the same across its attempts, so
+	// receivers can drop duplicates.
+	DeliveryHeader = "X-Snips-Delivery"
+	// TimestampHeader is the unix time the attempt was signed at.
+	TimestampHeader = "X-Snips-Timestamp"
+	// SignatureHeader is the hmac of the timestamp and body, see
+	// signer.SignPayload, prefixed with SignaturePrefix.
+	SignatureHeader = "X-Snips-Signature"
+	SignaturePrefix = "sha256="
+
+	// BackoffBase is how long a delivery waits after its first failed attempt,
+	// doubling with each failure after that, up to BackoffMax.
+	BackoffBase = 30 * time.Second
+	BackoffMax  = time.Hour
+
+	// BatchSize is how many due deliveries are attempted at once.
+	BatchSize = 16
+
+	userAgent = "snips.sh-webhooks"
+	// maxErrorLength caps the error recorded for an attempt.
+	maxErrorLength = 256
+	// maxDrainBytes is how much of a response body is read, so connections
+	// can be reused, before it's closed.
+	maxDrainBytes = 64 * 1024
+)
+
+// ErrPrivateAddress is returned for deliveries to a webhook whose host
+// resolves to a private, loopback, link-local or unspecified address, unless
+// cfg.Webhooks.AllowPrivate is set.
+var ErrPrivateAddress = errors.New("webhook address is not publicly routable")
+
+// Payload is the JSON body sent to webhooks.
+type Payload struct {
+	Event string      `json:"event"`
+	File  *snips.File `json:"file"`
+	// PreviousName is the file's name before a rename, empty if it had none.
+	PreviousName string `json:"previous_name,omitempty"`
+}
+
+// EventFor returns the webhook event a file event is delivered as, if any.
+// Appends are delivered as updates.
+func EventFor(kind events.Kind) (string, bool) {
+	switch kind {
+	case events.KindCreate:
+		return snips.WebhookEventCreate, true
+	case events.KindUpdate, events.KindAppend:
+		return snips.WebhookEventUpdate, true
+	case events.KindDelete:
+		return snips.WebhookEventDelete, true
+	case events.KindRename:
+		return snips.WebhookEventRename, true
+	default:
+		return "", false
+	}
+}
+
+// Backoff returns how long to wait before retrying a delivery that has failed
+// attempts times.
+func Backoff(attempts int) time.Duration {
+	backoff := BackoffBase
+	for i := 1; i < attempts && backoff < BackoffMax; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, BackoffMax)
+}
+
+// Dispatcher queues a delivery for each event a webhook subscribes to, and
+// sends due deliveries every interval. It implements the same
+// ListenAndServe/Shutdown pair as the network services so the app can manage
+// it alongside them.
+type Dispatcher struct {
+	db     *db.DB
+	cfg    *config.Config
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []events.Event
+	wake    chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewDispatcher creates a dispatcher observing every event published to hub.
+// A non-positive cfg.Webhooks.Interval disables it.
+func NewDispatcher(cfg *config.Config, database *db.DB, hub *events.Hub) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		db:     database,
+		cfg:    cfg,
+		client: newClient(cfg),
+		wake:   make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	if cfg.Webhooks.Interval > 0 {
+		hub.Observe(d.observe)
+	}
+
+	return d
+}
+
+// newClient returns the client deliveries are sent with. Unless
+// cfg.Webhooks.AllowPrivate is set, it refuses to connect to addresses that
+// aren't publicly routable, checked as each connection is dialed so a host
+// can't resolve to a public address when registered and a private one later.
+func newClient(cfg *config.Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.Webhooks.AllowPrivate {
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   publicOnly,
+		}
+		transport.DialContext = dialer.DialContext
+		// a proxy would be dialed in place of the webhook, dodging the check
+		transport.Proxy = nil
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Webhooks.Timeout,
+		Transport: transport,
+		// a redirect is a failed delivery, rather than a way to reach
+		// somewhere the webhook's url doesn't point
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonGlobal are special-purpose ranges that aren't reachable on the public
+// internet, beyond those netip.Addr classifies itself. IPv4-mapped IPv6
+// addresses are unmapped before they're checked.
+var nonGlobal = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can embed any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// publicOnly is a net.Dialer Control rejecting connections to resolved
+// addresses that are private, loopback, link-local, multicast, unspecified or
+// in one of the nonGlobal ranges.
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+
+	addr := addrPort.Addr().Unmap()
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addr)
+	}
+
+	for _, prefix := range nonGlobal {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrPrivateAddress, addr)
+		}
+	}
+
+	return nil
+}
+
+// observe holds onto an event until it's queued, without blocking the
+// publisher on the database.
+func (d *Dispatcher) observe(event events.Event) {
+	if event.File == nil {
+		return
+	}
+	if _, ok := EventFor(event.Kind); !ok {
+		return
+	}
+
+	d.mu.Lock()
+	d.pending = append(d.pending, event)
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ListenAndServe queues observed events and sends due deliveries as events
+// arrive and every interval, until Shutdown.
+func (d *Dispatcher) ListenAndServe() error {
+	defer close(d.done)
+
+	if d.cfg.Webhooks.Interval <= 0 {
+		slog.Info("webhook dispatcher disabled")
+		return nil
+	}
+
+	ticker := time.NewTicker(d.cfg.Webhooks.Interval)
+	defer ticker.Stop()
+
+	for {
+		d.Queue(d.ctx)
+		d.Deliver(d.ctx)
+
+		select {
+		case <-d.ctx.Done():
+			// events observed while shutting down are still queued, and sent
+			// once the app is back
+			d.Queue(context.WithoutCancel(d.ctx))
+			return nil
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// Shutdown stops the dispatcher, waiting for in-flight deliveries to finish.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.once.Do(d.cancel)
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Queue persists a delivery for every webhook subscribed to each observed
+// event, returning the number queued.
+func (d *Dispatcher) Queue(ctx context.Context) int {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = nil
+	d.mu.Unlock()
+
+	queued := 0
+	hooksByUser := map[string][]*snips.Webhook{}
+	for _, event := range pending {
+		name, _ := EventFor(event.Kind)
+
+		hooks, ok := hooksByUser[event.File.UserID]
+		if !ok {
+			var err error
+			hooks, err = d.db.Webhooks.FindByUser(ctx, event.File.UserID)
+			if err != nil {
+				slog.Error("unable to find webhooks", "user_id", event.File.UserID, "err", err)
+				continue
+			}
+			hooksByUser[event.File.UserID] = hooks
+		}
+
+		for _, hook := range hooks {
+			if !hook.Subscribes(name) {
+				continue
+			}
+
+			if err := d.queue(ctx, hook, name, event); err != nil {
+				slog.Error("unable to queue webhook delivery", "webhook_id", hook.ID, "file_id", event.FileID, "err", err)
+				continue
+			}
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		metrics.IncrCounter([]string{"webhook", "queued"}, float32(queued))
+	}
+
+	return queued
+}
+
+func (d *Dispatcher) queue(ctx context.Context, hook *snips.Webhook, name string, event events.Event) error {
+	payload, err := json.Marshal(Payload{
+		Event:        name,
+		File:         event.File,
+		PreviousName: event.PreviousName,
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	delivery := &snips.WebhookDelivery{
+		WebhookID:     hook.ID,
+		Event:         name,
+		FileID:        event.FileID,
+		Payload:       payload,
+		Status:        snips.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+
+	return d.db.WebhookDeliveries.Create(ctx, delivery, d.cfg.Limits.DeliveriesPerWebhook)
+}
+
+// Deliver attempts every due delivery, returning the number attempted.
+func (d *Dispatcher) Deliver(ctx context.Context) int {
+	attempted := 0
+	for ctx.Err() == nil {
+		due, err := d.db.WebhookDeliveries.FindDue(ctx, time.Now().UTC(), BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("unable to find due webhook deliveries", "err", err)
+			}
+			return attempted
+		}
+
+		wg := sync.WaitGroup{}
+		for _, delivery := range due {
+			wg.Go(func() {
+				d.attempt(ctx, delivery)
+			})
+		}
+		wg.Wait()
+
+		attempted += len(due)
+		if len(due) < BatchSize {
+			break
+		}
+	}
+
+	return attempted
+}
+
+// attempt sends a delivery to its webhook and records the outcome, scheduling
+// a retry if it failed and has attempts left.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *snips.WebhookDelivery) {
+	log := slog.With("webhook_id", delivery.WebhookID, "delivery_id", delivery.ID)
+
+	hook, err := d.db.Webhooks.Find(ctx, delivery.WebhookID)
+	if err != nil {
+		log.Error("unable to find webhook", "err", err)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.ResponseCode = 0
+	delivery.Error = ""
+
+	if hook == nil {
+		delivery.Error = "webhook was removed"
+	} else {
+		delivery.ResponseCode, err = d.send(ctx, hook, delivery)
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+	}
+
+	switch {
+	case delivery.Error == "":
+		delivery.Status = snips.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		metrics.IncrCounter([]string{"webhook", "delivered"}, 1)
+		log.Info("webhook delivered", "attempts", delivery.Attempts, "status", delivery.ResponseCode)
+	case hook == nil || delivery.Attempts >= d.cfg.Webhooks.MaxAttempts:
+		delivery.Status = snips.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		metrics.IncrCounter([]string{"webhook", "failed"}, 1)
+		log.Warn("webhook delivery failed", "attempts", delivery.Attempts, "err", delivery.Error)
+	default:
+		next := time.Now().UTC().Add(Backoff(delivery.Attempts))
+		delivery.Status = snips.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+		metrics.IncrCounter([]string{"webhook", "retried"}, 1)
+		log.Warn("webhook delivery will be retried", "attempts", delivery.Attempts, "next_attempt_at", next, "err", delivery.Error)
+	}
+
+	if len(delivery.Error) > maxErrorLength {
+		delivery.Error = delivery.Error[:maxErrorLength]
+	}
+
+	// the outcome is recorded even when shutting down mid-attempt, so it's
+	// not attempted again as if it never happened
+	if err := d.db.WebhookDeliveries.Update(context.WithoutCancel(ctx), delivery); err != nil {
+		log.Error("unable to record webhook delivery", "err", err)
+	}
+}
+
+// send posts a delivery's signed payload to hook, returning the response
+// code, and an error unless it's a 2xx.
+func (d *Dispatcher) send(ctx context.Context, hook *snips.Webhook, delivery *snips.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(SignatureHeader, SignaturePrefix+signer.New(hook.Secret).SignPayload(timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
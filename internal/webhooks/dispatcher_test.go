@@ -0,0 +1,222 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robherley/snips.sh/internal/config"
+	dbmock "github.com/robherley/snips.sh/internal/db/mock"
+	"github.com/robherley/snips.sh/internal/events"
+	"github.com/robherley/snips.sh/internal/signer"
+	"github.com/robherley/snips.sh/internal/snips"
+	"github.com/robherley/snips.sh/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Webhooks.Interval = time.Minute
+	cfg.Webhooks.Timeout = 5 * time.Second
+	cfg.Webhooks.MaxAttempts = 3
+	cfg.Limits.DeliveriesPerWebhook = 10
+	// test servers listen on loopback
+	cfg.Webhooks.AllowPrivate = true
+	return cfg
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, webhooks.BackoffBase, webhooks.Backoff(1))
+	assert.Equal(t, 2*webhooks.BackoffBase, webhooks.Backoff(2))
+	assert.Equal(t, 8*webhooks.BackoffBase, webhooks.Backoff(4))
+	assert.Equal(t, webhooks.BackoffMax, webhooks.Backoff(100))
+}
+
+func TestDispatcher_Queue(t *testing.T) {
+	mockDB := dbmock.NewDB(t)
+	hub := events.NewHub()
+	dispatcher := webhooks.NewDispatcher(testConfig(), mockDB.DB, hub)
+
+	updates := &snips.Webhook{ID: "hook1", Events: []string{snips.WebhookEventUpdate}}
+	renames := &snips.Webhook{ID: "hook2", Events: []string{snips.WebhookEventRename}}
+	mockDB.Webhooks.EXPECT().FindByUser(mock.Anything, "user1").Return([]*snips.Webhook{updates, renames}, nil).Once()
+
+	var queued []*snips.WebhookDelivery
+	mockDB.WebhookDeliveries.EXPECT().Create(mock.Anything, mock.Anything, uint64(10)).RunAndReturn(
+		func(_ context.Context, delivery *snips.WebhookDelivery, _ uint64) error {
+			queued = append(queued, delivery)
+			return nil
+		})
+
+	file := &snips.File{ID: "file1", UserID: "user1", Name: "notes"}
+	hub.Publish(events.NewEvent(events.KindAppend, file))
+	hub.Publish(events.NewRenameEvent(file, "old-notes"))
+
+	assert.Equal(t, 2, dispatcher.Queue(t.Context()))
+	require.Len(t, queued, 2)
+
+	assert.Equal(t, "hook1", queued[0].WebhookID)
+	assert.Equal(t, snips.WebhookEventUpdate, queued[0].Event)
+	assert.Equal(t, snips.WebhookDeliveryPending, queued[0].Status)
+	assert.NotNil(t, queued[0].NextAttemptAt)
+
+	assert.Equal(t, "hook2", queued[1].WebhookID)
+	var payload webhooks.Payload
+	require.NoError(t, json.Unmarshal(queued[1].Payload, &payload))
+	assert.Equal(t, snips.WebhookEventRename, payload.Event)
+	assert.Equal(t, "old-notes", payload.PreviousName)
+	assert.Equal(t, "notes", payload.File.Name)
+
+	// the queue was drained
+	assert.Zero(t, dispatcher.Queue(t.Context()))
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"event":"update"}`)
+
+	var status int
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hook := &snips.Webhook{ID: "hook1", URL: server.URL, Secret: secret}
+
+	t.Run("succeeds", func(t *testing.T) {
+		status = http.StatusNoContent
+		mockDB := dbmock.NewDB(t)
+		dispatcher := webhooks.NewDispatcher(testConfig(), mockDB.DB, events.NewHub())
+
+		delivery := &snips.WebhookDelivery{ID: "delivery1", WebhookID: hook.ID, Event: snips.WebhookEventUpdate, Payload: payload}
+		mockDB.WebhookDeliveries.EXPECT().FindDue(mock.Anything, mock.Anything, uint64(webhooks.BatchSize)).Return([]*snips.WebhookDelivery{delivery}, nil).Once()
+		mockDB.Webhooks.EXPECT().Find(mock.Anything, hook.ID).Return(hook, nil).Once()
+		mockDB.WebhookDeliveries.EXPECT().Update(mock.Anything, delivery).Return(nil).Once()
+
+		assert.Equal(t, 1, dispatcher.Deliver(t.Context()))
+
+		assert.Equal(t, snips.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.ResponseCode)
+		assert.Nil(t, delivery.NextAttemptAt)
+		assert.Empty(t, delivery.Error)
+
+		require.NotNil(t, received)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "update", received.Header.Get(webhooks.EventHeader))
+		assert.Equal(t, "delivery1", received.Header.Get(webhooks.DeliveryHeader))
+
+		unix, err := strconv.ParseInt(received.Header.Get(webhooks.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+		signature, ok := strings.CutPrefix(received.Header.Get(webhooks.SignatureHeader), webhooks.SignaturePrefix)
+		require.True(t, ok)
+		assert.True(t, signer.New(secret).VerifyPayload(time.Unix(unix, 0), body, signature))
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		mockDB := dbmock.NewDB(t)
+		dispatcher := webhooks.NewDispatcher(testConfig(), mockDB.DB, events.NewHub())
+
+		delivery := &snips.WebhookDelivery{ID: "delivery1", WebhookID: hook.ID, Payload: payload, Attempts: 1}
+		mockDB.WebhookDeliveries.EXPECT().FindDue(mock.Anything, mock.Anything, mock.Anything).Return([]*snips.WebhookDelivery{delivery}, nil).Once()
+		mockDB.Webhooks.EXPECT().Find(mock.Anything, hook.ID).Return(hook, nil).Once()
+		mockDB.WebhookDeliveries.EXPECT().Update(mock.Anything, delivery).Return(nil).Once()
+
+		before := time.Now()
+		dispatcher.Deliver(t.Context())
+
+		assert.Equal(t, snips.WebhookDeliveryPending, delivery.Status)
+		assert.Equal(t, 2, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.ResponseCode)
+		assert.Contains(t, delivery.Error, "500")
+		require.NotNil(t, delivery.NextAttemptAt)
+		assert.WithinRange(t, *delivery.NextAttemptAt, before.Add(webhooks.Backoff(2)), time.Now().Add(webhooks.Backoff(2)))
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		status = http.StatusBadGateway
+		mockDB := dbmock.NewDB(t)
+		dispatcher := webhooks.NewDispatcher(testConfig(), mockDB.DB, events.NewHub())
+
+		delivery := &snips.WebhookDelivery{ID: "delivery1", WebhookID: hook.ID, Payload: payload, Attempts: 2}
+		mockDB.WebhookDeliveries.EXPECT().FindDue(mock.Anything, mock.Anything, mock.Anything).Return([]*snips.WebhookDelivery{delivery}, nil).Once()
+		mockDB.Webhooks.EXPECT().Find(mock.Anything, hook.ID).Return(hook, nil).Once()
+		mockDB.WebhookDeliveries.EXPECT().Update(mock.Anything, delivery).Return(nil).Once()
+
+		dispatcher.Deliver(t.Context())
+
+		assert.Equal(t, snips.WebhookDeliveryFailed, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Nil(t, delivery.NextAttemptAt)
+	})
+}
+
+func TestDispatcher_PrivateAddress(t *testing.T) {
+	received := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		received = true
+	}))
+	defer server.Close()
+
+	urls := []string{
+		server.URL,
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+		"http://0.0.0.1",
+		"http://100.64.0.1",
+		"http://198.18.0.1",
+		"http://224.0.0.1",
+		"http://255.255.255.255",
+		"http://[::ffff:10.0.0.1]",
+		"http://[64:ff9b::7f00:1]",
+		"http://[fd00::1]",
+		"http://[ff02::1]",
+	}
+
+	for _, url := range urls {
+		cfg := testConfig()
+		cfg.Webhooks.AllowPrivate = false
+
+		mockDB := dbmock.NewDB(t)
+		dispatcher := webhooks.NewDispatcher(cfg, mockDB.DB, events.NewHub())
+
+		hook := &snips.Webhook{ID: "hook1", URL: url, Secret: "whsec_test"}
+		delivery := &snips.WebhookDelivery{ID: "delivery1", WebhookID: hook.ID, Payload: []byte(`{}`)}
+		mockDB.WebhookDeliveries.EXPECT().FindDue(mock.Anything, mock.Anything, mock.Anything).Return([]*snips.WebhookDelivery{delivery}, nil).Once()
+		mockDB.Webhooks.EXPECT().Find(mock.Anything, hook.ID).Return(hook, nil).Once()
+		mockDB.WebhookDeliveries.EXPECT().Update(mock.Anything, delivery).Return(nil).Once()
+
+		dispatcher.Deliver(t.Context())
+
+		assert.Equal(t, snips.WebhookDeliveryPending, delivery.Status, url)
+		assert.Contains(t, delivery.Error, webhooks.ErrPrivateAddress.Error(), url)
+	}
+
+	assert.False(t, received)
+}
+
+func TestDispatcher_Disabled(t *testing.T) {
+	mockDB := dbmock.NewDB(t)
+	cfg := testConfig()
+	cfg.Webhooks.Interval = 0
+	hub := events.NewHub()
+
+	dispatcher := webhooks.NewDispatcher(cfg, mockDB.DB, hub)
+	hub.Publish(events.NewEvent(events.KindCreate, &snips.File{ID: "file1", UserID: "user1"}))
+
+	require.NoError(t, dispatcher.ListenAndServe())
+	require.NoError(t, dispatcher.Shutdown(t.Context()))
+	assert.Zero(t, dispatcher.Queue(t.Context()))
+}
@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"embed"
+	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 
+	"github.com/robherley/snips.sh/internal/admin"
 	"github.com/robherley/snips.sh/internal/app"
 	"github.com/robherley/snips.sh/internal/config"
+	"github.com/robherley/snips.sh/internal/db/dsn"
 	"github.com/robherley/snips.sh/internal/logger"
 	"github.com/robherley/snips.sh/internal/stats"
 	"github.com/robherley/snips.sh/internal/web"
@@ -36,12 +40,6 @@ func main() {
 		logger.Initialize(slog.LevelDebug)
 	}
 
-	statsd, err := stats.Initialize(cfg.Metrics.Statsd, cfg.Metrics.UseDogStatsd)
-	if err != nil {
-		slog.Error("unable to initialize metrics", "err", err)
-		os.Exit(1)
-	}
-
 	usage := flag.Bool("usage", false, "print environment variable usage")
 	flag.Parse()
 	if usage != nil && *usage {
@@ -49,6 +47,23 @@ func main() {
 		return
 	}
 
+	if args := flag.Args(); len(args) > 0 && args[0] == "admin" {
+		if err := runAdmin(cfg, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			if errors.Is(err, admin.ErrUsage) {
+				fmt.Fprint(os.Stderr, "\n"+admin.Usage)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	statsd, err := stats.Initialize(cfg.Metrics.Statsd, cfg.Metrics.UseDogStatsd)
+	if err != nil {
+		slog.Error("unable to initialize metrics", "err", err)
+		os.Exit(1)
+	}
+
 	assets, err := web.NewAssets(
 		&webFS,
 		&docsFS,
@@ -85,3 +100,15 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runAdmin runs `snips admin` commands against the configured database.
+func runAdmin(cfg *config.Config, args []string) error {
+	database, err := dsn.Parse(cfg.DB.URL).NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	cli := &admin.CLI{DB: database, Out: os.Stdout}
+	return cli.Run(context.Background(), args)
+}
@@ -11,26 +11,33 @@ import {
   Folder,
   GitBranch,
   GitCommitHorizontal,
+  GitCompare,
   Globe,
   HardDrive,
+  Hash,
   HatGlasses,
   KeyRound,
+  NotebookText,
   Package,
+  Radio,
   SquarePen,
   Tag,
   Terminal,
   Zap,
 } from "lucide";
 
-// getSelectedLines will return the lines specified in the hash.
+// getSelectedLines will return the line anchor prefix and the lines specified
+// in the hash. Bundle members prefix their line anchors with the member's
+// anchor, e.g. #file-main-go-L2-file-main-go-L5 instead of #L2-L5.
 const getSelectedLines = () => {
-  if (!location.hash.startsWith("#L")) return [];
-  return location.hash
-    .slice(1)
-    .split("-")
-    .map((n) => parseInt(n.slice(1), 10))
-    .filter((e) => !Number.isNaN(e))
+  const match = location.hash.match(/^#(.*?L)(\d+)(?:-\1(\d+))?$/);
+  if (!match) return { prefix: "L", lines: [] };
+
+  const lines = [match[2], match[3]]
+    .filter(Boolean)
+    .map((n) => parseInt(n, 10))
     .sort((a, b) => a - b);
+  return { prefix: match[1], lines };
 };
 
 // highlightLines will highlight the lines specified in the hash.
@@ -39,11 +46,14 @@ const highlightLines = () => {
     el.classList.remove("hl");
   });
 
-  const [start, end = start] = getSelectedLines();
+  const {
+    prefix,
+    lines: [start, end = start],
+  } = getSelectedLines();
   if (!start) return;
 
   for (let i = start; i <= end; i++) {
-    const el = document.querySelector(`#L${i}`);
+    const el = document.getElementById(`${prefix}${i}`);
     if (!el) return;
     el.parentElement.classList.add("hl");
   }
@@ -51,58 +61,66 @@ const highlightLines = () => {
 
 // scrollToLine will scroll to the selected lines on hash #L2
 const scrollToLine = () => {
-  const [start] = getSelectedLines();
+  const {
+    prefix,
+    lines: [start],
+  } = getSelectedLines();
   if (!start) return;
 
   // needs to defer the execution to be able to scroll even when page gets refresh
   setTimeout(() => {
-    document.querySelector(`#L${start}`).scrollIntoView({ behavior: "smooth" });
+    document
+      .getElementById(`${prefix}${start}`)
+      ?.scrollIntoView({ behavior: "smooth" });
   }, 100);
 };
 
 // watchForShiftClick watches for shift-clicks on line numbers, and will set the anchor appropriately.
 const watchForShiftClick = () => {
-  const chroma = document.querySelector(".chroma");
-  if (!chroma) return;
-
-  chroma.addEventListener("click", (event) => {
-    if (!event.shiftKey) return;
-
-    const el = event.target;
-    if (!el.matches(".lnlinks")) return;
-
-    event.preventDefault();
-
-    const lineNum = parseInt(el.href.split("#")[1].slice(1), 10);
-    if (Number.isNaN(lineNum)) return;
-
-    const lines = getSelectedLines();
-    switch (lines.length) {
-      case 0:
-        location.hash = `#L${lineNum}`;
-        break;
-      case 1:
-        if (lineNum < lines[0]) {
-          lines.unshift(lineNum);
-        } else {
-          lines.push(lineNum);
-        }
-        location.hash = `#L${lines[0]}-L${lines[1]}`;
-        break;
-      case 2:
-        if (lineNum < lines[0]) {
-          lines[1] = lines[0];
-          lines[0] = lineNum;
-        } else if (lineNum > lines[0] && lineNum < lines[1]) {
-          lines[1] = lineNum;
-        } else if (lineNum > lines[1]) {
-          lines[1] = lineNum;
-        }
-        location.hash = `#L${lines[0]}-L${lines[1]}`;
-        break;
-      default:
-        return;
-    }
+  document.querySelectorAll(".chroma").forEach((chroma) => {
+    chroma.addEventListener("click", (event) => {
+      if (!event.shiftKey) return;
+
+      const el = event.target;
+      if (!el.matches(".lnlinks")) return;
+
+      event.preventDefault();
+
+      const match = el.href.split("#")[1].match(/^(.*?L)(\d+)$/);
+      if (!match) return;
+      const [, prefix, num] = match;
+      const lineNum = parseInt(num, 10);
+
+      // a selection can't span two bundle members
+      const selected = getSelectedLines();
+      const lines = selected.prefix === prefix ? selected.lines : [];
+      switch (lines.length) {
+        case 0:
+          location.hash = `#${prefix}${lineNum}`;
+          break;
+        case 1:
+          if (lineNum < lines[0]) {
+            lines.unshift(lineNum);
+          } else {
+            lines.push(lineNum);
+          }
+          location.hash = `#${prefix}${lines[0]}-${prefix}${lines[1]}`;
+          break;
+        case 2:
+          if (lineNum < lines[0]) {
+            lines[1] = lines[0];
+            lines[0] = lineNum;
+          } else if (lineNum > lines[0] && lineNum < lines[1]) {
+            lines[1] = lineNum;
+          } else if (lineNum > lines[1]) {
+            lines[1] = lineNum;
+          }
+          location.hash = `#${prefix}${lines[0]}-${prefix}${lines[1]}`;
+          break;
+        default:
+          return;
+      }
+    });
   });
 };
 
@@ -156,11 +174,15 @@ const initIcons = () => {
       Folder,
       GitBranch,
       GitCommitHorizontal,
+      GitCompare,
       Globe,
       HardDrive,
+      Hash,
       HatGlasses,
       KeyRound,
+      NotebookText,
       Package,
+      Radio,
       SquarePen,
       Tag,
       Terminal,
@@ -254,6 +276,18 @@ const initColorPicker = () => {
   });
 };
 
+const flashCopied = (btn) => {
+  const kbd = btn.querySelector("kbd");
+  const label = btn.textContent.replace(kbd?.textContent ?? "", "").trim();
+  btn.textContent = "copied!";
+  if (kbd) btn.prepend(kbd);
+
+  setTimeout(() => {
+    btn.textContent = label;
+    if (kbd) btn.prepend(kbd);
+  }, 1500);
+};
+
 const initCopyButton = () => {
   const copyBtn = document.querySelector("#copy-content");
   if (!copyBtn) return;
@@ -263,15 +297,84 @@ const initCopyButton = () => {
     if (!rawContent) return;
 
     await navigator.clipboard.writeText(rawContent.textContent);
+    flashCopied(copyBtn);
+  });
+};
 
-    const kbd = copyBtn.querySelector("kbd");
-    copyBtn.textContent = "copied!";
-    copyBtn.prepend(kbd);
+// isScrolledToBottom reports whether the end of the page is in view, so a
+// live-updated file can keep following new lines like tail -f.
+const isScrolledToBottom = () =>
+  window.innerHeight + window.scrollY >= document.body.scrollHeight - 32;
+
+// refreshFile swaps in the latest file details and content without reloading.
+// Changes arriving mid-refresh are picked up by one more refresh after it.
+let refreshing = null;
+let refreshQueued = false;
+const refreshFile = async () => {
+  if (refreshing) {
+    refreshQueued = true;
+    return;
+  }
 
-    setTimeout(() => {
-      copyBtn.textContent = "copy";
-      copyBtn.prepend(kbd);
-    }, 1500);
+  refreshing = swapFile().finally(() => {
+    refreshing = null;
+    if (refreshQueued) {
+      refreshQueued = false;
+      refreshFile();
+    }
+  });
+};
+
+const swapFile = async () => {
+  const following = isScrolledToBottom();
+
+  const res = await fetch(location.href, { headers: { Accept: "text/html" } });
+  if (!res.ok) return;
+
+  const doc = new DOMParser().parseFromString(await res.text(), "text/html");
+  for (const selector of [".file-details", ".file-content", "#raw-content"]) {
+    const current = document.querySelector(selector);
+    const latest = doc.querySelector(selector);
+    if (current && latest) current.replaceWith(latest);
+  }
+
+  // the new details bring a fresh, hidden indicator
+  document.querySelector("#live-indicator")?.removeAttribute("hidden");
+  initIcons();
+  watchForShiftClick();
+  highlightLines();
+  await initMermaid();
+
+  if (following) window.scrollTo({ top: document.body.scrollHeight });
+};
+
+// initLiveUpdates subscribes to the file's changes and refreshes it as they
+// happen, e.g. while a build log is being appended to.
+const initLiveUpdates = () => {
+  const indicator = document.querySelector("#live-indicator");
+  if (!indicator || !window.EventSource) return;
+
+  const source = new EventSource(indicator.dataset.eventsHref);
+  const live = (on) =>
+    document.querySelector("#live-indicator")?.toggleAttribute("hidden", !on);
+
+  source.addEventListener("open", () => live(true));
+  source.addEventListener("error", () => live(false));
+  source.addEventListener("update", refreshFile);
+  source.addEventListener("append", refreshFile);
+  source.addEventListener("delete", () => {
+    source.close();
+    live(false);
+  });
+};
+
+// buttons that copy a fixed snippet of text, e.g. an ssh command
+const initCopyTextButtons = () => {
+  document.querySelectorAll("[data-copy-text]").forEach((btn) => {
+    btn.addEventListener("click", async () => {
+      await navigator.clipboard.writeText(btn.dataset.copyText);
+      flashCopied(btn);
+    });
   });
 };
 
@@ -284,7 +387,9 @@ window.addEventListener("DOMContentLoaded", async () => {
   initIcons();
   initKeyboardShortcuts();
   initCopyButton();
+  initCopyTextButtons();
   initColorPicker();
+  initLiveUpdates();
 
   await initMermaid();
 });